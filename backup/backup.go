@@ -0,0 +1,358 @@
+// Package backup writes the snippet files, the config and the files pet
+// keeps in the data directory to timestamped archives, and restores them,
+// whatever the sync backend.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+const (
+	prefix     = "pet-backup-"
+	suffix     = ".tar.gz"
+	timeLayout = "20060102-150405"
+)
+
+// The directories of the entries of an archive
+const (
+	snippetFileEntry = "snippetfile"
+	snippetDirEntry  = "snippetdir"
+	configEntry      = "config"
+	dataEntry        = "data"
+)
+
+// Archive is a backup in the backup directory
+type Archive struct {
+	Path string
+	// Time is when the backup was made, from the name of the archive
+	Time time.Time
+	Size int64
+}
+
+// Dir returns the directory of the archives: dir in [Backup], or backups in
+// the data directory
+func Dir() (string, error) {
+	dir := config.Conf.Backup.Dir
+	if dir == "" {
+		var err error
+		if dir, err = config.GetDataFile("backups"); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("Failed to create backup directory: %v", err)
+	}
+	return dir, nil
+}
+
+// Create writes an archive of the snippet file, the files of snippetdir,
+// the config file without its tokens and credentials (see
+// config.WithoutTokens) and the files of the data directory to dir, and
+// returns its path. The snippet files are archived as they are on disk,
+// encrypted if they are. The project file is not, it belongs to its
+// project.
+func Create(dir, configFile string, now time.Time) (string, error) {
+	file := filepath.Join(dir, prefix+now.Format(timeLayout)+suffix)
+	for n := 2; exists(file); n++ {
+		file = filepath.Join(dir, fmt.Sprintf("%s%s-%d%s", prefix, now.Format(timeLayout), n, suffix))
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create backup: %v", err)
+	}
+	if err := write(f, dir, configFile); err != nil {
+		f.Close()
+		os.Remove(file)
+		return "", fmt.Errorf("Failed to create backup: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(file)
+		return "", fmt.Errorf("Failed to create backup: %v", err)
+	}
+	return file, nil
+}
+
+func write(w io.Writer, backupDir, configFile string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	add := func(name string, data []byte, modTime time.Time) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	addFile := func(name, file string) error {
+		fi, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return add(name, data, fi.ModTime())
+	}
+
+	skip := map[string]bool{}
+	snippetFile := config.Conf.General.SnippetFile
+	if snippetFile != "" {
+		skip[absPath(snippetFile)] = true
+		if err := addFile(snippetFileEntry+"/"+filepath.Base(snippetFile), snippetFile); err != nil {
+			return err
+		}
+	}
+	if dir := config.Conf.General.SnippetDir; dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if skip[absPath(m)] {
+				continue
+			}
+			skip[absPath(m)] = true
+			if err := addFile(snippetDirEntry+"/"+filepath.Base(m), m); err != nil {
+				return err
+			}
+		}
+	}
+	if configFile != "" {
+		skip[absPath(configFile)] = true
+		if fi, err := os.Stat(configFile); err == nil {
+			data, err := config.WithoutTokens(configFile)
+			if err != nil {
+				return err
+			}
+			if err := add(configEntry+"/"+filepath.Base(configFile), data, fi.ModTime()); err != nil {
+				return err
+			}
+		}
+	}
+
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return err
+	}
+	err = filepath.Walk(dataDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if fi.IsDir() {
+			// the other profiles have their own backups
+			if absPath(path) == absPath(backupDir) || (rel == "profiles" && config.ProfileName() == "") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// the lock, the temporary files and the sockets are not data
+		if !fi.Mode().IsRegular() || skip[absPath(path)] || fi.Name() == "snippet.lock" || strings.HasPrefix(fi.Name(), ".") {
+			return nil
+		}
+		return addFile(dataEntry+"/"+filepath.ToSlash(rel), path)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// List returns the archives of dir, newest first
+func List(dir string) ([]Archive, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, prefix+"*"+suffix))
+	if err != nil {
+		return nil, err
+	}
+	var archives []Archive
+	seq := map[string]int{}
+	for _, m := range matches {
+		t, n, ok := archiveTime(filepath.Base(m))
+		if !ok {
+			continue
+		}
+		fi, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		archives = append(archives, Archive{Path: m, Time: t, Size: fi.Size()})
+		seq[m] = n
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		if !archives[i].Time.Equal(archives[j].Time) {
+			return archives[i].Time.After(archives[j].Time)
+		}
+		return seq[archives[i].Path] > seq[archives[j].Path]
+	})
+	return archives, nil
+}
+
+// archiveTime returns the time in the name of an archive and its number
+// among the archives of the same second, 1 for the first one
+func archiveTime(name string) (time.Time, int, bool) {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	if len(stamp) < len(timeLayout) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(timeLayout, stamp[:len(timeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	n := 1
+	if rest := stamp[len(timeLayout):]; rest != "" {
+		if n, err = strconv.Atoi(strings.TrimPrefix(rest, "-")); err != nil || !strings.HasPrefix(rest, "-") {
+			return time.Time{}, 0, false
+		}
+	}
+	return t, n, true
+}
+
+// Prune removes the archives of dir beyond the keep newest ones or older
+// than keepDays days, either without limit if 0, and returns the removed
+// ones
+func Prune(dir string, keep, keepDays int, now time.Time) ([]string, error) {
+	archives, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, a := range archives {
+		if (keep > 0 && i >= keep) || (keepDays > 0 && now.Sub(a.Time) > time.Duration(keepDays)*24*time.Hour) {
+			if err := os.Remove(a.Path); err != nil {
+				return removed, fmt.Errorf("Failed to remove backup: %v", err)
+			}
+			removed = append(removed, a.Path)
+		}
+	}
+	return removed, nil
+}
+
+// Restore writes the files of the archive back and returns them. The config
+// file is restored to configFile, without its tokens, and skipped if
+// configFile is empty; the files of snippetdir are skipped if there is no
+// snippetdir, and the record of the last sync, the audit log and the index
+// are never restored. The files which are not in the archive are left as
+// they are.
+func Restore(archive, configFile string) ([]string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open backup: %v", err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read backup: %v", err)
+	}
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []string
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return restored, fmt.Errorf("Failed to read backup: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		kind, rel, _ := strings.Cut(hdr.Name, "/")
+		rel = filepath.FromSlash(rel)
+		if !filepath.IsLocal(rel) {
+			return restored, fmt.Errorf("Invalid file in backup: %s", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return restored, fmt.Errorf("Failed to read backup: %v", err)
+		}
+
+		var target string
+		switch kind {
+		case snippetFileEntry:
+			target = config.Conf.General.SnippetFile
+		case snippetDirEntry:
+			if config.Conf.General.SnippetDir == "" {
+				continue
+			}
+			target = filepath.Join(config.Conf.General.SnippetDir, filepath.Base(rel))
+		case configEntry:
+			if configFile == "" {
+				continue
+			}
+			target = configFile
+		case dataEntry:
+			if !restorable(rel) {
+				continue
+			}
+			target = filepath.Join(dataDir, rel)
+		default:
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return restored, fmt.Errorf("Failed to create directory: %v", err)
+		}
+		if kind == snippetFileEntry || kind == snippetDirEntry {
+			err = snippet.RestoreFile(target, data)
+		} else {
+			err = os.WriteFile(target, data, 0o600)
+		}
+		if err != nil {
+			return restored, fmt.Errorf("Failed to restore %s: %v", target, err)
+		}
+		restored = append(restored, target)
+	}
+	return restored, nil
+}
+
+// restorable reports whether a file of the data directory is restored. The
+// record of the last sync would make the next sync take the rolled back
+// snippets for unchanged and download the remote ones over them, the audit
+// log would lose the executions since the backup, and the index and the
+// sync log follow the current files.
+func restorable(rel string) bool {
+	switch name := filepath.ToSlash(rel); {
+	case name == "last_sync.json", name == "index.json", name == "sync.log":
+		return false
+	case name == "audit.jsonl", strings.HasPrefix(name, "audit.jsonl."):
+		return false
+	}
+	return true
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func absPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+func setup(t *testing.T) (dir string, configFile string) {
+	dir = t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_PROFILE", "")
+	old := config.Conf
+	t.Cleanup(func() { config.Conf = old })
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = filepath.Join(dir, "snippets")
+	config.Conf.Backup = config.BackupConfig{}
+
+	files := map[string]string{
+		"snippet.toml":        "[[snippets]]\n  description = \"old\"\n  command = \"echo old\"\n",
+		"snippets/work.toml":  "[[snippets]]\n  description = \"work\"\n  command = \"echo work\"\n",
+		"config.toml":         "[General]\n  snippetfile = \"snippet.toml\"\n[Gist]\n  access_token = \"ghp_secret\"\n",
+		"usage.json":          `{"old": 1}`,
+		"last_sync.json":      `{"gist": {"time": "2024-05-01T12:00:00Z"}}`,
+		"audit.jsonl":         "{\"description\":\"old\"}\n",
+		"audit.jsonl.1":       "{\"description\":\"older\"}\n",
+		"index.json":          `{"old": true}`,
+		"versions/old.toml":   "old version",
+		"snippet.lock":        "",
+		".snippet.toml.12345": "half written",
+	}
+	for name, data := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, filepath.Join(dir, "config.toml")
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	dir, configFile := setup(t)
+	backupDir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := Create(backupDir, configFile, time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "backups", "pet-backup-20240501-120000.tar.gz"); archive != want {
+		t.Errorf("Create() = %s, want %s", archive, want)
+	}
+
+	for _, name := range []string{"snippet.toml", "snippets/work.toml", "usage.json", "versions/old.toml", "last_sync.json", "audit.jsonl", "audit.jsonl.1", "index.json"} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte("new"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restored, err := Restore(archive, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the snippet file, the snippetdir file and the two data files
+	if len(restored) != 4 {
+		t.Errorf("Restore() restored %v, want 4 files", restored)
+	}
+	for name, want := range map[string]string{
+		"snippet.toml":       "echo old",
+		"snippets/work.toml": "echo work",
+		"usage.json":         `{"old": 1}`,
+		"versions/old.toml":  "old version",
+		"config.toml":        "ghp_secret",
+		// the sync, the audit log and the index follow the current files
+		"last_sync.json": "new",
+		"audit.jsonl":    "new",
+		"audit.jsonl.1":  "new",
+		"index.json":     "new",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s = %q, want %q in it", name, data, want)
+		}
+	}
+
+	// the config is restored without its tokens
+	if _, err := Restore(archive, configFile); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ghp_secret") || !strings.Contains(string(data), "snippetfile") {
+		t.Errorf("config.toml = %q", data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	for _, days := range []int{0, 1, 2, 5, 20} {
+		name := prefix + now.AddDate(0, 0, -days).Format(timeLayout) + suffix
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not an archive
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(dir, 4, 10, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || !strings.Contains(removed[0], "20240420") {
+		t.Errorf("Prune(4, 10) removed %v, want the archive of 20 days ago", removed)
+	}
+	if removed, _ = Prune(dir, 2, 0, now); len(removed) != 2 {
+		t.Errorf("Prune(2, 0) removed %v, want the 2 oldest archives", removed)
+	}
+	if removed, _ = Prune(dir, 0, 0, now); len(removed) != 0 {
+		t.Errorf("Prune(0, 0) removed %v, want none", removed)
+	}
+	archives, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || !archives[0].Time.Equal(now) {
+		t.Errorf("List() = %v, want the 2 newest archives, newest first", archives)
+	}
+
+	// the second archive of the same second is the newer
+	second := filepath.Join(dir, prefix+now.Format(timeLayout)+"-2"+suffix)
+	if err := os.WriteFile(second, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if archives, _ = List(dir); len(archives) != 3 || archives[0].Path != second {
+		t.Errorf("List() = %v, want %s first", archives, second)
+	}
+}
@@ -0,0 +1,132 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	atotto "github.com/atotto/clipboard"
+)
+
+const (
+	// Auto detects the backend from the environment
+	Auto = "auto"
+	// Native uses the system API (github.com/atotto/clipboard)
+	Native = "native"
+	// OSC52 asks the terminal emulator to set the clipboard, which also works over SSH
+	OSC52 = "osc52"
+)
+
+// commands are the backends which receive the text on stdin
+var commands = map[string][]string{
+	"wl-copy":              {"wl-copy"},
+	"xclip":                {"xclip", "-selection", "clipboard"},
+	"xsel":                 {"xsel", "--clipboard", "--input"},
+	"pbcopy":               {"pbcopy"},
+	"clip":                 {"clip.exe"},
+	"termux-clipboard-set": {"termux-clipboard-set"},
+	"tmux":                 {"tmux", "load-buffer", "-w", "-"},
+	"powershell":           {"powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard"},
+}
+
+// Backends returns the names of the supported backends
+func Backends() []string {
+	names := []string{Auto, Native, OSC52}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names[3:])
+	return names
+}
+
+// Detect returns the best backend for the current environment
+func Detect() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "pbcopy"
+	case "windows":
+		return Native
+	}
+
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "" && available("wl-copy"):
+		return "wl-copy"
+	case os.Getenv("DISPLAY") != "" && available("xclip"):
+		return "xclip"
+	case os.Getenv("DISPLAY") != "" && available("xsel"):
+		return "xsel"
+	case os.Getenv("TERMUX_VERSION") != "" && available("termux-clipboard-set"):
+		return "termux-clipboard-set"
+	case os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "":
+		return OSC52
+	}
+	return Native
+}
+
+// Write copies text to the clipboard with the backend.
+// An empty backend is the same as Auto.
+func Write(backend, text string) error {
+	if backend == "" || backend == Auto {
+		backend = Detect()
+	}
+
+	switch backend {
+	case Native:
+		if err := atotto.WriteAll(text); err != nil {
+			return fmt.Errorf("Failed to write to the clipboard: %v", err)
+		}
+		return nil
+	case OSC52:
+		return writeOSC52(text)
+	}
+
+	args, ok := commands[backend]
+	if !ok {
+		return fmt.Errorf("unknown clipboard backend: %s (%s)", backend, strings.Join(Backends(), ", "))
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to write to the clipboard with %s: %v", backend, err)
+	}
+	return nil
+}
+
+// Available reports whether the backend can be used in this environment
+func Available(backend string) bool {
+	switch backend {
+	case "", Auto, OSC52:
+		return true
+	case Native:
+		return !atotto.Unsupported
+	}
+	args, ok := commands[backend]
+	return ok && available(args[0])
+}
+
+func writeOSC52(text string) error {
+	var w io.Writer = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		w = tty
+	}
+
+	seq := fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	if os.Getenv("TMUX") != "" {
+		// tmux passthrough: wrap in DCS and double the escape characters
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+	_, err := io.WriteString(w, seq)
+	return err
+}
+
+func available(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
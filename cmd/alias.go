@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Generate shell aliases from named snippets",
+	Long: `Print alias and function definitions for the named snippets
+
+Parameters become positional arguments in order of appearance, falling back
+to their default values. Add to your .zshrc, for example:
+
+  eval "$(pet alias --shell zsh)"`,
+	RunE: alias,
+}
+
+func alias(cmd *cobra.Command, args []string) error {
+	shell := config.Flag.Shell
+	if shell != "bash" && shell != "zsh" && shell != "fish" {
+		return fmt.Errorf("Unsupported shell: %s (bash, zsh or fish)", shell)
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	for _, s := range snippets.Active().Snippets {
+		if s.Name == "" {
+			continue
+		}
+		fmt.Printf("# %s\n", s.Description)
+		if shell == "fish" {
+			fmt.Print(fishFunction(s))
+		} else {
+			fmt.Print(shFunction(s))
+		}
+	}
+	return nil
+}
+
+// shFunction returns a bash/zsh alias for a snippet without parameters,
+// or a function taking the parameters as arguments
+func shFunction(s snippet.SnippetInfo) string {
+	params := dialog.ParseParams(s.Command)
+	if len(params) == 0 {
+		return fmt.Sprintf("alias %s=%s\n", s.Name, shellescape.Quote(s.Command))
+	}
+	body := dialog.ReplaceParams(s.Command, func(name string) string {
+		for i, p := range params {
+			if p.Name == name {
+				if d := p.Default(); d != "" {
+					return fmt.Sprintf("${%d:-%s}", i+1, d)
+				}
+				return fmt.Sprintf("${%d}", i+1)
+			}
+		}
+		return ""
+	})
+	return fmt.Sprintf("%s() {\n  %s\n}\n", s.Name, indent(body, "  "))
+}
+
+// fishFunction returns a fish function taking the parameters as arguments
+func fishFunction(s snippet.SnippetInfo) string {
+	params := dialog.ParseParams(s.Command)
+	var b strings.Builder
+	fmt.Fprintf(&b, "function %s\n", s.Name)
+	if len(params) == 0 {
+		fmt.Fprintf(&b, "    %s $argv\nend\n", indent(s.Command, "    "))
+		return b.String()
+	}
+	for i, p := range params {
+		fmt.Fprintf(&b, "    set -l arg%d %s\n", i+1, shellescape.Quote(p.Default()))
+		fmt.Fprintf(&b, "    set -q argv[%d]; and set arg%d $argv[%d]\n", i+1, i+1, i+1)
+	}
+	body := dialog.ReplaceParams(s.Command, func(name string) string {
+		for i, p := range params {
+			if p.Name == name {
+				return fmt.Sprintf("$arg%d", i+1)
+			}
+		}
+		return ""
+	})
+	fmt.Fprintf(&b, "    %s\nend\n", indent(body, "    "))
+	return b.String()
+}
+
+func init() {
+	RootCmd.AddCommand(aliasCmd)
+	aliasCmd.Flags().StringVarP(&config.Flag.Shell, "shell", "s", "zsh",
+		`Shell to generate the definitions for (bash, zsh or fish)`)
+	aliasCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(
+		[]string{"bash", "zsh", "fish"}, cobra.ShellCompDirectiveNoFileComp))
+}
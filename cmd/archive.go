@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive [NAME...]",
+	Short: "Archive snippets",
+	Long:  `Hide the selected snippets (or the snippets with the NAMEs) from the selector without deleting them`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setArchived(args, true)
+	},
+}
+
+// unarchiveCmd represents the unarchive command
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive [NAME...]",
+	Short: "Unarchive snippets",
+	Long:  `Show the selected archived snippets (or the snippets with the NAMEs) in the selector again`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setArchived(args, false)
+	},
+}
+
+func setArchived(names []string, archived bool) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	var targets []snippet.SnippetInfo
+	if len(names) > 0 {
+		for _, name := range names {
+			s, ok := snippets.FindByName(name)
+			if !ok {
+				return fmt.Errorf("Snippet named [%s] not found", name)
+			}
+			targets = append(targets, s)
+		}
+	} else {
+		var candidates snippet.Snippets
+		for _, s := range snippets.FilterTags(tagFilter()).Snippets {
+			if s.Archived != archived && s.InPath(config.Flag.Path) {
+				candidates.Snippets = append(candidates.Snippets, s)
+			}
+		}
+		var err error
+		if targets, err = selectFrom(candidates, multiSelectOptions()); err != nil {
+			return err
+		}
+	}
+	if len(targets) == 0 {
+		return errors.New("no snippets selected")
+	}
+
+	changed := 0
+	for _, t := range targets {
+		if i := snippets.Index(t); i >= 0 && snippets.Snippets[i].Archived != archived {
+			snippets.Snippets[i].Archived = archived
+			changed++
+		}
+	}
+	if archived {
+		fmt.Printf("Archived %d snippets\n", changed)
+	} else {
+		fmt.Printf("Unarchived %d snippets\n", changed)
+	}
+	if changed == 0 {
+		return nil
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(archiveCmd, unarchiveCmd)
+	for _, c := range []*cobra.Command{archiveCmd, unarchiveCmd} {
+		addFilterFlags(c)
+		c.ValidArgsFunction = completeNames
+	}
+}
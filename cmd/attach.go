@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// attachCmd represents the attach command
+var attachCmd = &cobra.Command{
+	Use:   "attach FILE...",
+	Short: "Attach files to a snippet",
+	Long: `Keep the FILEs in the selected snippet (or the snippet with --name)
+
+When the snippet runs, the files are written to a temporary directory and
+the parameters named after them (e.g. <query.sql>) are their paths.
+An attachment with the same name is replaced; --remove removes them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: attach,
+}
+
+func attach(cmd *cobra.Command, args []string) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	var target snippet.SnippetInfo
+	if config.Flag.Name != "" {
+		var ok bool
+		if target, ok = snippets.FindByName(config.Flag.Name); !ok {
+			return fmt.Errorf("Snippet named [%s] not found", config.Flag.Name)
+		}
+	} else {
+		selected, err := selectSnippets(nil, tagFilter())
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return errors.New("no snippets selected")
+		}
+		target = selected[0]
+	}
+	i := snippets.Index(target)
+	if i < 0 {
+		return fmt.Errorf("Snippet [%s] not found", target.Description)
+	}
+	s := &snippets.Snippets[i]
+
+	for _, file := range args {
+		name := filepath.Base(file)
+		if err := snippet.CheckAttachmentName(name); err != nil {
+			return err
+		}
+		kept := s.Attachments[:0]
+		for _, a := range s.Attachments {
+			if a.Name != name {
+				kept = append(kept, a)
+			}
+		}
+		s.Attachments = kept
+		if config.Flag.Remove {
+			fmt.Printf("Removed %s from [%s]\n", name, s.Description)
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Failed to read the file: %v", err)
+		}
+		s.Attachments = append(s.Attachments, snippet.Attachment{Name: name, Content: string(content)})
+		fmt.Printf("Attached %s to [%s] as <%s>\n", file, s.Description, name)
+	}
+
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(attachCmd)
+	addFilterFlags(attachCmd)
+	attachCmd.Flags().StringVarP(&config.Flag.Name, "name", "", "",
+		`Attach to the snippet with this name instead of the selected one`)
+	attachCmd.Flags().BoolVarP(&config.Flag.Remove, "remove", "", false,
+		`Remove the attachments with the names of the FILEs`)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/backup"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the snippets, the config and the data of pet",
+	Long: `Write the snippet file, the files of snippetdir, the config file without its
+access tokens and credentials and the files pet keeps in the data directory (usage, trash,
+versions...) to a timestamped archive in dir of [Backup], backups in the data
+directory by default. The archives beyond keep, or older than keep_days, are
+removed. The snippet files are archived as they are on disk, encrypted if
+they are.
+
+With --list, the archives are printed instead, newest first.`,
+	Args: cobra.NoArgs,
+	RunE: backupFiles,
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore ARCHIVE",
+	Short: "Restore the snippets and the data of pet from a backup",
+	Long: `Write the files of an archive of pet backup back, the archive being a path or
+the name of one in the backup directory. The current files are backed up
+first. The config file is restored only with --with-config, and without its
+access tokens, which are to be set again. The record of the last sync, the
+audit log and the index are kept as they are, so that the next sync uploads
+the restored snippets, as are the files which are not in the archive.`,
+	Args: cobra.ExactArgs(1),
+	RunE: restore,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return archiveNames(toComplete), cobra.ShellCompDirectiveDefault
+	},
+}
+
+func backupFiles(cmd *cobra.Command, args []string) error {
+	dir, err := backup.Dir()
+	if err != nil {
+		return err
+	}
+	if config.Flag.List {
+		archives, err := backup.List(dir)
+		if err != nil {
+			return err
+		}
+		for _, a := range archives {
+			fmt.Fprintf(color.Output, "%s  %s  %d KB\n", color.GreenString(a.Time.Format("2006-01-02 15:04:05")),
+				filepath.Base(a.Path), (a.Size+1023)/1024)
+		}
+		return nil
+	}
+
+	file, err := createBackup(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "%s %s\n", color.GreenString("Backed up to"), file)
+	bc := config.Conf.Backup
+	removed, err := backup.Prune(dir, bc.Keep, bc.KeepDays, time.Now())
+	for _, r := range removed {
+		fmt.Fprintf(color.Output, "%s %s\n", color.YellowString("Removed"), r)
+	}
+	return err
+}
+
+func restore(cmd *cobra.Command, args []string) error {
+	dir, err := backup.Dir()
+	if err != nil {
+		return err
+	}
+	archive := args[0]
+	if _, err := os.Stat(archive); os.IsNotExist(err) && !strings.ContainsRune(archive, os.PathSeparator) {
+		archive = filepath.Join(dir, archive)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		return fmt.Errorf("Failed to open backup: %v", err)
+	}
+	if !config.Flag.Yes && !confirm(i18n.T("Restore the snippets from %s?", filepath.Base(archive))) {
+		return errors.New("canceled")
+	}
+
+	file, err := createBackup(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "%s %s\n", color.GreenString("Backed up the current files to"), file)
+	target := ""
+	if config.Flag.WithConfig {
+		target = configFile
+	}
+	restored, err := backup.Restore(archive, target)
+	for _, r := range restored {
+		fmt.Fprintf(color.Output, "%s %s\n", color.GreenString("Restored"), r)
+	}
+	return err
+}
+
+// createBackup writes an archive of the current files to dir
+func createBackup(dir string) (string, error) {
+	return backup.Create(dir, configFile, time.Now())
+}
+
+// archiveNames returns the names of the archives in the backup directory
+func archiveNames(toComplete string) []string {
+	dir, err := backup.Dir()
+	if err != nil {
+		return nil
+	}
+	archives, err := backup.List(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, a := range archives {
+		if name := filepath.Base(a.Path); strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func init() {
+	RootCmd.AddCommand(backupCmd)
+	RootCmd.AddCommand(restoreCmd)
+	backupCmd.Flags().BoolVarP(&config.Flag.List, "list", "l", false,
+		`Print the archives instead of making one`)
+	restoreCmd.Flags().BoolVarP(&config.Flag.WithConfig, "with-config", "", false,
+		`Restore the config file too, without its access tokens`)
+	restoreCmd.Flags().BoolVarP(&config.Flag.Yes, "yes", "y", false,
+		`Restore without asking`)
+}
@@ -4,18 +4,19 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/fatih/color"
+	"github.com/knqyf263/pet/clipboard"
 	"github.com/knqyf263/pet/config"
 	"github.com/spf13/cobra"
 )
 
 // clipCmd represents the clip command
 var clipCmd = &cobra.Command{
-	Use:   "clip",
-	Short: "Copy the selected commands",
-	Long:  `Copy the selected commands to clipboard`,
-	RunE:  clip,
+	Use:     "clip",
+	Aliases: []string{"copy"},
+	Short:   "Copy the selected commands",
+	Long:    `Copy the selected commands to clipboard after filling in their parameters`,
+	RunE:    clip,
 }
 
 func clip(cmd *cobra.Command, args []string) (err error) {
@@ -23,10 +24,10 @@ func clip(cmd *cobra.Command, args []string) (err error) {
 
 	var options []string
 	if flag.Query != "" {
-		options = append(options, fmt.Sprintf("--query %s", flag.Query))
+		options = append(options, fmt.Sprintf("--query %s", quoteArg(flag.Query)))
 	}
 
-	commands, err := filter(options, flag.FilterTag)
+	commands, err := filter(options, tagFilter())
 	if err != nil {
 		return err
 	}
@@ -34,7 +35,7 @@ func clip(cmd *cobra.Command, args []string) (err error) {
 	if flag.Command && command != "" {
 		fmt.Printf("%s: %s\n", color.YellowString("Command"), command)
 	}
-	return clipboard.WriteAll(command)
+	return clipboard.Write(config.Conf.General.Clipboard, command)
 }
 
 func init() {
@@ -45,6 +46,7 @@ func init() {
 		`Display snippets in one line`)
 	clipCmd.Flags().StringVarP(&config.Flag.Delimiter, "delimiter", "d", "; ",
 		`Use delim as the command delimiter character`)
-	clipCmd.Flags().StringVarP(&config.Flag.FilterTag, "tag", "t", "",
-		`Filter tag`)
+	addFilterFlags(clipCmd)
+	clipCmd.RegisterFlagCompletionFunc("query", completeDescriptions)
+	addAllFlag(clipCmd)
 }
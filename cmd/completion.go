@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// completeDescriptions completes snippet descriptions, e.g. for --query
+func completeDescriptions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var descriptions []string
+	for _, s := range snippets.Snippets {
+		if strings.HasPrefix(strings.ToLower(s.Description), strings.ToLower(toComplete)) {
+			descriptions = append(descriptions, s.Description+"\t"+firstLine(s.Command))
+		}
+	}
+	return descriptions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTags completes the tags used by the snippets
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	seen := map[string]bool{}
+	var tags []string
+	for _, s := range snippets.Snippets {
+		for _, t := range s.Tag {
+			if !seen[t] && strings.HasPrefix(t, toComplete) {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles completes the profiles of the config
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for name := range config.Conf.Profiles {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemotes completes the names of the remotes of the config
+func completeRemotes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, r := range config.Conf.Remotes {
+		if strings.HasPrefix(r.Name, toComplete) {
+			names = append(names, r.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePaths completes the namespaces of the snippets
+func completePaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var paths []string
+	for _, p := range snippets.Paths() {
+		if strings.HasPrefix(p, toComplete) {
+			paths = append(paths, p+"/")
+		}
+	}
+	return paths, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeNames completes the names of the snippets
+func completeNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, s := range snippets.Snippets {
+		if s.Name != "" && strings.HasPrefix(s.Name, toComplete) {
+			names = append(names, s.Name+"\t"+s.Description)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
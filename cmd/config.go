@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set config values",
+	Long:  `Get and set the values of the config file without an editor, e.g. for scripts`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a config value",
+	Long:  `Print the value of a config key such as general.editor (of the profile in use)`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  configGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE...",
+	Short: "Set a config value",
+	Long: `Set a config key such as gitlab.visibility in the config file, or in the profile in use.
+Lists take several values, e.g. pet config set general.cmd bash -c`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: configSet,
+}
+
+func configGet(cmd *cobra.Command, args []string) error {
+	value, err := config.Conf.Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func configSet(cmd *cobra.Command, args []string) error {
+	return config.Set(configFile, args[0], args[1:])
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	// values such as -c are not flags
+	configSetCmd.Flags().SetInterspersed(false)
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/knqyf263/pet/config"
 	"github.com/spf13/cobra"
 )
@@ -9,15 +12,69 @@ import (
 var configureCmd = &cobra.Command{
 	Use:   "configure",
 	Short: "Edit config file",
-	Long:  `Edit config file (default: opened by vim)`,
+	Long:  `Edit config file (opened by editor, $VISUAL, $EDITOR or vi)`,
 	RunE:  configure,
 }
 
 func configure(cmd *cobra.Command, args []string) (err error) {
+	if config.Flag.StoreToken {
+		return storeTokens()
+	}
+	if config.Flag.Migrate {
+		return migrateConfig()
+	}
 	editor := config.Conf.General.Editor
 	return editFile(editor, configFile)
 }
 
+// storeTokens moves the access tokens of the config file to the OS keychain
+func storeTokens() error {
+	tokens := []struct {
+		backend, table, token string
+	}{
+		{"gist", "Gist", config.Conf.Gist.AccessToken},
+		{"gitlab", "GitLab", config.Conf.GitLab.AccessToken},
+	}
+	stored := false
+	for _, t := range tokens {
+		if t.token == "" {
+			continue
+		}
+		if err := config.StoreToken(t.backend, t.token); err != nil {
+			return err
+		}
+		if err := config.SetString(configFile, "", t.table, "access_token"); err != nil {
+			return err
+		}
+		fmt.Printf("Stored the %s access_token in the keychain\n", t.table)
+		stored = true
+	}
+	if !stored {
+		return errors.New("No access_token in the config file")
+	}
+	return nil
+}
+
+// migrateConfig upgrades the layout of the config file
+func migrateConfig() error {
+	if config.Conf.Version >= config.ConfigVersion {
+		fmt.Printf("%s is up to date (version %d)\n", configFile, config.Conf.Version)
+		return nil
+	}
+	notes, err := config.Migrate(configFile, config.Conf.Version)
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		fmt.Println(n)
+	}
+	return nil
+}
+
 func init() {
 	RootCmd.AddCommand(configureCmd)
+	configureCmd.Flags().BoolVarP(&config.Flag.StoreToken, "store-token", "", false,
+		`Move the access tokens of the config file to the OS keychain`)
+	configureCmd.Flags().BoolVarP(&config.Flag.Migrate, "migrate", "", false,
+		`Upgrade the config file from older or upstream pet layouts`)
 }
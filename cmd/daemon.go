@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/daemon"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the snippets in memory for the other commands",
+	Long: `Keep the snippets in memory for the other commands
+
+pet daemon decodes the snippet files once, and again only when they change,
+and serves them as JSON-RPC on a local socket (pet daemon --socket prints it).
+The other pet commands read the snippets from the daemon while it runs.
+
+Methods of the "Pet" service:
+  Pet.Decode(FILE)                     a snippet file as it is decoded
+  Pet.List({"query": Q, "tag": TAG})   the snippets, searched like pet search
+  Pet.Version()                        the version of the snippets
+  Pet.Wait({"version": V})             the version, once it is not V
+  Pet.Sync()                           sync the snippets like pet sync`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+	if config.Flag.Socket {
+		fmt.Println(socket)
+		return nil
+	}
+	l, err := daemon.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		l.Close()
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", socket)
+	return daemon.Serve(l, daemon.NewService())
+}
+
+// useDaemon reads the snippet files from pet daemon if it runs
+func useDaemon() {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return
+	}
+	client, err := daemon.Dial(socket)
+	if err != nil {
+		return
+	}
+	snippet.Decoder = client.Decode
+}
+
+func init() {
+	RootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().BoolVarP(&config.Flag.Socket, "socket", "", false,
+		`Print the socket of the daemon`)
+}
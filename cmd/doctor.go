@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/clipboard"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// configErr is the error of loading the config file for pet doctor
+var configErr error
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration problems",
+	Long:  `Check the config and snippet files, external commands, clipboard and sync backend`,
+	RunE:  doctor,
+}
+
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// doctorReport prints the results of the checks
+type doctorReport struct {
+	failures int
+}
+
+func (r *doctorReport) print(status checkStatus, name, format string, a ...interface{}) {
+	var label string
+	switch status {
+	case checkOK:
+		label = color.GreenString("[ OK ]")
+	case checkWarn:
+		label = color.YellowString("[WARN]")
+	case checkFail:
+		label = color.RedString("[FAIL]")
+		r.failures++
+	}
+	fmt.Fprintf(color.Output, "%s %-14s %s\n", label, name+":", fmt.Sprintf(format, a...))
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	r := &doctorReport{}
+
+	checkConfig(r)
+	checkSnippetFile(r)
+	checkSelector(r)
+	checkCommand(r, "Editor", strings.Join(editorCommand(config.Conf.General.Editor), " "))
+	if len(config.Conf.General.Cmd) > 0 {
+		checkCommand(r, "Shell", config.Conf.General.Cmd[0])
+	}
+	checkClipboard(r)
+	if config.Flag.Offline {
+		r.print(checkWarn, "Sync", "skipped (--offline)")
+	} else {
+		checkSync(r)
+	}
+
+	if r.failures > 0 {
+		return fmt.Errorf("pet doctor found %d problem(s)", r.failures)
+	}
+	return nil
+}
+
+func checkConfig(r *doctorReport) {
+	if configErr != nil {
+		r.print(checkFail, "Config", "%s: %v", configFile, configErr)
+		return
+	}
+	for _, w := range config.Conf.Warnings {
+		r.print(checkWarn, "Config", "%v", w)
+	}
+	if len(config.Conf.Warnings) > 0 {
+		return
+	}
+	if name := config.ProfileName(); name != "" {
+		r.print(checkOK, "Config", "%s (profile %s)", configFile, name)
+		return
+	}
+	r.print(checkOK, "Config", "%s", configFile)
+}
+
+func checkSnippetFile(r *doctorReport) {
+	file := config.Conf.General.SnippetFile
+	if file == "" {
+		r.print(checkFail, "Snippet file", "snippetfile is not set")
+		return
+	}
+	if _, err := os.Stat(file); err != nil {
+		r.print(checkFail, "Snippet file", "%v", err)
+		return
+	}
+
+	data, err := snippet.ReadFile(file)
+	if err != nil {
+		r.print(checkFail, "Snippet file", "%v", err)
+		return
+	}
+	var snippets snippet.Snippets
+	md, err := toml.Decode(string(data), &snippets)
+	if err != nil {
+		r.print(checkFail, "Snippet file", "%s: %v", file, err)
+		return
+	}
+
+	var problems []string
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		var keys []string
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		problems = append(problems, "unknown keys "+strings.Join(keys, ", "))
+	}
+	seen := map[string]bool{}
+	for _, s := range snippets.Snippets {
+		if seen[s.Description] {
+			problems = append(problems, fmt.Sprintf("duplicate description [%s]", s.Description))
+		}
+		seen[s.Description] = true
+	}
+	if len(problems) > 0 {
+		r.print(checkWarn, "Snippet file", "%s: %s", file, strings.Join(problems, "; "))
+		return
+	}
+	r.print(checkOK, "Snippet file", "%s (%d snippets)", file, len(snippets.Snippets))
+}
+
+func checkCommand(r *doctorReport, name, command string) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		r.print(checkFail, name, "not configured")
+		return
+	}
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		r.print(checkFail, name, "%s not found in $PATH", fields[0])
+		return
+	}
+	r.print(checkOK, name, "%s (%s)", command, path)
+}
+
+// checkSelector reports the selector, which falls back to the embedded fuzzy
+// finder if it is not installed
+func checkSelector(r *doctorReport) {
+	selectCmd := config.Conf.General.SelectCmd
+	fields := strings.Fields(selectCmd)
+	switch {
+	case len(fields) == 0 || fields[0] == builtinSelectCmd:
+		r.print(checkOK, "Selector", "embedded fuzzy finder")
+	case builtinSelector():
+		r.print(checkWarn, "Selector", "%s not found in $PATH, using the embedded fuzzy finder", fields[0])
+	default:
+		checkCommand(r, "Selector", selectCmd)
+		return
+	}
+	for _, a := range keyActions() {
+		if _, err := dialog.ParseKey(a.key); err != nil {
+			r.print(checkWarn, "Keybind", "%s: %v", a.name, err)
+		}
+	}
+}
+
+func checkClipboard(r *doctorReport) {
+	backend := config.Conf.General.Clipboard
+	if backend == "" || backend == clipboard.Auto {
+		backend = clipboard.Detect()
+	}
+	if !clipboard.Available(backend) {
+		r.print(checkWarn, "Clipboard", "%s is not available (pet clip will not work)", backend)
+		return
+	}
+	r.print(checkOK, "Clipboard", "%s", backend)
+}
+
+func checkSync(r *doctorReport) {
+	backend := config.Conf.General.Backend
+	if backend == "" {
+		backend = "gist"
+	}
+	if !syncConfigured() {
+		r.print(checkWarn, "Sync", "%s: no access token configured (pet sync is disabled)", backend)
+		return
+	}
+
+	client, err := petSync.NewSyncClient()
+	if err != nil {
+		r.print(checkFail, "Sync", "%s: %v", backend, err)
+		return
+	}
+	if _, err := client.GetSnippet(); err != nil {
+		r.print(checkFail, "Sync", "%s: %v", backend, err)
+		return
+	}
+	r.print(checkOK, "Sync", "%s", backend)
+}
+
+// syncConfigured reports whether an access token for the backend is set, or
+// the backend is a plugin
+func syncConfigured() bool {
+	if _, ok := config.Conf.Plugins[config.Conf.General.Backend]; ok {
+		// plugins have their own credentials
+		return true
+	}
+	if config.Conf.General.Backend == "gitlab" {
+		return config.Conf.GitLab.AccessToken != "" || os.Getenv("PET_GITLAB_ACCESS_TOKEN") != "" ||
+			config.KeyringToken("gitlab") != ""
+	}
+	return config.Conf.Gist.AccessToken != "" || os.Getenv("PET_GITHUB_ACCESS_TOKEN") != "" ||
+		config.KeyringToken("gist") != ""
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVarP(&config.Flag.Offline, "offline", "", false,
+		`Skip checking the sync backend`)
+}
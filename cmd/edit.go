@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
 	petSync "github.com/knqyf263/pet/sync"
 	"github.com/spf13/cobra"
 )
@@ -12,37 +16,175 @@ import (
 var editCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit snippet file",
-	Long:  `Edit snippet file (default: opened by vim)`,
+	Long:  `Edit snippet file (opened by editor, $VISUAL, $EDITOR or vi)`,
 	RunE:  edit,
 }
 
 func edit(cmd *cobra.Command, args []string) (err error) {
 	editor := config.Conf.General.Editor
-	snippetFile := config.Conf.General.SnippetFile
+	snippetFile, err := snippetFilePath(config.Flag.File)
+	if err != nil {
+		return err
+	}
+
+	if config.Flag.Select {
+		changed, err := editSelected(editor)
+		if err != nil || !changed {
+			return err
+		}
+		if config.Conf.Gist.AutoSync {
+			return petSync.AutoSync(config.Conf.General.SnippetFile)
+		}
+		return nil
+	}
+
+	// an encrypted file is edited decrypted in a temporary file
+	editedFile := snippetFile
+	if snippet.Encrypted(snippetFile) {
+		if editedFile, err = decryptedCopy(snippetFile); err != nil {
+			return err
+		}
+		defer os.Remove(editedFile)
+	}
 
 	// file content before editing
-	before := fileContent(snippetFile)
+	before := fileContent(editedFile)
+	var beforeSnippets snippet.Snippets
+	if err := beforeSnippets.Load(); err != nil {
+		return err
+	}
 
-	err = editFile(editor, snippetFile)
+	err = editFile(editor, editedFile)
 	if err != nil {
 		return
 	}
 
 	// file content after editing
-	after := fileContent(snippetFile)
+	after := fileContent(editedFile)
 
 	// return if same file content
 	if before == after {
 		return nil
 	}
+	if editedFile != snippetFile {
+		if err := snippet.WriteFile(snippetFile, []byte(after)); err != nil {
+			return err
+		}
+	}
+
+	var afterSnippets snippet.Snippets
+	if err := afterSnippets.Load(); err == nil {
+		warnSimilarTags(beforeSnippets.Snippets, afterSnippets.Snippets)
+		if err := snippet.Trash(snippet.Removed(beforeSnippets.Snippets, afterSnippets.Snippets)); err != nil {
+			return err
+		}
+		if err := snippet.RecordEdits(beforeSnippets.Snippets, afterSnippets.Snippets); err != nil {
+			return err
+		}
+		if afterSnippets.Stamp(beforeSnippets.Snippets, time.Now()) {
+			if err := afterSnippets.Save(); err != nil {
+				return err
+			}
+		}
+	}
 
 	if config.Conf.Gist.AutoSync {
-		return petSync.AutoSync(snippetFile)
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
 	}
 
 	return nil
 }
 
+// editSelected opens only the selected snippet in the editor and merges the
+// result back into the snippet file. Removing the snippet from the temporary
+// file deletes it, adding more snippets inserts them.
+func editSelected(editor string) (changed bool, err error) {
+	var options []string
+	if config.Flag.Query != "" {
+		options = append(options, fmt.Sprintf("--query %s", quoteArg(config.Flag.Query)))
+	}
+	selected, err := selectSnippets(options, snippet.TagFilter{})
+	if err != nil || len(selected) == 0 {
+		return false, err
+	}
+	return editSnippet(editor, selected[0])
+}
+
+// editSnippet opens the snippet in the editor and merges the result back
+// into its snippet file
+func editSnippet(editor string, s snippet.SnippetInfo) (changed bool, err error) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return false, err
+	}
+	idx := snippets.Index(s)
+	if idx < 0 {
+		return false, fmt.Errorf("Snippet [%s] not found", s.Description)
+	}
+
+	f, err := os.CreateTemp("", "pet-*.toml")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(f.Name())
+
+	single := snippet.Snippets{Snippets: []snippet.SnippetInfo{snippets.Snippets[idx]}}
+	before, err := single.ToString()
+	if err != nil {
+		return false, err
+	}
+	_, err = f.WriteString(before)
+	f.Close()
+	if err != nil {
+		return false, err
+	}
+
+	if err = editFile(editor, f.Name()); err != nil {
+		return false, err
+	}
+	if fileContent(f.Name()) == before {
+		return false, nil
+	}
+
+	var edited snippet.Snippets
+	if _, err := toml.DecodeFile(f.Name(), &edited); err != nil {
+		return false, fmt.Errorf("Failed to parse the edited snippet: %v", err)
+	}
+	for i := range edited.Snippets {
+		edited.Snippets[i].SetFile(snippets.Snippets[idx].File())
+	}
+	warnSimilarTags(snippets.Snippets, edited.Snippets)
+
+	if err := snippet.Trash(snippet.Removed(single.Snippets, edited.Snippets)); err != nil {
+		return false, err
+	}
+	rest := append([]snippet.SnippetInfo{}, snippets.Snippets[idx+1:]...)
+	snippets.Snippets = append(append(snippets.Snippets[:idx], edited.Snippets...), rest...)
+	return true, snippets.Save()
+}
+
+// decryptedCopy writes the encrypted snippet file decrypted to a temporary
+// file
+func decryptedCopy(file string) (string, error) {
+	data, err := snippet.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "pet-*.toml")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
 func fileContent(fname string) string {
 	data, _ := os.ReadFile(fname)
 	return string(data)
@@ -50,4 +192,11 @@ func fileContent(fname string) string {
 
 func init() {
 	RootCmd.AddCommand(editCmd)
+	editCmd.Flags().BoolVarP(&config.Flag.Select, "select", "s", false,
+		`Select a snippet and edit only that one`)
+	editCmd.Flags().StringVarP(&config.Flag.Query, "query", "q", "",
+		`Initial value for query (with --select)`)
+	editCmd.Flags().StringVarP(&config.Flag.File, "file", "f", "",
+		`Snippet file to edit (a bare name is looked up in snippetdir)`)
+	editCmd.RegisterFlagCompletionFunc("query", completeDescriptions)
 }
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// waitFlags are the options which keep GUI editors from returning before
+// the file is closed
+var waitFlags = map[string]string{
+	"code":     "--wait",
+	"codium":   "--wait",
+	"cursor":   "--wait",
+	"subl":     "--wait",
+	"atom":     "--wait",
+	"zed":      "--wait",
+	"mate":     "--wait",
+	"gvim":     "--nofork",
+	"mvim":     "--nofork",
+	"gedit":    "--wait",
+	"kate":     "--block",
+	"idea":     "--wait",
+	"charm":    "--wait",
+	"goland":   "--wait",
+	"pycharm":  "--wait",
+	"webstorm": "--wait",
+}
+
+// editorCommand returns the words of the editor: the configured one,
+// $VISUAL, $EDITOR or vi (notepad on Windows)
+func editorCommand(editor string) []string {
+	for _, e := range []string{editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if args := splitArgs(e); len(args) > 0 {
+			return withWaitFlag(args)
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// withWaitFlag adds the wait option of a known GUI editor, unless it is
+// given (e.g. "code" runs as "code --wait")
+func withWaitFlag(args []string) []string {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(args[0])), ".exe")
+	flag, ok := waitFlags[name]
+	if !ok {
+		return args
+	}
+	for _, a := range args[1:] {
+		if a == flag || a == "-w" || a == "-f" || a == "--wait" || a == "--block" {
+			return args
+		}
+	}
+	return append(args, flag)
+}
+
+// splitArgs splits the command into words like a shell, with single and
+// double quotes (and backslashes except on Windows)
+func splitArgs(command string) []string {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range command {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'' && runtime.GOOS != "windows":
+			escaped, inWord = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args
+}
+
+// editFile opens the file in the editor and waits until it exits. The
+// editor is run without a shell, so that the file needs no quoting.
+func editFile(editor, file string) error {
+	args := append(editorCommand(editor), file)
+	before, _ := os.Stat(file)
+	start := time.Now()
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to run the editor %s: %v", strings.Join(args[:len(args)-1], " "), err)
+	}
+
+	// a forking editor returns before the file is saved
+	after, _ := os.Stat(file)
+	if time.Since(start) < 500*time.Millisecond && before != nil && after != nil && after.ModTime().Equal(before.ModTime()) {
+		fmt.Fprintf(os.Stderr, "The editor %s returned at once without changes. If it forks, add its wait option to editor (e.g. \"code --wait\").\n", args[0])
+	}
+	return nil
+}
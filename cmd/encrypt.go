@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the snippet files",
+	Long: `Encrypt the snippet files with the identity or the passphrase of [Encryption], and the
+versions, trash, last execution and output, usage and parameter history pet keeps
+in the data directory`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return encryptFiles(true)
+	},
+}
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the snippet files",
+	Long:  `Decrypt the snippet files and the data files encrypted by pet encrypt`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return encryptFiles(false)
+	},
+}
+
+// encryptFiles encrypts or decrypts the snippet files which are not yet
+func encryptFiles(encrypt bool) error {
+	enc := config.Conf.Encryption
+	if encrypt && enc.Identity == "" && !enc.Passphrase {
+		return errors.New("set identity or passphrase in [Encryption] to encrypt the snippet files")
+	}
+	if encrypt && enc.Passphrase && os.Getenv("PET_PASSPHRASE") == "" {
+		// a mistyped passphrase would lock the snippets away
+		pass, err := askPassphrase()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, "Again: ")
+		again, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		if string(again) != pass {
+			return errors.New("the passphrases differ")
+		}
+		snippet.SetPassphrase(pass)
+	}
+	files, err := snippet.SnippetFiles()
+	if err != nil {
+		return err
+	}
+	action := map[bool]string{true: "Encrypted", false: "Decrypted"}[encrypt]
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) || snippet.Encrypted(file) == encrypt {
+			continue
+		}
+		if err := snippet.EncryptFile(file, encrypt); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", action, file)
+	}
+	changed, err := snippet.EncryptDataFiles(encrypt)
+	for _, file := range changed {
+		fmt.Printf("%s %s\n", action, file)
+	}
+	return err
+}
+
+// askPassphrase asks the passphrase of the snippet files on the terminal
+func askPassphrase() (string, error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("the snippet files are encrypted with a passphrase, set $PET_PASSPHRASE")
+	}
+	fmt.Fprint(os.Stderr, "Passphrase of the snippets: ")
+	pass, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(pass), err
+}
+
+func init() {
+	RootCmd.AddCommand(encryptCmd)
+	RootCmd.AddCommand(decryptCmd)
+	snippet.AskPassphrase = askPassphrase
+}
@@ -1,44 +1,525 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
 	"github.com/spf13/cobra"
 	"gopkg.in/alessio/shellescape.v1"
 )
 
 // execCmd represents the exec command
 var execCmd = &cobra.Command{
-	Use:   "exec",
+	Use:   "exec [NAME] [-- ARG...]",
 	Short: "Run the selected commands",
-	Long:  `Run the selected commands directly`,
-	RunE:  execute,
+	Long: `Run the selected commands directly
+
+If the NAME of a snippet is given, it is run without the selector.
+Several snippets selected together (Tab in fzf) run one by one in order,
+stopping at the first failure unless --keep-going is given.
+
+The arguments after -- are quoted and added at the end of each command, or
+where the command has {{args}}:
+
+  pet exec grep-todo -- src/main.go "docs/a b.md"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args = args[:dash]
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	RunE: execute,
 }
 
 func execute(cmd *cobra.Command, args []string) (err error) {
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, snippet.Args = args[:dash], args[dash:]
+	}
 	flag := config.Flag
+	// confirm the expanded command in the parameter dialog before running it
+	dialog.Review = !flag.Yes && !flag.DryRun
 
 	var options []string
 	if flag.Query != "" {
-		options = append(options, fmt.Sprintf("--query %s", shellescape.Quote(flag.Query)))
+		options = append(options, fmt.Sprintf("--query %s", quoteArg(flag.Query)))
+	}
+
+	var snippets []snippet.SnippetInfo
+	var executions []snippet.Execution
+	switch {
+	case flag.Last:
+		if snippets, executions, err = lastExecutions(flag.Reprompt); err != nil {
+			return err
+		}
+	case len(args) > 0:
+		s, err := snippetByName(args[0])
+		if err != nil {
+			return err
+		}
+		snippets = []snippet.SnippetInfo{s}
+	default:
+		if snippets, err = selectSnippets(append(options, multiSelectOptions()...), tagFilter()); err != nil {
+			return err
+		}
 	}
+	return runSnippets(snippets, executions)
+}
 
-	commands, err := filter(options, flag.FilterTag)
+// runSnippets runs the snippets, filling in the parameters unless executions
+// are given, and records them as the last execution and in the usage stats.
+func runSnippets(snippets []snippet.SnippetInfo, executions []snippet.Execution) (err error) {
+	for _, s := range snippets {
+		if s.Supports(runtime.GOOS) || config.Flag.Yes || config.Flag.DryRun {
+			continue
+		}
+		msg := i18n.T("Snippet [%s] is for %s. Run it on %s?", s.Description, strings.Join(s.Platform, ", "), runtime.GOOS)
+		if !confirm(color.RedString(msg)) {
+			return errors.New("canceled")
+		}
+	}
+	for _, s := range snippets {
+		missing := s.Missing(exec.LookPath)
+		if len(missing) == 0 || config.Flag.Host != "" || config.Flag.Yes || config.Flag.DryRun {
+			continue
+		}
+		msg := i18n.T("Snippet [%s] requires %s, not found on PATH. Run it anyway?", s.Description, strings.Join(missing, ", "))
+		if !confirm(color.RedString(msg)) {
+			return errors.New("canceled")
+		}
+	}
+	out, err := openOutput()
 	if err != nil {
 		return err
 	}
-	command := strings.Join(commands, "; ")
+	defer func() {
+		if cerr := out.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	if len(snippets) > 1 && !config.Flag.DryRun {
+		return runEach(snippets, executions, out.Writer)
+	}
+	_, err = runTo(snippets, executions, out.Writer, nil)
+	return err
+}
+
+// capturing reports whether a snippet captures its output
+func capturing(snippets []snippet.SnippetInfo) bool {
+	for _, s := range snippets {
+		if s.Capture != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runEach runs the snippets one by one in order, so that each runs with its
+// own shell and the output of a snippet with capture is the value of that
+// parameter in the next ones. It stops at the first failure unless
+// --keep-going is given. The parameters are asked for all the snippets first,
+// unless a snippet captures its output for the next ones. The output is
+// written to out.
+func runEach(snippets []snippet.SnippetInfo, executions []snippet.Execution, out io.Writer) error {
+	if executions == nil && !capturing(snippets) {
+		var err error
+		if executions, err = expandSnippets(snippets); err != nil {
+			return err
+		}
+	}
+
+	var saved []snippet.Execution
+	failed := 0
+	for i, s := range snippets {
+		var e []snippet.Execution
+		if executions != nil {
+			e = executions[i : i+1]
+		}
+		public, err := runCapturing(s, e, out, saved)
+		saved = append(saved, public...)
+		if err != nil {
+			if err == errCanceled || !config.Flag.KeepGoing {
+				return fmt.Errorf("Snippet [%s] failed: %v", s.Description, err)
+			}
+			fmt.Fprintf(os.Stderr, "%s [%s]: %v\n", color.RedString("Failed"), s.Description, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snippets failed", failed, len(snippets))
+	}
+	return nil
+}
+
+// runCapturing runs the snippet like runTo, and keeps its output as the
+// value of its capture parameter in the next snippets
+func runCapturing(s snippet.SnippetInfo, executions []snippet.Execution, w io.Writer, previous []snippet.Execution) ([]snippet.Execution, error) {
+	var buf bytes.Buffer
+	if s.Capture != "" {
+		w = io.MultiWriter(w, &buf)
+	}
+	public, err := runTo([]snippet.SnippetInfo{s}, executions, w, previous)
+	if err == nil && s.Capture != "" {
+		captured[s.Capture] = strings.TrimSpace(buf.String())
+	}
+	return public, err
+}
+
+// runTo runs the snippets with the output written to w and saves them after
+// previous as the last execution. It returns the executions as saved.
+func runTo(snippets []snippet.SnippetInfo, executions []snippet.Execution, w io.Writer, previous []snippet.Execution) (public []snippet.Execution, err error) {
+	if executions == nil {
+		if executions, err = expandSnippets(snippets); err != nil {
+			return nil, err
+		}
+	}
+
+	command, display, public := joinExecutions(executions)
 	if config.Flag.Debug {
-		fmt.Printf("Command: %s\n", command)
+		fmt.Printf("Command: %s\n", display)
+	}
+	if config.Flag.DryRun {
+		display = redactor().Redact(display)
+		if config.Flag.Quote {
+			display = shellescape.Quote(display)
+		}
+		fmt.Println(display)
+		return public, nil
+	}
+	var dir string
+	if config.Flag.Host != "" {
+		dir, err = remoteDir(snippets)
+	} else {
+		dir, err = workDir(executions)
+	}
+	if err != nil {
+		return public, err
+	}
+	timeout, err := execTimeout(snippets)
+	if err != nil {
+		return public, err
+	}
+	if shouldCheck() {
+		if err := checkExecution(command, snippets); err != nil {
+			return nil, err
+		}
+	}
+	sudo := needsSudo(snippets)
+	if !config.Flag.Yes && (needsConfirm(snippets) || sudo) {
+		fmt.Fprintf(color.Output, "%s: %s\n", color.RedString("Command"), display)
+		question := "This snippet is marked as dangerous. Run it?"
+		if sudo && needsConfirm(snippets) {
+			question = "This snippet is marked as dangerous. Run it with elevated privileges?"
+		} else if sudo {
+			question = "Run this snippet with elevated privileges?"
+		}
+		if !confirm(color.RedString(i18n.T(question))) {
+			return nil, errCanceled
+		}
+	} else if config.Flag.Command {
+		fmt.Printf("%s: %s\n", color.YellowString("Command"), display)
+	}
+	opts := scriptOptions{dir: dir, timeout: timeout, sudo: sudo, host: config.Flag.Host}
+	err = runExecutions(snippets, executions, public, previous, command, display, opts, os.Stdin, w)
+	if err != nil && err != errSentToTmux && len(snippets) == 1 && snippets[0].OnFailure != "" {
+		runOnFailure(snippets[0], executions[0], w)
+	}
+	if err == errSentToTmux {
+		err = nil
+	}
+	return public, err
+}
+
+// errSentToTmux is returned by runExecutions for a command sent to a tmux
+// pane, which has not run yet
+var errSentToTmux = errors.New("sent to tmux")
+
+// joinExecutions returns the commands of the executions run together, as
+// they are run and as they are shown, and the executions as they are saved
+func joinExecutions(executions []snippet.Execution) (command, display string, public []snippet.Execution) {
+	var commands, shown []string
+	for _, e := range executions {
+		commands = append(commands, e.Command)
+		public = append(public, e.Public())
+		shown = append(shown, e.Public().Command)
+	}
+	separator := "; "
+	if strings.Contains(strings.Join(commands, ""), "\n") {
+		// a heredoc must end on its own line
+		separator = "\n"
+	}
+	// secret values are never shown
+	return strings.Join(commands, separator), strings.Join(shown, separator), public
+}
+
+// runExecutions runs the command of the executions of the snippets, as
+// joined by joinExecutions, with their attachments, hooks and output
+// filter, and records it in the last executions, the parameter history,
+// the audit log and the usage. It is the run of pet exec once the
+// parameters are filled in and the command confirmed.
+func runExecutions(snippets []snippet.SnippetInfo, executions, public, previous []snippet.Execution, command, display string, opts scriptOptions, r io.Reader, w io.Writer) error {
+	if len(public) > 0 {
+		for i := range public {
+			public[i].Time = time.Now()
+		}
+		if lerr := snippet.SaveLast(append(previous, public...)); lerr != nil && config.Flag.Debug {
+			fmt.Fprintf(os.Stderr, "Failed to save the last execution: %v\n", lerr)
+		}
+		if perr := snippet.RecordParams(public); perr != nil && config.Flag.Debug {
+			fmt.Fprintf(os.Stderr, "Failed to save the parameter history: %v\n", perr)
+		}
+	}
+	if config.Flag.Tmux != "" {
+		if uerr := snippet.RecordUsage(snippets); uerr != nil && config.Flag.Debug {
+			fmt.Fprintf(os.Stderr, "Failed to record usage: %v\n", uerr)
+		}
+		if err := sendToTmux(command, config.Flag.Tmux, !config.Flag.NoEnter); err != nil {
+			return err
+		}
+		return errSentToTmux
+	}
+	for i, s := range snippets {
+		if i >= len(executions) || len(s.Attachments) == 0 {
+			continue
+		}
+		dir := executions[i].AttachmentDir
+		if err := s.WriteAttachments(dir); err != nil {
+			return err
+		}
+		defer snippet.RemoveAttachments(dir)
+	}
+	opts.shell, _ = commonShell(snippets)
+	opts.env = execEnv(executions)
+	env := hookEnv(snippets, display)
+	if err := runPreHooks(snippets, env); err != nil {
+		return err
+	}
+	filtered, wait, err := filterOutput(snippets, w, opts.dir)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = runScript(command, opts, r, filtered)
+	if ferr := wait(); err == nil {
+		err = ferr
+	}
+	took := time.Since(start)
+	if aerr := snippet.Audit(auditEntries(public, err, start, took)); aerr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the audit log: %v\n", aerr)
+	}
+	runPostHooks(snippets, env, err, took)
+	notifyDone(snippets, err, took)
+	if uerr := snippet.RecordUsage(snippets); uerr != nil && config.Flag.Debug {
+		fmt.Fprintf(os.Stderr, "Failed to record usage: %v\n", uerr)
+	}
+	return err
+}
+
+// runOnFailure offers to run the on_failure snippet of the failed snippet s,
+// with the values of the parameters of its execution e filled in. The
+// snippet still fails, whatever the on_failure snippet does.
+func runOnFailure(s snippet.SnippetInfo, e snippet.Execution, w io.Writer) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the on_failure snippet: %v\n", err)
+		return
+	}
+	fallback, ok := snippets.FindByRef(s.OnFailure)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "on_failure snippet [%s] of [%s] not found\n", s.OnFailure, s.Description)
+		return
+	}
+	if !config.Flag.Yes && !confirm(color.YellowString(i18n.T("Snippet [%s] failed. Run [%s]?", s.Description, fallback.Description))) {
+		return
+	}
+	// no chain of on_failure snippets
+	fallback.OnFailure = ""
+	fallback.Command = dialog.FillParams(fallback.Command, e.Public().Params)
+	if _, err := runTo([]snippet.SnippetInfo{fallback}, nil, w, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "%s [%s]: %v\n", color.RedString("Failed"), fallback.Description, err)
+	}
+}
+
+// filterOutput returns the writer of the output of a snippet with an
+// output_filter, which writes it to w through the filter, and the function
+// waiting for the filter to finish. It is w itself for the others, several
+// snippets and with --raw.
+func filterOutput(snippets []snippet.SnippetInfo, w io.Writer, dir string) (io.Writer, func() error, error) {
+	if len(snippets) != 1 || snippets[0].OutputFilter == "" || config.Flag.Raw {
+		return w, func() error { return nil }, nil
+	}
+	filter := snippets[0].OutputFilter
+	if strings.HasPrefix(filter, ".") {
+		filter = "jq -r " + quoteArg(filter)
+	}
+	cmd := newCommand(shellArgs(filter))
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = w, os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("Failed to run the output filter %s: %v", filter, err)
+	}
+	return in, func() error {
+		in.Close()
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("The output filter %s failed: %v", filter, err)
+		}
+		return nil
+	}, nil
+}
+
+// workDir returns the directory the executions run in: that of --cwd, or
+// the one of their snippet (several snippets run one by one)
+func workDir(executions []snippet.Execution) (dir string, err error) {
+	if config.Flag.Cwd != "" {
+		if dir, err = snippet.ExpandDir(config.Flag.Cwd); err != nil {
+			return "", err
+		}
+	} else if len(executions) > 0 {
+		dir = executions[0].Dir
+	}
+	if dir == "" {
+		return "", nil
 	}
-	if config.Flag.Command {
-		fmt.Printf("%s: %s\n", color.YellowString("Command"), command)
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("The directory %s does not exist", dir)
+	}
+	return dir, nil
+}
+
+// execEnv returns the environment variables of the executions
+func execEnv(executions []snippet.Execution) map[string]string {
+	env := map[string]string{}
+	for _, e := range executions {
+		for k, v := range e.Env {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// execTimeout returns the timeout of --timeout, or the one of the
+// snippets (the shortest if several run together)
+func execTimeout(snippets []snippet.SnippetInfo) (timeout time.Duration, err error) {
+	if config.Flag.Timeout != "" {
+		return snippet.SnippetInfo{Timeout: config.Flag.Timeout}.TimeoutDuration()
+	}
+	for _, s := range snippets {
+		d, err := s.TimeoutDuration()
+		if err != nil {
+			return 0, fmt.Errorf("Snippet [%s]: %v", s.Description, err)
+		}
+		if d > 0 && (timeout == 0 || d < timeout) {
+			timeout = d
+		}
+	}
+	return timeout, nil
+}
+
+// auditEntries returns the audit log entries of the executions run together
+func auditEntries(public []snippet.Execution, runErr error, start time.Time, took time.Duration) []snippet.AuditEntry {
+	var entries []snippet.AuditEntry
+	for _, e := range public {
+		entries = append(entries, snippet.AuditEntry{
+			Time:        start,
+			Name:        e.Name,
+			Description: e.Description,
+			Command:     e.Command,
+			Params:      e.Params,
+			ExitCode:    exitCode(runErr),
+			DurationMS:  took.Milliseconds(),
+		})
+	}
+	return entries
+}
+
+// lastExecutions returns the last executed snippets. If reprompt is true,
+// the parameters are asked again with the previous values as defaults.
+func lastExecutions(reprompt bool) ([]snippet.SnippetInfo, []snippet.Execution, error) {
+	last, err := snippet.LoadLast()
+	if err != nil {
+		return nil, nil, err
+	}
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, nil, err
+	}
+
+	var selected []snippet.SnippetInfo
+	for _, e := range last {
+		s, ok := snippets.Find(e.Description)
+		if !ok {
+			// the snippet was removed or renamed
+			s = snippet.SnippetInfo{Name: e.Name, Description: e.Description, Command: e.Command, Dir: e.Dir}
+		} else if reprompt {
+			s.Command = dialog.WithDefaults(s.Command, e.Params)
+		}
+		selected = append(selected, s)
+	}
+	if reprompt {
+		executions, err := expandSnippets(selected)
+		return selected, executions, err
+	}
+
+	// the secrets were not saved and are asked again
+	for i, e := range last {
+		if !dialog.HasSecrets(e.Command) {
+			continue
+		}
+		s := selected[i]
+		s.Command = e.Command
+		executions, err := expandSnippets([]snippet.SnippetInfo{s})
+		if err != nil {
+			return nil, nil, err
+		}
+		last[i].Command = executions[0].Command
+		last[i].Env = executions[0].Env
+		last[i].Redacted = e.Command
+	}
+	// the attachments are written to a new directory
+	for i, e := range last {
+		if len(selected[i].Attachments) == 0 {
+			continue
+		}
+		if e.AttachmentDir == "" {
+			// saved before the executions kept their directory
+			executions, err := expandSnippetsWith(selected[i:i+1], e.Params, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			last[i] = executions[0]
+			continue
+		}
+		dir, err := snippet.NewAttachmentDir()
+		if err != nil {
+			return nil, nil, err
+		}
+		last[i] = e.WithAttachmentDir(dir)
+	}
+	return selected, last, nil
+}
+
+func needsConfirm(snippets []snippet.SnippetInfo) bool {
+	for _, s := range snippets {
+		if s.NeedsConfirm() {
+			return true
+		}
 	}
-	return run(command, os.Stdin, os.Stdout)
+	return false
 }
 
 func init() {
@@ -47,8 +528,52 @@ func init() {
 		`Enable colorized output (only fzf)`)
 	execCmd.Flags().StringVarP(&config.Flag.Query, "query", "q", "",
 		`Initial value for query`)
-	execCmd.Flags().StringVarP(&config.Flag.FilterTag, "tag", "t", "",
-		`Filter tag`)
+	addFilterFlags(execCmd)
+	execCmd.Flags().StringArrayVarP(&config.Flag.Params, "param", "p", nil,
+		`Parameter value NAME=VALUE instead of asking (can be repeated)`)
+	execCmd.ValidArgsFunction = completeNames
 	execCmd.Flags().BoolVarP(&config.Flag.Command, "command", "c", false,
 		`Show the command with the plain text before executing`)
+	execCmd.Flags().BoolVarP(&config.Flag.DryRun, "dry-run", "n", false,
+		`Print the command after filling in the parameters without executing it`)
+	execCmd.Flags().BoolVarP(&config.Flag.Quote, "quote", "", false,
+		`Shell-escape the command printed by --dry-run`)
+	execCmd.Flags().BoolVarP(&config.Flag.Yes, "yes", "y", false,
+		`Run snippets requiring confirmation without asking, and the filled in command without review`)
+	execCmd.Flags().BoolVarP(&config.Flag.Last, "last", "l", false,
+		`Run the last executed snippets again with the same parameters`)
+	execCmd.Flags().BoolVarP(&config.Flag.Reprompt, "reprompt", "", false,
+		`With --last, ask for the parameters again (previous values as defaults)`)
+	execCmd.Flags().BoolVarP(&config.Flag.KeepGoing, "keep-going", "k", false,
+		`With several snippets, run the next ones after a failure`)
+	execCmd.Flags().StringVarP(&config.Flag.Cwd, "cwd", "", "",
+		`Run the commands in this directory instead of the directory of the snippets`)
+	execCmd.Flags().StringVarP(&config.Flag.Timeout, "timeout", "", "",
+		`Stop the commands after this duration (e.g. 30s), instead of the timeout of the snippets`)
+	execCmd.Flags().BoolVarP(&config.Flag.Sudo, "sudo", "", false,
+		`Run the commands with elevated privileges (sudo_cmd of the config, sudo or doas)`)
+	execCmd.Flags().BoolVarP(&config.Flag.Pager, "pager", "", false,
+		`Page the output with $PAGER (default: less -R)`)
+	execCmd.Flags().StringVarP(&config.Flag.Tee, "tee", "", "",
+		`Also write the output to this file`)
+	execCmd.Flags().BoolVarP(&config.Flag.Raw, "raw", "", false,
+		`Show the output without the output_filter of the snippet`)
+	execCmd.Flags().BoolVarP(&config.Flag.SaveOutput, "save-output", "", false,
+		`Save the output for pet show --last-output (also with --pager and --tee)`)
+	execCmd.Flags().BoolVarP(&config.Flag.CopyOutput, "copy-output", "", false,
+		`Copy the output to the clipboard after the commands finish`)
+	execCmd.Flags().BoolVarP(&config.Flag.Notify, "notify", "", false,
+		`Send a desktop notification when the commands finish, however long they ran`)
+	execCmd.Flags().BoolVarP(&config.Flag.Check, "check", "", false,
+		`Check the commands with shellcheck before they run`)
+	execCmd.Flags().StringVarP(&config.Flag.Host, "host", "", "",
+		`Run the commands over ssh on this host of the config or of ~/.ssh/config`)
+	execCmd.RegisterFlagCompletionFunc("host", completeHosts)
+	execCmd.Flags().StringVarP(&config.Flag.Tmux, "tmux", "", "",
+		`Send the commands to this tmux pane (--tmux alone: the last pane) instead of running them`)
+	execCmd.Flags().Lookup("tmux").NoOptDefVal = lastPane
+	execCmd.Flags().BoolVarP(&config.Flag.NoEnter, "no-enter", "", false,
+		`With --tmux, type the commands without pressing Enter`)
+	execCmd.RegisterFlagCompletionFunc("query", completeDescriptions)
+	addAllFlag(execCmd)
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/exporter"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [- | DIR]",
+	Short: "Export snippets to other tools",
+	Long: `Export the snippets to the formats other tools import:
+
+  toml     the snippet file of pet, read by pet import - (the default)
+  alfred   an Alfred snippet collection (pet export --format alfred > pet.alfredsnippets)
+  raycast  the JSON of Raycast snippets
+  html     a searchable cheatsheet to publish, without the secrets (see --reveal)
+           and the local only snippets
+  markdown a note per snippet in the directory, e.g. an Obsidian vault or a
+           folder to import in Notion (pet export --format markdown ~/vault/pet)
+
+The snippets are written to stdout, also with -, e.g. to copy them to
+another machine: pet export - | ssh host pet import -
+
+The markdown notes are updated by exporting again: only the changed notes are
+written, and the notes of the removed snippets are deleted.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: export,
+}
+
+func export(cmd *cobra.Command, args []string) error {
+	if config.Flag.Format == "markdown" {
+		if len(args) == 0 || args[0] == "-" {
+			return fmt.Errorf("the markdown notes are written to a directory: pet export --format markdown DIR")
+		}
+	} else if len(args) > 0 && args[0] != "-" {
+		return fmt.Errorf("pet export writes to stdout (-), redirect it to %s", args[0])
+	}
+	snippets, err := loadFiltered(tagFilter(), config.Flag.Path)
+	if err != nil {
+		return err
+	}
+	switch config.Flag.Format {
+	case "", "toml":
+		exported := snippet.Snippets{Snippets: shareable(snippets.Snippets)}
+		content, err := exported.ToString()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Print(content)
+		return err
+	case "alfred":
+		if terminal.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("the Alfred collection is a zip file, redirect it to a .alfredsnippets file")
+		}
+		return exporter.ToAlfred(os.Stdout, snippets.Snippets)
+	case "raycast":
+		return exporter.ToRaycast(os.Stdout, snippets.Snippets)
+	case "html":
+		return exporter.ToHTML(os.Stdout, config.Flag.Title, published(snippets.Snippets))
+	case "markdown":
+		result, err := exporter.ToMarkdownVault(args[0], shareable(snippets.Snippets))
+		if err != nil {
+			return fmt.Errorf("Failed to export the notes: %v", err)
+		}
+		fmt.Fprintf(color.Output, "%s %d notes, removed %d (%d unchanged) in %s\n",
+			color.GreenString("Wrote"), len(result.Written), len(result.Removed), result.Unchanged, args[0])
+		return nil
+	}
+	return fmt.Errorf("unknown format: %s (toml, alfred, raycast, html or markdown)", config.Flag.Format)
+}
+
+// shareable returns the snippets which may leave the machine
+func shareable(snippets []snippet.SnippetInfo) []snippet.SnippetInfo {
+	var public []snippet.SnippetInfo
+	for _, s := range snippets {
+		if !s.LocalOnly() {
+			public = append(public, s)
+		}
+	}
+	return public
+}
+
+// published returns the shareable snippets with their secrets hidden
+func published(snippets []snippet.SnippetInfo) []snippet.SnippetInfo {
+	r := redactor()
+	public := shareable(snippets)
+	for i, s := range public {
+		public[i] = s.Redact(r)
+	}
+	return public
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&config.Flag.Format, "format", "", "",
+		`Output format (toml, alfred, raycast, html or markdown)`)
+	exportCmd.Flags().StringVarP(&config.Flag.Title, "title", "", "pet snippets",
+		`Title of the html cheatsheet`)
+	exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"toml", "alfred", "raycast", "html", "markdown"}, cobra.ShellCompDirectiveNoFileComp))
+	addFilterFlags(exportCmd)
+	addAllFlag(exportCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// favCmd represents the fav command
+var favCmd = &cobra.Command{
+	Use:   "fav [NAME...]",
+	Short: "Toggle favorite snippets",
+	Long:  `Pin the selected snippets (or the snippets with the NAMEs) at the top of the selector, or unpin them if they are favorites already`,
+	RunE:  fav,
+}
+
+func fav(cmd *cobra.Command, args []string) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	var targets []snippet.SnippetInfo
+	if len(args) > 0 {
+		for _, name := range args {
+			s, ok := snippets.FindByName(name)
+			if !ok {
+				return fmt.Errorf("Snippet named [%s] not found", name)
+			}
+			targets = append(targets, s)
+		}
+	} else {
+		var err error
+		if targets, err = selectSnippets(multiSelectOptions(), tagFilter()); err != nil {
+			return err
+		}
+	}
+	if len(targets) == 0 {
+		return errors.New("no snippets selected")
+	}
+
+	for _, t := range targets {
+		i := snippets.Index(t)
+		if i < 0 {
+			continue
+		}
+		s := &snippets.Snippets[i]
+		s.Favorite = !s.Favorite
+		if s.Favorite {
+			fmt.Printf("Pinned [%s]\n", s.Description)
+		} else {
+			fmt.Printf("Unpinned [%s]\n", s.Description)
+		}
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(favCmd)
+	addFilterFlags(favCmd)
+	favCmd.ValidArgsFunction = completeNames
+}
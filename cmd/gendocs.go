@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// gendocsCmd represents the gendocs command
+var gendocsCmd = &cobra.Command{
+	Use:    "gendocs",
+	Short:  "Generate documentation for all commands",
+	Long:   `Generate man pages or markdown documentation for all commands (for packagers)`,
+	Hidden: true,
+	RunE:   gendocs,
+}
+
+func gendocs(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+	if !flag.Man && !flag.Markdown {
+		return errors.New("specify --man and/or --markdown")
+	}
+
+	if err := os.MkdirAll(flag.Dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create directory: %v", err)
+	}
+
+	RootCmd.DisableAutoGenTag = true
+	if flag.Man {
+		header := &doc.GenManHeader{
+			Title:   "PET",
+			Section: "1",
+			Source:  "pet " + version,
+			Manual:  "pet manual",
+		}
+		if err := doc.GenManTree(RootCmd, header, flag.Dir); err != nil {
+			return fmt.Errorf("Failed to generate man pages: %v", err)
+		}
+	}
+	if flag.Markdown {
+		if err := doc.GenMarkdownTree(RootCmd, flag.Dir); err != nil {
+			return fmt.Errorf("Failed to generate markdown: %v", err)
+		}
+	}
+	fmt.Printf("Documentation written to %s\n", flag.Dir)
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(gendocsCmd)
+	gendocsCmd.Flags().BoolVarP(&config.Flag.Man, "man", "", false,
+		`Generate man pages`)
+	gendocsCmd.Flags().BoolVarP(&config.Flag.Markdown, "markdown", "", false,
+		`Generate markdown documentation`)
+	gendocsCmd.Flags().StringVarP(&config.Flag.Dir, "dir", "", "man",
+		`Output directory`)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// grepCmd represents the grep command
+var grepCmd = &cobra.Command{
+	Use:   "grep [-e PATTERN]... [PATTERN]",
+	Short: "Search snippets non-interactively",
+	Long: `Search commands, descriptions and tags with regular expressions and print
+the matches without launching the selector (exit status is 1 if nothing matched)`,
+	RunE: grep,
+}
+
+// grepMatch is a line of a snippet matching the patterns
+type grepMatch struct {
+	Field string `json:"field"`
+	Line  string `json:"line"`
+}
+
+// grepResult is a snippet matching the patterns
+type grepResult struct {
+	snippet.SnippetInfo
+	Matches []grepMatch `json:"matches"`
+}
+
+func grep(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	patterns := flag.Patterns
+	if len(patterns) == 0 {
+		if len(args) == 0 {
+			return errors.New("pattern is required")
+		}
+		patterns, args = args[:1], args[1:]
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		if flag.IgnoreCase {
+			p = "(?i)" + p
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("Invalid pattern: %v", err)
+		}
+		res = append(res, re)
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	if !flag.All {
+		snippets = snippets.Active()
+	}
+	results := grepSnippets(snippets.Snippets, res)
+
+	switch {
+	case flag.JSON:
+		if results == nil {
+			results = []grepResult{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	case flag.Count:
+		fmt.Println(len(results))
+	case flag.FilesWithMatches:
+		for _, r := range results {
+			fmt.Println(r.Description)
+		}
+	default:
+		for _, r := range results {
+			for _, m := range r.Matches {
+				fmt.Fprintf(color.Output, "%s:%s:%s\n",
+					color.MagentaString(r.Description), color.GreenString(m.Field),
+					highlight(m.Line, res))
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		return errNoMatch
+	}
+	return nil
+}
+
+// grepSnippets returns the snippets where any line of the command,
+// description or tags matches any of the patterns
+func grepSnippets(snippets []snippet.SnippetInfo, res []*regexp.Regexp) (results []grepResult) {
+	matchAny := func(s string) bool {
+		for _, re := range res {
+			if re.MatchString(s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, s := range snippets {
+		var matches []grepMatch
+		if matchAny(s.Description) {
+			matches = append(matches, grepMatch{Field: "description", Line: s.Description})
+		}
+		for _, line := range strings.Split(s.Command, "\n") {
+			if matchAny(line) {
+				matches = append(matches, grepMatch{Field: "command", Line: line})
+			}
+		}
+		for _, t := range s.Tag {
+			if matchAny(t) {
+				matches = append(matches, grepMatch{Field: "tag", Line: t})
+			}
+		}
+		if len(matches) > 0 {
+			results = append(results, grepResult{SnippetInfo: s, Matches: matches})
+		}
+	}
+	return results
+}
+
+func highlight(line string, res []*regexp.Regexp) string {
+	for _, re := range res {
+		line = re.ReplaceAllStringFunc(line, func(s string) string {
+			return color.RedString(s)
+		})
+	}
+	return line
+}
+
+func init() {
+	RootCmd.AddCommand(grepCmd)
+	grepCmd.Flags().StringArrayVarP(&config.Flag.Patterns, "regexp", "e", nil,
+		`Use PATTERN for matching (can be repeated)`)
+	grepCmd.Flags().BoolVarP(&config.Flag.IgnoreCase, "ignore-case", "i", false,
+		`Ignore case distinctions`)
+	grepCmd.Flags().BoolVarP(&config.Flag.FilesWithMatches, "files-with-matches", "l", false,
+		`Print only the descriptions of matching snippets`)
+	grepCmd.Flags().BoolVarP(&config.Flag.Count, "count", "c", false,
+		`Print only the number of matching snippets`)
+	grepCmd.Flags().BoolVarP(&config.Flag.JSON, "json", "", false,
+		`Print matching snippets as JSON`)
+	addAllFlag(grepCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// highlightCommand returns the command of the snippet colorized for its shell.
+// Commands are left as they are without colors, a highlight style in the
+// theme or a known language.
+func highlightCommand(s snippet.SnippetInfo) string {
+	if color.NoColor || colors.highlight == "" {
+		return s.Command
+	}
+	lexer := lexers.Get(interpreterFor(s.Shell).lexer)
+	if lexer == nil {
+		return s.Command
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, s.Command)
+	if err != nil {
+		return s.Command
+	}
+	tokens := iterator.Tokens()
+	if n := len(tokens); n > 0 && !strings.HasSuffix(s.Command, "\n") {
+		// lexers end the text with a newline
+		tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+	}
+	var b strings.Builder
+	if err := formatters.TTY256.Format(&b, styles.Get(colors.highlight), chroma.Literator(tokens...)); err != nil {
+		return s.Command
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Run snippets again from the audit log",
+	Long: `Select one of the executions of the audit log (enabled = true in [Audit]), most
+recent first, and run its snippet again with the parameter values of that
+execution as the defaults of the dialog. Secret values are asked again.
+
+With --list, the executions are printed instead.`,
+	RunE: history,
+}
+
+func history(cmd *cobra.Command, args []string) error {
+	entries, err := snippet.LoadAudit()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("No executions in the audit log, enable it with enabled = true in [Audit]")
+	}
+	// most recent first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit := config.Flag.Limit; limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	col := config.Conf.General.Column
+	if col == 0 {
+		col = column
+	}
+	if config.Flag.List {
+		for _, e := range entries {
+			fmt.Fprintf(color.Output, "%s  %s%s\n", color.GreenString(e.Time.Format("2006-01-02 15:04:05")),
+				runewidth.Truncate(historyLine(e), col, "..."), exitStatus(e))
+		}
+		return nil
+	}
+
+	lines := map[string]snippet.AuditEntry{}
+	var text strings.Builder
+	for _, e := range entries {
+		line := e.Time.Format("2006-01-02 15:04:05") + "  " + historyLine(e)
+		if _, ok := lines[line]; ok {
+			continue
+		}
+		lines[line] = e
+		text.WriteString(line + "\n")
+	}
+	var buf bytes.Buffer
+	if err := runSelector(nil, strings.NewReader(text.String()), &buf, dialog.FindOptions{}); err != nil {
+		return nil
+	}
+	e, ok := lines[strings.SplitN(strings.TrimSuffix(buf.String(), "\n"), "\n", 2)[0]]
+	if !ok {
+		return nil
+	}
+	dialog.Review = !config.Flag.Yes
+	return runSnippets([]snippet.SnippetInfo{historySnippet(e)}, nil)
+}
+
+// historyLine returns the description and the command of an execution on
+// one line
+func historyLine(e snippet.AuditEntry) string {
+	return fmt.Sprintf("[%s] %s", e.Description, strings.Replace(e.Command, "\n", "\\n", -1))
+}
+
+// exitStatus flags a failed execution
+func exitStatus(e snippet.AuditEntry) string {
+	if e.ExitCode == 0 {
+		return ""
+	}
+	return color.RedString(" (exit %d)", e.ExitCode)
+}
+
+// historySnippet returns the snippet of the execution with its parameter
+// values as defaults, or a snippet of the command as it was run if the
+// snippet was removed
+func historySnippet(e snippet.AuditEntry) snippet.SnippetInfo {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err == nil {
+		s, ok := snippets.Find(e.Description)
+		if e.Name != "" {
+			s, ok = snippets.FindByName(e.Name)
+		}
+		if ok {
+			s.Command = dialog.WithDefaults(s.Command, e.Params)
+			return s
+		}
+	}
+	return snippet.SnippetInfo{Name: e.Name, Description: e.Description, Command: e.Command}
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntVarP(&config.Flag.Limit, "limit", "n", 100,
+		`Number of executions to show (0: all)`)
+	historyCmd.Flags().BoolVarP(&config.Flag.List, "list", "l", false,
+		`Print the executions instead of selecting one`)
+	historyCmd.Flags().BoolVarP(&config.Flag.Yes, "yes", "y", false,
+		`Run snippets requiring confirmation without asking, and the filled in command without review`)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// hookEnv returns the environment of the hooks of the snippets run as the
+// (redacted) command
+func hookEnv(snippets []snippet.SnippetInfo, command string) map[string]string {
+	var descriptions, names, tags []string
+	for _, s := range snippets {
+		descriptions = append(descriptions, s.Description)
+		if s.Name != "" {
+			names = append(names, s.Name)
+		}
+		tags = append(tags, s.Tag...)
+	}
+	return map[string]string{
+		"PET_DESCRIPTION": strings.Join(descriptions, "; "),
+		"PET_NAME":        strings.Join(names, " "),
+		"PET_TAGS":        strings.Join(tags, " "),
+		"PET_COMMAND":     command,
+	}
+}
+
+// runPreHooks runs pre_exec of [Hooks] and of the snippets. A failing hook
+// stops the execution.
+func runPreHooks(snippets []snippet.SnippetInfo, env map[string]string) error {
+	hooks := []string{config.Conf.Hooks.PreExec}
+	for _, s := range snippets {
+		hooks = append(hooks, s.PreExec)
+	}
+	for _, hook := range hooks {
+		if hook == "" {
+			continue
+		}
+		if err := runEnv(hook, env, os.Stdin, os.Stderr); err != nil {
+			return fmt.Errorf("pre_exec hook failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// runPostHooks runs post_exec of the snippets and of [Hooks] with the exit
+// code and duration of the execution. Failing hooks are only reported.
+func runPostHooks(snippets []snippet.SnippetInfo, env map[string]string, runErr error, took time.Duration) {
+	var hooks []string
+	for _, s := range snippets {
+		hooks = append(hooks, s.PostExec)
+	}
+	hooks = append(hooks, config.Conf.Hooks.PostExec)
+
+	env["PET_EXIT_CODE"] = strconv.Itoa(exitCode(runErr))
+	env["PET_DURATION_MS"] = strconv.FormatInt(took.Milliseconds(), 10)
+	for _, hook := range hooks {
+		if hook == "" {
+			continue
+		}
+		if err := runEnv(hook, env, os.Stdin, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "post_exec hook failed: %v\n", err)
+		}
+	}
+}
+
+// exitCode returns the exit status of the command of the error
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// eventHook returns the hook of [Hooks] for the event
+func eventHook(event string) string {
+	hooks := config.Conf.Hooks
+	return map[string]string{
+		snippet.EventCreate:   hooks.OnCreate,
+		snippet.EventEdit:     hooks.OnEdit,
+		snippet.EventDelete:   hooks.OnDelete,
+		snippet.EventSync:     hooks.OnSync,
+		snippet.EventConflict: hooks.OnConflict,
+	}[event]
+}
+
+// runEventHook runs the hook of the event with its snippets in the
+// environment of the exec hooks. Failing hooks are only reported.
+func runEventHook(e snippet.Event) {
+	hook := eventHook(e.Name)
+	if hook == "" {
+		return
+	}
+	var commands []string
+	for _, s := range e.Snippets {
+		commands = append(commands, s.Command)
+	}
+	env := hookEnv(e.Snippets, strings.Join(commands, "; "))
+	env["PET_EVENT"] = e.Name
+	env["PET_SYNC"] = e.Detail
+	env["PET_COUNT"] = strconv.Itoa(len(e.Snippets))
+	if err := runEnv(hook, env, nil, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "on_%s hook failed: %v\n", e.Name, err)
+	}
+}
+
+func init() {
+	snippet.OnEvent = runEventHook
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/importer"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [-]",
+	Short: "Import snippets from other sources",
+	Long: `Import snippets from other sources (e.g. Makefile targets, justfile recipes, shared snippets, SnippetsLab, Dash, Warp and iTerm2)
+
+With -, the snippets written by pet export are read from stdin.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: importSnippets,
+}
+
+func importSnippets(cmd *cobra.Command, args []string) (err error) {
+	flag := config.Flag
+
+	var imported []snippet.SnippetInfo
+	switch {
+	case len(args) > 0 && args[0] == "-":
+		imported, err = importStdin()
+	case len(args) > 0:
+		return fmt.Errorf("pet import reads stdin (-), use pet merge %s to merge a snippet file", args[0])
+	case flag.Makefile != "":
+		imported, err = importMakefile(flag.Makefile, flag.Recipe)
+	case flag.Justfile != "":
+		imported, err = importJustfile(flag.Justfile, flag.Recipe)
+	case flag.URL != "":
+		imported, err = importURL(flag.URL)
+	case flag.SnippetsLab != "":
+		imported, err = importSnippetsLab(flag.SnippetsLab)
+	case flag.Dash != "":
+		imported, err = importDash(flag.Dash)
+	case flag.Atuin != "":
+		imported, err = importAtuin(flag.Atuin)
+	case flag.Warp != "":
+		imported, err = importWarp(flag.Warp)
+	case flag.ITerm2 != "":
+		imported, err = importITerm2(flag.ITerm2)
+	default:
+		return cmd.Help()
+	}
+	if err != nil {
+		return err
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	count := addSnippets(&snippets, imported)
+	if count == 0 {
+		fmt.Println(i18n.T("No new snippets to import"))
+		return nil
+	}
+	if err = snippets.Save(); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("Imported %d snippets", count))
+
+	snippetFile := config.Conf.General.SnippetFile
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(snippetFile)
+	}
+	return nil
+}
+
+// addSnippets appends the snippets which are not there yet, with the same
+// description and the same normalized command, and returns the number of
+// added snippets. The targets of the same name of two Makefiles are both
+// imported.
+func addSnippets(snippets *snippet.Snippets, imported []snippet.SnippetInfo) int {
+	key := func(s snippet.SnippetInfo) string {
+		return s.Description + "\n" + snippet.NormalizeCommand(s.Command)
+	}
+	exists := map[string]bool{}
+	for _, s := range snippets.Snippets {
+		exists[key(s)] = true
+	}
+
+	count := 0
+	for _, s := range imported {
+		if exists[key(s)] {
+			continue
+		}
+		exists[key(s)] = true
+		snippets.Snippets = append(snippets.Snippets, s)
+		count++
+	}
+	return count
+}
+
+func importMakefile(path string, recipe bool) ([]snippet.SnippetInfo, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		path = filepath.Join(path, "Makefile")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open Makefile: %v", err)
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	return importer.FromMakefile(f, dir, recipe, []string{repoName(dir)})
+}
+
+func importJustfile(path string, recipe bool) ([]snippet.SnippetInfo, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		path = filepath.Join(path, "justfile")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open justfile: %v", err)
+	}
+	defer f.Close()
+
+	return importer.FromJustfile(f, path, recipe, []string{repoName(filepath.Dir(path))})
+}
+
+// importURL imports a snippet file shared with pet share
+func importURL(url string) ([]snippet.SnippetInfo, error) {
+	content, err := petSync.FetchShared(url)
+	if err != nil {
+		return nil, err
+	}
+	var shared snippet.Snippets
+	if _, err := toml.Decode(content, &shared); err != nil {
+		return nil, fmt.Errorf("Failed to parse the shared snippets: %v", err)
+	}
+	return shared.Snippets, nil
+}
+
+// importStdin imports the snippets written by pet export from stdin
+func importStdin() ([]snippet.SnippetInfo, error) {
+	var exported snippet.Snippets
+	if _, err := toml.DecodeReader(os.Stdin, &exported); err != nil {
+		return nil, fmt.Errorf("Failed to parse the snippets on stdin: %v", err)
+	}
+	return exported.Snippets, nil
+}
+
+// importSnippetsLab imports the JSON export of SnippetsLab
+func importSnippetsLab(path string) ([]snippet.SnippetInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the SnippetsLab export: %v", err)
+	}
+	defer f.Close()
+	return importer.FromSnippetsLab(f)
+}
+
+// importDash imports a CSV export of Dash, or its SQLite library
+// (Snippets.dash) read with the sqlite3 command
+func importDash(path string) ([]snippet.SnippetInfo, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the Dash snippets: %v", err)
+		}
+		defer f.Close()
+		return importer.FromDash(f)
+	}
+	out, err := querySQLite(path, importer.DashQuery)
+	if err != nil {
+		return nil, err
+	}
+	return importer.FromDash(bytes.NewReader(out))
+}
+
+// atuinLimit is the number of the most frequent commands of atuin offered
+const atuinLimit = 500
+
+// importAtuin imports the commands picked from the most frequent ones of the
+// atuin history, which are not snippets yet, asking for their descriptions
+func importAtuin(path string) ([]snippet.SnippetInfo, error) {
+	if !terminal.IsTerminal(0) {
+		return nil, errors.New("pet import --atuin asks for the commands to import, it needs a terminal")
+	}
+	out, err := querySQLite(path, importer.AtuinQuery(atuinLimit))
+	if err != nil {
+		return nil, err
+	}
+	commands, err := importer.FromAtuin(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, err
+	}
+
+	lines := map[string]string{}
+	var text strings.Builder
+	for _, c := range commands {
+		if snippets.FindByCommand(c.Command) >= 0 {
+			continue
+		}
+		line := fmt.Sprintf("%5d  %s", c.Count, strings.Replace(c.Command, "\n", "\\n", -1))
+		lines[line] = c.Command
+		text.WriteString(line + "\n")
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	if err := runSelector(multiSelectOptions(), strings.NewReader(text.String()), &buf, dialog.FindOptions{}); err != nil {
+		return nil, errCanceled
+	}
+
+	var imported []snippet.SnippetInfo
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		command, ok := lines[line]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(color.Output, "%s %s\n", color.YellowString(i18n.T("Command>")), command)
+		description, err := scan(color.GreenString(i18n.T("Description> ")))
+		if err != nil {
+			return nil, err
+		}
+		imported = append(imported, snippet.SnippetInfo{Description: description, Command: command})
+	}
+	return imported, nil
+}
+
+// importWarp imports a workflow file of Warp, or the YAML files of a
+// directory of workflows
+func importWarp(path string) ([]snippet.SnippetInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the Warp workflows: %v", err)
+	}
+	files := []string{path}
+	if fi.IsDir() {
+		files = nil
+		err := filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(file); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the Warp workflows: %v", err)
+		}
+	}
+
+	var imported []snippet.SnippetInfo
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the Warp workflow: %v", err)
+		}
+		snippets, err := importer.FromWarp(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		imported = append(imported, snippets...)
+	}
+	return imported, nil
+}
+
+// importITerm2 imports the snippets of iTerm2 from its preferences, converted
+// with plutil when they are a binary plist, or from a JSON file
+func importITerm2(path string) ([]snippet.SnippetInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the iTerm2 snippets: %v", err)
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		if _, err := exec.LookPath("plutil"); err != nil {
+			return nil, fmt.Errorf("%s is a binary plist, convert it with plutil -convert xml1", path)
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command("plutil", "-convert", "xml1", "-o", "-", path)
+		cmd.Stderr = &stderr
+		if data, err = cmd.Output(); err != nil {
+			return nil, fmt.Errorf("Failed to convert %s: %v %s", path, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return importer.FromITerm2(bytes.NewReader(data))
+}
+
+// querySQLite returns the result of the query on the SQLite database as CSV
+// with a header, read with the sqlite3 command
+func querySQLite(path, query string) ([]byte, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Failed to open %s: %v", path, err)
+	}
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, fmt.Errorf("sqlite3 is needed to read %s", path)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("sqlite3", "-readonly", "-csv", "-header", path, query)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %v %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// repoName returns the name of the git repository containing dir, or the
+// base name of dir if it is not in a repository.
+func repoName(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err == nil {
+		if top := strings.TrimSpace(string(out)); top != "" {
+			return filepath.Base(top)
+		}
+	}
+	return filepath.Base(dir)
+}
+
+func init() {
+	RootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&config.Flag.Makefile, "makefile", "", "",
+		`Import targets from a Makefile (default: ./Makefile)`)
+	importCmd.Flags().Lookup("makefile").NoOptDefVal = "Makefile"
+	importCmd.Flags().StringVarP(&config.Flag.Justfile, "justfile", "", "",
+		`Import recipes from a justfile (default: ./justfile)`)
+	importCmd.Flags().Lookup("justfile").NoOptDefVal = "justfile"
+	importCmd.Flags().StringVarP(&config.Flag.URL, "url", "", "",
+		`Import snippets shared with pet share (gist, GitLab Snippet or raw TOML URL)`)
+	importCmd.Flags().StringVarP(&config.Flag.SnippetsLab, "snippetslab", "", "",
+		`Import the JSON export of SnippetsLab`)
+	importCmd.Flags().StringVarP(&config.Flag.Dash, "dash", "", "",
+		`Import the snippets of Dash (Snippets.dash, or a CSV export)`)
+	importCmd.Flags().StringVarP(&config.Flag.Atuin, "atuin", "", "",
+		`Import commands picked from the atuin history (default: ~/.local/share/atuin/history.db)`)
+	importCmd.Flags().Lookup("atuin").NoOptDefVal = importer.AtuinFile()
+	importCmd.Flags().StringVarP(&config.Flag.Warp, "warp", "", "",
+		`Import Warp workflows from a YAML file or directory (default: ~/.warp/workflows)`)
+	importCmd.Flags().Lookup("warp").NoOptDefVal = importer.WarpDir()
+	importCmd.Flags().StringVarP(&config.Flag.ITerm2, "iterm2", "", "",
+		`Import the snippets of iTerm2 (default: ~/Library/Preferences/com.googlecode.iterm2.plist)`)
+	importCmd.Flags().Lookup("iterm2").NoOptDefVal = importer.ITerm2File()
+	importCmd.Flags().BoolVarP(&config.Flag.Recipe, "recipe", "", false,
+		`Use the recipe as the snippet command instead of invoking make/just`)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init [SHELL]",
+	Short: "Print the shell integration of pet",
+	Long: `Print the integration of pet for the shell (bash, zsh, fish, nu or xonsh,
+default: $SHELL): the widget of pet widget, bound to Ctrl-S (Ctrl-X Ctrl-R in
+bash) or --key, and a prev function saving the previous command as a snippet.
+Load it from the rc file of the shell:
+
+  bash   eval "$(pet init bash)"                   in ~/.bashrc
+  zsh    eval "$(pet init zsh)"                    in ~/.zshrc
+  fish   pet init fish | source                    in ~/.config/fish/config.fish
+  xonsh  execx($(pet init xonsh))                  in ~/.xonshrc
+  nu     pet init nu | save -f ~/.config/nushell/pet.nu, then
+         source ~/.config/nushell/pet.nu           in config.nu`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: widgetShells,
+	RunE:      initShell,
+}
+
+func initShell(cmd *cobra.Command, args []string) error {
+	var shell string
+	if len(args) > 0 {
+		shell = args[0]
+	}
+	widget, err := widgetScript(shell, config.Flag.Key)
+	if err != nil {
+		return err
+	}
+	fmt.Print(widget)
+	fmt.Print(prevFunctions[widgetShell(shell)])
+	return nil
+}
+
+// prevFunctions define prev, which saves the previous command with pet new
+var prevFunctions = map[string]string{
+	"bash": `prev() {
+  local PREV
+  PREV=$(fc -ln -1)
+  pet new "${PREV#"${PREV%%[![:space:]]*}"}"
+}
+`,
+	"zsh": `prev() {
+  pet new "$(fc -ln -1)"
+}
+`,
+	"fish": `function prev
+    pet new $history[1]
+end
+`,
+	"nu": `def prev [] {
+    pet new (history | last 2 | first | get command)
+}
+`,
+	"xonsh": `def _pet_prev():
+    import subprocess
+    subprocess.run(['pet', 'new', __xonsh__.history[-1].cmd.strip()])
+
+aliases['prev'] = _pet_prev
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVarP(&config.Flag.Key, "key", "", "",
+		`Key of the widget in the notation of the shell`)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knqyf263/pet/clipboard"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+)
+
+// keyAction is a pet action bound to a key of the selector ([Keybind])
+type keyAction struct {
+	key  string
+	name string
+	run  func(selected []snippet.SnippetInfo) error
+}
+
+// keyActions returns the actions of the configured keys, if the selector
+// supports them (fzf and the embedded fuzzy finder)
+func keyActions() []keyAction {
+	if !fzfSelector() && !builtinSelector() {
+		return nil
+	}
+	kb := config.Conf.Keybind
+	all := []keyAction{
+		{kb.Copy, "copy", copySnippets},
+		{kb.Edit, "edit", editSnippets},
+		{kb.Delete, "delete", deleteSnippets},
+	}
+	var actions []keyAction
+	for _, a := range all {
+		if a.key != "" {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// keybindHeader describes the keys of the actions, e.g. "ctrl-y: copy"
+func keybindHeader(actions []keyAction) string {
+	var keys []string
+	for _, a := range actions {
+		keys = append(keys, a.key+": "+a.name)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// keybindOptions returns the selector options to accept the selection with
+// the keys of the actions
+func keybindOptions(actions []keyAction) []string {
+	var keys []string
+	for _, a := range actions {
+		keys = append(keys, a.key)
+	}
+	return []string{
+		"--expect=" + strings.Join(keys, ","),
+		"--header " + quoteArg(keybindHeader(actions)),
+	}
+}
+
+// copySnippets copies the commands of the snippets like pet clip
+func copySnippets(selected []snippet.SnippetInfo) error {
+	commands, err := expandCommands(selected)
+	if err != nil {
+		return err
+	}
+	command := strings.Join(commands, "; ")
+	if err := clipboard.Write(config.Conf.General.Clipboard, command); err != nil {
+		return err
+	}
+	// stdout may be read by a shell widget
+	fmt.Fprintf(os.Stderr, "Copied: %s\n", command)
+	return nil
+}
+
+// editSnippets opens the first snippet in the editor like pet edit --select
+func editSnippets(selected []snippet.SnippetInfo) error {
+	changed, err := editSnippet(config.Conf.General.Editor, selected[0])
+	if err != nil || !changed {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+// deleteSnippets moves the snippets to the trash
+func deleteSnippets(selected []snippet.SnippetInfo) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	var remove []snippet.SnippetInfo
+	for _, s := range selected {
+		i := snippets.Index(s)
+		if i < 0 {
+			return fmt.Errorf("Snippet [%s] not found", s.Description)
+		}
+		remove = append(remove, snippets.Snippets[i])
+		snippets.Snippets = append(snippets.Snippets[:i], snippets.Snippets[i+1:]...)
+	}
+	if err := snippet.Trash(remove); err != nil {
+		return err
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	for _, s := range remove {
+		fmt.Fprintf(os.Stderr, "Deleted: [%s] (pet undo restores it)\n", s.Description)
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
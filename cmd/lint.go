@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [FILE...]",
+	Short: "Check snippet files for problems",
+	Long: `Check the snippet files (default: the snippet file and snippetdir) for syntax errors, unknown fields, duplicate descriptions,
+empty commands, malformed <param> placeholders and unbalanced quotes.
+Exits with status 1 if errors (or warnings with --strict) are found.`,
+	RunE: lint,
+}
+
+func lint(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	files := args
+	if len(files) == 0 {
+		var err error
+		if files, err = snippet.SnippetFiles(); err != nil {
+			return err
+		}
+	}
+
+	issues := []snippet.Issue{}
+	for _, file := range files {
+		found, err := snippet.LintFile(file)
+		if err != nil {
+			return fmt.Errorf("Failed to read %s: %v", file, err)
+		}
+		issues = append(issues, found...)
+	}
+
+	failed := false
+	for _, i := range issues {
+		if i.Severity == snippet.SeverityError || flag.Strict {
+			failed = true
+		}
+	}
+
+	if flag.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, i := range issues {
+			line := i.String()
+			if i.Severity == snippet.SeverityError {
+				line = color.RedString(line)
+			} else {
+				line = color.YellowString(line)
+			}
+			fmt.Fprintln(color.Output, line)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().BoolVarP(&config.Flag.JSON, "json", "", false,
+		`Print the problems as JSON`)
+	lintCmd.Flags().BoolVarP(&config.Flag.Strict, "strict", "", false,
+		`Exit with status 1 on warnings too`)
+}
@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/knqyf263/pet/config"
@@ -17,65 +23,332 @@ const (
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [PATH]",
 	Short: "Show all snippets",
-	Long:  `Show all snippets`,
+	Long:  `Show all snippets, or the snippets in the namespace PATH (e.g. k8s/)`,
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  list,
 }
 
 func list(cmd *cobra.Command, args []string) error {
-	var snippets snippet.Snippets
-	if err := snippets.Load(); err != nil {
+	path := config.Flag.Path
+	if len(args) > 0 {
+		path = args[0]
+	}
+	// one line per snippet shows neither the output nor the notes
+	snippet.SkipBodies = config.Flag.OneLine && config.Flag.Format == ""
+	snippets, err := loadFiltered(tagFilter(), path)
+	if err != nil {
 		return err
 	}
+	r := redactor()
+	for i, s := range snippets.Snippets {
+		snippets.Snippets[i] = s.Redact(r)
+	}
 
 	col := config.Conf.General.Column
 	if col == 0 {
 		col = column
 	}
 
-	for _, snippet := range snippets.Snippets {
-		if config.Flag.OneLine {
-			description := runewidth.FillRight(runewidth.Truncate(snippet.Description, col, "..."), col)
-			command := runewidth.Truncate(snippet.Command, 100-4-col, "...")
-			// make sure multiline command printed as oneline
-			command = strings.Replace(command, "\n", "\\n", -1)
-			fmt.Fprintf(color.Output, "%s : %s\n",
-				color.GreenString(description), color.YellowString(command))
+	if config.Flag.Tree && config.Flag.GroupBy != "" {
+		return errors.New("--tree cannot be used with --group-by")
+	}
+	if config.Flag.Format != "" && (config.Flag.Tree || config.Flag.GroupBy != "") {
+		return errors.New("--format cannot be used with --group-by or --tree")
+	}
+	switch config.Flag.Format {
+	case "":
+	case "json", "tsv", "table":
+		return listFormatted(os.Stdout, snippets.Snippets, config.Flag.Format, config.Flag.Fields)
+	default:
+		return fmt.Errorf("unknown format: %s (json, tsv or table)", config.Flag.Format)
+	}
+
+	w := color.Output
+	switch {
+	case config.Flag.Tree:
+		root := snippet.NamespaceTree(snippets.Snippets)
+		for _, child := range root.Children {
+			printNamespace(w, child, "", col)
+		}
+		if len(root.Snippets) > 0 {
+			printNamespace(w, &snippet.Namespace{Name: "(no namespace)", Snippets: root.Snippets, Count: len(root.Snippets)}, "", col)
+		}
+		return nil
+	case config.Flag.GroupBy != "":
+		groups, err := snippet.GroupBy(snippets.Snippets, config.Flag.GroupBy)
+		if err != nil {
+			return err
+		}
+		for i, g := range groups {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			printHeader(w, g.Name, len(g.Snippets), "")
+			for _, s := range g.Snippets {
+				printSnippet(&indentWriter{w: w, prefix: "  "}, s, col)
+			}
+		}
+		return nil
+	}
+	for _, s := range snippets.Snippets {
+		printSnippet(w, s, col)
+	}
+	return nil
+}
+
+// printSnippet prints the snippet in one line with --oneline, or with one
+// line per field
+func printSnippet(w io.Writer, s snippet.SnippetInfo, col int) {
+	if config.Flag.OneLine {
+		description := runewidth.FillRight(runewidth.Truncate(s.Description, col, "..."), col)
+		command := runewidth.Truncate(s.Command, 100-4-col, "...")
+		// make sure multiline command printed as oneline
+		command = strings.Replace(command, "\n", "\\n", -1)
+		if s.Expired(time.Now()) {
+			// expired snippets are flagged in red
+			description = colors.warning.Sprint(description)
 		} else {
-			fmt.Fprintf(color.Output, "%12s %s\n",
-				color.GreenString("Description:"), snippet.Description)
-			if strings.Contains(snippet.Command, "\n") {
-				lines := strings.Split(snippet.Command, "\n")
-				firstLine, restLines := lines[0], lines[1:]
-				fmt.Fprintf(color.Output, "%12s %s\n",
-					color.YellowString("    Command:"), firstLine)
-				for _, line := range restLines {
-					fmt.Fprintf(color.Output, "%12s %s\n",
-						" ", line)
-				}
-			} else {
-				fmt.Fprintf(color.Output, "%12s %s\n",
-					color.YellowString("    Command:"), snippet.Command)
+			description = colors.description.Sprint(description)
+		}
+		fmt.Fprintf(w, "%s : %s\n",
+			description, colors.command.Sprint(command))
+	} else {
+		fmt.Fprintf(w, "%12s %s\n",
+			colors.description.Sprint("Description:"), s.Description)
+		if s.Archived {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.meta.Sprint("   Archived:"), "yes")
+		}
+		if s.Favorite {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.meta.Sprint("   Favorite:"), "yes")
+		}
+		if s.Expires != "" {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.meta.Sprint("    Expires:"), expiry(s))
+		}
+		if s.Name != "" {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.meta.Sprint("       Name:"), s.Name)
+		}
+		if s.Path != "" {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.meta.Sprint("       Path:"), s.Path)
+		}
+		command := highlightCommand(s)
+		if strings.Contains(command, "\n") {
+			lines := strings.Split(command, "\n")
+			firstLine, restLines := lines[0], lines[1:]
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.command.Sprint("    Command:"), firstLine)
+			for _, line := range restLines {
+				fmt.Fprintf(w, "%12s %s\n",
+					" ", line)
 			}
-			if snippet.Tag != nil {
-				tag := strings.Join(snippet.Tag, " ")
-				fmt.Fprintf(color.Output, "%12s %s\n",
-					color.CyanString("        Tag:"), tag)
+		} else {
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.command.Sprint("    Command:"), command)
+		}
+		if s.Tag != nil {
+			tag := strings.Join(s.Tag, " ")
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.tag.Sprint("        Tag:"), tag)
+		}
+		if s.Output != "" {
+			output := strings.Replace(s.Output, "\n", "\n             ", -1)
+			fmt.Fprintf(w, "%12s %s\n",
+				colors.output.Sprint("     Output:"), output)
+		}
+		fmt.Fprintln(w, strings.Repeat("-", 30))
+	}
+}
+
+// printHeader prints the header of a group of pet list with its number of
+// snippets
+func printHeader(w io.Writer, name string, count int, prefix string) {
+	fmt.Fprintf(w, "%s%s %s\n", prefix, colors.tag.Sprint(name), colors.meta.Sprintf("(%d)", count))
+}
+
+// printNamespace prints the namespace, its snippets and its children
+// indented under it
+func printNamespace(w io.Writer, n *snippet.Namespace, prefix string, col int) {
+	printHeader(w, n.Name, n.Count, prefix)
+	for _, s := range n.Snippets {
+		printSnippet(&indentWriter{w: w, prefix: prefix + "  "}, s, col)
+	}
+	for _, child := range n.Children {
+		printNamespace(w, child, prefix+"  ", col)
+	}
+}
+
+// indentWriter prefixes each line written to w
+type indentWriter struct {
+	w      io.Writer
+	prefix string
+	// mid is true within a line
+	mid bool
+}
+
+func (iw *indentWriter) Write(p []byte) (int, error) {
+	var b []byte
+	for _, c := range p {
+		if !iw.mid {
+			b = append(b, iw.prefix...)
+		}
+		b = append(b, c)
+		iw.mid = c != '\n'
+	}
+	if _, err := iw.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// snippetFields are the fields available in formatted list output
+var snippetFields = map[string]func(s snippet.SnippetInfo) interface{}{
+	"name":        func(s snippet.SnippetInfo) interface{} { return s.Name },
+	"path":        func(s snippet.SnippetInfo) interface{} { return s.Path },
+	"description": func(s snippet.SnippetInfo) interface{} { return s.Description },
+	"command":     func(s snippet.SnippetInfo) interface{} { return s.Command },
+	"tag":         func(s snippet.SnippetInfo) interface{} { return s.Tag },
+	"output":      func(s snippet.SnippetInfo) interface{} { return s.Output },
+	"archived":    func(s snippet.SnippetInfo) interface{} { return s.Archived },
+	"favorite":    func(s snippet.SnippetInfo) interface{} { return s.Favorite },
+	"capture":     func(s snippet.SnippetInfo) interface{} { return s.Capture },
+	"shell":       func(s snippet.SnippetInfo) interface{} { return s.Shell },
+	"dir":         func(s snippet.SnippetInfo) interface{} { return s.Dir },
+	"timeout":     func(s snippet.SnippetInfo) interface{} { return s.Timeout },
+	"platform":    func(s snippet.SnippetInfo) interface{} { return strings.Join(s.Platform, ",") },
+	"requires":    func(s snippet.SnippetInfo) interface{} { return s.Requires },
+	"visibility":  func(s snippet.SnippetInfo) interface{} { return s.Visibility },
+	"notes":       func(s snippet.SnippetInfo) interface{} { return s.Notes },
+	"expires":     func(s snippet.SnippetInfo) interface{} { return s.Expires },
+	"expired":     func(s snippet.SnippetInfo) interface{} { return s.Expired(time.Now()) },
+	"file":        func(s snippet.SnippetInfo) interface{} { return s.File() },
+	"created":     func(s snippet.SnippetInfo) interface{} { return s.CreatedAt },
+	"updated":     func(s snippet.SnippetInfo) interface{} { return s.UpdatedAt },
+	"count":       func(s snippet.SnippetInfo) interface{} { return listUsage.Get(s).Count },
+	"last_used":   func(s snippet.SnippetInfo) interface{} { return lastUsed(listUsage.Get(s)) },
+}
+
+// listUsage is the usage statistics shown by the count and last_used fields
+var listUsage snippet.UsageStats
+
+// expiry returns when the snippet expires, flagged if it is expired
+func expiry(s snippet.SnippetInfo) string {
+	at, err := s.ExpiresAt()
+	switch {
+	case err != nil:
+		return colors.warning.Sprint(err.Error())
+	case at.IsZero():
+		return s.Expires
+	case s.Expired(time.Now()):
+		return colors.warning.Sprintf("%s (expired)", expiryDate(s, at))
+	}
+	return expiryDate(s, at)
+}
+
+// expiryDate formats the expiry time at of the snippet, as the date itself
+// if expires is a date
+func expiryDate(s snippet.SnippetInfo, at time.Time) string {
+	if _, err := time.Parse("2006-01-02", s.Expires); err == nil {
+		return s.Expires
+	}
+	return at.Format("2006-01-02 15:04")
+}
+
+func lastUsed(u snippet.Usage) *time.Time {
+	if u.LastUsed.IsZero() {
+		return nil
+	}
+	return &u.LastUsed
+}
+
+var defaultListFields = []string{"description", "command", "tag", "output"}
+
+func listFormatted(w io.Writer, snippets []snippet.SnippetInfo, format string, fields []string) error {
+	if len(fields) == 0 {
+		fields = defaultListFields
+	}
+	for _, f := range fields {
+		if _, ok := snippetFields[f]; !ok {
+			return fmt.Errorf("unknown field: %s", f)
+		}
+		if (f == "count" || f == "last_used") && listUsage == nil {
+			usage, err := snippet.LoadUsage()
+			if err != nil {
+				return err
 			}
-			if snippet.Output != "" {
-				output := strings.Replace(snippet.Output, "\n", "\n             ", -1)
-				fmt.Fprintf(color.Output, "%12s %s\n",
-					color.RedString("     Output:"), output)
+			listUsage = usage
+		}
+	}
+
+	if format == "json" {
+		records := []map[string]interface{}{}
+		for _, s := range snippets {
+			record := map[string]interface{}{}
+			for _, f := range fields {
+				record[f] = snippetFields[f](s)
 			}
-			fmt.Println(strings.Repeat("-", 30))
+			records = append(records, record)
 		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(records)
+	}
+
+	var tw io.Writer = w
+	if format == "table" {
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(fields, "\t")))
+	}
+	for _, s := range snippets {
+		var values []string
+		for _, f := range fields {
+			values = append(values, fieldString(snippetFields[f](s)))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	if t, ok := tw.(*tabwriter.Writer); ok {
+		return t.Flush()
 	}
 	return nil
 }
 
+// fieldString flattens a field value into a single line without tabs
+func fieldString(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case []string:
+		s = strings.Join(v, ",")
+	case *time.Time:
+		if v != nil {
+			s = v.Format(time.RFC3339)
+		}
+	default:
+		s = fmt.Sprint(v)
+	}
+	return strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n").Replace(s)
+}
+
 func init() {
 	RootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolVarP(&config.Flag.OneLine, "oneline", "", false,
 		`Display snippets in one line`)
+	listCmd.Flags().StringVarP(&config.Flag.Format, "format", "", "",
+		`Output format (json, tsv or table)`)
+	listCmd.Flags().StringSliceVarP(&config.Flag.Fields, "fields", "", nil,
+		`Comma separated fields for --format (name, path, description, command, tag, output, archived, favorite, capture, shell, platform, requires, visibility, notes, expires, expired, file, created, updated, count, last_used)`)
+	listCmd.Flags().StringVarP(&config.Flag.GroupBy, "group-by", "", "",
+		`Print the snippets under a header per tag or namespace, with their number`)
+	listCmd.Flags().BoolVarP(&config.Flag.Tree, "tree", "", false,
+		`Print the snippets under the tree of their namespaces`)
+	listCmd.RegisterFlagCompletionFunc("group-by", cobra.FixedCompletions(
+		[]string{"tag", "namespace"}, cobra.ShellCompDirectiveNoFileComp))
+	addFilterFlags(listCmd)
+	addAllFlag(listCmd)
+	listCmd.ValidArgsFunction = completePaths
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	mdHeading = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	mdBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	mdCode    = regexp.MustCompile("`([^`]+)`")
+	mdBold    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// renderMarkdown renders the markdown of snippet notes for the terminal:
+// headings, bullets, inline code, bold text and links. Fenced code blocks
+// are printed as they are.
+func renderMarkdown(text string) string {
+	bold := color.New(color.Bold).SprintFunc()
+	var lines []string
+	fenced := false
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			lines = append(lines, "    "+color.CyanString(line))
+			continue
+		}
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			lines = append(lines, bold(m[1]))
+			continue
+		}
+		line = mdBullet.ReplaceAllString(line, "$1• ")
+		line = mdCode.ReplaceAllStringFunc(line, func(s string) string {
+			return color.CyanString(mdCode.FindStringSubmatch(s)[1])
+		})
+		line = mdBold.ReplaceAllStringFunc(line, func(s string) string {
+			return bold(mdBold.FindStringSubmatch(s)[1])
+		})
+		line = mdLink.ReplaceAllStringFunc(line, func(s string) string {
+			m := mdLink.FindStringSubmatch(s)
+			return m[1] + " (" + color.BlueString(m[2]) + ")"
+		})
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge FILE...",
+	Short: "Merge other snippet files into the snippet file",
+	Long: `Merge other pet snippet files into the snippet file
+
+Snippets with the same description and command are combined, as well as a
+snippet of another description with the same command as a local one, whose
+tags are added to the local one. For the same
+description with a different command, you are asked whether to keep the local
+snippet, take the other one or keep both, unless --prefer is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: merge,
+}
+
+func merge(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	var resolve func(local, other snippet.SnippetInfo) (snippet.Resolution, error)
+	switch flag.Prefer {
+	case "local":
+		resolve = func(_, _ snippet.SnippetInfo) (snippet.Resolution, error) { return snippet.KeepLocal, nil }
+	case "theirs":
+		resolve = func(_, _ snippet.SnippetInfo) (snippet.Resolution, error) { return snippet.TakeOther, nil }
+	case "both":
+		resolve = func(_, _ snippet.SnippetInfo) (snippet.Resolution, error) { return snippet.KeepBoth, nil }
+	case "":
+		resolve = askResolution
+	default:
+		return fmt.Errorf("Invalid --prefer: %s (local, theirs or both)", flag.Prefer)
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	var total snippet.MergeResult
+	for _, file := range args {
+		other, err := snippet.DecodeFile(file)
+		if err != nil {
+			return fmt.Errorf("Failed to load %s: %v", file, err)
+		}
+		result, err := snippets.Merge(other.Snippets, resolve)
+		if err != nil {
+			return err
+		}
+		total.Added += result.Added
+		total.Replaced += result.Replaced
+		total.Updated += result.Updated
+		total.Skipped += result.Skipped
+	}
+
+	fmt.Printf("Added %d, replaced %d, updated tags of %d, skipped %d snippets\n",
+		total.Added, total.Replaced, total.Updated, total.Skipped)
+	if flag.DryRun || total.Added+total.Replaced+total.Updated == 0 {
+		return nil
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func askResolution(local, other snippet.SnippetInfo) (snippet.Resolution, error) {
+	fmt.Fprintf(color.Output, "[%s] differs:\n  %s %s\n  %s %s\n",
+		color.GreenString(local.Description),
+		color.YellowString("local: "), indent(local.Command, "         "),
+		color.CyanString("theirs:"), indent(other.Command, "         "))
+	for {
+		answer, err := prompt(i18n.T("  keep (l)ocal, take (t)heirs, keep (b)oth, (q)uit [l]: "))
+		if err != nil {
+			return snippet.KeepLocal, err
+		}
+		switch strings.ToLower(answer) {
+		case "", "l", "local":
+			return snippet.KeepLocal, nil
+		case "t", "theirs":
+			return snippet.TakeOther, nil
+		case "b", "both":
+			return snippet.KeepBoth, nil
+		case "q", "quit":
+			return snippet.KeepLocal, errors.New("canceled")
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().StringVarP(&config.Flag.Prefer, "prefer", "", "",
+		`Resolve conflicts without asking: local, theirs or both`)
+	mergeCmd.Flags().BoolVarP(&config.Flag.DryRun, "dry-run", "n", false,
+		`Only print what would be merged`)
+	mergeCmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions(
+		[]string{"local", "theirs", "both"}, cobra.ShellCompDirectiveNoFileComp))
+}
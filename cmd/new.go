@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/chzyer/readline"
 	"github.com/fatih/color"
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/importer"
 	"github.com/knqyf263/pet/snippet"
 	petSync "github.com/knqyf263/pet/sync"
 	"github.com/spf13/cobra"
@@ -21,19 +24,36 @@ import (
 var newCmd = &cobra.Command{
 	Use:   "new COMMAND",
 	Short: "Create a new snippet",
-	Long:  `Create a new snippet (default: $HOME/.config/pet/snippet.toml)`,
+	Long:  `Create a new snippet (default: $XDG_DATA_HOME/pet/snippet.toml)`,
 	RunE:  new,
 }
 
 func scan(message string) (string, error) {
-	tempFile := "/tmp/pet.tmp"
-	if runtime.GOOS == "windows" {
-		tempDir := os.Getenv("TEMP")
-		tempFile = filepath.Join(tempDir, "pet.tmp")
+	return scanLine(message, "", false)
+}
+
+// scanLine reads a line prefilled with def. Empty lines are only returned
+// if allowEmpty is true.
+func scanLine(message, def string, allowEmpty bool) (string, error) {
+	return scanLineWith(message, def, allowEmpty, nil)
+}
+
+// scanTags reads space separated tags prefilled with def, completing them
+// with Tab from the tags of the snippets
+func scanTags(message, def string, snippets []snippet.SnippetInfo) ([]string, error) {
+	t, err := scanLineWith(message, def, true, tagCompleter(snippet.TagNames(snippets)))
+	if err != nil {
+		return nil, err
 	}
+	return strings.Fields(t), nil
+}
+
+func scanLineWith(message, def string, allowEmpty bool, completer readline.AutoCompleter) (string, error) {
+	tempFile := filepath.Join(os.TempDir(), "pet.tmp")
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:          message,
 		HistoryFile:     tempFile,
+		AutoComplete:    completer,
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
 
@@ -45,7 +65,7 @@ func scan(message string) (string, error) {
 	defer l.Close()
 
 	for {
-		line, err := l.Readline()
+		line, err := l.ReadlineWithDefault(def)
 		if err == readline.ErrInterrupt {
 			if len(line) == 0 {
 				break
@@ -57,7 +77,7 @@ func scan(message string) (string, error) {
 		}
 
 		line = strings.TrimSpace(line)
-		if line == "" {
+		if line == "" && !allowEmpty {
 			continue
 		}
 		return line, nil
@@ -75,26 +95,54 @@ func new(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	if len(args) > 0 {
+	if _, ok := snippets.FindByName(config.Flag.Name); ok {
+		return fmt.Errorf("Snippet named [%s] already exists", config.Flag.Name)
+	}
+
+	file, err := snippetFilePath(config.Flag.File)
+	if err != nil {
+		return err
+	}
+
+	if config.Flag.Interactive {
+		return newInteractive(&snippets, strings.Join(args, " "), file)
+	}
+
+	if config.Flag.History > 0 {
+		if command, err = selectHistory(config.Flag.History); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "%s %s\n", colors.command.Sprint(i18n.T("Command>")), command)
+	} else if len(args) > 0 {
 		command = strings.Join(args, " ")
-		fmt.Fprintf(color.Output, "%s %s\n", color.YellowString("Command>"), command)
+		fmt.Fprintf(color.Output, "%s %s\n", colors.command.Sprint(i18n.T("Command>")), command)
 	} else {
-		command, err = scan(color.YellowString("Command> "))
+		command, err = scan(colors.command.Sprint(i18n.T("Command> ")))
 		if err != nil {
 			return err
 		}
 	}
-	description, err = scan(color.GreenString("Description> "))
+
+	if shouldCheck() {
+		checkNewCommand(command)
+	}
+
+	if i := snippets.FindByCommand(command); i >= 0 {
+		updated, err := updateDuplicate(&snippets, i, "", nil)
+		if err != nil || updated {
+			return err
+		}
+	}
+
+	description, err = scan(colors.description.Sprint(i18n.T("Description> ")))
 	if err != nil {
 		return err
 	}
 
 	if config.Flag.Tag {
-		var t string
-		if t, err = scan(color.CyanString("Tag> ")); err != nil {
+		if tags, err = scanTags(colors.tag.Sprint(i18n.T("Tag> ")), "", snippets.Snippets); err != nil {
 			return err
 		}
-		tags = strings.Fields(t)
 	}
 
 	for _, s := range snippets.Snippets {
@@ -104,10 +152,14 @@ func new(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	newSnippet := snippet.SnippetInfo{
+		Name:        config.Flag.Name,
+		Path:        config.Flag.Path,
 		Description: description,
 		Command:     command,
 		Tag:         tags,
 	}
+	newSnippet.SetFile(file)
+	warnSimilarTags(snippets.Snippets, []snippet.SnippetInfo{newSnippet})
 	snippets.Snippets = append(snippets.Snippets, newSnippet)
 	if err = snippets.Save(); err != nil {
 		return err
@@ -121,8 +173,195 @@ func new(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// updateDuplicate warns that the snippet at index i has the same command and
+// offers to update its description and tags instead of adding a duplicate.
+// The description and tags are asked for unless given.
+func updateDuplicate(snippets *snippet.Snippets, i int, description string, tags []string) (bool, error) {
+	existing := &snippets.Snippets[i]
+	fmt.Fprintf(color.Output, "%s %s\n",
+		colors.warning.Sprint(i18n.T("Warning:")), i18n.T("[%s] has the same command", existing.Description))
+	answer, err := scanLine(i18n.T("Update its description and tags instead? [y/N]: "), "", true)
+	if err != nil {
+		return false, err
+	}
+	if a := strings.ToLower(answer); a != "y" && a != "yes" {
+		return false, nil
+	}
+
+	if description == "" {
+		if description, err = scan(colors.description.Sprint(i18n.T("Description> "))); err != nil {
+			return false, err
+		}
+	}
+	if tags == nil {
+		if tags, err = scanTags(colors.tag.Sprint(i18n.T("Tag> ")), strings.Join(existing.Tag, " "), snippets.Snippets); err != nil {
+			return false, err
+		}
+	}
+	for j, s := range snippets.Snippets {
+		if j != i && s.Description == description {
+			return false, fmt.Errorf("Snippet [%s] already exists", description)
+		}
+	}
+
+	warnSimilarTags(snippets.Snippets, []snippet.SnippetInfo{{Tag: tags}})
+	existing.Description = description
+	existing.Tag = tags
+	if existing.Name == "" {
+		existing.Name = config.Flag.Name
+	}
+	if err := snippets.Save(); err != nil {
+		return false, err
+	}
+	if config.Conf.Gist.AutoSync {
+		return true, petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return true, nil
+}
+
+// tagCompleter completes the last word of a line of tags
+type tagCompleter []string
+
+func (c tagCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	word := string(line[start:pos])
+	var candidates [][]rune
+	for _, t := range c {
+		if strings.HasPrefix(t, word) && t != word {
+			candidates = append(candidates, []rune(t[len(word):]+" "))
+		}
+	}
+	return candidates, len([]rune(word))
+}
+
+// warnSimilarTags warns about the tags new in after which look like another
+// spelling of a tag of before, e.g. k8s and kubernetes
+func warnSimilarTags(before, after []snippet.SnippetInfo) {
+	known := snippet.TagNames(before)
+	used := map[string]bool{}
+	for _, t := range known {
+		used[t] = true
+	}
+	for _, t := range snippet.TagNames(after) {
+		if used[t] {
+			continue
+		}
+		if similar := snippet.SimilarTags(t, known); len(similar) > 0 {
+			fmt.Fprintf(color.Output, "%s %s\n", colors.warning.Sprint(i18n.T("Warning:")),
+				i18n.T("tag %s looks like %s", t, strings.Join(similar, ", ")))
+		}
+	}
+}
+
+// selectHistory lets the user pick one of the last n shell commands
+func selectHistory(n int) (string, error) {
+	f, err := os.Open(importer.HistoryFile())
+	if err != nil {
+		return "", fmt.Errorf("Failed to open shell history: %v", err)
+	}
+	defer f.Close()
+
+	history, err := importer.ParseHistory(f)
+	if err != nil {
+		return "", err
+	}
+
+	lines := map[string]string{}
+	var text string
+	for _, c := range importer.RecentCommands(history, n) {
+		line := strings.Replace(c, "\n", "\\n", -1)
+		lines[line] = c
+		text += line + "\n"
+	}
+
+	var buf bytes.Buffer
+	if err := runSelector(nil, strings.NewReader(text), &buf, dialog.FindOptions{}); err != nil {
+		return "", errors.New("canceled")
+	}
+	selected := strings.SplitN(strings.TrimSuffix(buf.String(), "\n"), "\n", 2)[0]
+	command, ok := lines[selected]
+	if !ok {
+		return "", errors.New("canceled")
+	}
+	return command, nil
+}
+
+// newInteractive creates a snippet with a form instead of prompts
+func newInteractive(snippets *snippet.Snippets, command, file string) error {
+	form, ok, err := dialog.GenerateSnippetForm(dialog.SnippetForm{Command: command},
+		func(f dialog.SnippetForm) error {
+			switch {
+			case f.Command == "":
+				return errors.New("Command must not be empty")
+			case f.Description == "":
+				return errors.New("Description must not be empty")
+			}
+			for _, s := range snippets.Snippets {
+				if s.Description == f.Description {
+					return fmt.Errorf("Snippet [%s] already exists", f.Description)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("canceled")
+	}
+
+	if shouldCheck() {
+		checkNewCommand(form.Command)
+	}
+
+	if i := snippets.FindByCommand(form.Command); i >= 0 {
+		updated, err := updateDuplicate(snippets, i, form.Description, strings.Fields(form.Tag))
+		if err != nil || updated {
+			return err
+		}
+	}
+
+	newSnippet := snippet.SnippetInfo{
+		Name:        config.Flag.Name,
+		Path:        config.Flag.Path,
+		Description: form.Description,
+		Command:     form.Command,
+		Tag:         strings.Fields(form.Tag),
+		Output:      form.Output,
+	}
+	newSnippet.SetFile(file)
+	warnSimilarTags(snippets.Snippets, []snippet.SnippetInfo{newSnippet})
+	snippets.Snippets = append(snippets.Snippets, newSnippet)
+	if err = snippets.Save(); err != nil {
+		return err
+	}
+
+	snippetFile := config.Conf.General.SnippetFile
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(snippetFile)
+	}
+	return nil
+}
+
 func init() {
 	RootCmd.AddCommand(newCmd)
 	newCmd.Flags().BoolVarP(&config.Flag.Tag, "tag", "t", false,
 		`Display tag prompt (delimiter: space)`)
+	newCmd.Flags().BoolVarP(&config.Flag.Interactive, "interactive", "i", false,
+		`Fill in the snippet with a form (multi-line command and output)`)
+	newCmd.Flags().BoolVarP(&config.Flag.Check, "check", "", false,
+		`Check the command with shellcheck`)
+	newCmd.Flags().StringVarP(&config.Flag.Name, "name", "n", "",
+		`Unique name to run the snippet with pet exec NAME`)
+	newCmd.Flags().StringVarP(&config.Flag.Path, "path", "", "",
+		`Namespace of the snippet (e.g. k8s/debug)`)
+	newCmd.RegisterFlagCompletionFunc("path", completePaths)
+	newCmd.Flags().StringVarP(&config.Flag.File, "file", "f", "",
+		`Snippet file to add the snippet to (a bare name is looked up in snippetdir)`)
+	newCmd.Flags().IntVarP(&config.Flag.History, "history", "", 0,
+		`Pick the command from the last N shell history entries (default: 50)`)
+	newCmd.Flags().Lookup("history").NoOptDefVal = "50"
 }
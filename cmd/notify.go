@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// toastScript shows a toast notification of the title and the message
+// (PowerShell strings) on Windows
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode(%s)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('pet').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// notifyDone sends a desktop notification that the snippets finished, with
+// --notify or when they ran longer than after of [Notify]
+func notifyDone(snippets []snippet.SnippetInfo, runErr error, took time.Duration) {
+	if !config.Flag.Notify {
+		after, err := time.ParseDuration(config.Conf.Notify.After)
+		if config.Conf.Notify.After == "" || err != nil || took < after {
+			return
+		}
+	}
+	var descriptions []string
+	for _, s := range snippets {
+		descriptions = append(descriptions, s.Description)
+	}
+	title := "pet: " + strings.Join(descriptions, ", ")
+	took = took.Round(time.Second)
+	message := fmt.Sprintf("Finished in %s", took)
+	if runErr != nil {
+		message = fmt.Sprintf("Failed with exit status %d after %s", exitCode(runErr), took)
+	}
+	if err := notify(title, message); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send the notification: %v\n", err)
+	}
+}
+
+// notify shows a desktop notification with the command of [Notify], or
+// notify-send, osascript or a toast on Windows
+func notify(title, message string) error {
+	var args []string
+	switch {
+	case len(config.Conf.Notify.Command) > 0:
+		args = append(append([]string{}, config.Conf.Notify.Command...), title, message)
+	case runtime.GOOS == "darwin":
+		args = []string{"osascript", "-e",
+			fmt.Sprintf("display notification %s with title %s", appleScriptQuote(message), appleScriptQuote(title))}
+	case runtime.GOOS == "windows":
+		args = []string{"powershell", "-NoProfile", "-Command", fmt.Sprintf(toastScript, psQuote(title), psQuote(message))}
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return errors.New("notify-send is not installed, set command in [Notify]")
+		}
+		args = []string{"notify-send", "--app-name=pet", title, message}
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// appleScriptQuote quotes s as an AppleScript string
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// psQuote quotes s as a PowerShell string
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/knqyf263/pet/clipboard"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// execOutput is where pet exec writes the output of the commands: the
+// terminal, or also the pager, the --tee file, the saved last output and the
+// clipboard
+type execOutput struct {
+	io.Writer
+	tail *tailBuffer
+	// save saves the output of tail for pet show --last-output
+	save  bool
+	file  *os.File
+	pager *exec.Cmd
+	pipe  io.WriteCloser
+}
+
+// openOutput returns the output of the commands for --pager, --tee,
+// --save-output and --copy-output, which is stdout without them
+func openOutput() (*execOutput, error) {
+	flag := config.Flag
+	out := &execOutput{Writer: os.Stdout, save: flag.Pager || flag.Tee != "" || flag.SaveOutput}
+	if flag.DryRun || flag.Tmux != "" || !out.save && !flag.CopyOutput {
+		return out, nil
+	}
+	var writers []io.Writer
+
+	// a pager is only useful on a terminal
+	if flag.Pager && terminal.IsTerminal(int(os.Stdout.Fd())) {
+		args := pagerCommand()
+		out.pager = exec.Command(args[0], args[1:]...)
+		out.pager.Stdout = os.Stdout
+		out.pager.Stderr = os.Stderr
+		pipe, err := out.pager.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := out.pager.Start(); err != nil {
+			return nil, fmt.Errorf("Failed to run the pager %s: %v", args[0], err)
+		}
+		out.pipe = pipe
+		writers = append(writers, pipe)
+	} else {
+		writers = append(writers, os.Stdout)
+	}
+	if flag.Tee != "" {
+		f, err := os.Create(flag.Tee)
+		if err != nil {
+			out.Close()
+			return nil, fmt.Errorf("Failed to create %s: %v", flag.Tee, err)
+		}
+		out.file = f
+		writers = append(writers, f)
+	}
+	out.tail = &tailBuffer{max: snippet.MaxLastOutput}
+	writers = append(writers, out.tail)
+	out.Writer = io.MultiWriter(writers...)
+	return out, nil
+}
+
+// Close saves the output for pet show --last-output, copies it for
+// --copy-output, closes the --tee file and waits until the pager exits
+func (o *execOutput) Close() error {
+	var err error
+	if o.tail != nil && o.save {
+		if serr := snippet.SaveLastOutput(o.tail.buf); serr != nil {
+			err = serr
+		}
+	}
+	if o.tail != nil && config.Flag.CopyOutput {
+		if cerr := copyOutput(o.tail.buf); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if o.file != nil {
+		if cerr := o.file.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("Failed to write %s: %v", o.file.Name(), cerr)
+		}
+	}
+	if o.pager != nil {
+		o.pipe.Close()
+		o.pager.Wait()
+	}
+	return err
+}
+
+// copyOutput copies the output without its last line break to the
+// clipboard, which is left as it is if there is no output
+func copyOutput(output []byte) error {
+	text := strings.TrimRight(string(output), "\r\n")
+	if text == "" {
+		fmt.Fprintf(os.Stderr, "%s no output, the clipboard was not changed\n", colors.warning.Sprint("Warning:"))
+		return nil
+	}
+	return clipboard.Write(config.Conf.General.Clipboard, text)
+}
+
+// pagerCommand returns the words of $PAGER, or less -R (more if it is not
+// installed, and on Windows)
+func pagerCommand() []string {
+	if args := splitArgs(os.Getenv("PAGER")); len(args) > 0 {
+		return args
+	}
+	if _, err := exec.LookPath("less"); err == nil && runtime.GOOS != "windows" {
+		return []string{"less", "-R"}
+	}
+	return []string{"more"}
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	// keep up to twice max so that the buffer is not moved on every write
+	if len(b.buf) > 2*b.max {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
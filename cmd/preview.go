@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// previewCmd prints the snippet of a selector line for the preview window
+var previewCmd = &cobra.Command{
+	Use:    "preview LINE",
+	Short:  "Print the snippet of a selector line",
+	Args:   cobra.MinimumNArgs(1),
+	Hidden: true,
+	RunE:   preview,
+}
+
+// previewText returns the full command of the snippet with its description,
+// tags, output and notes, shown in the preview of the selector
+func previewText(s snippet.SnippetInfo) string {
+	s = s.Redact(redactor())
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", colors.description.Sprint(s.Description), highlightCommand(s))
+	if len(s.Tag) > 0 {
+		fmt.Fprintf(&b, "\n%s %s\n", colors.tag.Sprint("Tag:"), strings.Join(s.Tag, " "))
+	}
+	if s.Output != "" {
+		fmt.Fprintf(&b, "\n%s\n%s\n", colors.output.Sprint("Output:"), s.Output)
+	}
+	if s.Notes != "" {
+		fmt.Fprintf(&b, "\n%s\n", renderMarkdown(s.Notes))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func preview(cmd *cobra.Command, args []string) error {
+	// the preview window of fzf shows colors, unless they are disabled
+	color.NoColor = colors.noColor
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	line := strings.Join(args, " ")
+	if d := config.Flag.FieldDelimiter; d != "" {
+		// the fields of a selector plugin follow the line
+		line = strings.SplitN(line, d, 2)[0]
+	}
+	for _, s := range snippets.Snippets {
+		if selectorLine(s, false) == line {
+			fmt.Fprintln(color.Output, previewText(s))
+			return nil
+		}
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(previewCmd)
+	previewCmd.Flags().StringVarP(&config.Flag.FieldDelimiter, "delimiter", "", "",
+		`The delimiter of the fields which follow the line`)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// killGrace is how long the commands have to exit after being terminated
+// before they are killed
+const killGrace = 3 * time.Second
+
+// runCommand runs cmd in its own process group, which is stopped as a whole
+// after the timeout (none if 0) or when pet is interrupted, so that no
+// command it started keeps running
+func runCommand(cmd *exec.Cmd, timeout time.Duration) error {
+	restore := setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer restore()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-done:
+		// killed by Ctrl-C on the terminal, the commands started in the
+		// background are stopped too
+		if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			signalGroup(cmd, false)
+		}
+		return err
+	case <-expired:
+		stopGroup(cmd, done)
+		return fmt.Errorf("Timed out after %s", timeout)
+	case s := <-interrupt:
+		stopGroup(cmd, done)
+		return fmt.Errorf("Interrupted (%s)", s)
+	}
+}
+
+// stopGroup terminates the process group of cmd and kills what is left of
+// it once cmd exits, or after killGrace
+func stopGroup(cmd *exec.Cmd, done <-chan error) {
+	signalGroup(cmd, false)
+	select {
+	case <-done:
+		signalGroup(cmd, true)
+	case <-time.After(killGrace):
+		signalGroup(cmd, true)
+		<-done
+	}
+}
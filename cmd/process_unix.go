@@ -0,0 +1,67 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/sys/unix"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// newCommand returns the command of the arguments
+func newCommand(args []string) *exec.Cmd {
+	return exec.Command(args[0], args[1:]...)
+}
+
+// setProcessGroup makes cmd start a process group. On a terminal the group
+// is put in the foreground, so that interactive commands read it and Ctrl-C
+// reaches all of them; restore gives the terminal back to pet.
+func setProcessGroup(cmd *exec.Cmd) (restore func()) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	f, ok := cmd.Stdin.(*os.File)
+	if !ok || !terminal.IsTerminal(int(f.Fd())) {
+		return func() {}
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(f.Fd())
+	return func() {
+		// pet is in the background until then
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		unix.IoctlSetPointerInt(int(f.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+	}
+}
+
+// signalGroup terminates, or kills, the process group of cmd
+func signalGroup(cmd *exec.Cmd, kill bool) {
+	sig := syscall.SIGTERM
+	if kill {
+		sig = syscall.SIGKILL
+	}
+	syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// elevate returns the command run with sudo_cmd. The variables are given to
+// env as sudo and doas reset the environment.
+func elevate(args []string, env map[string]string) ([]string, error) {
+	sudo, err := sudoCmd()
+	if err != nil {
+		return nil, err
+	}
+	line := append([]string{}, sudo...)
+	if len(env) > 0 {
+		line = append(append(line, "env"), envArgs(env)...)
+	}
+	return append(line, args...), nil
+}
+
+// quoteArg quotes an argument of the selector and preview command lines for
+// the shell
+func quoteArg(s string) string {
+	return shellescape.Quote(s)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/knqyf263/pet/config"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// newCommand returns the command of the arguments. The command of cmd /c
+// is given to cmd as it is: the quoting of Go for other programs (\") is not
+// that of cmd.
+func newCommand(args []string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0])))
+	if name == "cmd" && len(args) == 3 && strings.EqualFold(args[1], "/c") {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			CmdLine: fmt.Sprintf(`%s /s /c "%s"`, syscall.EscapeArg(args[0]), args[2]),
+		}
+	}
+	return cmd
+}
+
+// setProcessGroup does nothing on Windows: the commands share the console
+// of pet, which stops them with signalGroup
+func setProcessGroup(cmd *exec.Cmd) (restore func()) {
+	return func() {}
+}
+
+// signalGroup kills cmd and the processes it started
+func signalGroup(cmd *exec.Cmd, kill bool) {
+	exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// elevate returns the command run with sudo_cmd or the sudo of Windows 11,
+// or else elevated through UAC by PowerShell, in a new window
+func elevate(args []string, env map[string]string) ([]string, error) {
+	if sudo := config.Conf.General.SudoCmd; len(sudo) > 0 {
+		return append(append([]string{}, sudo...), args...), nil
+	}
+	if _, err := exec.LookPath("sudo"); err == nil {
+		return append([]string{"sudo"}, args...), nil
+	}
+
+	var escaped []string
+	for _, a := range args[1:] {
+		escaped = append(escaped, syscall.EscapeArg(a))
+	}
+	script := fmt.Sprintf("$p = Start-Process -FilePath %s -ArgumentList %s -WorkingDirectory $PWD -Verb RunAs -Wait -PassThru; exit $p.ExitCode",
+		psQuote(args[0]), psQuote(strings.Join(escaped, " ")))
+	return []string{"powershell", "-NoProfile", "-Command", script}, nil
+}
+
+// quoteArg quotes an argument of the selector and preview command lines for
+// cmd, or for the POSIX shell of the cmd of the config (e.g. Git Bash)
+func quoteArg(s string) string {
+	if len(config.Conf.General.Cmd) > 0 {
+		return shellescape.Quote(s)
+	}
+	return syscall.EscapeArg(s)
+}
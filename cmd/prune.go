@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove or archive stale snippets",
+	Long: `List the snippets neither executed nor created within --unused-for (or with
+--expired, the expired snippets) and remove or archive them
+
+Each snippet is asked for unless --archive or --delete is given.`,
+	RunE: prune,
+}
+
+func prune(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	age, err := snippet.ParseAge(flag.UnusedFor)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-age)
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	usage, err := snippet.LoadUsage()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var stale []snippet.SnippetInfo
+	for _, s := range snippets.Snippets {
+		if flag.Expired {
+			if s.Expired(now) {
+				stale = append(stale, s)
+			}
+			continue
+		}
+		if s.Archived {
+			continue
+		}
+		if usage.Unused(s, cutoff) {
+			stale = append(stale, s)
+		}
+	}
+	if len(stale) == 0 {
+		if flag.Expired {
+			fmt.Println("No expired snippets")
+		} else {
+			fmt.Printf("No snippets unused for %s\n", flag.UnusedFor)
+		}
+		return nil
+	}
+
+	var remove []snippet.SnippetInfo
+	archived := 0
+loop:
+	for _, s := range stale {
+		last := "never used"
+		if u := usage.Get(s); u.Count > 0 {
+			last = "last used " + u.LastUsed.Format("2006-01-02")
+		}
+		if flag.Expired {
+			at, _ := s.ExpiresAt()
+			last = "expired " + expiryDate(s, at)
+		}
+		fmt.Fprintf(color.Output, "[%s]: %s (%s)\n",
+			color.GreenString(s.Description), firstLine(s.Command), last)
+
+		action := "k"
+		switch {
+		case flag.DryRun:
+		case flag.Archive:
+			action = "a"
+		case flag.Delete:
+			action = "d"
+		default:
+			answer, err := prompt(i18n.T("  (a)rchive, (d)elete, (k)eep, (q)uit [k]: "))
+			if err != nil {
+				break loop
+			}
+			if answer != "" {
+				action = strings.ToLower(answer[:1])
+			}
+		}
+
+		switch action {
+		case "a":
+			snippets.Snippets[snippets.Index(s)].Archived = true
+			archived++
+		case "d":
+			remove = append(remove, s)
+		case "q":
+			break loop
+		}
+	}
+
+	for _, s := range remove {
+		i := snippets.Index(s)
+		snippets.Snippets = append(snippets.Snippets[:i], snippets.Snippets[i+1:]...)
+	}
+	if err := snippet.Trash(remove); err != nil {
+		return err
+	}
+	fmt.Printf("Archived %d, deleted %d snippets\n", archived, len(remove))
+	if archived+len(remove) == 0 {
+		return nil
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().StringVarP(&config.Flag.UnusedFor, "unused-for", "u", "180d",
+		`Snippets not executed within this duration are stale (e.g. 180d, 4w, 12h)`)
+	pruneCmd.Flags().BoolVarP(&config.Flag.Expired, "expired", "", false,
+		`List the expired snippets instead of the unused ones`)
+	pruneCmd.Flags().BoolVarP(&config.Flag.Archive, "archive", "", false,
+		`Archive all stale snippets without asking`)
+	pruneCmd.Flags().BoolVarP(&config.Flag.Delete, "delete", "", false,
+		`Delete all stale snippets without asking`)
+	pruneCmd.Flags().BoolVarP(&config.Flag.DryRun, "dry-run", "n", false,
+		`Only list the stale snippets`)
+}
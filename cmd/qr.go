@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// printQR prints the text as a QR code drawn with half blocks, two rows of
+// modules per line. The light modules are drawn, so that the code reads on
+// a dark terminal.
+func printQR(w io.Writer, text string) error {
+	q, err := qrcode.New(text, qrcode.Low)
+	if err != nil {
+		return fmt.Errorf("Failed to make the QR code: %v", err)
+	}
+	bitmap := q.Bitmap()
+	var b strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			top := !bitmap[y][x]
+			bottom := y+1 < len(bitmap) && !bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
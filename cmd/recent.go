@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// recentCmd represents the recent command
+var recentCmd = &cobra.Command{
+	Use:   "recent",
+	Short: "Run recently executed snippets",
+	Long: `Select one of the last executed snippets, most recent first, and run it again
+
+With --list, the snippets are printed instead.`,
+	RunE: recent,
+}
+
+func recent(cmd *cobra.Command, args []string) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	usage, err := snippet.LoadUsage()
+	if err != nil {
+		return err
+	}
+	recentSnippets := recentlyUsed(snippets, usage, config.Flag.Limit)
+
+	if config.Flag.List {
+		col := config.Conf.General.Column
+		if col == 0 {
+			col = column
+		}
+		for _, s := range recentSnippets.Snippets {
+			fmt.Fprintf(color.Output, "%s  %s\n",
+				color.GreenString(usage.Get(s).LastUsed.Format("2006-01-02 15:04")),
+				runewidth.Truncate(s.Description, col, "..."))
+		}
+		return nil
+	}
+	if len(recentSnippets.Snippets) == 0 {
+		return fmt.Errorf("No snippets have been executed yet")
+	}
+
+	selected, err := selectFrom(recentSnippets, nil)
+	if err != nil || len(selected) == 0 {
+		return err
+	}
+	return runSnippets(selected, nil)
+}
+
+// recentlyUsed returns up to limit executed snippets, most recent first
+func recentlyUsed(snippets snippet.Snippets, usage snippet.UsageStats, limit int) snippet.Snippets {
+	var used []snippet.SnippetInfo
+	for _, s := range snippets.Snippets {
+		if usage.Get(s).Count > 0 {
+			used = append(used, s)
+		}
+	}
+	sort.SliceStable(used, func(i, j int) bool {
+		return usage.Get(used[i]).LastUsed.After(usage.Get(used[j]).LastUsed)
+	})
+	if limit > 0 && len(used) > limit {
+		used = used[:limit]
+	}
+	return snippet.Snippets{Snippets: used}
+}
+
+func init() {
+	RootCmd.AddCommand(recentCmd)
+	recentCmd.Flags().IntVarP(&config.Flag.Limit, "limit", "n", 10,
+		`Number of snippets to show (0: all)`)
+	recentCmd.Flags().BoolVarP(&config.Flag.List, "list", "l", false,
+		`Print the recent snippets instead of selecting one`)
+	recentCmd.Flags().BoolVarP(&config.Flag.Color, "color", "", false,
+		`Enable colorized output (only fzf)`)
+	recentCmd.Flags().BoolVarP(&config.Flag.Yes, "yes", "y", false,
+		`Run snippets requiring confirmation without asking`)
+}
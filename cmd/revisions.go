@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// revisionsCmd represents the revisions command. It is not pet history,
+// which re-runs the executions of the audit log, nor pet versions, next to
+// pet version.
+var revisionsCmd = &cobra.Command{
+	Use:   "revisions [NAME]",
+	Short: "Show the previous revisions of a snippet",
+	Long:  `Show the previous revisions of the selected snippet (or the snippet with the NAME), newest first`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  revisions,
+}
+
+// revertCmd represents the revert command
+var revertCmd = &cobra.Command{
+	Use:   "revert [NAME]",
+	Short: "Restore a previous revision of a snippet",
+	Long:  `Restore a previous revision of the selected snippet (or the snippet with the NAME), chosen with the selector unless --revision is given`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  revert,
+}
+
+// snippetRevisions returns the snippet with the NAME in args, or the
+// selected one, with its previous revisions
+func snippetRevisions(args []string) (snippet.SnippetInfo, []snippet.Version, error) {
+	var s snippet.SnippetInfo
+	if len(args) > 0 {
+		var err error
+		if s, err = snippetByName(args[0]); err != nil {
+			return s, nil, err
+		}
+	} else {
+		selected, err := selectSnippets(nil, tagFilter())
+		if err != nil {
+			return s, nil, err
+		}
+		if len(selected) == 0 {
+			return s, nil, errors.New("no snippets selected")
+		}
+		s = selected[0]
+	}
+
+	all, err := snippet.LoadVersions()
+	if err != nil {
+		return s, nil, err
+	}
+	previous := all.Get(s)
+	if len(previous) == 0 {
+		return s, nil, fmt.Errorf("Snippet [%s] has no previous revisions", s.Description)
+	}
+	return s, previous, nil
+}
+
+// revisionLine returns the one line summary of the n-th previous revision
+func revisionLine(n int, v snippet.Version) string {
+	command := strings.Replace(v.Command, "\n", "\\n", -1)
+	return fmt.Sprintf("%d  %s  %s", n, v.ReplacedAt.Format("2006-01-02 15:04"), command)
+}
+
+func revisions(cmd *cobra.Command, args []string) error {
+	s, previous, err := snippetRevisions(args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "%s %s\n", color.GreenString("Current:"), s.Command)
+	for i, v := range previous {
+		fmt.Fprintf(color.Output, "%s  %s  %s\n", color.YellowString("%3d", i+1),
+			color.CyanString(v.ReplacedAt.Format("2006-01-02 15:04")), indent(v.Command, strings.Repeat(" ", 23)))
+		if strings.Join(v.Tag, " ") != strings.Join(s.Tag, " ") {
+			fmt.Printf("%23s tag: %s\n", "", strings.Join(v.Tag, " "))
+		}
+	}
+	return nil
+}
+
+func revert(cmd *cobra.Command, args []string) error {
+	s, previous, err := snippetRevisions(args)
+	if err != nil {
+		return err
+	}
+
+	n := config.Flag.Revision
+	if n == 0 {
+		lines := map[string]int{}
+		var text string
+		for i, v := range previous {
+			line := revisionLine(i+1, v)
+			lines[line] = i + 1
+			text += line + "\n"
+		}
+		var buf bytes.Buffer
+		if err := runSelector(nil, strings.NewReader(text), &buf, dialog.FindOptions{}); err != nil {
+			return errors.New("canceled")
+		}
+		selected := strings.SplitN(strings.TrimSuffix(buf.String(), "\n"), "\n", 2)[0]
+		if n = lines[selected]; n == 0 {
+			return errors.New("canceled")
+		}
+	}
+	if n < 1 || n > len(previous) {
+		return fmt.Errorf("Snippet [%s] has %d previous revisions", s.Description, len(previous))
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	i := snippets.Index(s)
+	if i < 0 {
+		return fmt.Errorf("Snippet [%s] not found", s.Description)
+	}
+	v := previous[n-1]
+	snippets.Snippets[i].Command = v.Command
+	snippets.Snippets[i].Output = v.Output
+	snippets.Snippets[i].Tag = v.Tag
+	// the current version is kept, so the revert can be reverted
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Reverted [%s] to the revision of %s\n", s.Description, v.ReplacedAt.Format("2006-01-02 15:04"))
+
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(revisionsCmd, revertCmd)
+	for _, c := range []*cobra.Command{revisionsCmd, revertCmd} {
+		addFilterFlags(c)
+		c.ValidArgsFunction = completeNames
+	}
+	revertCmd.Flags().IntVarP(&config.Flag.Revision, "revision", "", 0,
+		`Previous revision to restore (1 is the newest, see pet revisions)`)
+}
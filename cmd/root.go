@@ -21,11 +21,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +48,10 @@ var RootCmd = &cobra.Command{
 // Execute adds all child commands to the root command sets flags appropriately.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		if errors.Is(err, errNoMatch) {
+			os.Exit(1)
+		}
+		fmt.Println(i18n.T(err.Error()))
 		os.Exit(-1)
 	}
 }
@@ -54,33 +59,71 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	RootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVarP(&config.Flag.Check, "check", "", false,
+		`Check whether a newer release of pet is out`)
 
-	RootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is $HOME/.config/pet/config.toml)")
+	RootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is $PET_CONFIG or $XDG_CONFIG_HOME/pet/config.toml)")
 	RootCmd.PersistentFlags().BoolVarP(&config.Flag.Debug, "debug", "", false, "debug mode")
+	RootCmd.PersistentFlags().StringVar(&config.Flag.Profile, "profile", "", "profile of the config (default is $PET_PROFILE)")
+	RootCmd.PersistentFlags().BoolVarP(&config.Flag.Reveal, "reveal", "", false, "show the secrets of the snippets in pet list, pet show, the previews and --dry-run")
+	RootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number",
-	Long:  `Print the version number`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long:  `Print the version number, and with --check whether a newer release is out`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Printf("pet version %s\n", version)
+		if config.Flag.Check {
+			return checkVersion()
+		}
+		return nil
 	},
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if configFile == "" {
-		dir, err := config.GetDefaultConfigDir()
+		file, err := config.GetConfigFile()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v", err)
 			os.Exit(1)
 		}
-		configFile = filepath.Join(dir, "config.toml")
+		configFile = file
 	}
 
-	if err := config.Conf.Load(configFile); err != nil {
+	c, _, err := RootCmd.Find(os.Args[1:])
+	if err == nil {
+		invokedCommand = strings.TrimPrefix(c.CommandPath(), RootCmd.Name()+" ")
+	}
+
+	// the backends are set up only by the commands which sync
+	config.CheckTokens = c == doctorCmd
+	err = config.Conf.Load(configFile)
+	i18n.SetLanguage(config.Conf.General.Language)
+	if err == nil {
+		err = applyTheme(config.Conf.Theme)
+	}
+	if err == nil {
+		err = registerContexts(config.Conf.Context)
+	}
+	if err != nil {
+		// let pet doctor report a broken config file
+		if c == doctorCmd {
+			configErr = err
+			return
+		}
 		fmt.Fprintf(os.Stderr, "%v", err)
 		os.Exit(1)
 	}
+	if c != daemonCmd {
+		useDaemon()
+	}
+	// pet doctor lists the warnings with the other checks
+	if c != doctorCmd {
+		for _, w := range config.Conf.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", w)
+		}
+	}
 }
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run [RUNBOOK]",
+	Short: "Run the steps of a runbook",
+	Long: `Run the snippets of a runbook ([[runbooks]] of the snippet files) step by step
+
+Without RUNBOOK, the runbooks are listed. A step with pause waits for Enter
+after its message, and a step with confirm asks before it runs. The output of
+a step with capture is the value of that parameter in the next steps. After a
+failure, the step can be retried or skipped, or the runbook stopped.`,
+	Args:              cobra.MaximumNArgs(1),
+	RunE:              runRunbook,
+	ValidArgsFunction: completeRunbooks,
+}
+
+func runRunbook(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return listRunbooks(snippets.Runbooks)
+	}
+	r, ok := snippets.FindRunbook(args[0])
+	if !ok {
+		return fmt.Errorf("Runbook %s not found", args[0])
+	}
+	steps, err := snippets.StepSnippets(r)
+	if err != nil {
+		return err
+	}
+	if flag.From < 1 || flag.From > len(steps) {
+		return fmt.Errorf("--from must be between 1 and %d, the steps of runbook %s", len(steps), r.Name)
+	}
+	dialog.Review = !flag.Yes && !flag.DryRun
+	interactive := !flag.Yes && !flag.DryRun && terminal.IsTerminal(0)
+
+	var saved []snippet.Execution
+	for i := flag.From - 1; i < len(steps); i++ {
+		step, s := r.Steps[i], steps[i]
+		fmt.Fprintf(color.Output, "%s %s\n", colors.info.Sprintf("Step %d/%d:", i+1, len(steps)), colors.description.Sprint(s.Description))
+		if interactive && step.Pause != "" {
+			fmt.Println(step.Pause)
+			if _, err := prompt(i18n.T("Press Enter to continue ")); err != nil {
+				return errCanceled
+			}
+		}
+		if interactive && step.Confirm {
+			answer := askStep("Run this step? [y]es, [s]kip or [q]uit: ", "ysq")
+			if answer == 'q' {
+				return fmt.Errorf("Runbook %s stopped at step %d", r.Name, i+1)
+			} else if answer == 's' {
+				continue
+			}
+		}
+
+		for {
+			public, err := runCapturing(s, nil, os.Stdout, saved)
+			saved = append(saved, public...)
+			if err == nil {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "%s [%s]: %v\n", color.RedString("Failed"), s.Description, err)
+			answer := byte('q')
+			if interactive {
+				answer = askStep("[r]etry, [s]kip or [q]uit: ", "rsq")
+			}
+			if answer == 'q' {
+				return fmt.Errorf("Step %d of runbook %s failed (pet run %s --from %d to resume)", i+1, r.Name, r.Name, i+1)
+			} else if answer == 's' {
+				break
+			}
+		}
+	}
+	fmt.Fprintf(color.Output, "%s\n", colors.info.Sprintf("Runbook %s done", r.Name))
+	return nil
+}
+
+// askStep asks the question until the answer is one of the letters, and
+// returns it. It returns 'q' when there is no answer.
+func askStep(question, letters string) byte {
+	for {
+		answer, err := prompt(i18n.T(question))
+		if err != nil {
+			return 'q'
+		}
+		if answer = strings.ToLower(answer); answer != "" && strings.IndexByte(letters, answer[0]) >= 0 {
+			return answer[0]
+		}
+	}
+}
+
+// listRunbooks prints the runbooks with their descriptions and steps
+func listRunbooks(runbooks []snippet.Runbook) error {
+	if len(runbooks) == 0 {
+		fmt.Fprintln(os.Stderr, "No runbooks, add [[runbooks]] to a snippet file")
+		return nil
+	}
+	tw := tabwriter.NewWriter(color.Output, 0, 4, 2, ' ', 0)
+	for _, r := range runbooks {
+		fmt.Fprintf(tw, "%s\t%s\t%d steps\n", r.Name, r.Description, len(r.Steps))
+	}
+	return tw.Flush()
+}
+
+// completeRunbooks completes the names of the runbooks
+func completeRunbooks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	for _, r := range snippets.Runbooks {
+		if strings.HasPrefix(r.Name, toComplete) {
+			names = append(names, r.Name+"\t"+r.Description)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	RootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVarP(&config.Flag.Yes, "yes", "y", false,
+		`Run all the steps without pauses and questions, and stop at the first failure`)
+	runCmd.Flags().BoolVarP(&config.Flag.DryRun, "dry-run", "n", false,
+		`Print the commands of the steps instead of running them`)
+	runCmd.Flags().IntVarP(&config.Flag.From, "from", "", 1,
+		`Start at this step`)
+}
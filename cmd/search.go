@@ -2,12 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/knqyf263/pet/config"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
-	"gopkg.in/alessio/shellescape.v1"
 )
 
 var delimiter string
@@ -16,8 +16,12 @@ var delimiter string
 var searchCmd = &cobra.Command{
 	Use:   "search",
 	Short: "Search snippets",
-	Long:  `Search snippets interactively (default filtering tool: peco)`,
-	RunE:  search,
+	Long: `Search snippets interactively (default filtering tool: peco)
+
+With --print-buffer, the command is printed to be put on the command line of
+the shell by a widget (see pet widget): without a newline, and nothing but
+the exit status 1 if no snippet is selected.`,
+	RunE: search,
 }
 
 func search(cmd *cobra.Command, args []string) (err error) {
@@ -25,13 +29,21 @@ func search(cmd *cobra.Command, args []string) (err error) {
 
 	var options []string
 	if flag.Query != "" {
-		options = append(options, fmt.Sprintf("--query %s", shellescape.Quote(flag.Query)))
+		options = append(options, fmt.Sprintf("--query %s", quoteArg(flag.Query)))
 	}
-	commands, err := filter(options, flag.FilterTag)
+	commands, err := filter(options, tagFilter())
 	if err != nil {
 		return err
 	}
 
+	if flag.PrintBuffer {
+		if len(commands) == 0 {
+			os.Exit(1)
+		}
+		fmt.Print(strings.Join(commands, flag.Delimiter))
+		return nil
+	}
+
 	fmt.Print(strings.Join(commands, flag.Delimiter))
 	if terminal.IsTerminal(1) {
 		fmt.Print("\n")
@@ -45,8 +57,11 @@ func init() {
 		`Enable colorized output (only fzf)`)
 	searchCmd.Flags().StringVarP(&config.Flag.Query, "query", "q", "",
 		`Initial value for query`)
-	searchCmd.Flags().StringVarP(&config.Flag.FilterTag, "tag", "t", "",
-		`Filter tag`)
+	addFilterFlags(searchCmd)
 	searchCmd.Flags().StringVarP(&config.Flag.Delimiter, "delimiter", "d", "; ",
 		`Use delim as the command delimiter character`)
+	searchCmd.Flags().BoolVarP(&config.Flag.PrintBuffer, "print-buffer", "", false,
+		`Print the command for the command line of the shell (see pet widget)`)
+	searchCmd.RegisterFlagCompletionFunc("query", completeDescriptions)
+	addAllFlag(searchCmd)
 }
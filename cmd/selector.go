@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// builtinSelectCmd is the selectcmd of the embedded fuzzy finder
+const builtinSelectCmd = "builtin"
+
+// maxSelectorLine is the longest line of the embedded fuzzy finder
+const maxSelectorLine = 1 << 20
+
+// invokedCommand is the path of the running command without "pet", e.g.
+// "exec" or "trash restore", which picks its [Selector.command] settings
+var invokedCommand string
+
+// selectCmd returns the selector of the running command, from its
+// [Selector.command] settings or selectcmd
+func selectCmd() string {
+	if c, ok := config.Conf.Selector.Commands[invokedCommand]; ok && c.SelectCmd != "" {
+		return c.SelectCmd
+	}
+	return config.Conf.General.SelectCmd
+}
+
+// selectorArgs returns the arguments of [Selector] and of the running
+// command, quoted for the shell
+func selectorArgs() string {
+	args := append([]string{}, config.Conf.Selector.Args...)
+	args = append(args, config.Conf.Selector.Commands[invokedCommand].Args...)
+	for i, a := range args {
+		args[i] = quoteArg(a)
+	}
+	return strings.Join(args, " ")
+}
+
+// selectorEnv returns the environment of [Selector] and of the running
+// command, which overrides it
+func selectorEnv() map[string]string {
+	env := map[string]string{}
+	for k, v := range config.Conf.Selector.Env {
+		env[k] = v
+	}
+	for k, v := range config.Conf.Selector.Commands[invokedCommand].Env {
+		env[k] = v
+	}
+	return env
+}
+
+// builtinSelector reports whether the embedded fuzzy finder is used: when
+// selectcmd is empty or "builtin", or its command is not installed
+func builtinSelector() bool {
+	fields := strings.Fields(selectCmd())
+	if len(fields) == 0 || fields[0] == builtinSelectCmd {
+		return true
+	}
+	_, err := exec.LookPath(fields[0])
+	return err != nil
+}
+
+// selectorPlugins are the selectors pet knows which are not compatible with
+// fzf. [Selector.plugin] overrides them.
+var selectorPlugins = map[string]config.SelectorPlugin{
+	"rofi": {
+		// only the text before the tab is shown
+		Args:      []string{"-display-columns", "1", "-display-column-separator", "\t"},
+		Multi:     []string{"-multi-select"},
+		Query:     []string{"-filter", "{q}"},
+		Delimiter: "\t",
+	},
+	"wofi":  {Query: []string{"--search", "{q}"}},
+	"dmenu": {},
+}
+
+// selectorName returns the name of the command of the selector, e.g. "rofi"
+func selectorName() string {
+	fields := strings.Fields(selectCmd())
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+}
+
+// selectorPlugin returns the plugin of the selector from [Selector.plugin]
+// or the ones pet knows
+func selectorPlugin() (config.SelectorPlugin, bool) {
+	if builtinSelector() {
+		return config.SelectorPlugin{}, false
+	}
+	name := selectorName()
+	if p, ok := config.Conf.Selector.Plugins[name]; ok {
+		return p, true
+	}
+	p, ok := selectorPlugins[name]
+	return p, ok
+}
+
+// pluginOptions returns the options of the plugin for the fzf options pet
+// uses. The options the plugin has none for are left out.
+func pluginOptions(p config.SelectorPlugin, options []string) string {
+	args := append([]string{}, p.Args...)
+	for _, o := range options {
+		switch {
+		case o == "--multi":
+			args = append(args, p.Multi...)
+		case strings.HasPrefix(o, "--query "):
+			for _, a := range p.Query {
+				args = append(args, strings.ReplaceAll(a, "{q}", config.Flag.Query))
+			}
+		case strings.HasPrefix(o, "--preview "):
+			for _, a := range p.Preview {
+				args = append(args, strings.ReplaceAll(a, "{cmd}", previewCommand(p.Delimiter)))
+			}
+		}
+	}
+	for i, a := range args {
+		args[i] = quoteArg(a)
+	}
+	return strings.Join(args, " ")
+}
+
+// pluginFields returns the fields of the n-th snippet which follow its line
+// for a plugin with a delimiter
+func pluginFields(s snippet.SnippetInfo, n int, delimiter string) string {
+	fields := []string{strconv.Itoa(n), s.Name, strings.Join(s.Tag, ","), s.Path, s.Shell}
+	for i, f := range fields {
+		fields[i] = strings.ReplaceAll(f, delimiter, " ")
+	}
+	return delimiter + strings.Join(fields, delimiter)
+}
+
+// runSelector runs the selector with the options on the lines read from r
+// and writes the chosen lines to w. The embedded fuzzy finder understands the
+// --multi, --query and --expect options, and uses the preview and tags of
+// find.
+func runSelector(options []string, r io.Reader, w io.Writer, find dialog.FindOptions) error {
+	if !builtinSelector() {
+		var command string
+		if p, ok := selectorPlugin(); ok {
+			command = strings.Join([]string{selectCmd(), selectorArgs(), pluginOptions(p, options)}, " ")
+		} else {
+			command = strings.Join(append([]string{selectCmd(), selectorArgs()}, options...), " ")
+		}
+		command = strings.TrimSpace(command)
+		if popup := config.Conf.Selector.TmuxPopup; popup != "" && os.Getenv("TMUX") != "" {
+			return runInPopup(command, popup, selectorEnv(), r, w)
+		}
+		return runEnv(command, selectorEnv(), r, w)
+	}
+
+	opts := find
+	for _, o := range options {
+		switch {
+		case o == "--multi":
+			opts.Multi = true
+		case strings.HasPrefix(o, "--query "):
+			opts.Query = config.Flag.Query
+		case strings.HasPrefix(o, "--expect="):
+			opts.Expect = strings.Split(strings.TrimPrefix(o, "--expect="), ",")
+			opts.Header = keybindHeader(keyActions())
+		}
+	}
+	// the embedded fuzzy finder starts with all the lines
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxSelectorLine)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	selected, err := dialog.Find(lines, opts)
+	if err != nil {
+		return err
+	}
+	for _, l := range selected {
+		if _, err := io.WriteString(w, l+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
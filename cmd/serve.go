@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/server"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve snippets over a local HTTP JSON API",
+	Long: `Serve snippets over a local HTTP JSON API
+
+Endpoints:
+  GET  /snippets[?tag=TAG]  list snippets
+  POST /snippets            create a snippet
+  GET  /search?q=QUERY      search snippets
+  POST /exec                expand (and with --allow-exec, run) a snippet
+  GET/PUT/DELETE /snippets/DESCRIPTION
+                            get, update or delete a snippet
+  POST /sync                sync snippets
+  GET  /changes?version=N   wait until the snippet files change
+
+The web UI is served at /. The API only answers requests to localhost (or
+the host of --addr), JSON bodies with Content-Type: application/json, and
+with --token requests with the bearer token. --allow-exec requires a token,
+one is generated and printed without --token. The snippets are run as by
+pet exec, except those with sudo. POST /exec requires a token, even to only
+expand a snippet, as it runs its script and template functions.`,
+	RunE: serve,
+}
+
+func serve(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	var execFunc server.ExecFunc
+	if flag.AllowExec {
+		execFunc = runServed
+	}
+
+	token := flag.Token
+	if flag.AllowExec && token == "" {
+		// any page of the browser could run commands otherwise
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Errorf("Failed to generate token: %v", err)
+		}
+		token = hex.EncodeToString(b)
+		fmt.Printf("Token: %s\n", token)
+	}
+	s := server.New(token, execFunc)
+	if host, _, err := net.SplitHostPort(flag.Addr); err == nil && host != "" {
+		s.Hosts = []string{host}
+	}
+	if w, err := s.Watch(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the changes of the snippet files are not watched: %v\n", err)
+	} else {
+		defer w.Close()
+	}
+	fmt.Printf("Listening on http://%s\n", flag.Addr)
+	return http.ListenAndServe(flag.Addr, s)
+}
+
+// runServed runs a snippet for POST /exec as pet exec does, with the hooks,
+// the directory, the environment and the timeout of the snippet, and writes
+// it to the audit log. There is no terminal to ask for the parameters which
+// are not given, nor for a password.
+func runServed(s snippet.SnippetInfo, params map[string]string) (string, error) {
+	snippets := []snippet.SnippetInfo{s}
+	if needsSudo(snippets) {
+		return "", fmt.Errorf("Snippet [%s] runs with elevated privileges, run it with pet exec", s.Description)
+	}
+	executions, err := expandSnippetsWith(snippets, params, true)
+	if err != nil {
+		return "", err
+	}
+	command, display, public := joinExecutions(executions)
+	dir, err := workDir(executions)
+	if err != nil {
+		return "", err
+	}
+	timeout, err := execTimeout(snippets)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = runExecutions(snippets, executions, public, nil, command, display, scriptOptions{dir: dir, timeout: timeout}, strings.NewReader(""), &buf)
+	return buf.String(), err
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&config.Flag.Addr, "addr", "", "127.0.0.1:7777",
+		`Address to listen on`)
+	serveCmd.Flags().StringVarP(&config.Flag.Token, "token", "", "",
+		`Require this bearer token on every request`)
+	serveCmd.Flags().BoolVarP(&config.Flag.AllowExec, "allow-exec", "", false,
+		`Allow running snippets through POST /exec (with a token, generated without --token)`)
+}
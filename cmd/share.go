@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// shareCmd represents the share command
+var shareCmd = &cobra.Command{
+	Use:   "share [NAME]",
+	Short: "Publish snippets and print the URL",
+	Long: `Upload the selected snippets, or the snippet with the NAME, as a new gist or
+GitLab Snippet and print its URL. The visibility is the most private of the
+visibility of the snippets, which follows the sync backend settings without
+one.
+
+Import it on the other side with: pet import --url URL
+
+--qr prints the command of the snippets as a QR code instead, without
+uploading them, and --qr=url the QR code of the URL.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: share,
+}
+
+func share(cmd *cobra.Command, args []string) error {
+	switch config.Flag.QR {
+	case "", "command", "url":
+	default:
+		return fmt.Errorf("unknown --qr: %s (command or url)", config.Flag.QR)
+	}
+	var selected []snippet.SnippetInfo
+	if len(args) > 0 {
+		s, err := snippetByName(args[0])
+		if err != nil {
+			return err
+		}
+		selected = []snippet.SnippetInfo{s}
+	} else {
+		var options []string
+		if config.Flag.Query != "" {
+			options = append(options, fmt.Sprintf("--query %s", quoteArg(config.Flag.Query)))
+		}
+		var err error
+		if selected, err = selectSnippets(options, tagFilter()); err != nil {
+			return err
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	for _, s := range selected {
+		if s.LocalOnly() {
+			return fmt.Errorf("Snippet [%s] has one of sync_exclude_tags, it is not shared", s.Description)
+		}
+	}
+	if config.Flag.QR == "command" {
+		var commands []string
+		for _, s := range selected {
+			commands = append(commands, s.Command)
+		}
+		return printQR(os.Stdout, strings.Join(commands, "\n"))
+	}
+	shared := snippet.Snippets{Snippets: selected}
+	for i := range shared.Snippets {
+		shared.Snippets[i].Archived = false
+	}
+	content, err := shared.ToString()
+	if err != nil {
+		return err
+	}
+
+	title := selected[0].Description
+	if len(selected) > 1 {
+		title = fmt.Sprintf("%d pet snippets", len(selected))
+	}
+	url, err := petSync.Share(title, content, petSync.Visibility(selected))
+	if err != nil {
+		return err
+	}
+	fmt.Println(url)
+	if config.Flag.QR == "url" {
+		return printQR(os.Stdout, url)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(shareCmd)
+	shareCmd.Flags().StringVarP(&config.Flag.Query, "query", "q", "",
+		`Initial value for query`)
+	shareCmd.Flags().StringVarP(&config.Flag.QR, "qr", "", "",
+		`Print the command (or --qr=url the URL) as a QR code`)
+	shareCmd.Flags().Lookup("qr").NoOptDefVal = "command"
+	addFilterFlags(shareCmd)
+	addAllFlag(shareCmd)
+	shareCmd.ValidArgsFunction = completeNames
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// interpreter is how commands are run for a snippet shell
+type interpreter struct {
+	exe string
+	// inline are the arguments before a single line command
+	inline []string
+	// file are the arguments before a script file
+	file []string
+	// ext is the extension of script files
+	ext string
+	// lexer is the name of the syntax highlighting of the commands
+	lexer string
+}
+
+// interpreters are the known snippet shells. Other shells are run as
+// "SHELL -c COMMAND".
+var interpreters = map[string]interpreter{
+	"sh":         {"sh", []string{"-c"}, nil, ".sh", "bash"},
+	"bash":       {"bash", []string{"-c"}, nil, ".sh", "bash"},
+	"zsh":        {"zsh", []string{"-c"}, nil, ".zsh", "bash"},
+	"fish":       {"fish", []string{"-c"}, nil, ".fish", "fish"},
+	"pwsh":       {"pwsh", []string{"-NoProfile", "-Command"}, []string{"-NoProfile", "-File"}, ".ps1", "powershell"},
+	"powershell": {"powershell", []string{"-NoProfile", "-Command"}, []string{"-NoProfile", "-File"}, ".ps1", "powershell"},
+	"cmd":        {"cmd", []string{"/c"}, []string{"/c"}, ".bat", "batchfile"},
+	"python":     {"python3", []string{"-c"}, nil, ".py", "python"},
+	"node":       {"node", []string{"-e"}, nil, ".js", "javascript"},
+	"ruby":       {"ruby", []string{"-e"}, nil, ".rb", "ruby"},
+	"perl":       {"perl", []string{"-e"}, nil, ".pl", "perl"},
+	"sql":        {"psql", []string{"-c"}, []string{"-f"}, ".sql", "postgresql"},
+	"psql":       {"psql", []string{"-c"}, []string{"-f"}, ".sql", "postgresql"},
+}
+
+// interpreterFor returns the interpreter of the shell, a known name or the
+// path of one. An empty shell is the configured shell or cmd, or sh (cmd on
+// Windows).
+func interpreterFor(shell string) interpreter {
+	if shell == "" {
+		shell = config.Conf.General.Shell
+	}
+	if shell == "" {
+		if cmd := config.Conf.General.Cmd; len(cmd) > 0 {
+			// the script replaces the -c argument of the configured shell
+			return interpreter{exe: cmd[0], inline: cmd[1:], ext: ".sh", lexer: filepath.Base(cmd[0])}
+		}
+		if runtime.GOOS == "windows" {
+			shell = "cmd"
+		} else {
+			shell = "sh"
+		}
+	}
+	if in, ok := interpreters[shell]; ok {
+		return in
+	}
+	// e.g. /usr/local/bin/fish
+	if in, ok := interpreters[strings.TrimSuffix(filepath.Base(shell), ".exe")]; ok {
+		in.exe = shell
+		return in
+	}
+	return interpreter{exe: shell, inline: []string{"-c"}, lexer: shell}
+}
+
+// commonShell returns the shell shared by all the snippets, and false if
+// they use different shells
+func commonShell(snippets []snippet.SnippetInfo) (string, bool) {
+	if len(snippets) == 0 {
+		return "", true
+	}
+	for _, s := range snippets {
+		if s.Shell != snippets[0].Shell {
+			return "", false
+		}
+	}
+	return snippets[0].Shell, true
+}
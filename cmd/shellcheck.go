@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// shellcheckDialects are the shells shellcheck knows
+var shellcheckDialects = map[string]bool{"sh": true, "bash": true, "dash": true, "ksh": true}
+
+// shellcheckDialect returns the shellcheck dialect of the snippet shell, or
+// "" if shellcheck cannot check it (e.g. fish or python)
+func shellcheckDialect(shell string) string {
+	if shell == "" {
+		shell = config.Conf.General.Shell
+	}
+	if shell == "" {
+		if cmd := config.Conf.General.Cmd; len(cmd) > 0 {
+			shell = cmd[0]
+		} else if runtime.GOOS != "windows" || config.Flag.Host != "" {
+			shell = "sh"
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(shell), ".exe")
+	if shellcheckDialects[name] {
+		return name
+	}
+	return ""
+}
+
+// shouldCheck reports whether the commands are checked with shellcheck,
+// with --check or shellcheck = true
+func shouldCheck() bool {
+	return config.Flag.Check || config.Conf.General.Shellcheck
+}
+
+// shellcheck returns the problems shellcheck finds in the command, one per
+// line without the lines of the command, so that secrets are not shown
+func shellcheck(command, dialect string) (string, error) {
+	if _, err := exec.LookPath("shellcheck"); err != nil {
+		return "", errors.New("shellcheck is not installed")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("shellcheck", "--shell", dialect, "--format", "gcc", "-")
+	cmd.Stdin = strings.NewReader(command + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	// shellcheck exits with 1 when it finds problems
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", fmt.Errorf("Failed to run shellcheck: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(strings.ReplaceAll(stdout.String(), "-:", "line "), "\n"), nil
+}
+
+// warnShellcheck prints the problems shellcheck finds in the command of the
+// shell, and reports whether there are any. Commands shellcheck cannot check
+// are left out.
+func warnShellcheck(command, shell string) bool {
+	dialect := shellcheckDialect(shell)
+	if dialect == "" {
+		return false
+	}
+	problems, err := shellcheck(command, dialect)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", colors.warning.Sprint("Warning:"), err)
+		return false
+	}
+	if problems == "" {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s\n%s\n", colors.warning.Sprint("shellcheck:"), problems)
+	return true
+}
+
+// checkExecution checks the expanded command before it runs and asks
+// whether to run it anyway when shellcheck finds problems
+func checkExecution(command string, snippets []snippet.SnippetInfo) error {
+	shell, _ := commonShell(snippets)
+	if !warnShellcheck(command, shell) || config.Flag.Yes {
+		return nil
+	}
+	if !confirm(i18n.T("shellcheck found problems. Run it anyway?")) {
+		return errCanceled
+	}
+	return nil
+}
+
+// checkNewCommand checks the command of a new snippet, with the parameters
+// replaced by a word
+func checkNewCommand(command string) {
+	warnShellcheck(dialog.ReplaceParams(command, func(string) string { return "param" }), "")
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show [NAME]",
+	Short: "Show the details of a snippet",
+	Long: `Show the details of the selected snippet, or the snippet with the NAME. The
+secrets are hidden as in pet list, unless --reveal is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: show,
+}
+
+// snippetDetail is the JSON output of pet show
+type snippetDetail struct {
+	snippet.SnippetInfo
+	Params []dialog.Param `json:"params"`
+}
+
+func show(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+	if flag.LastOutput {
+		output, err := snippet.LoadLastOutput()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+
+	var snippets []snippet.SnippetInfo
+	if len(args) > 0 {
+		s, err := snippetByName(args[0])
+		if err != nil {
+			return err
+		}
+		snippets = []snippet.SnippetInfo{s}
+	} else {
+		var options []string
+		if flag.Query != "" {
+			options = append(options, fmt.Sprintf("--query %s", quoteArg(flag.Query)))
+		}
+		var err error
+		if snippets, err = selectSnippets(options, tagFilter()); err != nil {
+			return err
+		}
+	}
+
+	r := redactor()
+	for i, s := range snippets {
+		snippets[i] = s.Redact(r)
+	}
+
+	if flag.JSON {
+		details := []snippetDetail{}
+		for _, s := range snippets {
+			params := dialog.ParseParams(s.Command)
+			if params == nil {
+				params = []dialog.Param{}
+			}
+			details = append(details, snippetDetail{SnippetInfo: s, Params: params})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if len(args) > 0 && len(details) == 1 {
+			return enc.Encode(details[0])
+		}
+		return enc.Encode(details)
+	}
+
+	for i, s := range snippets {
+		if i > 0 {
+			fmt.Println(strings.Repeat("-", 30))
+		}
+		printDetail(s)
+	}
+	return nil
+}
+
+// printDetail prints every field of a snippet
+func printDetail(s snippet.SnippetInfo) {
+	field := func(label, value string) {
+		lines := strings.Split(value, "\n")
+		fmt.Fprintf(color.Output, "%s %s\n", label, lines[0])
+		for _, l := range lines[1:] {
+			fmt.Fprintf(color.Output, "%12s %s\n", "", l)
+		}
+	}
+
+	field(colors.description.Sprint("Description:"), s.Description)
+	if s.Favorite {
+		field(colors.meta.Sprint("   Favorite:"), "yes")
+	}
+	if s.Name != "" {
+		field(colors.meta.Sprint("       Name:"), s.Name)
+	}
+	if s.Path != "" {
+		field(colors.meta.Sprint("       Path:"), s.Path)
+	}
+	if s.Expires != "" {
+		field(colors.meta.Sprint("    Expires:"), expiry(s))
+	}
+	if s.CreatedAt != nil {
+		field(colors.meta.Sprint("    Created:"), s.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	if s.UpdatedAt != nil {
+		field(colors.meta.Sprint("    Updated:"), s.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	field(colors.command.Sprint("    Command:"), highlightCommand(s))
+	if len(s.Tag) > 0 {
+		field(colors.tag.Sprint("        Tag:"), strings.Join(s.Tag, " "))
+	}
+	if s.Output != "" {
+		field(colors.output.Sprint("     Output:"), s.Output)
+	}
+	if s.Capture != "" {
+		field(colors.info.Sprint("    Capture:"), s.Capture)
+	}
+	if s.OutputFilter != "" {
+		field(colors.info.Sprint("     Filter:"), s.OutputFilter)
+	}
+	if s.OnFailure != "" {
+		field(colors.info.Sprint(" On failure:"), s.OnFailure)
+	}
+	if s.Shell != "" {
+		field(colors.info.Sprint("      Shell:"), s.Shell)
+	}
+	if s.Dir != "" {
+		field(colors.info.Sprint("        Dir:"), s.Dir)
+	}
+	if len(s.Env) > 0 {
+		var env []string
+		for k, v := range s.Env {
+			env = append(env, k+"="+v)
+		}
+		sort.Strings(env)
+		field(colors.info.Sprint("        Env:"), strings.Join(env, " "))
+	}
+	if s.Timeout != "" {
+		field(colors.info.Sprint("    Timeout:"), s.Timeout)
+	}
+	if len(s.Platform) > 0 {
+		field(colors.info.Sprint("   Platform:"), strings.Join(s.Platform, " "))
+	}
+	if len(s.Requires) > 0 {
+		field(colors.info.Sprint("   Requires:"), strings.Join(s.Requires, " "))
+	}
+	if s.NeedsConfirm() {
+		field(colors.warning.Sprint("    Confirm:"), "yes")
+	}
+	if s.Sudo {
+		field(colors.warning.Sprint("       Sudo:"), "yes")
+	}
+	if s.Visibility != "" {
+		field(colors.info.Sprint(" Visibility:"), s.Visibility)
+	}
+	if s.PreExec != "" {
+		field(colors.info.Sprint("   Pre exec:"), s.PreExec)
+	}
+	if s.PostExec != "" {
+		field(colors.info.Sprint("  Post exec:"), s.PostExec)
+	}
+	if s.Script != "" {
+		field(colors.info.Sprint("     Script:"), s.Script)
+	}
+
+	params := dialog.ParseParams(s.Command)
+	if len(params) > 0 {
+		fmt.Fprintf(color.Output, "%s\n", colors.info.Sprint("     Params:"))
+		for _, p := range params {
+			if p.Type != "" {
+				p.Name += " (" + p.Type + ")"
+			}
+			switch {
+			case p.Store != "":
+				fmt.Printf("%12s %s (from %s:%s)\n", "", p.Name, p.Store, p.Ref)
+			case p.Provider != "":
+				fmt.Printf("%12s %s (choices: output of %s)\n", "", p.Name, p.Provider)
+			case len(p.Options) > 1:
+				fmt.Printf("%12s %s (default: %s, choices: %s)\n", "", p.Name, p.Default(), strings.Join(p.Options, ", "))
+			case len(p.Options) == 1:
+				fmt.Printf("%12s %s (default: %s)\n", "", p.Name, p.Default())
+			default:
+				fmt.Printf("%12s %s\n", "", p.Name)
+			}
+		}
+	}
+	if len(s.Attachments) > 0 {
+		var names []string
+		for _, a := range s.Attachments {
+			names = append(names, a.Name)
+		}
+		field(colors.info.Sprint("Attachments:"), strings.Join(names, " "))
+	}
+	if s.Notes != "" {
+		field(colors.description.Sprint("      Notes:"), renderMarkdown(s.Notes))
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(showCmd)
+	showCmd.Flags().StringVarP(&config.Flag.Query, "query", "q", "",
+		`Initial value for query`)
+	addFilterFlags(showCmd)
+	showCmd.Flags().BoolVarP(&config.Flag.LastOutput, "last-output", "", false,
+		`Show the output of the last pet exec with --save-output, --tee or --pager`)
+	showCmd.Flags().BoolVarP(&config.Flag.JSON, "json", "", false,
+		`Print the snippet as JSON`)
+	showCmd.ValidArgsFunction = completeNames
+	showCmd.RegisterFlagCompletionFunc("query", completeDescriptions)
+	addAllFlag(showCmd)
+}
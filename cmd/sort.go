@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// sortCmd represents the sort command
+var sortCmd = &cobra.Command{
+	Use:   "sort",
+	Short: "Rewrite the snippet file in a canonical order",
+	Long: `Rewrite the snippet file sorted by tag, description or usage
+
+A stable order keeps the diffs of a snippet file shared in git reviewable.
+With --group, snippets are grouped by their first tag under comment headers.`,
+	RunE: sortSnippets,
+}
+
+func sortSnippets(cmd *cobra.Command, args []string) error {
+	flag := config.Flag
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	usage, err := snippet.LoadUsage()
+	if err != nil {
+		return err
+	}
+	files, err := snippet.SnippetFiles()
+	if err != nil {
+		return err
+	}
+	byFile := map[string]*snippet.Snippets{}
+	for _, file := range files {
+		if fs := snippets.FileSnippets(file); len(fs.Snippets) > 0 {
+			byFile[file] = fs
+		}
+	}
+
+	// each file is sorted on its own so that snippets stay in their files
+	for _, file := range files {
+		snippets, ok := byFile[file]
+		if !ok {
+			continue
+		}
+		if err := snippets.Sort(flag.By, usage); err != nil {
+			return err
+		}
+
+		var content string
+		if flag.Group {
+			content, err = snippets.GroupedString()
+		} else {
+			content, err = snippets.ToString()
+		}
+		if err != nil {
+			return err
+		}
+
+		if flag.DryRun {
+			if len(byFile) > 1 {
+				fmt.Printf("# ==> %s <==\n", file)
+			}
+			fmt.Print(content)
+			continue
+		}
+		if err := snippet.WriteFile(file, []byte(content)); err != nil {
+			return fmt.Errorf("Failed to save snippet file. err: %s", err)
+		}
+	}
+
+	if !flag.DryRun && config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(sortCmd)
+	sortCmd.Flags().StringVarP(&config.Flag.By, "by", "", "description",
+		`Sort key: tag, description or usage`)
+	sortCmd.Flags().BoolVarP(&config.Flag.Group, "group", "g", false,
+		`Group snippets by their first tag with comment headers`)
+	sortCmd.Flags().BoolVarP(&config.Flag.DryRun, "dry-run", "n", false,
+		`Print the sorted snippet file instead of writing it`)
+	sortCmd.RegisterFlagCompletionFunc("by", cobra.FixedCompletions(
+		[]string{"tag", "description", "usage"}, cobra.ShellCompDirectiveNoFileComp))
+}
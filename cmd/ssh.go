@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// sshArgs returns the ssh command line running the command on the host of
+// the config, or else the host as ssh knows it (e.g. from ~/.ssh/config).
+// The remote shell is assumed to be a POSIX shell.
+func sshArgs(name, command string, opts scriptOptions, w io.Writer) []string {
+	host := config.Conf.Hosts[name]
+	dest := name
+	if host.Destination != "" {
+		dest = host.Destination
+	}
+
+	var line []string
+	if opts.sudo {
+		sudo := host.SudoCmd
+		if len(sudo) == 0 {
+			sudo = []string{"sudo"}
+		}
+		line = append(line, sudo...)
+	}
+	if len(opts.env) > 0 {
+		line = append(append(line, "env"), envArgs(opts.env)...)
+	}
+	shell := opts.shell
+	if shell == "" {
+		shell = config.Conf.General.Shell
+	}
+	if shell == "" {
+		shell = "sh"
+	}
+	in := interpreterFor(shell)
+	line = append(append(append(line, in.exe), in.inline...), command)
+	for i, a := range line {
+		line[i] = shellescape.Quote(a)
+	}
+	remote := strings.Join(line, " ")
+	if opts.dir != "" {
+		remote = "cd " + remotePath(opts.dir) + " && " + remote
+	}
+
+	args := []string{"ssh"}
+	// a terminal on the host for prompts, e.g. of sudo, unless the output
+	// is captured
+	if w == os.Stdout && terminal.IsTerminal(0) && terminal.IsTerminal(1) {
+		args = append(args, "-t")
+	}
+	if host.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(host.Port))
+	}
+	if host.IdentityFile != "" {
+		// ssh expands a leading ~
+		args = append(args, "-i", host.IdentityFile)
+	}
+	args = append(args, host.Args...)
+	return append(args, "--", dest, remote)
+}
+
+// remotePath quotes the directory for the remote shell, with a leading ~
+// left as the home directory of the host
+func remotePath(dir string) string {
+	if dir == "~" {
+		return "~"
+	}
+	if strings.HasPrefix(dir, "~/") {
+		return "~/" + shellescape.Quote(dir[2:])
+	}
+	return shellescape.Quote(dir)
+}
+
+// remoteDir returns the directory the snippets run in on the host: that of
+// --cwd, or the one of their snippet, with a leading ~ for the home of the
+// host
+func remoteDir(snippets []snippet.SnippetInfo) (string, error) {
+	dir := config.Flag.Cwd
+	if dir == "" && len(snippets) > 0 {
+		dir = snippets[0].Dir
+	}
+	return snippet.RenderDir(dir)
+}
+
+// sshHosts returns the hosts of the config and the Host names of
+// ~/.ssh/config without patterns
+func sshHosts() []string {
+	seen := map[string]bool{}
+	var hosts []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			hosts = append(hosts, name)
+		}
+	}
+	for name := range config.Conf.Hosts {
+		add(name)
+	}
+	sort.Strings(hosts)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return hosts
+	}
+	f, err := os.Open(filepath.Join(home, ".ssh", "config"))
+	if err != nil {
+		return hosts
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.ReplaceAll(scanner.Text(), "=", " "))
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "Host") {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if !strings.ContainsAny(name, "*?!") {
+				add(name)
+			}
+		}
+	}
+	return hosts
+}
+
+// completeHosts completes the hosts of pet exec --host
+func completeHosts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return sshHosts(), cobra.ShellCompDirectiveNoFileComp
+}
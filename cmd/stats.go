@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show snippet usage statistics",
+	Long:  `Show most used snippets, never used snippets and tag distribution`,
+	RunE:  stats,
+}
+
+func stats(cmd *cobra.Command, args []string) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	usage, err := snippet.LoadUsage()
+	if err != nil {
+		return err
+	}
+
+	col := config.Conf.General.Column
+	if col == 0 {
+		col = column
+	}
+	limit := config.Flag.Limit
+
+	var used, unused []snippet.SnippetInfo
+	tags := map[string]int{}
+	for _, s := range snippets.Snippets {
+		if usage.Get(s).Count > 0 {
+			used = append(used, s)
+		} else {
+			unused = append(unused, s)
+		}
+		for _, t := range s.Tag {
+			tags[t]++
+		}
+	}
+	sort.SliceStable(used, func(i, j int) bool {
+		return usage.Get(used[i]).Count > usage.Get(used[j]).Count
+	})
+
+	fmt.Fprintf(color.Output, "%s\n", color.GreenString("Most used snippets:"))
+	for i, s := range used {
+		if limit > 0 && i >= limit {
+			break
+		}
+		u := usage.Get(s)
+		fmt.Fprintf(color.Output, "%6d  %s  %s\n", u.Count,
+			u.LastUsed.Format("2006-01-02 15:04"),
+			runewidth.Truncate(s.Description, col, "..."))
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", color.YellowString("Never used snippets (%d):", len(unused)))
+	for i, s := range unused {
+		if limit > 0 && i >= limit {
+			fmt.Printf("  ... and %d more\n", len(unused)-limit)
+			break
+		}
+		fmt.Printf("  %s\n", runewidth.Truncate(s.Description, col, "..."))
+	}
+
+	var names []string
+	for t := range tags {
+		names = append(names, t)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if tags[names[i]] != tags[names[j]] {
+			return tags[names[i]] > tags[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintf(color.Output, "\n%s\n", color.CyanString("Tags:"))
+	for _, t := range names {
+		fmt.Printf("%6d  %s\n", tags[t], t)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().IntVarP(&config.Flag.Limit, "limit", "n", 10,
+		`Number of snippets to show per section (0: all)`)
+}
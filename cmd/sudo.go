@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"sort"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// sudoCmd returns the command running commands with elevated privileges:
+// sudo_cmd of the config, or sudo or doas, whichever is installed
+func sudoCmd() ([]string, error) {
+	if cmd := config.Conf.General.SudoCmd; len(cmd) > 0 {
+		return cmd, nil
+	}
+	for _, name := range []string{"sudo", "doas"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return []string{name}, nil
+		}
+	}
+	return nil, errors.New("Neither sudo nor doas is installed, set sudo_cmd in the config")
+}
+
+// needsSudo reports whether the snippets run with elevated privileges
+func needsSudo(snippets []snippet.SnippetInfo) bool {
+	if config.Flag.Sudo {
+		return true
+	}
+	for _, s := range snippets {
+		if s.Sudo {
+			return true
+		}
+	}
+	return false
+}
+
+// envArgs returns the variables as the NAME=VALUE arguments of env
+func envArgs(env map[string]string) []string {
+	var args []string
+	for k, v := range env {
+		args = append(args, k+"="+v)
+	}
+	sort.Strings(args)
+	return args
+}
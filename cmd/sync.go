@@ -1,7 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/schedule"
 	petSync "github.com/knqyf263/pet/sync"
 	"github.com/spf13/cobra"
 )
@@ -10,14 +17,85 @@ import (
 var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync snippets",
-	Long:  `Sync snippets with gist/gitlab`,
-	RunE:  sync,
+	Long: `Sync snippets with gist/gitlab
+
+--install-schedule runs pet sync every sync_interval of [General] (default:
+1h) in the background, with a systemd user timer on Linux, a launchd agent on
+macOS or the Task Scheduler on Windows. --remove-schedule removes it.`,
+	RunE: sync,
 }
 
+// defaultSyncInterval is the interval of the scheduled syncs without
+// sync_interval
+const defaultSyncInterval = time.Hour
+
 func sync(cmd *cobra.Command, args []string) (err error) {
-	return petSync.AutoSync(config.Conf.General.SnippetFile)
+	switch {
+	case config.Flag.InstallSchedule:
+		return installSyncSchedule()
+	case config.Flag.RemoveSchedule:
+		if err := schedule.Uninstall(syncJobName()); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "%s the scheduled sync\n", color.GreenString("Removed"))
+		return nil
+	}
+	if len(config.Flag.Remotes) == 0 {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return petSync.SyncRemotes(config.Conf.General.SnippetFile, config.Flag.Remotes)
+}
+
+// syncJobName returns the name of the scheduled sync, one per profile
+func syncJobName() string {
+	if p := config.ProfileName(); p != "" {
+		return "pet-sync-" + p
+	}
+	return "pet-sync"
+}
+
+func installSyncSchedule() error {
+	interval := defaultSyncInterval
+	if config.Conf.General.SyncInterval != "" {
+		// checked when the config is loaded
+		interval, _ = time.ParseDuration(config.Conf.General.SyncInterval)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	configPath, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	command := []string{exe, "--config", configPath}
+	if p := config.ProfileName(); p != "" {
+		command = append(command, "--profile", p)
+	}
+	command = append(command, "sync")
+	for _, r := range config.Flag.Remotes {
+		command = append(command, "--remote", r)
+	}
+	log, err := config.GetDataFile("sync.log")
+	if err != nil {
+		return err
+	}
+
+	file, err := schedule.Install(schedule.Job{Name: syncJobName(), Command: command, Interval: interval, Log: log})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "%s pet sync every %s (%s)\n", color.GreenString("Scheduled"), interval, file)
+	return nil
 }
 
 func init() {
 	RootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringSliceVarP(&config.Flag.Remotes, "remote", "", nil,
+		`Sync with the named remotes ([[remote]]) instead of the backend (repeatable)`)
+	syncCmd.Flags().BoolVarP(&config.Flag.InstallSchedule, "install-schedule", "", false,
+		`Run pet sync every sync_interval in the background`)
+	syncCmd.Flags().BoolVarP(&config.Flag.RemoveSchedule, "remove-schedule", "", false,
+		`Remove the scheduled sync`)
+	syncCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
 }
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	"github.com/spf13/cobra"
+)
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage tags of snippets",
+	Long:  `Add, remove, rename and list tags across many snippets at once`,
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tags with the number of snippets",
+	Args:  cobra.NoArgs,
+	RunE:  tagList,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add TAG...",
+	Short: "Add tags to the selected snippets",
+	Long:  `Add tags to the snippets chosen in the selector or matching --filter`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tagSelected(args, func(s *snippet.SnippetInfo, tag string) bool { return s.AddTag(tag) })
+	},
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:     "rm TAG...",
+	Aliases: []string{"remove"},
+	Short:   "Remove tags from the selected snippets",
+	Long:    `Remove tags from the snippets chosen in the selector or matching --filter`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tagSelected(args, func(s *snippet.SnippetInfo, tag string) bool { return s.RemoveTag(tag) })
+	},
+}
+
+var tagRenameCmd = &cobra.Command{
+	Use:   "rename OLD NEW",
+	Short: "Rename a tag in all snippets",
+	Args:  cobra.ExactArgs(2),
+	RunE:  tagRename,
+}
+
+func tagList(cmd *cobra.Command, args []string) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, s := range snippets.Snippets {
+		for _, t := range s.Tag {
+			counts[t]++
+		}
+	}
+	var tags []string
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	for _, t := range tags {
+		fmt.Printf("%6d  %s\n", counts[t], t)
+	}
+	return nil
+}
+
+// tagTargets returns the indices of the snippets matching --filter, or
+// chosen in the selector
+func tagTargets(snippets *snippet.Snippets) ([]int, error) {
+	var targets []snippet.SnippetInfo
+	if config.Flag.Filter != "" {
+		targets = snippets.Search(config.Flag.Filter)
+	} else {
+		var err error
+		if targets, err = selectSnippets(multiSelectOptions(), tagFilter()); err != nil {
+			return nil, err
+		}
+	}
+
+	var indices []int
+	for _, s := range targets {
+		if i := snippets.Index(s); i >= 0 {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("no snippets selected")
+	}
+	return indices, nil
+}
+
+func tagSelected(tags []string, update func(s *snippet.SnippetInfo, tag string) bool) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	indices, err := tagTargets(&snippets)
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, i := range indices {
+		updated := false
+		for _, t := range tags {
+			if update(&snippets.Snippets[i], t) {
+				updated = true
+			}
+		}
+		if updated {
+			changed++
+		}
+	}
+	return saveTagChanges(&snippets, changed)
+}
+
+func tagRename(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+	if strings.ContainsAny(to, " \t") {
+		return fmt.Errorf("tag must not contain spaces: %s", to)
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	changed := 0
+	for i := range snippets.Snippets {
+		s := &snippets.Snippets[i]
+		if s.RemoveTag(from) {
+			s.AddTag(to)
+			changed++
+		}
+	}
+	return saveTagChanges(&snippets, changed)
+}
+
+func saveTagChanges(snippets *snippet.Snippets, changed int) error {
+	fmt.Printf("Updated %d snippets\n", changed)
+	if changed == 0 {
+		return nil
+	}
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagListCmd, tagAddCmd, tagRmCmd, tagRenameCmd)
+	for _, c := range []*cobra.Command{tagAddCmd, tagRmCmd} {
+		c.Flags().StringVarP(&config.Flag.Filter, "filter", "f", "",
+			`Apply to all snippets matching the query instead of using the selector`)
+		addFilterFlags(c)
+	}
+	tagRmCmd.ValidArgsFunction = completeTags
+	tagRenameCmd.ValidArgsFunction = completeTags
+}
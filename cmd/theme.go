@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/awesome-gocui/gocui"
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+)
+
+// themePresets are the named themes of the [theme] section
+var themePresets = map[string]config.ThemeConfig{
+	"default": {
+		Description: "green", Command: "yellow", Tag: "cyan", Output: "red",
+		Meta: "magenta", Info: "blue", Warning: "red",
+		SelectorDescription: "red", SelectorPath: "magenta", SelectorTag: "blue", SelectorShell: "cyan",
+		Favorite: "yellow", Selected: "green", Highlight: "monokai",
+	},
+	// light avoids yellow and cyan, which are hard to read on white
+	"light": {
+		Description: "blue", Command: "magenta", Tag: "green", Output: "red",
+		Meta: "magenta", Info: "blue", Warning: "red",
+		SelectorDescription: "blue", SelectorPath: "magenta", SelectorTag: "green", SelectorShell: "magenta",
+		Favorite: "red", Selected: "blue", Highlight: "github",
+	},
+	"mono": {
+		Description: "bold", Command: "none", Tag: "none", Output: "none",
+		Meta: "bold", Info: "bold", Warning: "bold",
+		SelectorDescription: "bold", SelectorPath: "none", SelectorTag: "none", SelectorShell: "none",
+		Favorite: "bold", Selected: "reverse", Highlight: "none",
+	},
+	// none disables colors like NO_COLOR
+	"none": {
+		Description: "none", Command: "none", Tag: "none", Output: "none",
+		Meta: "none", Info: "none", Warning: "none",
+		SelectorDescription: "none", SelectorPath: "none", SelectorTag: "none", SelectorShell: "none",
+		Favorite: "none", Selected: "reverse", Highlight: "none",
+	},
+}
+
+// palette is the compiled theme
+type palette struct {
+	description, command, tag, output, meta, info, warning                  *color.Color
+	selectorDescription, selectorPath, selectorTag, selectorShell, favorite *color.Color
+	// selected and warningView are the colors of the highlighted line and
+	// the errors of the selector and dialogs
+	selected, warningView gocui.Attribute
+	// highlight is the chroma style, empty for no highlighting
+	highlight string
+	noColor   bool
+}
+
+// colors is the theme in use, set from the config by applyTheme
+var colors, _ = newPalette(config.ThemeConfig{})
+
+var textAttributes = map[string]color.Attribute{
+	"black": color.FgBlack, "red": color.FgRed, "green": color.FgGreen, "yellow": color.FgYellow,
+	"blue": color.FgBlue, "magenta": color.FgMagenta, "cyan": color.FgCyan, "white": color.FgWhite,
+	"bold": color.Bold, "italic": color.Italic, "underline": color.Underline, "reverse": color.ReverseVideo,
+}
+
+var viewAttributes = map[string]gocui.Attribute{
+	"black": gocui.ColorBlack, "red": gocui.ColorRed, "green": gocui.ColorGreen, "yellow": gocui.ColorYellow,
+	"blue": gocui.ColorBlue, "magenta": gocui.ColorMagenta, "cyan": gocui.ColorCyan, "white": gocui.ColorWhite,
+	"bold": gocui.AttrBold, "italic": gocui.AttrItalic, "underline": gocui.AttrUnderline, "reverse": gocui.AttrReverse,
+}
+
+// parseColor returns the color of words such as "bold hi-red". Bright
+// colors are bold in the selector.
+func parseColor(spec string) (*color.Color, gocui.Attribute, error) {
+	c := color.New()
+	view := gocui.ColorDefault
+	n := 0
+	for _, w := range strings.Fields(spec) {
+		if w == "none" {
+			continue
+		}
+		name := strings.TrimPrefix(w, "hi-")
+		attr, ok := textAttributes[name]
+		if !ok || name != w && (attr < color.FgBlack || attr > color.FgWhite) {
+			return nil, 0, fmt.Errorf("unknown color: %s", w)
+		}
+		if name != w {
+			attr += color.FgHiBlack - color.FgBlack
+			view |= gocui.AttrBold
+		}
+		c.Add(attr)
+		view |= viewAttributes[name]
+		n++
+	}
+	if n == 0 {
+		c.DisableColor()
+	}
+	return c, view, nil
+}
+
+// newPalette compiles the theme over its preset
+func newPalette(cfg config.ThemeConfig) (*palette, error) {
+	preset := cfg.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	t, ok := themePresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme preset: %s (default, light, mono or none)", preset)
+	}
+	override := func(v *string, with string) {
+		if with != "" {
+			*v = with
+		}
+	}
+	override(&t.Description, cfg.Description)
+	override(&t.Command, cfg.Command)
+	override(&t.Tag, cfg.Tag)
+	override(&t.Output, cfg.Output)
+	override(&t.Meta, cfg.Meta)
+	override(&t.Info, cfg.Info)
+	override(&t.Warning, cfg.Warning)
+	override(&t.SelectorDescription, cfg.SelectorDescription)
+	override(&t.SelectorPath, cfg.SelectorPath)
+	override(&t.SelectorTag, cfg.SelectorTag)
+	override(&t.SelectorShell, cfg.SelectorShell)
+	override(&t.Favorite, cfg.Favorite)
+	override(&t.Selected, cfg.Selected)
+	override(&t.Highlight, cfg.Highlight)
+
+	p := &palette{noColor: preset == "none" || os.Getenv("NO_COLOR") != ""}
+	var err error
+	for _, c := range []struct {
+		to   **color.Color
+		spec string
+	}{
+		{&p.description, t.Description}, {&p.command, t.Command}, {&p.tag, t.Tag},
+		{&p.output, t.Output}, {&p.meta, t.Meta}, {&p.info, t.Info}, {&p.warning, t.Warning},
+		{&p.selectorDescription, t.SelectorDescription}, {&p.selectorPath, t.SelectorPath},
+		{&p.selectorTag, t.SelectorTag}, {&p.selectorShell, t.SelectorShell}, {&p.favorite, t.Favorite},
+	} {
+		if *c.to, _, err = parseColor(c.spec); err != nil {
+			return nil, fmt.Errorf("Invalid theme: %v", err)
+		}
+	}
+
+	if _, p.selected, err = parseColor(t.Selected); err != nil {
+		return nil, fmt.Errorf("Invalid theme: %v", err)
+	}
+	if _, p.warningView, err = parseColor(t.Warning); err != nil {
+		return nil, fmt.Errorf("Invalid theme: %v", err)
+	}
+	if p.noColor {
+		p.selected, p.warningView = gocui.AttrReverse, gocui.ColorDefault
+	}
+
+	if t.Highlight != "none" {
+		if _, ok := styles.Registry[t.Highlight]; !ok {
+			return nil, fmt.Errorf("Invalid theme: unknown highlight style: %s", t.Highlight)
+		}
+		p.highlight = t.Highlight
+	}
+	return p, nil
+}
+
+// applyTheme sets the theme of the config, which also colors the selector
+// and the parameter dialogs
+func applyTheme(cfg config.ThemeConfig) error {
+	p, err := newPalette(cfg)
+	if err != nil {
+		return err
+	}
+	colors = p
+	dialog.SelectedColor, dialog.WarningColor = p.selected, p.warningView
+	if p.noColor {
+		color.NoColor = true
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// lastPane is the target of pet exec --tmux without a pane
+const lastPane = "{last}"
+
+// sendToTmux types the command in the tmux pane, and presses Enter if enter
+// is true
+func sendToTmux(command, pane string, enter bool) error {
+	keys := [][]string{{"-l", "--", command}}
+	if enter {
+		keys = append(keys, []string{"Enter"})
+	}
+	for _, k := range keys {
+		var stderr bytes.Buffer
+		cmd := exec.Command("tmux", append([]string{"send-keys", "-t", pane}, k...)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("Failed to send the command to tmux pane %s: %v %s", pane, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+// runInPopup runs the selector command in a tmux popup of the size (width
+// and height, or both) on the lines read from r, and writes the chosen lines
+// to w
+func runInPopup(command, size string, env map[string]string, r io.Reader, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "pet-popup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	f, err := os.Create(in)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	// the popup runs the default shell of tmux, which may not be sh
+	var vars []string
+	for k, v := range env {
+		vars = append(vars, shellescape.Quote(k+"="+v))
+	}
+	sort.Strings(vars)
+	if len(vars) > 0 {
+		command = "env " + strings.Join(vars, " ") + " " + command
+	}
+	command += " < " + shellescape.Quote(in) + " > " + shellescape.Quote(out)
+
+	width, height, _ := strings.Cut(size, ",")
+	if height == "" {
+		height = width
+	}
+	cmd := exec.Command("tmux", "display-popup", "-E", "-w", width, "-h", height, command)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to open a tmux popup: %v", err)
+	}
+	selected, err := os.ReadFile(out)
+	if err != nil || len(selected) == 0 {
+		return errors.New("nothing selected")
+	}
+	_, err = w.Write(selected)
+	return err
+}
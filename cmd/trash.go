@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// trashCmd represents the trash command
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted snippets",
+	Long: `List, restore and empty the snippets deleted by pet prune, pet edit and
+pet serve. Deleted snippets are kept for trash_days (default: 30) days.`,
+	Args: cobra.NoArgs,
+	RunE: trashList,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted snippets",
+	Args:  cobra.NoArgs,
+	RunE:  trashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore [DESCRIPTION...]",
+	Short: "Restore deleted snippets",
+	Long:  `Restore the deleted snippets chosen in the selector, or with the DESCRIPTIONs`,
+	RunE:  trashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Delete the snippets in the trash permanently",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return snippet.Trashcan{}.Save()
+	},
+}
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the last deleted snippets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		trash, err := snippet.LoadTrash()
+		if err != nil {
+			return err
+		}
+		if len(trash) == 0 {
+			return fmt.Errorf("Nothing to undo")
+		}
+		return restoreTrashed(trash, trash.Last())
+	},
+}
+
+func trashList(cmd *cobra.Command, args []string) error {
+	trash, err := snippet.LoadTrash()
+	if err != nil {
+		return err
+	}
+	col := config.Conf.General.Column
+	if col == 0 {
+		col = column
+	}
+	for _, t := range trash {
+		fmt.Fprintf(color.Output, "%s  [%s]: %s\n",
+			color.YellowString(t.DeletedAt.Format("2006-01-02 15:04")),
+			color.GreenString(t.Description),
+			runewidth.Truncate(firstLine(t.Command), col, "..."))
+	}
+	return nil
+}
+
+func trashRestore(cmd *cobra.Command, args []string) error {
+	trash, err := snippet.LoadTrash()
+	if err != nil {
+		return err
+	}
+
+	var indexes []int
+	if len(args) > 0 {
+		for _, d := range args {
+			found := false
+			for i, t := range trash {
+				if t.Description == d {
+					indexes = append(indexes, i)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("Snippet [%s] not found in the trash", d)
+			}
+		}
+	} else {
+		var trashed snippet.Snippets
+		for _, t := range trash {
+			trashed.Snippets = append(trashed.Snippets, t.SnippetInfo)
+		}
+		selected, err := selectFrom(trashed, nil)
+		if err != nil {
+			return err
+		}
+		for _, s := range selected {
+			if i := trashed.Index(s); i >= 0 {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	return restoreTrashed(trash, indexes)
+}
+
+// restoreTrashed moves the trashed snippets at the indexes back to the
+// snippet file. Snippets whose description exists again stay in the trash.
+func restoreTrashed(trash snippet.Trashcan, indexes []int) error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+
+	var restorable []int
+	for _, i := range indexes {
+		if _, ok := snippets.Find(trash[i].Description); ok {
+			fmt.Fprintf(color.Output, "%s [%s] already exists, kept in the trash\n",
+				color.YellowString("Skipped:"), trash[i].Description)
+			continue
+		}
+		restorable = append(restorable, i)
+	}
+	restored := trash.Restore(restorable)
+	if len(restored) == 0 {
+		return nil
+	}
+
+	snippets.Snippets = append(snippets.Snippets, restored...)
+	if err := snippets.Save(); err != nil {
+		return err
+	}
+	if err := trash.Save(); err != nil {
+		return err
+	}
+	for _, s := range restored {
+		fmt.Fprintf(color.Output, "%s [%s]\n", color.GreenString("Restored:"), s.Description)
+	}
+	if config.Conf.Gist.AutoSync {
+		return petSync.AutoSync(config.Conf.General.SnippetFile)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(trashCmd, undoCmd)
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+}
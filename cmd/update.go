@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/update"
+	"github.com/spf13/cobra"
+)
+
+// selfUpdateCmd represents the self-update command
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update pet to the latest release",
+	Long: `Download the latest release of pet for this platform from GitHub, verify it
+against the checksums of the release and replace the running binary with it.
+
+pet installed with a package manager (Homebrew, Nix or Scoop) is updated with
+the package manager instead.`,
+	Args: cobra.NoArgs,
+	RunE: selfUpdate,
+}
+
+// packageManagers are the directories of the binaries installed by package
+// managers
+var packageManagers = map[string]string{
+	"/Cellar/":    "brew upgrade pet",
+	"/nix/store/": "nix",
+	"/scoop/":     "scoop update pet",
+}
+
+func selfUpdate(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	for dir, manager := range packageManagers {
+		if strings.Contains(filepath.ToSlash(exe), dir) {
+			return fmt.Errorf("%s is installed by a package manager, update it with %s", exe, manager)
+		}
+	}
+
+	release, err := update.Latest()
+	if err != nil {
+		return err
+	}
+	if !update.Newer(version, release.Version()) {
+		fmt.Printf("pet %s is the latest release\n", version)
+		return nil
+	}
+	fmt.Printf("Downloading pet %s...\n", release.Version())
+	binary, err := update.Download(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	if err := update.Replace(exe, binary); errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%s cannot be replaced, run pet self-update as its owner (e.g. with sudo)", exe)
+	} else if err != nil {
+		return fmt.Errorf("Failed to replace %s: %v", exe, err)
+	}
+	fmt.Fprintf(color.Output, "%s pet %s to %s\n", color.GreenString("Updated"), version, release.Version())
+	return nil
+}
+
+// checkVersion prints whether a newer release of pet is out
+func checkVersion() error {
+	release, err := update.Latest()
+	if err != nil {
+		return err
+	}
+	if !update.Newer(version, release.Version()) {
+		fmt.Println("pet is up to date")
+		return nil
+	}
+	fmt.Fprintf(color.Output, "%s pet %s is out: %s\n", color.YellowString("New release:"), release.Version(), release.URL)
+	fmt.Println("Run pet self-update, or update it with your package manager")
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(selfUpdateCmd)
+}
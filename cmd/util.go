@@ -1,103 +1,763 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/knqyf263/pet/config"
 	"github.com/knqyf263/pet/dialog"
 	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
-func editFile(command, file string) error {
-	command += " " + file
-	return run(command, os.Stdin, os.Stdout)
+func run(command string, r io.Reader, w io.Writer) error {
+	return runEnv(command, nil, r, w)
 }
 
-func run(command string, r io.Reader, w io.Writer) error {
-	var cmd *exec.Cmd
+// runEnv is run with more environment variables
+func runEnv(command string, env map[string]string, r io.Reader, w io.Writer) error {
+	cmd := newCommand(shellArgs(command))
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = w
+	cmd.Stdin = r
+	return cmd.Run()
+}
+
+// shellArgs returns the command run with the cmd of the config, or the
+// default shell
+func shellArgs(command string) []string {
 	if len(config.Conf.General.Cmd) > 0 {
-		line := append(config.Conf.General.Cmd, command)
-		cmd = exec.Command(line[0], line[1:]...)
+		return append(append([]string{}, config.Conf.General.Cmd...), command)
 	} else if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", command)
+		return []string{"cmd", "/c", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// scriptOptions are how runScript runs a command
+type scriptOptions struct {
+	// shell is the interpreter, the shell of the config or the default
+	// shell if empty
+	shell string
+	// dir is the working directory, the current one if empty
+	dir string
+	// env are added to the environment
+	env map[string]string
+	// timeout stops the command unless 0
+	timeout time.Duration
+	// sudo runs the command with elevated privileges
+	sudo bool
+	// host runs the command over ssh on this host, see sshArgs
+	host string
+}
+
+// runScript runs a command as given by the options. A command spanning
+// several lines is run from a temporary script, so that heredocs and small
+// scripts are passed unchanged.
+func runScript(command string, opts scriptOptions, r io.Reader, w io.Writer) error {
+	multiline := strings.Contains(command, "\n")
+	var args []string
+	if opts.host != "" {
+		args = sshArgs(opts.host, command, opts, w)
+	} else if opts.shell == "" && config.Conf.General.Shell == "" && !multiline {
+		args = shellArgs(command)
 	} else {
-		cmd = exec.Command("sh", "-c", command)
+		in := interpreterFor(opts.shell)
+		args = append([]string{in.exe}, in.inline...)
+		if multiline {
+			f, err := os.CreateTemp("", "pet-*"+in.ext)
+			if err != nil {
+				return fmt.Errorf("Failed to create a script: %v", err)
+			}
+			defer os.Remove(f.Name())
+			if _, err := f.WriteString(command + "\n"); err != nil {
+				f.Close()
+				return fmt.Errorf("Failed to write the script: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("Failed to write the script: %v", err)
+			}
+			script := f.Name()
+			if in.exe == "cmd" {
+				// the script is a command line of cmd /c
+				script = `"` + script + `"`
+			}
+			args = append(append([]string{in.exe}, in.file...), script)
+		} else {
+			args = append(args, command)
+		}
+	}
+	if opts.sudo && opts.host == "" {
+		var err error
+		if args, err = elevate(args, opts.env); err != nil {
+			return err
+		}
+	}
+
+	cmd := newCommand(args)
+	if opts.host == "" {
+		cmd.Dir = opts.dir
+	}
+	if len(opts.env) > 0 && opts.host == "" {
+		cmd.Env = os.Environ()
+		for k, v := range opts.env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
 	}
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = w
 	cmd.Stdin = r
-	return cmd.Run()
+	return runCommand(cmd, opts.timeout)
+}
+
+// errCanceled is returned when a prompt is declined
+var errCanceled = errors.New("canceled")
+
+// errNoMatch is returned by pet grep when no snippet matches, which exits
+// with status 1 like grep, without a message
+var errNoMatch = errors.New("no match")
+
+// stdin is shared by the prompts so that piped answers are not lost
+var stdin = bufio.NewReader(os.Stdin)
+
+// prompt asks a question on the terminal and returns the trimmed answer
+func prompt(message string) (string, error) {
+	fmt.Fprintf(color.Output, "%s", message)
+	answer, err := stdin.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// confirm asks a yes/no question on the terminal; anything but y/yes is no
+func confirm(message string) bool {
+	answer, err := prompt(message + " [y/N]: ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// tagFilter returns the tag filter given by the --tag and --any-tag flags
+func tagFilter() snippet.TagFilter {
+	return snippet.TagFilter{Tags: config.Flag.FilterTags, Any: config.Flag.AnyTag}
 }
 
-func filter(options []string, tag string) (commands []string, err error) {
+// addFilterFlags adds the --tag, --any-tag and --path flags to the command
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&config.Flag.FilterTags, "tag", "t", nil,
+		`Filter tag (can be repeated, snippets must have all of them)`)
+	cmd.Flags().BoolVarP(&config.Flag.AnyTag, "any-tag", "", false,
+		`Match snippets having any of the --tag tags`)
+	cmd.Flags().StringVarP(&config.Flag.Path, "path", "", "",
+		`Only snippets in the namespace (e.g. k8s/debug)`)
+	cmd.Flags().StringVarP(&config.Flag.Since, "since", "", "",
+		`Only snippets created within the duration (e.g. 7d, 2w, 12h)`)
+	cmd.Flags().StringVarP(&config.Flag.Sort, "sort", "", "",
+		`Order of the snippets, overriding sortby (e.g. created, -updated)`)
+	cmd.RegisterFlagCompletionFunc("tag", completeTags)
+	cmd.RegisterFlagCompletionFunc("path", completePaths)
+	cmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{
+		"recency", "-recency", "description", "-description", "command", "-command",
+		"output", "-output", "created", "-created", "updated", "-updated"},
+		cobra.ShellCompDirectiveNoFileComp))
+}
+
+// loadFiltered loads the snippets in the order of --sort and applies the
+// --tag, --path, --since and --all filters. Without --all, archived
+// snippets, snippets for other platforms and those requiring programs not
+// on PATH are left out. The programs of the remote host of --host are not
+// known, its snippets are all kept.
+func loadFiltered(tags snippet.TagFilter, path string) (snippet.Snippets, error) {
+	if config.Flag.Sort != "" {
+		config.Conf.General.SortBy = config.Flag.Sort
+	}
 	var snippets snippet.Snippets
 	if err := snippets.Load(); err != nil {
-		return commands, fmt.Errorf("Load snippet failed: %v", err)
+		return snippets, err
 	}
+	snippets = snippets.FilterTags(tags)
+	snippets = snippets.FilterPath(path)
+	if config.Flag.Since != "" {
+		age, err := snippet.ParseAge(config.Flag.Since)
+		if err != nil {
+			return snippets, err
+		}
+		snippets = snippets.CreatedSince(time.Now().Add(-age))
+	}
+	if !config.Flag.All {
+		snippets = snippets.Active()
+		snippets = snippets.ForPlatform(runtime.GOOS)
+		if config.Flag.Host == "" {
+			snippets = snippets.Runnable(exec.LookPath)
+		}
+	}
+	return snippets, nil
+}
 
-	if 0 < len(tag) {
-		var filteredSnippets snippet.Snippets
-		for _, snippet := range snippets.Snippets {
-			for _, t := range snippet.Tag {
-				if tag == t {
-					filteredSnippets.Snippets = append(filteredSnippets.Snippets, snippet)
-				}
-			}
+// addAllFlag adds the --all flag to include archived snippets, snippets
+// for other platforms and those requiring missing programs
+func addAllFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&config.Flag.All, "all", "a", false,
+		`Include archived snippets, snippets for other platforms and those requiring programs not on PATH`)
+}
+
+// multiSelectOptions returns the selector options to allow choosing several
+// entries, if the selector is known to support it
+func multiSelectOptions() []string {
+	if p, ok := selectorPlugin(); ok && len(p.Multi) > 0 || fzfSelector() || builtinSelector() {
+		return []string{"--multi"}
+	}
+	return nil
+}
+
+// fzfSelector reports whether the selector is fzf or compatible with its options
+func fzfSelector() bool {
+	fields := strings.Fields(selectCmd())
+	if len(fields) == 0 || builtinSelector() {
+		return false
+	}
+	if _, ok := config.Conf.Selector.Plugins[selectorName()]; ok {
+		return false
+	}
+	switch filepath.Base(fields[0]) {
+	case "fzf", "sk", "fzf-tmux":
+		return true
+	}
+	return false
+}
+
+func filter(options []string, tags snippet.TagFilter) (commands []string, err error) {
+	snippets, err := selectSnippets(options, tags)
+	if err != nil {
+		return nil, err
+	}
+	return expandCommands(snippets)
+}
+
+// selectSnippets runs the selector and returns the chosen snippets.
+// Archived snippets are only shown with --all. The keys of [Keybind] run
+// their action on the highlighted snippets instead, which returns none.
+func selectSnippets(options []string, tags snippet.TagFilter) (selected []snippet.SnippetInfo, err error) {
+	load := func() (snippet.Snippets, error) {
+		snippets, err := loadFiltered(tags, config.Flag.Path)
+		if err != nil {
+			return snippets, fmt.Errorf("Load snippet failed: %v", err)
+		}
+		return snippets, nil
+	}
+	actions := keyActions()
+	if len(actions) == 0 {
+		_, selected, err = selectWithKey(load, options, false)
+		return selected, err
+	}
+	key, selected, err := selectWithKey(load, append(options, keybindOptions(actions)...), true)
+	if err != nil || key == "" {
+		return selected, err
+	}
+	for _, a := range actions {
+		if a.key == key && len(selected) > 0 {
+			// the selection is used up by the action
+			return nil, a.run(selected)
 		}
-		snippets = filteredSnippets
 	}
+	return nil, nil
+}
+
+// favoriteMark prefixes the favorite snippets pinned at the top of the selector
+const favoriteMark = "*"
+
+// selectorLine returns the line of the snippet in the selector, colorized
+// for --color
+func selectorLine(s snippet.SnippetInfo, colorize bool) string {
+	command := strings.Replace(s.Command, "\n", "\\n", -1)
+	tags := ""
+	for _, tag := range s.Tag {
+		tags += fmt.Sprintf(" #%s", tag)
+	}
+	if !s.Supports(runtime.GOOS) {
+		// only listed with --all
+		tags += " @" + strings.Join(s.Platform, ",")
+	}
+	missing := s.Missing(exec.LookPath)
+	if len(missing) > 0 {
+		// only listed with --all
+		tags += " !" + strings.Join(missing, ",")
+	}
+
+	shell := ""
+	if s.Shell != "" {
+		// the language of the snippet
+		shell = fmt.Sprintf("(%s) ", s.Shell)
+	}
+
+	description, path, mark := s.Description, s.Path, favoriteMark
+	if colorize && len(missing) > 0 {
+		// dimmed since it cannot run here
+		description = color.New(color.Faint).Sprint(description)
+	} else if colorize {
+		description = colors.selectorDescription.Sprint(description)
+		path = colors.selectorPath.Sprint(path)
+		mark = colors.favorite.Sprint(mark)
+		tags = colors.selectorTag.Sprint(tags)
+		shell = colors.selectorShell.Sprint(shell)
+	}
+	t := fmt.Sprintf("[%s]: %s%s%s", description, shell, command, tags)
+	if s.Path != "" {
+		t = path + " " + t
+	}
+	if s.Favorite {
+		t = mark + " " + t
+	}
+	return t
+}
+
+// selectFrom runs the selector on the snippets and returns the chosen ones.
+// Favorites are listed first, and with frecency the snippets run most
+// often and most recently come next.
+func selectFrom(snippets snippet.Snippets, options []string) (selected []snippet.SnippetInfo, err error) {
+	load := func() (snippet.Snippets, error) { return snippets, nil }
+	_, selected, err = selectWithKey(load, options, false)
+	return selected, err
+}
 
+// selectWithKey is selectFrom for the snippets of load and the selector
+// options with --expect, whose first line of output is the key which
+// accepted the selection. The selector starts while the snippets are loaded
+// and shows their lines as they are written, so that large collections do
+// not delay it.
+func selectWithKey(load func() (snippet.Snippets, error), options []string, expect bool) (key string, selected []snippet.SnippetInfo, err error) {
 	snippetTexts := map[string]snippet.SnippetInfo{}
-	var text string
-	for _, s := range snippets.Snippets {
-		command := s.Command
-		if strings.ContainsAny(command, "\n") {
-			command = strings.Replace(command, "\n", "\\n", -1)
+	// a plugin with a delimiter may write the fields of the lines instead
+	ids := map[string]snippet.SnippetInfo{}
+	delimiter := ""
+	if p, ok := selectorPlugin(); ok {
+		delimiter = p.Delimiter
+	}
+	var loadErr error
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		snippets, err := load()
+		if err == nil && config.Conf.General.Frecency {
+			var usage snippet.UsageStats
+			if usage, err = snippet.LoadUsage(); err == nil {
+				snippets.Snippets = usage.ByFrecency(snippets.Snippets, time.Now())
+			}
 		}
-		t := fmt.Sprintf("[%s]: %s", s.Description, command)
+		if err != nil {
+			loadErr = err
+			pw.CloseWithError(err)
+			return
+		}
+		bw := bufio.NewWriter(pw)
+		for i, s := range snippets.Pinned().Snippets {
+			line, fields := selectorLine(s, false), ""
+			if delimiter != "" {
+				fields = pluginFields(s, i+1, delimiter)
+				ids[strconv.Itoa(i+1)] = s
+			}
+			snippetTexts[line+fields] = s
+			if _, err := bw.WriteString(selectorLine(s, config.Flag.Color) + fields + "\n"); err != nil {
+				// the selector exited
+				break
+			}
+		}
+		pw.CloseWithError(bw.Flush())
+	}()
 
-		tags := ""
-		for _, tag := range s.Tag {
-			tags += fmt.Sprintf(" #%s", tag)
+	options = append(options, previewOptions()...)
+	preview := func(line string) string {
+		if s, ok := snippetTexts[line]; ok {
+			return previewText(s)
 		}
-		t += tags
+		return ""
+	}
 
-		snippetTexts[t] = s
-		if config.Flag.Color {
-			t = fmt.Sprintf("[%s]: %s%s",
-				color.RedString(s.Description), command, color.BlueString(tags))
+	tags := func(line string) []string {
+		return snippetTexts[line].Tag
+	}
+	score := func(query, line string) (int, bool) {
+		s, ok := snippetTexts[line]
+		if !ok {
+			return dialog.FuzzyMatch(query, line)
 		}
-		text += t + "\n"
+		return s.Score(query, dialog.FuzzyMatch)
 	}
 
 	var buf bytes.Buffer
-	selectCmd := fmt.Sprintf("%s %s",
-		config.Conf.General.SelectCmd, strings.Join(options, " "))
-	err = run(selectCmd, strings.NewReader(text), &buf)
+	err = runSelector(options, pr, &buf, dialog.FindOptions{Preview: preview, Tags: tags, Score: score})
+	pr.Close()
+	<-done
+	if loadErr != nil {
+		return "", nil, loadErr
+	}
 	if err != nil {
-		return nil, nil
+		return "", nil, nil
 	}
 
 	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
-
-	params := dialog.SearchForParams(lines)
-	if params != nil {
-		snippetInfo := snippetTexts[lines[0]]
-		dialog.CurrentCommand = snippetInfo.Command
-		dialog.GenerateParamsLayout(params, dialog.CurrentCommand)
-		res := []string{dialog.FinalCommand}
-		return res, nil
+	if expect {
+		key, lines = lines[0], lines[1:]
 	}
 	for _, line := range lines {
-		snippetInfo := snippetTexts[line]
-		commands = append(commands, fmt.Sprint(snippetInfo.Command))
+		if snippetInfo, ok := snippetTexts[line]; ok {
+			selected = append(selected, snippetInfo)
+		} else if snippetInfo, ok := ids[pluginID(line, delimiter)]; ok {
+			selected = append(selected, snippetInfo)
+		}
+	}
+	return key, selected, nil
+}
+
+// pluginID returns the number of the snippet of a line written by a plugin,
+// which is the whole line or only its fields
+func pluginID(line, delimiter string) string {
+	fields := strings.Split(line, delimiter)
+	if _, err := strconv.Atoi(strings.TrimSpace(fields[0])); err == nil || len(fields) == 1 {
+		return strings.TrimSpace(fields[0])
+	}
+	return fields[1]
+}
+
+// previewOptions returns the selector options to preview the highlighted
+// snippet, if the selector is known to support it
+func previewOptions() []string {
+	p, ok := selectorPlugin()
+	if !fzfSelector() && (!ok || len(p.Preview) == 0) {
+		return nil
+	}
+	preview := previewCommand(p.Delimiter)
+	if preview == "" {
+		return nil
+	}
+	return []string{"--preview " + quoteArg(preview), "--preview-window down:wrap"}
+}
+
+// previewCommand returns the command printing the preview of the line {}
+func previewCommand(delimiter string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	preview := fmt.Sprintf("%s --config %s preview", quoteArg(exe), quoteArg(configFile))
+	if delimiter != "" {
+		preview += " --delimiter " + quoteArg(delimiter)
+	}
+	if config.Flag.Reveal {
+		preview += " --reveal"
+	}
+	return preview + " {}"
+}
+
+// redactor returns the Redactor of the secrets shown by pet list, the
+// previews and --dry-run, nil with --reveal
+func redactor() *snippet.Redactor {
+	if config.Flag.Reveal || config.Conf.Redact.Disable {
+		return nil
+	}
+	// the patterns of the config are checked when it is loaded
+	r, _ := snippet.NewRedactor(config.Conf.Redact.Patterns)
+	return r
+}
+
+// snippetByName returns the snippet with the name
+func snippetByName(name string) (snippet.SnippetInfo, error) {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return snippet.SnippetInfo{}, err
+	}
+	s, ok := snippets.FindByName(name)
+	if !ok {
+		return s, fmt.Errorf("Snippet named [%s] not found", name)
+	}
+	return s, nil
+}
+
+// paramValues returns the values given by --param NAME=VALUE
+func paramValues() (map[string]string, error) {
+	values := map[string]string{}
+	for _, p := range config.Flag.Params {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid parameter (NAME=VALUE): %s", p)
+		}
+		values[kv[0]] = kv[1]
+	}
+	return values, nil
+}
+
+// expandCommands returns the commands of the snippets after filling in their parameters
+func expandCommands(snippets []snippet.SnippetInfo) (commands []string, err error) {
+	executions, err := expandSnippets(snippets)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range executions {
+		commands = append(commands, e.Command)
 	}
 	return commands, nil
 }
+
+// expandSnippets asks for the parameter values of every snippet that has
+// parameters. When parameters are given with --param or there is no
+// terminal, the defaults are used for the missing ones instead of asking.
+// Values not in the list of values of a parameter are rejected. The template
+// functions are evaluated first.
+func expandSnippets(snippets []snippet.SnippetInfo) (executions []snippet.Execution, err error) {
+	values, err := paramValues()
+	if err != nil {
+		return nil, err
+	}
+	return expandSnippetsWith(snippets, values, len(config.Flag.Params) > 0 || !terminal.IsTerminal(0))
+}
+
+// expandSnippetsWith returns the executions of the snippets with the values
+// of their parameters, the other ones asked in the dialog unless noDialog
+func expandSnippetsWith(snippets []snippet.SnippetInfo, values map[string]string, noDialog bool) (executions []snippet.Execution, err error) {
+	dialog.Provide = provide
+	if config.Conf.General.ParamPicker {
+		dialog.Pick = pickParam
+	}
+	var history snippet.ParamHistory
+	dialog.History = func(name string) []string {
+		if history == nil {
+			var herr error
+			if history, herr = snippet.LoadParamHistory(); herr != nil && config.Flag.Debug {
+				fmt.Fprintf(os.Stderr, "Failed to load the parameter history: %v\n", herr)
+			}
+		}
+		return history[name]
+	}
+
+	all := &snippet.Snippets{}
+	if err := all.Load(); err != nil {
+		return nil, err
+	}
+	lookup := all.FindByName
+
+	for _, s := range snippets {
+		// --param overrides the variables
+		vars := all.Variables(s)
+		for name := range values {
+			delete(vars, name)
+		}
+		command, err := s.ScriptedCommand()
+		if err != nil {
+			return nil, err
+		}
+		// the arguments of pet exec are added after the parameters are
+		// filled in, they are none of them
+		appendArgs := !snippet.UsesArgs(command)
+		if command, err = snippet.Render(command, lookup); err != nil {
+			return nil, err
+		}
+		var attachmentDir string
+		if len(s.Attachments) > 0 {
+			if attachmentDir, err = snippet.NewAttachmentDir(); err != nil {
+				return nil, err
+			}
+		}
+		command = dialog.FillParams(command, s.AttachmentPaths(attachmentDir))
+		command = dialog.FillParams(command, captured)
+		command = dialog.FillParams(command, vars)
+		dir, err := snippet.ExpandDir(s.Dir)
+		if err != nil {
+			return nil, err
+		}
+		e := snippet.Execution{
+			Name:          s.Name,
+			Description:   s.Description,
+			Command:       command,
+			Dir:           dir,
+			Time:          time.Now(),
+			AttachmentDir: attachmentDir,
+		}
+		// the secrets of the stores are not asked, and redacted with the command
+		secrets, err := storedValues(command, values)
+		if err != nil {
+			return nil, err
+		}
+		filled := dialog.FillParams(command, secrets)
+		if noDialog {
+			if err := dialog.ValidateParams(filled, values); err != nil {
+				return nil, err
+			}
+			provided, err := providedValues(filled, values)
+			if err != nil {
+				return nil, err
+			}
+			e.Command = dialog.ExpandParams(filled, provided)
+			e.Params = provided
+		} else if params := dialog.SearchForParams([]string{filled}); params != nil {
+			dialog.CurrentCommand = filled
+			dialog.GenerateParamsLayout(params, dialog.CurrentCommand)
+			e.Command = dialog.FinalCommand
+			e.Params = dialog.FilledParams
+		} else {
+			e.Command = filled
+		}
+		if len(s.Env) > 0 {
+			e.Env = map[string]string{}
+			for name, value := range s.Env {
+				if value, err = snippet.Render(value, lookup); err != nil {
+					return nil, err
+				}
+				value = dialog.FillParams(value, captured)
+				value = dialog.FillParams(value, vars)
+				value = dialog.FillParams(value, values)
+				value = dialog.FillParams(value, secrets)
+				e.Env[name] = dialog.ExpandParams(value, e.Params)
+			}
+		}
+		if dialog.HasSecrets(command) {
+			e.Redacted, e.Params = dialog.Redact(command, e.Params)
+		}
+		if appendArgs {
+			e.Command = snippet.WithArgs(e.Command, snippet.Args)
+			if e.Redacted != "" {
+				e.Redacted = snippet.WithArgs(e.Redacted, snippet.Args)
+			}
+		}
+		executions = append(executions, e)
+	}
+	return executions, nil
+}
+
+// pickParam runs the selector on the choices of a parameter and returns the
+// chosen one, "" if the selector was canceled
+func pickParam(name string, choices []string) (string, error) {
+	var buf bytes.Buffer
+	find := dialog.FindOptions{Header: "<" + name + ">"}
+	if err := runSelector(nil, strings.NewReader(strings.Join(choices, "\n")+"\n"), &buf, find); err != nil {
+		return "", nil
+	}
+	return strings.SplitN(strings.TrimSuffix(buf.String(), "\n"), "\n", 2)[0], nil
+}
+
+// captured are the outputs of the snippets run with capture in this session,
+// by the name of the parameter they fill in
+var captured = map[string]string{}
+
+// registerContexts adds the context variables of the config, whose values
+// are the outputs of their commands
+func registerContexts(commands map[string]string) error {
+	for name, command := range commands {
+		command := command
+		err := snippet.RegisterContext(name, func() (string, error) {
+			var buf bytes.Buffer
+			if err := run(command, strings.NewReader(""), &buf); err != nil {
+				return "", fmt.Errorf("Failed to run %s: %v", command, err)
+			}
+			return strings.TrimSpace(buf.String()), nil
+		})
+		if err != nil {
+			return fmt.Errorf("Invalid [context]: %v", err)
+		}
+	}
+	return nil
+}
+
+// provide runs the provider command of a parameter and returns the lines of
+// its output
+func provide(command string) ([]string, error) {
+	var buf bytes.Buffer
+	if err := run(command, strings.NewReader(""), &buf); err != nil {
+		return nil, fmt.Errorf("Failed to run %s: %v", command, err)
+	}
+	var lines []string
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
+
+// providedValues returns the values with the first output line of the
+// provider of every parameter without a value
+func providedValues(command string, values map[string]string) (map[string]string, error) {
+	provided := map[string]string{}
+	for k, v := range values {
+		provided[k] = v
+	}
+	for _, p := range dialog.ParseParams(command) {
+		if _, ok := provided[p.Name]; ok || p.Provider == "" {
+			continue
+		}
+		lines, err := provide(p.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) > 0 {
+			provided[p.Name] = lines[0]
+		}
+	}
+	return provided, nil
+}
+
+// storedValues reads the parameters of the command which are in a secret
+// store, except those given with --param
+func storedValues(command string, values map[string]string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, p := range dialog.ParseParams(command) {
+		if _, ok := values[p.Name]; ok || p.Store == "" {
+			continue
+		}
+		v, err := snippet.ReadSecret(p.Store, p.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to fill in <%s>: %v", p.Name, err)
+		}
+		secrets[p.Name] = v
+	}
+	return secrets, nil
+}
+
+// indent prefixes the continuation lines of s
+func indent(s, prefix string) string {
+	return strings.Replace(s, "\n", "\n"+prefix, -1)
+}
+
+// snippetFilePath resolves --file. A bare file name is looked up in
+// snippetdir, an empty one is the snippet file and .pet.toml is the nearest
+// project file (created in the working directory if there is none).
+func snippetFilePath(name string) (string, error) {
+	switch name {
+	case "":
+		return config.Conf.General.SnippetFile, nil
+	case snippet.ProjectFileName:
+		if project := snippet.ProjectFile(); project != "" {
+			return project, nil
+		}
+		return filepath.Abs(name)
+	}
+	if filepath.IsAbs(name) || strings.ContainsRune(name, filepath.Separator) {
+		return filepath.Abs(name)
+	}
+	dir := config.Conf.General.SnippetDir
+	if dir == "" {
+		return "", fmt.Errorf("snippetdir is not set, cannot find %s", name)
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [-- COMMAND [ARG...]]",
+	Short: "Report the changes of the snippet files",
+	Long: `Watch the snippet file, snippetdir and the project file, and print a line
+each time they change on disk, e.g. edited in another terminal or by a sync.
+With a command, run it after each change instead, e.g. to reload the list of
+a running fzf started with --listen 6266:
+
+  pet watch -- curl -s -XPOST localhost:6266 -d 'reload(pet list --oneline)'
+
+pet serve and pet daemon watch the snippet files themselves.`,
+	RunE: watch,
+}
+
+func watch(cmd *cobra.Command, args []string) error {
+	w, err := snippet.NewWatcher()
+	if err != nil {
+		return err
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		w.Close()
+	}()
+
+	return w.Run(func() {
+		if len(args) == 0 {
+			fmt.Fprintf(color.Output, "%s snippets changed\n", color.YellowString(time.Now().Format("15:04:05")))
+			return
+		}
+		c := exec.Command(args[0], args[1:]...)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		}
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+}
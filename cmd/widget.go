@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/spf13/cobra"
+)
+
+// widgetCmd represents the widget command
+var widgetCmd = &cobra.Command{
+	Use:   "widget",
+	Short: "Generate a shell widget inserting snippets into the command line",
+	Long: `Print a widget which runs pet search and puts the selected command, with
+its parameters filled in, on the command line of the shell to be reviewed
+and run from there (and so kept in the shell history). The query is the
+current line. Add to your .zshrc, for example:
+
+  eval "$(pet widget --shell zsh)"
+
+The keys are Ctrl-S in zsh, fish, nushell and xonsh and Ctrl-X Ctrl-R in
+bash, or --key in the notation of the shell (e.g. '^g' for zsh, '\C-g' for
+bash, \cg for fish, 'control char_g' for nushell, c-g for xonsh). See also
+pet init.`,
+	Args: cobra.NoArgs,
+	RunE: widget,
+}
+
+// widgetShells are the shells pet has widgets for
+var widgetShells = []string{"bash", "zsh", "fish", "nu", "xonsh"}
+
+// widgetKeys are the default keys of the widgets
+var widgetKeys = map[string]string{
+	"bash":  `\C-x\C-r`,
+	"zsh":   "^s",
+	"fish":  `\cs`,
+	"nu":    "control char_s",
+	"xonsh": "c-s",
+}
+
+func widget(cmd *cobra.Command, args []string) error {
+	script, err := widgetScript(config.Flag.WidgetShell, config.Flag.Key)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// widgetScript returns the widget of the shell ($SHELL if it is empty) bound
+// to the key, or to the default key of the shell
+func widgetScript(shell, key string) (string, error) {
+	shell = widgetShell(shell)
+	if key == "" {
+		key = widgetKeys[shell]
+	}
+	switch shell {
+	case "bash":
+		return bashWidget(key), nil
+	case "zsh":
+		return zshWidget(key), nil
+	case "fish":
+		return fishWidget(key), nil
+	case "nu":
+		return nuWidget(key), nil
+	case "xonsh":
+		return xonshWidget(key), nil
+	}
+	return "", fmt.Errorf("Unsupported shell: %s (bash, zsh, fish, nu or xonsh)", shell)
+}
+
+// widgetShell returns the name of the shell, $SHELL if it is empty
+func widgetShell(shell string) string {
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	if shell == "nushell" {
+		shell = "nu"
+	}
+	return shell
+}
+
+func bashWidget(key string) string {
+	return `pet-select() {
+  local out
+  out=$(pet search --print-buffer --query "$READLINE_LINE") || return
+  READLINE_LINE=$out
+  READLINE_POINT=${#READLINE_LINE}
+}
+bind -x '"` + strings.ReplaceAll(key, "'", "") + `": pet-select'
+`
+}
+
+func zshWidget(key string) string {
+	return `pet-select() {
+  local out
+  if out=$(pet search --print-buffer --query "$BUFFER" </dev/tty); then
+    BUFFER=$out
+    CURSOR=$#BUFFER
+  fi
+  zle reset-prompt
+}
+zle -N pet-select
+# Ctrl-S stops the output of the terminal by default
+[[ -t 0 ]] && stty -ixon 2>/dev/null
+bindkey '` + strings.ReplaceAll(key, "'", "") + `' pet-select
+`
+}
+
+func fishWidget(key string) string {
+	return `function pet-select
+    set -l out (pet search --print-buffer --query (commandline) | string collect)
+    and commandline -r -- $out
+    commandline -f repaint
+end
+bind ` + key + ` pet-select
+bind -M insert ` + key + ` pet-select 2>/dev/null
+`
+}
+
+// nuWidget appends a keybinding to the config of nushell, the key being the
+// modifier and the keycode (e.g. control char_s)
+func nuWidget(key string) string {
+	modifier, keycode, ok := strings.Cut(strings.TrimSpace(key), " ")
+	if !ok {
+		modifier, keycode = "none", modifier
+	}
+	return `$env.config.keybindings = ($env.config.keybindings | append {
+    name: pet_select
+    modifier: ` + modifier + `
+    keycode: ` + strings.TrimSpace(keycode) + `
+    mode: [emacs vi_normal vi_insert]
+    event: {
+        send: executehostcommand
+        cmd: "let out = (pet search --print-buffer --query (commandline) | complete); if $out.exit_code == 0 { commandline edit --replace $out.stdout }"
+    }
+})
+`
+}
+
+// xonshWidget binds the keys of prompt_toolkit (e.g. c-s, or c-x c-r) in
+// xonsh
+func xonshWidget(key string) string {
+	var keys []string
+	for _, k := range strings.Fields(key) {
+		keys = append(keys, "'"+strings.ReplaceAll(k, "'", "")+"'")
+	}
+	return `@events.on_ptk_create
+def _pet_bindings(prompter, history, completer, bindings, **kw):
+    from prompt_toolkit.application import run_in_terminal
+
+    @bindings.add(` + strings.Join(keys, ", ") + `)
+    def _pet_select(event):
+        import subprocess
+        buf = event.current_buffer
+
+        def select():
+            out = subprocess.run(['pet', 'search', '--print-buffer', '--query', buf.text],
+                                 stdout=subprocess.PIPE, text=True)
+            if out.returncode == 0:
+                buf.text = out.stdout
+                buf.cursor_position = len(buf.text)
+
+        run_in_terminal(select)
+`
+}
+
+func init() {
+	RootCmd.AddCommand(widgetCmd)
+	widgetCmd.Flags().StringVarP(&config.Flag.WidgetShell, "shell", "s", "",
+		`Shell to generate the widget for (bash, zsh, fish, nu or xonsh, default: $SHELL)`)
+	widgetCmd.Flags().StringVarP(&config.Flag.Key, "key", "", "",
+		`Key of the widget in the notation of the shell`)
+	widgetCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(
+		widgetShells, cobra.ShellCompDirectiveNoFileComp))
+}
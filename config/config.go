@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
@@ -16,20 +17,83 @@ var Conf Config
 
 // Config is a struct of config
 type Config struct {
-	General GeneralConfig `toml:"General"`
-	Gist    GistConfig    `toml:"Gist"`
-	GitLab  GitLabConfig  `toml:"GitLab"`
+	// Version is the layout of the file, see ConfigVersion
+	Version  int            `toml:"version"`
+	General  GeneralConfig  `toml:"General"`
+	Gist     GistConfig     `toml:"Gist"`
+	GitLab   GitLabConfig   `toml:"GitLab"`
+	Keybind  KeybindConfig  `toml:"Keybind"`
+	Theme    ThemeConfig    `toml:"theme"`
+	Search   SearchConfig   `toml:"Search"`
+	Selector SelectorConfig `toml:"Selector"`
+	Hooks    HooksConfig    `toml:"Hooks"`
+	Audit    AuditConfig    `toml:"Audit"`
+	Notify   NotifyConfig   `toml:"Notify"`
+	Backup   BackupConfig   `toml:"Backup"`
+	// Encryption encrypts the snippet files on disk with age
+	Encryption EncryptionConfig `toml:"Encryption"`
+	// Redact hides the secrets of the snippets in their listings
+	Redact RedactConfig `toml:"Redact"`
+	// Variables are substituted for the parameters of the same name in all
+	// snippets
+	Variables map[string]string `toml:"variables,omitempty"`
+	// Context are the context variables of commands besides the built-in
+	// ones, e.g. {{gcloud_project}}, with the commands printing their value
+	Context map[string]string `toml:"context,omitempty"`
+	// Hosts are the machines of pet exec --host, besides those of
+	// ~/.ssh/config
+	Hosts map[string]HostConfig `toml:"host,omitempty"`
+	// Plugins are the sync backends run as external commands, see
+	// PluginConfig
+	Plugins map[string]PluginConfig `toml:"plugin,omitempty"`
+	// Remotes are named sync backends besides [Gist] and [GitLab]
+	Remotes []RemoteConfig `toml:"remote,omitempty"`
+	// Profiles are named configs (e.g. [profile.work.General]) whose keys
+	// override the others with --profile or $PET_PROFILE
+	Profiles map[string]toml.Primitive `toml:"profile,omitempty"`
+	// Warnings are the problems of the file which do not stop pet
+	Warnings []Problem `toml:"-"`
 }
 
 // GeneralConfig is a struct of general config
 type GeneralConfig struct {
 	SnippetFile string   `toml:"snippetfile"`
+	SnippetDir  string   `toml:"snippetdir"`
 	Editor      string   `toml:"editor"`
 	Column      int      `toml:"column"`
 	SelectCmd   string   `toml:"selectcmd"`
 	Backend     string   `toml:"backend"`
 	SortBy      string   `toml:"sortby"`
 	Cmd         []string `toml:"cmd"`
+	// Shell is the shell of the snippets without one, e.g. bash or fish
+	Shell string `toml:"shell,omitempty"`
+	// SudoCmd runs the snippets with sudo, sudo or doas if empty
+	SudoCmd   []string `toml:"sudo_cmd,omitempty"`
+	Clipboard string   `toml:"clipboard"`
+	TrashDays int      `toml:"trash_days"`
+	Frecency  bool     `toml:"frecency"`
+	// Shellcheck checks the commands of pet new and pet exec with
+	// shellcheck, like --check
+	Shellcheck bool `toml:"shellcheck,omitempty"`
+	// ParamPicker picks the values of the parameters with choices (a|b|c
+	// or a provider) with selectcmd before the parameter dialog
+	ParamPicker bool `toml:"param_picker,omitempty"`
+	// Index keeps the decoded snippet files in index.json in the data
+	// directory, so that only the changed files are parsed
+	Index bool `toml:"index,omitempty"`
+	// SyncExcludeTags are the tags of the snippets which are never
+	// uploaded, e.g. private
+	SyncExcludeTags []string `toml:"sync_exclude_tags,omitempty"`
+	// SyncInterval is the time between the syncs scheduled with pet sync
+	// --install-schedule, 1h if empty
+	SyncInterval string `toml:"sync_interval,omitempty"`
+	// SyncConflict is what pet sync does when both sides changed: newer
+	// (the default) keeps the newer side, markers writes the conflicting
+	// snippets to the snippet file between conflict markers
+	SyncConflict string `toml:"sync_conflict,omitempty"`
+	// Language is the language of the messages (en or ja), the one of the
+	// locale if empty
+	Language string `toml:"language,omitempty"`
 }
 
 // GistConfig is a struct of config for Gist
@@ -52,30 +116,360 @@ type GitLabConfig struct {
 	Insecure    bool   `toml:"skip_ssl"`
 }
 
+// PluginConfig is a struct of a sync backend run as an external command,
+// pet-sync-NAME, with backend = "NAME" ([plugin.NAME])
+type PluginConfig struct {
+	// Command runs the plugin, pet-sync-NAME if empty
+	Command []string `toml:"command,omitempty"`
+	// FileName is the name of the snippet file in the backend
+	FileName string `toml:"file_name,omitempty"`
+	// Options are passed to the plugin as they are
+	Options map[string]string `toml:"options,omitempty"`
+}
+
+// RemoteConfig is a struct of a named sync backend ([[remote]]), such as a
+// second GitLab instance. It has the keys of [Gist] or [GitLab] for its
+// backend.
+type RemoteConfig struct {
+	Name        string `toml:"name"`
+	Backend     string `toml:"backend"`
+	FileName    string `toml:"file_name"`
+	AccessToken string `toml:"access_token"`
+	GistID      string `toml:"gist_id"`
+	Public      bool   `toml:"public"`
+	Url         string `toml:"url"`
+	ID          string `toml:"id"`
+	Visibility  string `toml:"visibility"`
+	Insecure    bool   `toml:"skip_ssl"`
+}
+
+// HostConfig is a struct of a machine the snippets run on over ssh
+// ([host.NAME])
+type HostConfig struct {
+	// Destination is [user@]hostname, the name of the host if empty
+	Destination  string `toml:"destination,omitempty"`
+	Port         int    `toml:"port,omitempty"`
+	IdentityFile string `toml:"identity_file,omitempty"`
+	// Args are more options of ssh, e.g. ["-J", "bastion"]
+	Args []string `toml:"args,omitempty"`
+	// SudoCmd runs the snippets with sudo = true on the host, sudo if empty
+	SudoCmd []string `toml:"sudo_cmd,omitempty"`
+}
+
+// KeybindConfig is a struct of the selector keys (fzf names such as ctrl-y)
+// which act on the highlighted snippets. An empty key disables the action.
+type KeybindConfig struct {
+	Copy   string `toml:"copy"`
+	Edit   string `toml:"edit"`
+	Delete string `toml:"delete"`
+}
+
+// ThemeConfig is a struct of the colors of the output, the selector and the
+// prompts. Empty colors are those of the preset (default, light, mono or
+// none). Colors are words such as "green", "hi-blue", "bold red" or "none".
+type ThemeConfig struct {
+	Preset      string `toml:"preset"`
+	Description string `toml:"description,omitempty"`
+	Command     string `toml:"command,omitempty"`
+	Tag         string `toml:"tag,omitempty"`
+	Output      string `toml:"output,omitempty"`
+	Meta        string `toml:"meta,omitempty"`
+	Info        string `toml:"info,omitempty"`
+	Warning     string `toml:"warning,omitempty"`
+	// colors of the selector lines (with --color) and of the highlighted line
+	SelectorDescription string `toml:"selector_description,omitempty"`
+	SelectorPath        string `toml:"selector_path,omitempty"`
+	SelectorTag         string `toml:"selector_tag,omitempty"`
+	SelectorShell       string `toml:"selector_shell,omitempty"`
+	Favorite            string `toml:"favorite,omitempty"`
+	Selected            string `toml:"selected,omitempty"`
+	// Highlight is the chroma style of the commands, "none" to disable it
+	Highlight string `toml:"highlight,omitempty"`
+}
+
+// SearchConfig is a struct of the weights of a match in each field when
+// searching snippets. A zero weight leaves the field out.
+type SearchConfig struct {
+	DescriptionWeight int `toml:"description_weight"`
+	TagWeight         int `toml:"tag_weight"`
+	CommandWeight     int `toml:"command_weight"`
+}
+
+// HooksConfig is a struct of the commands run around the snippets of pet
+// exec, with the snippet in $PET_DESCRIPTION, $PET_COMMAND... (see README)
+type HooksConfig struct {
+	PreExec  string `toml:"pre_exec,omitempty"`
+	PostExec string `toml:"post_exec,omitempty"`
+	// OnCreate, OnEdit and OnDelete are run after snippets are saved, with
+	// the snippets of the event
+	OnCreate string `toml:"on_create,omitempty"`
+	OnEdit   string `toml:"on_edit,omitempty"`
+	OnDelete string `toml:"on_delete,omitempty"`
+	// OnSync is run after a sync changed the local or the remote snippets,
+	// OnConflict before a sync overwrites the changes of the other side
+	OnSync     string `toml:"on_sync,omitempty"`
+	OnConflict string `toml:"on_conflict,omitempty"`
+}
+
+// AuditConfig is a struct of the log of the executions (JSON lines)
+type AuditConfig struct {
+	Enabled bool `toml:"enabled"`
+	// File is audit.jsonl in the data directory if empty
+	File      string `toml:"file,omitempty"`
+	MaxSizeMB int    `toml:"max_size_mb,omitempty"`
+	MaxFiles  int    `toml:"max_files,omitempty"`
+}
+
+// BackupConfig is a struct of the archives of pet backup
+type BackupConfig struct {
+	// Dir is where the archives are written, backups in the data directory
+	// if empty
+	Dir string `toml:"dir,omitempty"`
+	// Keep is the number of the newest archives kept, all if 0
+	Keep int `toml:"keep,omitempty"`
+	// KeepDays is how many days the archives are kept, forever if 0
+	KeepDays int `toml:"keep_days,omitempty"`
+}
+
+// NotifyConfig is a struct of the desktop notifications of long-running
+// snippets
+type NotifyConfig struct {
+	// After notifies when the commands ran longer than this duration (e.g.
+	// "1m"), never if empty
+	After string `toml:"after,omitempty"`
+	// Command is run with the title and the message instead of the
+	// notifier of the system
+	Command []string `toml:"command,omitempty"`
+}
+
+// EncryptionConfig is a struct of the encryption of the snippet files with
+// age, with an identity or a passphrase
+type EncryptionConfig struct {
+	// Identity is an identity file of age-keygen, which decrypts the files;
+	// its recipients encrypt them
+	Identity string `toml:"identity,omitempty"`
+	// Passphrase encrypts the files with a passphrase, $PET_PASSPHRASE or
+	// asked once per run
+	Passphrase bool `toml:"passphrase,omitempty"`
+}
+
+// RedactConfig is a struct of the secrets hidden by pet list, the previews
+// and --dry-run, unless --reveal is given
+type RedactConfig struct {
+	// Disable shows the secrets, like --reveal
+	Disable bool `toml:"disable,omitempty"`
+	// Patterns are regular expressions of more secrets; only the group
+	// named secret is hidden if they have one, e.g.
+	// 'corp_key=(?P<secret>\S+)'
+	Patterns []string `toml:"patterns,omitempty"`
+}
+
+// SelectorConfig is a struct of the arguments and environment of selectcmd.
+// The arguments are passed as they are, each quoted by pet.
+type SelectorConfig struct {
+	Args []string          `toml:"args"`
+	Env  map[string]string `toml:"env"`
+	// Commands override selectcmd and add arguments for the commands
+	// (e.g. "exec", "search" or "trash restore")
+	Commands map[string]SelectorCommandConfig `toml:"command"`
+	// TmuxPopup runs selectcmd in a tmux popup of this size (e.g. "80%" or
+	// "100,30") when pet runs in tmux
+	TmuxPopup string `toml:"tmux_popup,omitempty"`
+	// Plugins describe selectors which are not compatible with fzf, by the
+	// name of their command (e.g. "rofi"), and override the ones pet knows
+	Plugins map[string]SelectorPlugin `toml:"plugin,omitempty"`
+}
+
+// SelectorPlugin is how pet talks to a selector: the options it passes for
+// the features of pet and the lines it writes
+type SelectorPlugin struct {
+	// Args are always passed to the selector
+	Args []string `toml:"args,omitempty"`
+	// Multi are the options to choose several lines (e.g. "-multi-select")
+	Multi []string `toml:"multi,omitempty"`
+	// Query are the options of the initial query, where {q} is the query
+	Query []string `toml:"query,omitempty"`
+	// Preview are the options of the preview, where {cmd} is the command
+	// printing the preview of the line {}
+	Preview []string `toml:"preview,omitempty"`
+	// Delimiter follows the text of each line, and separates the fields of
+	// its snippet: its number, name, tags, path and shell
+	Delimiter string `toml:"delimiter,omitempty"`
+}
+
+// SelectorCommandConfig is a struct of the selector of a command
+type SelectorCommandConfig struct {
+	SelectCmd string            `toml:"selectcmd"`
+	Args      []string          `toml:"args"`
+	Env       map[string]string `toml:"env"`
+}
+
+// DefaultSearch returns the weights used when the config does not set them
+func DefaultSearch() SearchConfig {
+	return SearchConfig{
+		DescriptionWeight: 3,
+		TagWeight:         2,
+		CommandWeight:     1,
+	}
+}
+
+// DefaultKeybind returns the keys used when the config does not set them
+func DefaultKeybind() KeybindConfig {
+	return KeybindConfig{
+		Copy:   "ctrl-y",
+		Edit:   "ctrl-e",
+		Delete: "ctrl-d",
+	}
+}
+
 // Flag is global flag variable
 var Flag FlagConfig
 
 // FlagConfig is a struct of flag
 type FlagConfig struct {
-	Debug     bool
-	Query     string
-	FilterTag string
-	Command   bool
-	Delimiter string
-	OneLine   bool
-	Color     bool
-	Tag       bool
+	Debug      bool
+	Query      string
+	FilterTags []string
+	AnyTag     bool
+	Command    bool
+	Delimiter  string
+	OneLine    bool
+	Color      bool
+	Tag        bool
+	Makefile   string
+	Justfile   string
+	Recipe     bool
+	Limit      int
+
+	Patterns         []string
+	IgnoreCase       bool
+	FilesWithMatches bool
+	FieldDelimiter   string
+	Socket           bool
+	Reveal           bool
+	Title            string
+	SnippetsLab      string
+	Dash             string
+	Atuin            string
+	Warp             string
+	ITerm2           string
+	InstallSchedule  bool
+	RemoveSchedule   bool
+	QR               string
+	Count            bool
+	JSON             bool
+	Format           string
+	Fields           []string
+	Raw              bool
+	Select           bool
+	DryRun           bool
+	Quote            bool
+	Yes              bool
+	Interactive      bool
+	History          int
+	Addr             string
+	Token            string
+	AllowExec        bool
+	Offline          bool
+	Man              bool
+	Markdown         bool
+	Dir              string
+	Filter           string
+	Params           []string
+	Name             string
+	Last             bool
+	Reprompt         bool
+	KeepGoing        bool
+	Cwd              string
+	Timeout          string
+	Sudo             bool
+	PrintBuffer      bool
+	Key              string
+	WidgetShell      string
+	Tmux             string
+	Host             string
+	Check            bool
+	Pager            bool
+	Tee              string
+	SaveOutput       bool
+	LastOutput       bool
+	CopyOutput       bool
+	Notify           bool
+	From             int
+	NoEnter          bool
+	All              bool
+	UnusedFor        string
+	Expired          bool
+	Remove           bool
+	Archive          bool
+	Delete           bool
+	Strict           bool
+	List             bool
+	Shell            string
+	URL              string
+	Prefer           string
+	By               string
+	Group            bool
+	Path             string
+	File             string
+	Sort             string
+	Since            string
+	Revision         int
+	Profile          string
+	StoreToken       bool
+	Migrate          bool
+	Remotes          []string
+	GroupBy          string
+	Tree             bool
+	WithConfig       bool
 }
 
 // Load loads a config toml
 func (cfg *Config) Load(file string) error {
+	if err := cfg.load(file); err != nil {
+		return err
+	}
+	if env := os.Getenv("PET_SNIPPET_FILE"); env != "" {
+		cfg.General.SnippetFile = expandPath(env)
+	}
+	return nil
+}
+
+func (cfg *Config) load(file string) error {
+	cfg.Keybind = DefaultKeybind()
+	cfg.Search = DefaultSearch()
 	_, err := os.Stat(file)
 	if err == nil {
-		_, err := toml.DecodeFile(file, cfg)
+		md, err := decodeFile(file, cfg)
 		if err != nil {
 			return err
 		}
+		if name := ProfileName(); name != "" {
+			profile, ok := cfg.Profiles[name]
+			if !ok {
+				return fmt.Errorf("unknown profile: %s", name)
+			}
+			if err := md.PrimitiveDecode(profile, cfg); err != nil {
+				return fmt.Errorf("Invalid profile %s: %v", name, err)
+			}
+		}
+		var errs []string
+		for _, p := range cfg.validate(file, md) {
+			if p.Warning {
+				cfg.Warnings = append(cfg.Warnings, p)
+			} else {
+				errs = append(errs, p.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("Invalid config:\n  %s", strings.Join(errs, "\n  "))
+		}
 		cfg.General.SnippetFile = expandPath(cfg.General.SnippetFile)
+		cfg.General.SnippetDir = expandPath(cfg.General.SnippetDir)
+		cfg.Audit.File = expandPath(cfg.Audit.File)
+		cfg.Backup.Dir = expandPath(cfg.Backup.Dir)
+		cfg.Encryption.Identity = expandPath(cfg.Encryption.Identity)
+		cfg.General.Shell = expandPath(cfg.General.Shell)
 		return nil
 	}
 
@@ -87,15 +481,16 @@ func (cfg *Config) Load(file string) error {
 		return err
 	}
 
-	dir, err := GetDefaultConfigDir()
+	dir, err := GetDefaultDataDir()
 	if err != nil {
-		return errors.Wrap(err, "Failed to get the default config directory")
+		return errors.Wrap(err, "Failed to get the default data directory")
 	}
 	cfg.General.SnippetFile = filepath.Join(dir, "snippet.toml")
-	_, err = os.Create(cfg.General.SnippetFile)
+	sf, err := os.OpenFile(cfg.General.SnippetFile, os.O_CREATE|os.O_WRONLY, 0o600)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create a config file")
 	}
+	sf.Close()
 
 	cfg.General.Editor = os.Getenv("EDITOR")
 	if cfg.General.Editor == "" && runtime.GOOS != "windows" {
@@ -108,16 +503,92 @@ func (cfg *Config) Load(file string) error {
 	cfg.General.Column = 40
 	cfg.General.SelectCmd = "fzf"
 	cfg.General.Backend = "gist"
+	cfg.General.Clipboard = "auto"
+	cfg.General.TrashDays = 30
+	cfg.Theme.Preset = "default"
+	cfg.Version = ConfigVersion
 
 	cfg.Gist.FileName = "pet-snippet.toml"
 
 	cfg.GitLab.FileName = "pet-snippet.toml"
 	cfg.GitLab.Visibility = "private"
 
-	return toml.NewEncoder(f).Encode(cfg)
+	if err := toml.NewEncoder(f).Encode(cfg); err != nil {
+		return err
+	}
+	if name := ProfileName(); name != "" {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	return nil
+}
+
+// remoteName is the remote in use, see UseRemote
+var remoteName string
+
+// UseRemote makes the named remote the sync backend of the config
+func (cfg *Config) UseRemote(name string) error {
+	for _, r := range cfg.Remotes {
+		if r.Name != name {
+			continue
+		}
+		if _, ok := cfg.Plugins[r.Backend]; ok {
+			cfg.General.Backend = r.Backend
+			remoteName = name
+			return nil
+		}
+		fileName := r.FileName
+		if fileName == "" {
+			fileName = "pet-snippet.toml"
+		}
+		if r.Backend == "gitlab" {
+			visibility := r.Visibility
+			if visibility == "" {
+				visibility = "private"
+			}
+			cfg.General.Backend = "gitlab"
+			cfg.GitLab = GitLabConfig{
+				FileName:    fileName,
+				AccessToken: r.AccessToken,
+				Url:         r.Url,
+				ID:          r.ID,
+				Visibility:  visibility,
+				Insecure:    r.Insecure,
+			}
+		} else {
+			cfg.General.Backend = "gist"
+			cfg.Gist = GistConfig{
+				FileName:    fileName,
+				AccessToken: r.AccessToken,
+				GistID:      r.GistID,
+				Public:      r.Public,
+			}
+		}
+		remoteName = name
+		return nil
+	}
+	return fmt.Errorf("unknown remote: %s", name)
+}
+
+// ProfileName returns the profile in use: --profile, $PET_PROFILE or none
+func ProfileName() string {
+	if Flag.Profile != "" {
+		return Flag.Profile
+	}
+	return os.Getenv("PET_PROFILE")
+}
+
+// legacyConfigDir is the config directory of older versions, which did not
+// follow $XDG_CONFIG_HOME and kept every file there. Windows has none, the
+// config directory has always been %APPDATA%\pet.
+func legacyConfigDir() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "pet")
 }
 
-// GetDefaultConfigDir returns the default config directory
+// GetDefaultConfigDir returns the default config directory: $PET_CONFIG_DIR,
+// $XDG_CONFIG_HOME/pet or ~/.config/pet
 func GetDefaultConfigDir() (dir string, err error) {
 	if env, ok := os.LookupEnv("PET_CONFIG_DIR"); ok {
 		dir = env
@@ -127,8 +598,10 @@ func GetDefaultConfigDir() (dir string, err error) {
 			dir = filepath.Join(os.Getenv("USERPROFILE"), "Application Data", "pet")
 		}
 		dir = filepath.Join(dir, "pet")
+	} else if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dir = filepath.Join(xdg, "pet")
 	} else {
-		dir = filepath.Join(os.Getenv("HOME"), ".config", "pet")
+		dir = legacyConfigDir()
 	}
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return "", fmt.Errorf("cannot create directory: %v", err)
@@ -136,6 +609,120 @@ func GetDefaultConfigDir() (dir string, err error) {
 	return dir, nil
 }
 
+// GetDefaultDataDir returns the directory of the snippet file of a new config
+// and of the files pet keeps beside it (usage, trash, versions...):
+// $PET_CONFIG_DIR, $XDG_DATA_HOME/pet or ~/.local/share/pet. On Windows it
+// is the config directory.
+func GetDefaultDataDir() (dir string, err error) {
+	if _, ok := os.LookupEnv("PET_CONFIG_DIR"); ok || runtime.GOOS == "windows" {
+		return GetDefaultConfigDir()
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		dir = filepath.Join(xdg, "pet")
+	} else {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "share", "pet")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("cannot create directory: %v", err)
+	}
+	return dir, nil
+}
+
+// GetConfigFile returns the config file: $PET_CONFIG or config.toml in the
+// config directory, or config.yaml, config.yml or config.json if only one of
+// them exists. A config file of an older version in ~/.config/pet is moved
+// to $XDG_CONFIG_HOME/pet.
+func GetConfigFile() (string, error) {
+	if env := os.Getenv("PET_CONFIG"); env != "" {
+		return expandPath(env), nil
+	}
+	dir, err := GetDefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	for _, ext := range configExts {
+		file := filepath.Join(dir, "config"+ext)
+		if _, ok := os.LookupEnv("PET_CONFIG_DIR"); !ok && legacyConfigDir() != "" {
+			if err := migrateFile(filepath.Join(legacyConfigDir(), "config"+ext), file); err != nil {
+				return "", err
+			}
+		}
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// GetDataDir returns the data directory, or its profiles/<name> directory
+// for a profile
+func GetDataDir() (string, error) {
+	dir, err := GetDefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	if profile := ProfileName(); profile != "" {
+		dir = filepath.Join(dir, "profiles", profile)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", fmt.Errorf("cannot create directory: %v", err)
+		}
+	}
+	return dir, nil
+}
+
+// GetDataFile returns the path of the named file in the data directory, or
+// in its profiles/<name> directory for a profile. The file of an older
+// version in the config directory is moved there.
+func GetDataFile(name string) (string, error) {
+	dir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, name)
+	if ProfileName() != "" {
+		return file, nil
+	}
+	configDir, err := GetDefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	for _, from := range []string{configDir, legacyConfigDir()} {
+		if from == "" {
+			continue
+		}
+		if err := migrateFile(filepath.Join(from, name), file); err != nil {
+			return "", err
+		}
+	}
+	return file, nil
+}
+
+// migrateFile moves the file from its old path, unless there is none or the
+// new path already exists
+func migrateFile(from, to string) error {
+	if from == to {
+		return nil
+	}
+	if _, err := os.Stat(to); !os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(from); err != nil {
+		return nil
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	// the directories may be on different file systems
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return fmt.Errorf("Failed to migrate %s: %v", from, err)
+	}
+	if err := os.WriteFile(to, data, 0o600); err != nil {
+		return fmt.Errorf("Failed to migrate %s: %v", from, err)
+	}
+	return os.Remove(from)
+}
+
 func expandPath(s string) string {
 	if len(s) >= 2 && s[0] == '~' && os.IsPathSeparator(s[1]) {
 		if runtime.GOOS == "windows" {
@@ -148,9 +735,6 @@ func expandPath(s string) string {
 }
 
 func isCommandAvailable(name string) bool {
-	cmd := exec.Command("/bin/sh", "-c", "command -v "+name)
-	if err := cmd.Run(); err != nil {
-		return false
-	}
-	return true
+	_, err := exec.LookPath(name)
+	return err == nil
 }
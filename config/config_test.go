@@ -0,0 +1,535 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/zalando/go-keyring"
+)
+
+func TestGetDataFile_Migrates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the files are in %APPDATA%\\pet on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdgconfig"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "xdgdata"))
+	t.Setenv("PET_CONFIG_DIR", "")
+	os.Unsetenv("PET_CONFIG_DIR")
+
+	legacy := filepath.Join(home, ".config", "pet")
+	if err := os.MkdirAll(legacy, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"config.toml", "usage.json"} {
+		if err := os.WriteFile(filepath.Join(legacy, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "xdgconfig", "pet", "config.toml"); file != want {
+		t.Errorf("GetConfigFile() = %s, want %s", file, want)
+	}
+	file, err = GetDataFile("usage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "xdgdata", "pet", "usage.json"); file != want {
+		t.Errorf("GetDataFile() = %s, want %s", file, want)
+	}
+
+	for _, name := range []string{"xdgconfig/pet/config.toml", "xdgdata/pet/usage.json"} {
+		data, err := os.ReadFile(filepath.Join(home, name))
+		if err != nil {
+			t.Fatalf("%s was not migrated: %v", name, err)
+		}
+		if string(data) != filepath.Base(name) {
+			t.Errorf("%s = %q", name, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "usage.json")); !os.IsNotExist(err) {
+		t.Errorf("the old usage.json was not removed")
+	}
+}
+
+func TestGetConfigFile_Env(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_CONFIG", filepath.Join(dir, "other.toml"))
+	t.Setenv("PET_SNIPPET_FILE", filepath.Join(dir, "other-snippet.toml"))
+
+	file, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != filepath.Join(dir, "other.toml") {
+		t.Errorf("GetConfigFile() = %s, want $PET_CONFIG", file)
+	}
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.General.SnippetFile != filepath.Join(dir, "other-snippet.toml") {
+		t.Errorf("SnippetFile = %s, want $PET_SNIPPET_FILE", cfg.General.SnippetFile)
+	}
+	file, err = GetDataFile("usage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != filepath.Join(dir, "usage.json") {
+		t.Errorf("GetDataFile() = %s, want it in $PET_CONFIG_DIR", file)
+	}
+}
+
+func TestLoad_Profile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, "config.toml")
+	data := `[General]
+  snippetfile = "/base.toml"
+  editor = "vim"
+[Gist]
+  gist_id = "base"
+[profile.work.General]
+  snippetfile = "/work.toml"
+[profile.work.Gist]
+  gist_id = "work"
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PET_PROFILE", "work")
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.General.SnippetFile != "/work.toml" || cfg.Gist.GistID != "work" || cfg.General.Editor != "vim" {
+		t.Errorf("profile not applied: %+v %+v", cfg.General, cfg.Gist)
+	}
+	usage, err := GetDataFile("usage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "profiles", "work", "usage.json"); usage != want {
+		t.Errorf("GetDataFile() = %s, want %s", usage, want)
+	}
+
+	t.Setenv("PET_PROFILE", "home")
+	if err := new(Config).Load(file); err == nil {
+		t.Errorf("Load() with an unknown profile succeeded")
+	}
+}
+
+func TestLoad_Validate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, "config.toml")
+	write := func(data string) {
+		if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`[General]
+  snippetfle = "x"
+[Gits]
+  gist_id = "1"
+`)
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	for _, w := range cfg.Warnings {
+		warnings = append(warnings, w.Error())
+	}
+	want := []string{
+		file + ":2: General.snippetfle: unknown key, did you mean snippetfile?",
+		file + ":3: Gits: unknown key, did you mean Gist?",
+	}
+	if diff := deep.Equal(warnings, want); diff != nil {
+		t.Error(diff)
+	}
+
+	write(`[GitLab]
+  id = "abc"
+  visibility = "secret"
+[host.web]
+  port = 70000
+[Notify]
+  after = "soon"
+[plugin.gist]
+[Selector.plugin.rofi]
+  delimiter = "\n"
+[Encryption]
+  identity = "key.txt"
+  passphrase = true
+[Redact]
+  patterns = ["key=("]
+[context]
+  "gcloud project" = "gcloud config get-value project"
+[Backup]
+  keep = -1
+`)
+	err := new(Config).Load(file)
+	if err == nil {
+		t.Fatal("Load() of invalid values succeeded")
+	}
+	for _, want := range []string{
+		file + `:2: GitLab.id: "abc" is not a number`,
+		file + `:3: GitLab.visibility: "secret" is not private, internal or public`,
+		file + `:5: host.web.port: 70000 is not a port`,
+		file + `:7: Notify.after: "soon" is not a duration`,
+		file + `:8: plugin.gist: gist is a backend of pet`,
+		file + `:10: Selector.plugin.rofi.delimiter: "\n" ends the lines of the selector`,
+		file + `:13: Encryption.passphrase: the files are encrypted with either the identity or a passphrase`,
+		file + `:15: Redact.patterns: "key=(" is not a regular expression`,
+		file + `:17: context.gcloud project: "gcloud project" is not a name of a context variable`,
+		file + `:19: Backup.keep: -1 is negative`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() = %v, want %s", err, want)
+		}
+	}
+}
+
+func TestLoad_CheckTokens(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_GITHUB_ACCESS_TOKEN", "")
+	defer func() { CheckTokens = false }()
+	file := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(file, []byte("[Gist]\n  auto_sync = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keychain is not asked by default
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Warnings) > 0 {
+		t.Errorf("Load() warnings = %v, want none", cfg.Warnings)
+	}
+
+	CheckTokens = true
+	cfg = Config{}
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Warnings) != 1 || cfg.Warnings[0].Key != "Gist.auto_sync" {
+		t.Errorf("Load() warnings = %v, want the missing token", cfg.Warnings)
+	}
+	if err := StoreToken("gist", "token"); err != nil {
+		t.Fatal(err)
+	}
+	cfg = Config{}
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Warnings) > 0 {
+		t.Errorf("Load() warnings = %v with a token in the keychain", cfg.Warnings)
+	}
+}
+
+func TestSetString(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_PROFILE", "work")
+	file := filepath.Join(dir, "config.toml")
+	data := `[Gist]
+  # the token
+  access_token = "base"
+[profile.work.Gist]
+  access_token = "work"
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetString(file, "", "Gist", "access_token"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(data, `access_token = "work"`, `access_token = ""`, 1)
+	if string(got) != want {
+		t.Errorf("SetString() wrote\n%s\nwant\n%s", got, want)
+	}
+	if err := SetString(file, "x", "GitLab", "access_token"); err == nil {
+		t.Errorf("SetString() of a missing key succeeded")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.toml")
+	data := `# my config
+[General]
+  snippetfile = "/s.toml"
+  snippetdirs = ["/a", "/b"]
+[profile.work.General]
+  snippetdir = "/w"
+  snippetdirs = [
+    "/c",
+  ]
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !needsMigration(file, 0) {
+		t.Fatal("needsMigration() = false")
+	}
+	notes, err := Migrate(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 4 {
+		t.Errorf("Migrate() notes = %q", notes)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `version = 1
+
+# my config
+[General]
+  snippetfile = "/s.toml"
+  snippetdir = "/a"
+[profile.work.General]
+  snippetdir = "/w"
+`
+	if string(got) != want {
+		t.Errorf("Migrate() wrote\n%s\nwant\n%s", got, want)
+	}
+	if backup, _ := os.ReadFile(file + ".bak"); string(backup) != data {
+		t.Errorf("Migrate() did not back up the file")
+	}
+	if needsMigration(file, 1) {
+		t.Errorf("needsMigration() of the migrated file = true")
+	}
+}
+
+func TestLoad_YAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_PROFILE", "work")
+	files := map[string]string{
+		"config.yaml": `General:
+  snippetfile: /s.toml
+  column: 50
+  cmd: [bash, -c]
+Selector:
+  command:
+    exec:
+      args: [--height, "40%"]
+variables:
+  env: prod
+profile:
+  work:
+    Gist:
+      gist_id: work
+`,
+		"config.json": `{
+  "General": {"snippetfile": "/s.toml", "column": 50, "cmd": ["bash", "-c"]},
+  "Selector": {"command": {"exec": {"args": ["--height", "40%"]}}},
+  "variables": {"env": "prod"},
+  "profile": {"work": {"Gist": {"gist_id": "work"}}}
+}`,
+	}
+	for name, data := range files {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		var cfg Config
+		if err := cfg.Load(file); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.General.SnippetFile != "/s.toml" || cfg.General.Column != 50 || len(cfg.General.Cmd) != 2 ||
+			cfg.Variables["env"] != "prod" || cfg.Gist.GistID != "work" ||
+			len(cfg.Selector.Commands["exec"].Args) != 2 {
+			t.Errorf("%s: Load() = %+v", name, cfg)
+		}
+		if len(cfg.Warnings) > 0 {
+			t.Errorf("%s: warnings %v", name, cfg.Warnings)
+		}
+	}
+}
+
+func TestWithoutTokens(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.toml": `[Gist]
+  # the token of the gist
+  access_token = "ghp_secret"
+  gist_id = "1234"
+[[remote]]
+  name = "work"
+  access_token = 'glpat-secret'
+[plugin.s3]
+  options = { bucket = "1234-snippets", API_KEY = "s3-secret" }
+[plugin.vault.options]
+  client_secret = "vault-secret"
+  "db-password" = "db-secret"
+`,
+		"config.yaml": "Gist:\n  access_token: ghp_secret\n  gist_id: \"1234\"\nremote:\n  - name: work\n    access_token: glpat-secret\nplugin:\n  s3:\n    options:\n      bucket: 1234-snippets\n      api_key: s3-secret\n",
+		"config.json": `{"Gist": {"access_token": "ghp_secret", "gist_id": "1234"}, "remote": [{"name": "work", "access_token": "glpat-secret"}], "plugin": {"s3": {"options": {"bucket": "1234-snippets", "password": "s3-secret"}}}}`,
+	}
+	for name, data := range files {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := WithoutTokens(file)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// the options of the plugins named like credentials too
+		for _, secret := range []string{"ghp_secret", "glpat-secret", "s3-secret", "vault-secret", "db-secret"} {
+			if strings.Contains(string(got), secret) {
+				t.Errorf("%s: WithoutTokens() kept %s: %s", name, secret, got)
+			}
+		}
+		if !strings.Contains(string(got), `"1234"`) || !strings.Contains(string(got), "work") || !strings.Contains(string(got), "1234-snippets") {
+			t.Errorf("%s: WithoutTokens() = %s", name, got)
+		}
+		if name == "config.toml" && !strings.Contains(string(got), "# the token of the gist") {
+			t.Errorf("%s: the comments were dropped: %s", name, got)
+		}
+	}
+}
+
+func TestConfig_UseRemote(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, "config.toml")
+	data := `[General]
+  backend = "gist"
+[Gist]
+  gist_id = "default"
+[[remote]]
+  name = "selfhosted"
+  backend = "gitlab"
+  url = "https://git.example.com/api/v4"
+  id = "12"
+[[remote]]
+  name = "selfhosted"
+  visibility = "secret"
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := new(Config).Load(file)
+	for _, want := range []string{
+		`remote.2.name: "selfhosted" is the name of another remote`,
+		`remote.2.visibility: "secret" is not private, internal or public`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() = %v, want %s", err, want)
+		}
+	}
+
+	data = strings.Replace(data, "  name = \"selfhosted\"\n  visibility = \"secret\"", "  name = \"other\"", 1)
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.UseRemote("selfhosted"); err != nil {
+		t.Fatal(err)
+	}
+	want := GitLabConfig{FileName: "pet-snippet.toml", Url: "https://git.example.com/api/v4", ID: "12", Visibility: "private"}
+	if cfg.General.Backend != "gitlab" || cfg.GitLab != want {
+		t.Errorf("UseRemote() = %s %+v", cfg.General.Backend, cfg.GitLab)
+	}
+	if err := cfg.UseRemote("nope"); err == nil {
+		t.Errorf("UseRemote() of an unknown remote succeeded")
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, "config.toml")
+	data := `[General]
+  editor = "vim" # mine
+  cmd = [
+    "sh",
+  ]
+[GitLab]
+  visibility = "private"
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range [][]string{
+		{"general.editor", "code --wait"},
+		{"GENERAL.cmd", "bash", "-c"},
+		{"general.column", "50"},
+		{"variables.env", "prod"},
+	} {
+		if err := Set(file, set[0], set[1:]); err != nil {
+			t.Fatalf("Set(%v) = %v", set, err)
+		}
+	}
+	for _, set := range [][]string{
+		{"gitlab.visibility", "secret"},
+		{"general.column", "abc"},
+		{"general.nope", "1"},
+		{"gitlab", "x"},
+	} {
+		if err := Set(file, set[0], set[1:]); err == nil {
+			t.Errorf("Set(%v) succeeded", set)
+		}
+	}
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[General]
+  editor = "code --wait"
+  cmd = ["bash", "-c"]
+  column = 50
+[GitLab]
+  visibility = "private"
+
+[variables]
+  env = "prod"
+`
+	if string(got) != want {
+		t.Errorf("Set() wrote\n%s\nwant\n%s", got, want)
+	}
+
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"general.editor": "code --wait",
+		"general.cmd":    `["bash", "-c"]`,
+		"general.column": "50",
+		"variables.env":  "prod",
+		"variables.none": "",
+	} {
+		if got, err := cfg.Get(path); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SetString sets the string key of the config file and keeps the rest of the
+// file, with its comments, as it is. With a profile, its key is set if the
+// profile has it.
+func SetString(file, value string, key ...string) error {
+	return editFile(file, func(lines []string) ([]string, error) {
+		n := 0
+		if profile := ProfileName(); profile != "" {
+			n = findLine(lines, append(toml.Key{"profile", profile}, key...))
+		}
+		if n == 0 {
+			n = findLine(lines, key)
+		}
+		if n == 0 || !strings.Contains(lines[n-1], "=") {
+			return nil, fmt.Errorf("%s is not set in %s", toml.Key(key), file)
+		}
+		return replaceValue(lines, n, strconv.Quote(value)), nil
+	})
+}
+
+// Set sets the key of the config file (e.g. "gitlab.visibility"), or of the
+// profile in use, to the values, which are parsed for the type of the key.
+// A missing key or table is added. The file is left as it was if the new
+// config does not load.
+func Set(file, path string, values []string) error {
+	key, t, err := lookupKey(path)
+	if err != nil {
+		return err
+	}
+	literal, err := tomlLiteral(t, values)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	if profile := ProfileName(); profile != "" {
+		key = append(toml.Key{"profile", profile}, key...)
+	}
+
+	old, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to read config file: %v", err)
+	}
+	err = editFile(file, func(lines []string) ([]string, error) {
+		if n := findLine(lines, key); n > 0 && strings.Contains(lines[n-1], "=") {
+			return replaceValue(lines, n, literal), nil
+		}
+		return insertKey(lines, key, literal), nil
+	})
+	if err != nil {
+		return err
+	}
+	var cfg Config
+	if err := cfg.Load(file); err != nil {
+		os.WriteFile(file, old, 0o600)
+		return fmt.Errorf("%s was not changed: %v", file, err)
+	}
+	return nil
+}
+
+// Get returns the value of the key (e.g. "general.editor") in the config,
+// strings as they are and other values in TOML
+func (cfg *Config) Get(path string) (string, error) {
+	key, _, err := lookupKey(path)
+	if err != nil {
+		return "", err
+	}
+	v := reflect.ValueOf(*cfg)
+	for _, k := range key {
+		if v.Kind() == reflect.Map {
+			v = v.MapIndex(reflect.ValueOf(k))
+			if !v.IsValid() {
+				return "", nil
+			}
+			continue
+		}
+		v = v.FieldByIndex(fieldIndex(v.Type(), k))
+	}
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+	var values []string
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, fmt.Sprint(v.Index(i).Interface()))
+		}
+	} else {
+		values = []string{fmt.Sprint(v.Interface())}
+	}
+	return tomlLiteral(v.Type(), values)
+}
+
+// fieldIndex returns the index of the field of the key
+func fieldIndex(t reflect.Type, key string) []int {
+	for i := 0; i < t.NumField(); i++ {
+		if tomlName(t.Field(i)) == key {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+func tomlName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("toml"), ",")[0]
+}
+
+// lookupKey returns the key of the config of the dotted path, whose words
+// match case insensitively, and the type of its value. Values of maps (e.g.
+// variables.env) are keys too.
+func lookupKey(path string) (toml.Key, reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	var key toml.Key
+	for _, word := range strings.Split(path, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			found := false
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				name := tomlName(f)
+				if name == "" || name == "-" || !strings.EqualFold(name, word) || f.Type == reflect.TypeOf(toml.Primitive{}) {
+					continue
+				}
+				key, t, found = append(key, name), f.Type, true
+				break
+			}
+			if !found {
+				return nil, nil, fmt.Errorf("unknown key: %s", path)
+			}
+		case reflect.Map:
+			key, t = append(key, word), t.Elem()
+		default:
+			return nil, nil, fmt.Errorf("unknown key: %s", path)
+		}
+	}
+	if t.Kind() == reflect.Struct || t.Kind() == reflect.Map || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct {
+		return nil, nil, fmt.Errorf("%s is a table, not a key", key)
+	}
+	return key, t, nil
+}
+
+// tomlLiteral returns the values as a TOML value of the type
+func tomlLiteral(t reflect.Type, values []string) (string, error) {
+	if t.Kind() == reflect.Slice {
+		var items []string
+		for _, v := range values {
+			item, err := tomlLiteral(t.Elem(), []string{v})
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	if len(values) != 1 {
+		return "", fmt.Errorf("one value is needed, not %d", len(values))
+	}
+	v := values[0]
+	switch t.Kind() {
+	case reflect.String:
+		return strconv.Quote(v), nil
+	case reflect.Int:
+		if _, err := strconv.Atoi(v); err != nil {
+			return "", fmt.Errorf("%q is not a number", v)
+		}
+		return v, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", fmt.Errorf("%q is not true or false", v)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return "", fmt.Errorf("values of type %s cannot be set", t)
+}
+
+// editFile changes the lines of the TOML config file
+func editFile(file string, edit func(lines []string) ([]string, error)) error {
+	if !isTOML(file) {
+		return fmt.Errorf("Cannot change %s, pet only changes TOML config files", file)
+	}
+	mode := os.FileMode(0o600)
+	if fi, err := os.Stat(file); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to read config file: %v", err)
+	}
+	lines, err := edit(strings.Split(string(data), "\n"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")), mode); err != nil {
+		return fmt.Errorf("Failed to write config file: %v", err)
+	}
+	return nil
+}
+
+// replaceValue replaces the value of the key at line n, and the following
+// lines of a multi-line array
+func replaceValue(lines []string, n int, literal string) []string {
+	l := lines[n-1]
+	i := strings.Index(l, "=")
+	value := l[i+1:]
+	end := n
+	for strings.Count(value, "[") > strings.Count(value, "]") && end < len(lines) {
+		value += lines[end]
+		end++
+	}
+	out := append([]string{}, lines[:n-1]...)
+	out = append(out, strings.TrimRight(l[:i], " \t")+" = "+literal)
+	return append(out, lines[end:]...)
+}
+
+// insertKey adds the key at the end of its table, or a new table at the end
+// of the file
+func insertKey(lines []string, key toml.Key, literal string) []string {
+	name := key[len(key)-1]
+	table := key[:len(key)-1]
+	if len(table) == 0 {
+		return append([]string{name + " = " + literal}, lines...)
+	}
+	t := tables(lines)
+	last := -1
+	for i := range lines {
+		if t[i] == table.String() && strings.TrimSpace(lines[i]) != "" {
+			last = i
+		}
+	}
+	if last < 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		return append(lines, "", "["+table.String()+"]", "  "+name+" = "+literal, "")
+	}
+	out := append([]string{}, lines[:last+1]...)
+	out = append(out, "  "+name+" = "+literal)
+	return append(out, lines[last+1:]...)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configExts are the extensions of the config files pet reads, TOML first
+var configExts = []string{".toml", ".yaml", ".yml", ".json"}
+
+// isTOML reports whether the config file is TOML, which pet can also
+// change (pet configure --migrate and --store-token)
+func isTOML(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext != ".yaml" && ext != ".yml" && ext != ".json"
+}
+
+// decodeFile decodes the config file, YAML and JSON by their extension and
+// TOML otherwise. YAML and JSON have the keys of the TOML file.
+func decodeFile(file string, cfg *Config) (toml.MetaData, error) {
+	if isTOML(file) {
+		return toml.DecodeFile(file, cfg)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return toml.MetaData{}, err
+	}
+	var v map[string]interface{}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&v)
+	default:
+		err = yaml.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return toml.MetaData{}, fmt.Errorf("Failed to parse config file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(toTOML(v)); err != nil {
+		return toml.MetaData{}, fmt.Errorf("Failed to parse config file: %v", err)
+	}
+	return toml.Decode(buf.String(), cfg)
+}
+
+// toTOML converts the values of YAML and JSON to those of TOML and leaves
+// out nulls
+func toTOML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, e := range v {
+			if e != nil {
+				m[k] = toTOML(e)
+			}
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			if e != nil {
+				a = append(a, toTOML(e))
+			}
+		}
+		return a
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	}
+	return v
+}
+
+// secretKey is the part of the names of the keys holding credentials: the
+// access tokens of the backends, and the options of the sync plugins such as
+// api_key or password
+const secretKey = `[A-Za-z0-9_-]*(?i:token|secret|password|passwd|api_?key|credential)[A-Za-z0-9_-]*`
+
+// secretKeyRe matches the names of the keys holding credentials
+var secretKeyRe = regexp.MustCompile(`^` + secretKey + `$`)
+
+// tokenRe matches the string values of the keys holding credentials in a
+// TOML config file, inline tables included
+var tokenRe = regexp.MustCompile(`(?m)((?:^|[\s{,])["']?` + secretKey + `["']?\s*=\s*)("(?:[^"\\]|\\.)*"|'[^']*')`)
+
+// WithoutTokens returns the config file with its access tokens and the
+// other values of keys named like credentials (token, secret, password,
+// api_key...) emptied, e.g. for a backup. A TOML file keeps its comments.
+func WithoutTokens(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if isTOML(file) {
+		return tokenRe.ReplaceAll(data, []byte(`${1}""`)), nil
+	}
+	var v map[string]interface{}
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&v)
+	} else {
+		err = yaml.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse config file: %v", err)
+	}
+	clearTokens(v)
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		data, err = json.MarshalIndent(v, "", "  ")
+		return append(data, '\n'), err
+	}
+	return yaml.Marshal(v)
+}
+
+// clearTokens empties the values of the keys named like credentials of the
+// decoded YAML or JSON
+func clearTokens(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if _, ok := e.(string); ok && secretKeyRe.MatchString(k) {
+				v[k] = ""
+			} else {
+				clearTokens(e)
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			clearTokens(e)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service of the tokens in the OS keychain
+const keyringService = "pet"
+
+// keyringUser is the keychain account of the token of the backend (gist or
+// gitlab), one for each remote and profile
+func keyringUser(backend string) string {
+	if remoteName != "" {
+		backend += "@" + remoteName
+	}
+	if profile := ProfileName(); profile != "" {
+		return backend + ":" + profile
+	}
+	return backend
+}
+
+// KeyringToken returns the token of the backend in the OS keychain (macOS
+// Keychain, libsecret or Windows Credential Manager), or "" if there is none
+// or no keychain
+func KeyringToken(backend string) string {
+	token, err := keyring.Get(keyringService, keyringUser(backend))
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// StoreToken saves the token of the backend in the OS keychain
+func StoreToken(backend, token string) error {
+	if err := keyring.Set(keyringService, keyringUser(backend), token); err != nil {
+		return fmt.Errorf("Failed to store the %s token in the keychain: %v", backend, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigVersion is the layout of the config files of this pet. Config files
+// without a version are version 0.
+const ConfigVersion = 1
+
+// migration upgrades the lines of a config file from the previous version
+// and describes what it changed
+type migration func(lines []string) ([]string, []string)
+
+// migrations[i] upgrades version i to version i+1
+var migrations = []migration{
+	migrateSnippetDirs,
+}
+
+var (
+	versionRe     = regexp.MustCompile(`^\s*version\s*=`)
+	snippetDirRe  = regexp.MustCompile(`^\s*snippetdir\s*=`)
+	snippetDirsRe = regexp.MustCompile(`^\s*snippetdirs\s*=`)
+)
+
+// tables returns the table of each line
+func tables(lines []string) []string {
+	var table string
+	t := make([]string, len(lines))
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "[") {
+			table = strings.Trim(strings.SplitN(l, "#", 2)[0], "[] \t")
+			table = strings.ReplaceAll(strings.ReplaceAll(table, `"`, ""), " ", "")
+		}
+		t[i] = table
+	}
+	return t
+}
+
+// isGeneral reports whether the table is [General] or that of a profile
+func isGeneral(table string) bool {
+	return table == "General" || strings.HasPrefix(table, "profile.") && strings.HasSuffix(table, ".General")
+}
+
+// migrateSnippetDirs replaces snippetdirs, the list of snippet directories
+// of upstream pet, with snippetdir
+func migrateSnippetDirs(lines []string) ([]string, []string) {
+	var out, notes []string
+	t := tables(lines)
+	hasDir := map[string]bool{}
+	for i, l := range lines {
+		if isGeneral(t[i]) && snippetDirRe.MatchString(l) {
+			hasDir[t[i]] = true
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if !isGeneral(t[i]) || !snippetDirsRe.MatchString(l) {
+			out = append(out, l)
+			continue
+		}
+		// the array may span several lines
+		value := l
+		for strings.Count(value, "[") > strings.Count(value, "]") && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+		}
+		var v struct {
+			SnippetDirs []string `toml:"snippetdirs"`
+		}
+		if _, err := toml.Decode(strings.TrimSpace(value), &v); err != nil {
+			out = append(out, strings.Split(value, "\n")...)
+			notes = append(notes, fmt.Sprintf("[%s] snippetdirs is not a list of directories, left as it is", t[i]))
+			continue
+		}
+		indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		switch {
+		case hasDir[t[i]]:
+			notes = append(notes, fmt.Sprintf("[%s] snippetdirs removed, snippetdir is already set", t[i]))
+		case len(v.SnippetDirs) == 0:
+			notes = append(notes, fmt.Sprintf("[%s] empty snippetdirs removed", t[i]))
+		default:
+			out = append(out, indent+"snippetdir = "+strconv.Quote(v.SnippetDirs[0]))
+			notes = append(notes, fmt.Sprintf("[%s] snippetdirs replaced with snippetdir = %q", t[i], v.SnippetDirs[0]))
+			if len(v.SnippetDirs) > 1 {
+				notes = append(notes, fmt.Sprintf("[%s] only one snippetdir is supported, not migrated: %s",
+					t[i], strings.Join(v.SnippetDirs[1:], ", ")))
+			}
+		}
+	}
+	return out, notes
+}
+
+// migrateLines upgrades the lines of a config file of the version to
+// ConfigVersion and sets its version
+func migrateLines(lines []string, version int) ([]string, []string) {
+	var notes []string
+	if version < 0 {
+		version = 0
+	}
+	for _, m := range migrations[version:] {
+		var n []string
+		lines, n = m(lines)
+		notes = append(notes, n...)
+	}
+
+	versionLine := "version = " + strconv.Itoa(ConfigVersion)
+	t := tables(lines)
+	for i, l := range lines {
+		if t[i] == "" && versionRe.MatchString(l) {
+			lines[i] = versionLine
+			return lines, notes
+		}
+	}
+	return append([]string{versionLine, ""}, lines...), notes
+}
+
+// Migrate upgrades the config file to ConfigVersion and keeps the old file
+// as <file>.bak. It returns what was changed.
+func Migrate(file string, version int) ([]string, error) {
+	if version >= ConfigVersion {
+		return nil, nil
+	}
+	if !isTOML(file) {
+		return nil, fmt.Errorf("Cannot migrate %s, pet only changes TOML config files", file)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err)
+	}
+	lines, notes := migrateLines(strings.Split(string(data), "\n"), version)
+	if err := os.WriteFile(file+".bak", data, fi.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("Failed to back up config file: %v", err)
+	}
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")), fi.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("Failed to write config file: %v", err)
+	}
+	return append(notes, fmt.Sprintf("version = %d (the old file is %s.bak)", ConfigVersion, file)), nil
+}
+
+// needsMigration reports whether the migrations change the lines of a config
+// file of the version
+func needsMigration(file string, version int) bool {
+	if !isTOML(file) {
+		return false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	_, notes := migrateLines(strings.Split(string(data), "\n"), version)
+	return len(notes) > 0
+}
@@ -0,0 +1,349 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/knqyf263/pet/i18n"
+)
+
+// Problem is a mistake in the config file
+type Problem struct {
+	File string
+	// Line is 0 when the key is not found in the file
+	Line    int
+	Key     string
+	Message string
+	// Warning problems do not stop pet, e.g. unknown keys
+	Warning bool
+}
+
+func (p Problem) Error() string {
+	where := p.File
+	if p.Line > 0 {
+		where = fmt.Sprintf("%s:%d", where, p.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", where, p.Key, p.Message)
+}
+
+var (
+	backends     = []string{"gist", "gitlab"}
+	visibilities = []string{"private", "internal", "public"}
+	// contextNameRe matches the names of the [context] variables
+	contextNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// validator collects the problems of a decoded config file
+type validator struct {
+	file     string
+	md       toml.MetaData
+	lines    []string
+	problems []Problem
+}
+
+func newValidator(file string, md toml.MetaData) *validator {
+	v := &validator{file: file, md: md}
+	// the lines of YAML and JSON are not those of the keys
+	if !isTOML(file) {
+		return v
+	}
+	if f, err := os.Open(file); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			v.lines = append(v.lines, scanner.Text())
+		}
+	}
+	return v
+}
+
+// key returns the key as written in the file, in the profile in use if it
+// sets it
+func (v *validator) key(key ...string) toml.Key {
+	if name := ProfileName(); name != "" {
+		inProfile := append([]string{"profile", name}, key...)
+		if v.md.IsDefined(inProfile...) {
+			return inProfile
+		}
+	}
+	return key
+}
+
+func (v *validator) add(warning bool, key toml.Key, format string, a ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    v.line(key),
+		Key:     key.String(),
+		Message: fmt.Sprintf(format, a...),
+		Warning: warning,
+	})
+}
+
+func (v *validator) line(key toml.Key) int {
+	return findLine(v.lines, key)
+}
+
+// findLine returns the line number of the key, or of its table header, or 0
+func findLine(lines []string, key toml.Key) int {
+	want := strings.Join(key[:len(key)-1], ".")
+	name := key[len(key)-1]
+	for i, table := range tables(lines) {
+		l := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(l, "[") {
+			if table == key.String() {
+				return i + 1
+			}
+			continue
+		}
+		k := strings.Trim(strings.TrimSpace(strings.SplitN(l, "=", 2)[0]), `"`)
+		if table == want && k == name && strings.Contains(l, "=") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (v *validator) oneOf(value string, values []string, key ...string) {
+	if value == "" {
+		return
+	}
+	for _, s := range values {
+		if value == s {
+			return
+		}
+	}
+	v.add(false, v.key(key...), "%q is not %s", value, orList(values))
+}
+
+// validate checks the values of the config and reports the keys of the file
+// which pet does not know
+func (cfg *Config) validate(file string, md toml.MetaData) []Problem {
+	v := newValidator(file, md)
+
+	// the other profiles are only checked for unknown keys
+	for name, profile := range cfg.Profiles {
+		if name != ProfileName() {
+			var pc Config
+			md.PrimitiveDecode(profile, &pc)
+		}
+	}
+	known := knownKeys(reflect.TypeOf(Config{}))
+	reported := map[string]bool{}
+	for _, key := range md.Undecoded() {
+		// the keys of an unknown table are not reported again
+		reported[key.String()] = true
+		if len(key) > 1 && reported[strings.Join(key[:len(key)-1], ".")] {
+			continue
+		}
+		if hint := suggestKey(key, known); hint != "" {
+			v.add(true, key, "unknown key, did you mean %s?", hint)
+		} else {
+			v.add(true, key, "unknown key")
+		}
+	}
+
+	if cfg.Version > ConfigVersion {
+		v.add(false, toml.Key{"version"}, "%d is newer than the config version of this pet (%d), update pet", cfg.Version, ConfigVersion)
+	} else if cfg.Version < ConfigVersion && needsMigration(file, cfg.Version) {
+		v.add(true, toml.Key{"version"}, "old config layout, run pet configure --migrate")
+	}
+	allBackends := append([]string{}, backends...)
+	var plugins []string
+	for name := range cfg.Plugins {
+		plugins = append(plugins, name)
+	}
+	sort.Strings(plugins)
+	for _, name := range plugins {
+		if name == "gist" || name == "gitlab" {
+			v.add(false, v.key("plugin", name), "%s is a backend of pet, name the plugin otherwise", name)
+		}
+		allBackends = append(allBackends, name)
+	}
+	v.oneOf(cfg.General.Backend, allBackends, "General", "backend")
+	v.oneOf(cfg.GitLab.Visibility, visibilities, "GitLab", "visibility")
+	v.oneOf(cfg.General.Language, i18n.Languages, "General", "language")
+	if cfg.General.Column < 0 {
+		v.add(false, v.key("General", "column"), "%d is negative", cfg.General.Column)
+	}
+	if cfg.GitLab.ID != "" {
+		if _, err := strconv.Atoi(cfg.GitLab.ID); err != nil {
+			v.add(false, v.key("GitLab", "id"), "%q is not a number (the ID of the GitLab snippet)", cfg.GitLab.ID)
+		}
+	}
+	if cfg.GitLab.Url != "" {
+		if u, err := url.Parse(cfg.GitLab.Url); err != nil || u.Scheme == "" || u.Host == "" {
+			v.add(false, v.key("GitLab", "url"), "%q is not a URL such as https://gitlab.example.com", cfg.GitLab.Url)
+		}
+	}
+
+	if cfg.General.SyncInterval != "" {
+		if d, err := time.ParseDuration(cfg.General.SyncInterval); err != nil {
+			v.add(false, v.key("General", "sync_interval"), "%q is not a duration (e.g. 30m or 6h)", cfg.General.SyncInterval)
+		} else if d < time.Minute {
+			v.add(false, v.key("General", "sync_interval"), "%q is less than a minute", cfg.General.SyncInterval)
+		}
+	}
+	v.oneOf(cfg.General.SyncConflict, []string{"newer", "markers"}, "General", "sync_conflict")
+	if cfg.Notify.After != "" {
+		if _, err := time.ParseDuration(cfg.Notify.After); err != nil {
+			v.add(false, v.key("Notify", "after"), "%q is not a duration (e.g. 30s or 5m)", cfg.Notify.After)
+		}
+	}
+	if cfg.Backup.Keep < 0 {
+		v.add(false, v.key("Backup", "keep"), "%d is negative", cfg.Backup.Keep)
+	}
+	if cfg.Backup.KeepDays < 0 {
+		v.add(false, v.key("Backup", "keep_days"), "%d is negative", cfg.Backup.KeepDays)
+	}
+	if cfg.Encryption.Identity != "" && cfg.Encryption.Passphrase {
+		v.add(false, v.key("Encryption", "passphrase"), "the files are encrypted with either the identity or a passphrase")
+	}
+	for _, p := range cfg.Redact.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			v.add(false, v.key("Redact", "patterns"), "%q is not a regular expression: %v", p, err)
+		}
+	}
+	for name, p := range cfg.Selector.Plugins {
+		if strings.ContainsAny(p.Delimiter, "\r\n") {
+			v.add(false, v.key("Selector", "plugin", name, "delimiter"), "%q ends the lines of the selector, use another delimiter (e.g. \"\\t\")", p.Delimiter)
+		}
+	}
+	for name := range cfg.Context {
+		if !contextNameRe.MatchString(name) {
+			v.add(false, v.key("context", name), "%q is not a name of a context variable (letters, digits and _)", name)
+		}
+	}
+	for name, h := range cfg.Hosts {
+		if h.Port < 0 || h.Port > 65535 {
+			v.add(false, v.key("host", name, "port"), "%d is not a port", h.Port)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, r := range cfg.Remotes {
+		key := func(name string) toml.Key { return toml.Key{"remote", strconv.Itoa(i + 1), name} }
+		switch {
+		case r.Name == "":
+			v.add(false, key("name"), "a remote needs a name for pet sync --remote")
+		case names[r.Name]:
+			v.add(false, key("name"), "%q is the name of another remote", r.Name)
+		}
+		names[r.Name] = true
+		v.oneOf(r.Backend, allBackends, key("backend")...)
+		v.oneOf(r.Visibility, visibilities, key("visibility")...)
+		if _, err := strconv.Atoi(r.ID); r.ID != "" && err != nil {
+			v.add(false, key("id"), "%q is not a number (the ID of the GitLab snippet)", r.ID)
+		}
+	}
+
+	// auto_sync runs after every change, so a missing token would fail them
+	if CheckTokens {
+		v.checkTokens(cfg)
+	}
+	return v.problems
+}
+
+// CheckTokens makes Load check the access tokens of auto_sync. Asking the
+// keychain is slow, so only pet doctor sets it; the other commands find a
+// missing token when they sync.
+var CheckTokens bool
+
+// checkTokens warns about auto_sync without an access token for the backend
+func (v *validator) checkTokens(cfg *Config) {
+	if cfg.Gist.AutoSync && cfg.Gist.AccessToken == "" && os.Getenv("PET_GITHUB_ACCESS_TOKEN") == "" &&
+		(cfg.General.Backend == "" || cfg.General.Backend == "gist") && KeyringToken("gist") == "" {
+		v.add(true, v.key("Gist", "auto_sync"), "access_token or $PET_GITHUB_ACCESS_TOKEN is required (or pet configure --store-token)")
+	}
+	if cfg.GitLab.AutoSync && cfg.GitLab.AccessToken == "" && os.Getenv("PET_GITLAB_ACCESS_TOKEN") == "" &&
+		cfg.General.Backend == "gitlab" && KeyringToken("gitlab") == "" {
+		v.add(true, v.key("GitLab", "auto_sync"), "access_token or $PET_GITLAB_ACCESS_TOKEN is required (or pet configure --store-token)")
+	}
+}
+
+// knownKeys returns the keys of the struct and its tables, e.g.
+// "General.snippetfile"
+func knownKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		keys = append(keys, name)
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(toml.Primitive{}) {
+			for _, k := range knownKeys(f.Type) {
+				keys = append(keys, name+"."+k)
+			}
+		}
+	}
+	return keys
+}
+
+// suggestKey returns the known key closest to the unknown key, with the same
+// table case insensitively and a name at most two edits away
+func suggestKey(key toml.Key, known []string) string {
+	// profiles have the keys of the config
+	k := key
+	if len(k) > 2 && k[0] == "profile" {
+		k = k[2:]
+	}
+	var table string
+	if len(k) > 1 {
+		table = strings.ToLower(strings.Join(k[:len(k)-1], ".")) + "."
+	}
+	best, bestDist := "", 3
+	for _, candidate := range known {
+		i := strings.LastIndex(candidate, ".")
+		if strings.ToLower(candidate[:i+1]) != table {
+			continue
+		}
+		if d := editDistance(strings.ToLower(k[len(k)-1]), strings.ToLower(candidate[i+1:])); d < bestDist {
+			best, bestDist = candidate[i+1:], d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// orList joins the values as "a, b or c"
+func orList(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
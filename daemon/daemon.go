@@ -0,0 +1,265 @@
+// Package daemon keeps the decoded snippet files in memory and serves them
+// over JSON-RPC on a local socket, for the pet commands and editors.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+)
+
+// SocketName is the socket of the daemon in the data directory
+const SocketName = "daemon.sock"
+
+// PollInterval is how often the daemon checks the snippet files for changes
+// without the notifications of the system, ten times less often with them
+var PollInterval = time.Second
+
+// SocketPath returns the socket of the daemon
+func SocketPath() (string, error) {
+	return config.GetDataFile(SocketName)
+}
+
+// Service is the JSON-RPC service "Pet"
+type Service struct {
+	mu      sync.Mutex
+	files   map[string]cachedFile
+	version uint64
+	// changed is closed, and replaced, when the snippet files change
+	changed chan struct{}
+	state   string
+}
+
+// cachedFile is a decoded snippet file and what it was decoded from
+type cachedFile struct {
+	modTime  time.Time
+	size     int64
+	snippets snippet.Snippets
+}
+
+// ListArgs are the arguments of Pet.List
+type ListArgs struct {
+	// Query searches the snippets like pet search, all if empty
+	Query string `json:"query,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// WaitArgs are the arguments of Pet.Wait
+type WaitArgs struct {
+	// Version is the version of the snippets the caller has
+	Version uint64 `json:"version"`
+	// Timeout is the longest wait in seconds, 60 if 0
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// NewService returns a Service
+func NewService() *Service {
+	return &Service{files: map[string]cachedFile{}, changed: make(chan struct{})}
+}
+
+// decode returns the snippet file from the cache, decoding it again if it
+// changed
+func (s *Service) decode(file string) (snippet.Snippets, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return snippet.Snippets{}, err
+	}
+	s.mu.Lock()
+	c, ok := s.files[file]
+	s.mu.Unlock()
+	if ok && c.modTime.Equal(fi.ModTime()) && c.size == fi.Size() {
+		return c.snippets, nil
+	}
+	snippets, err := snippet.DecodeFile(file)
+	if err != nil {
+		return snippet.Snippets{}, err
+	}
+	s.mu.Lock()
+	s.files[file] = cachedFile{modTime: fi.ModTime(), size: fi.Size(), snippets: snippets}
+	s.mu.Unlock()
+	return snippets, nil
+}
+
+// Decode returns the snippet file as it is decoded, for snippet.Decoder
+func (s *Service) Decode(file string, reply *snippet.Snippets) error {
+	snippets, err := s.decode(file)
+	*reply = snippets
+	return err
+}
+
+// List returns the snippets of the files of the config of the daemon
+func (s *Service) List(args ListArgs, reply *[]snippet.SnippetInfo) error {
+	files, err := snippet.SnippetFiles()
+	if err != nil {
+		return err
+	}
+	var snippets snippet.Snippets
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		decoded, err := s.decode(file)
+		if err != nil {
+			return fmt.Errorf("Failed to load snippet file %s. %v", file, err)
+		}
+		snippets.Snippets = append(snippets.Snippets, decoded.Snippets...)
+	}
+	list := snippets.Snippets
+	if args.Query != "" {
+		list = snippets.Search(args.Query)
+	}
+	*reply = []snippet.SnippetInfo{}
+	for _, sn := range list {
+		if args.Tag == "" || sn.HasTag(args.Tag) {
+			*reply = append(*reply, sn)
+		}
+	}
+	return nil
+}
+
+// Version returns the version of the snippets, which changes with them
+func (s *Service) Version(args struct{}, reply *uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*reply = s.version
+	return nil
+}
+
+// Wait returns the version of the snippets once it is not that of args, or
+// after the timeout
+func (s *Service) Wait(args WaitArgs, reply *uint64) error {
+	timeout := time.Duration(args.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	s.mu.Lock()
+	version, changed := s.version, s.changed
+	s.mu.Unlock()
+	if version == args.Version {
+		select {
+		case <-changed:
+		case <-time.After(timeout):
+		}
+	}
+	return s.Version(struct{}{}, reply)
+}
+
+// Sync syncs the snippet file with the backend of the config like pet sync
+func (s *Service) Sync(args struct{}, reply *string) error {
+	if err := petSync.AutoSync(config.Conf.General.SnippetFile); err != nil {
+		return err
+	}
+	*reply = "ok"
+	return nil
+}
+
+// poll bumps the version when the snippet files, or their list, change
+func (s *Service) poll() {
+	state := snippet.FilesState()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state == s.state {
+		return
+	}
+	if s.state != "" {
+		s.version++
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	s.state = state
+}
+
+// Serve serves the service on the listener until it is closed
+func Serve(l net.Listener, s *Service) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Pet", s); err != nil {
+		return err
+	}
+	s.poll()
+	done, stopped := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+	// the notifications of the system are used if they can, the files are
+	// polled too for those they miss (e.g. network file systems)
+	interval := PollInterval
+	if w, err := snippet.NewWatcher(); err == nil {
+		defer w.Close()
+		go w.Run(s.poll)
+		interval *= 10
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.poll()
+			case <-done:
+				return
+			}
+		}
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Listen listens on the socket, replacing that of a daemon which is not
+// running
+func Listen(socket string) (net.Listener, error) {
+	if c, err := net.Dial("unix", socket); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("pet daemon is already running on %s", socket)
+	}
+	os.Remove(socket)
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen on %s: %v", socket, err)
+	}
+	return l, nil
+}
+
+// Client calls a running daemon
+type Client struct {
+	rpc *rpc.Client
+}
+
+// Dial connects to the daemon on the socket
+func Dial(socket string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socket, 100*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{rpc: jsonrpc.NewClient(conn)}, nil
+}
+
+// Decode returns the snippet file decoded by the daemon
+func (c *Client) Decode(file string) (snippet.Snippets, error) {
+	var snippets snippet.Snippets
+	err := c.rpc.Call("Pet.Decode", file, &snippets)
+	return snippets, err
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
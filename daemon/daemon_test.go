@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func start(t *testing.T) *Client {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	write(t, "greet", "echo hello")
+
+	PollInterval = 10 * time.Millisecond
+	socket := filepath.Join(dir, SocketName)
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() { served <- Serve(l, NewService()) }()
+	t.Cleanup(func() {
+		l.Close()
+		<-served
+	})
+
+	client, err := Dial(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func write(t *testing.T, description, command string) {
+	data := "[[snippets]]\n  description = \"" + description + "\"\n  command = \"" + command + "\"\n"
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	client := start(t)
+	snippet.Decoder = client.Decode
+	defer func() { snippet.Decoder = nil }()
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets.Snippets) != 1 || snippets.Snippets[0].Command != "echo hello" {
+		t.Fatalf("Load() = %+v", snippets.Snippets)
+	}
+	if got := snippets.Snippets[0].File(); got != config.Conf.General.SnippetFile {
+		t.Errorf("File() = %q, want %q", got, config.Conf.General.SnippetFile)
+	}
+
+	// a changed file is decoded again
+	write(t, "greet", "echo changed")
+	snippets = snippet.Snippets{}
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if snippets.Snippets[0].Command != "echo changed" {
+		t.Errorf("Command = %q, want the changed command", snippets.Snippets[0].Command)
+	}
+
+	if _, err := client.Decode(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("Decode() of a missing file succeeded")
+	}
+}
+
+func TestList(t *testing.T) {
+	client := start(t)
+	var list []snippet.SnippetInfo
+	if err := client.rpc.Call("Pet.List", ListArgs{Query: "hello"}, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Description != "greet" {
+		t.Errorf("List(hello) = %+v", list)
+	}
+	if err := client.rpc.Call("Pet.List", ListArgs{Query: "nothing"}, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("List(nothing) = %+v, want none", list)
+	}
+}
+
+func TestWait(t *testing.T) {
+	client := start(t)
+	var version uint64
+	if err := client.rpc.Call("Pet.Version", struct{}{}, &version); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		write(t, "greet", "echo another command")
+	}()
+	var changed uint64
+	if err := client.rpc.Call("Pet.Wait", WaitArgs{Version: version, Timeout: 5}, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if changed == version {
+		t.Errorf("Wait() = %d, want a new version", changed)
+	}
+}
+
+func TestListen_Running(t *testing.T) {
+	start(t)
+	if _, err := Listen(filepath.Join(os.Getenv("PET_CONFIG_DIR"), SocketName)); err == nil {
+		t.Error("Listen() succeeded with a running daemon")
+	}
+}
@@ -0,0 +1,377 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// ErrCanceled is returned by Find when nothing is selected
+var ErrCanceled = errors.New("canceled")
+
+const (
+	finderQueryView   = "finder-query"
+	finderListView    = "finder-list"
+	finderPreviewView = "finder-preview"
+	finderHelp        = "ENTER => select, TAB => mark, ESC => cancel"
+)
+
+// ansiRe matches the color escapes of colorized lines
+var ansiRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// FuzzyMatch reports whether every word of query matches line as a
+// subsequence, and the score of the match (higher is better). Matching is
+// case insensitive unless the word has upper case letters.
+func FuzzyMatch(query, line string) (int, bool) {
+	line = ansiRe.ReplaceAllString(line, "")
+	score := 0
+	for _, word := range strings.Fields(query) {
+		s, ok := fuzzyWord(word, line)
+		if !ok {
+			return 0, false
+		}
+		score += s
+	}
+	return score, true
+}
+
+func fuzzyWord(word, line string) (int, bool) {
+	text := []rune(line)
+	pattern := []rune(word)
+	if strings.ToLower(word) == word {
+		text = []rune(strings.ToLower(line))
+	}
+
+	score, j, prev := 0, 0, -2
+	for i := 0; i < len(text) && j < len(pattern); i++ {
+		if text[i] != pattern[j] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			// consecutive characters
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]) {
+			// start of a word
+			score += 3
+		}
+		prev = i
+		j++
+	}
+	return score, j == len(pattern)
+}
+
+// FindOptions are the options of the embedded fuzzy finder
+type FindOptions struct {
+	// Query is the initial query
+	Query string
+	// Multi allows marking several lines
+	Multi bool
+	// Preview returns the text shown for the highlighted line (without
+	// colors). There is no preview pane if it is nil.
+	Preview func(line string) string
+	// Expect are the keys (fzf names such as ctrl-y) which also accept the
+	// selection. Like fzf --expect, the pressed key is then returned before
+	// the lines, or an empty string for Enter.
+	Expect []string
+	// Header is shown in the title along with the help
+	Header string
+	// Tags returns the tags of a line (without colors). If it is not nil,
+	// ctrl-t switches to the list of tags, where the chosen ones narrow
+	// the lines to those with all of them.
+	Tags func(line string) []string
+	// Score returns the score of the query for a line (without colors),
+	// and false if it does not match. FuzzyMatch is used if it is nil.
+	Score func(query, line string) (int, bool)
+}
+
+// namedKeys are the keys besides ctrl-a to ctrl-z which can be expected
+var namedKeys = map[string]gocui.Key{
+	"del":        gocui.KeyDelete,
+	"home":       gocui.KeyHome,
+	"end":        gocui.KeyEnd,
+	"pgup":       gocui.KeyPgup,
+	"pgdn":       gocui.KeyPgdn,
+	"ctrl-space": gocui.KeyCtrlSpace,
+}
+
+// ParseKey returns the key of its fzf name: ctrl-a to ctrl-z, f1 to f12,
+// del, home, end, pgup, pgdn and ctrl-space.
+func ParseKey(name string) (gocui.Key, error) {
+	name = strings.ToLower(name)
+	if k, ok := namedKeys[name]; ok {
+		return k, nil
+	}
+	if c := strings.TrimPrefix(name, "ctrl-"); c != name && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return gocui.KeyCtrlA + gocui.Key(c[0]-'a'), nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "f")); err == nil && name[0] == 'f' && n >= 1 && n <= 12 {
+		return gocui.KeyF1 + gocui.Key(n-1), nil
+	}
+	return 0, fmt.Errorf("unsupported key: %s", name)
+}
+
+// finder is the state of the embedded fuzzy finder
+type finder struct {
+	lines   []string
+	matches []int
+	marked  map[int]bool
+	multi   bool
+	cursor  int
+	result  []string
+	preview func(line string) string
+	score   func(query, line string) (int, bool)
+	header  string
+	// expect is set with FindOptions.Expect, key is the key pressed then
+	expect bool
+	key    string
+	tagPanel
+}
+
+func (f *finder) filter(query string) {
+	if f.tagMode {
+		f.filterTags(query)
+		return
+	}
+	scoreOf := f.score
+	if scoreOf == nil {
+		scoreOf = FuzzyMatch
+	}
+	type match struct{ i, score int }
+	var found []match
+	for i, l := range f.lines {
+		if !f.hasChosenTags(i) {
+			continue
+		}
+		if score, ok := scoreOf(query, ansiRe.ReplaceAllString(l, "")); ok {
+			found = append(found, match{i, score})
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].score > found[b].score })
+	f.matches = nil
+	for _, m := range found {
+		f.matches = append(f.matches, m.i)
+	}
+	f.cursor = 0
+}
+
+func (f *finder) draw(g *gocui.Gui) error {
+	if f.tagMode {
+		return f.drawTags(g)
+	}
+	v, err := g.View(finderListView)
+	if err != nil {
+		return err
+	}
+	v.Clear()
+	for _, i := range f.matches {
+		mark := "  "
+		if f.marked[i] {
+			mark = "* "
+		}
+		fmt.Fprintln(v, mark+f.lines[i])
+	}
+	f.scroll(v)
+
+	help := finderHelp
+	if f.tags != nil {
+		help += ", " + tagHelp
+	}
+	if f.header != "" {
+		help += ", " + f.header
+	}
+	q, _ := g.View(finderQueryView)
+	q.Title = fmt.Sprintf("%d/%d%s (%s)", len(f.matches), len(f.lines), f.chosenTitle(), help)
+
+	if p, err := g.View(finderPreviewView); err == nil {
+		p.Clear()
+		p.SetOrigin(0, 0)
+		if len(f.matches) > 0 {
+			fmt.Fprint(p, f.preview(ansiRe.ReplaceAllString(f.lines[f.matches[f.cursor]], "")))
+		}
+	}
+	return nil
+}
+
+// scroll keeps the cursor of the list view visible
+func (f *finder) scroll(v *gocui.View) {
+	_, height := v.Size()
+	_, oy := v.Origin()
+	if f.cursor < oy {
+		oy = f.cursor
+	} else if f.cursor >= oy+height {
+		oy = f.cursor - height + 1
+	}
+	v.SetOrigin(0, oy)
+	v.SetCursor(0, f.cursor-oy)
+}
+
+func (f *finder) move(delta int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		n := len(f.matches)
+		if f.tagMode {
+			n = len(f.tagMatches)
+		}
+		if n == 0 {
+			return nil
+		}
+		f.cursor = (f.cursor + delta + n) % n
+		return f.draw(g)
+	}
+}
+
+func (f *finder) mark(g *gocui.Gui, v *gocui.View) error {
+	if f.tagMode {
+		return f.chooseTag(g, v)
+	}
+	if !f.multi || len(f.matches) == 0 {
+		return nil
+	}
+	i := f.matches[f.cursor]
+	f.marked[i] = !f.marked[i]
+	return f.move(1)(g, v)
+}
+
+func (f *finder) accept(g *gocui.Gui, v *gocui.View) error {
+	if f.tagMode {
+		return f.applyTags(g, v)
+	}
+	// the lines are returned without colors, like fzf --ansi
+	for i, l := range f.lines {
+		if f.marked[i] {
+			f.result = append(f.result, ansiRe.ReplaceAllString(l, ""))
+		}
+	}
+	if len(f.result) == 0 && len(f.matches) > 0 {
+		f.result = []string{ansiRe.ReplaceAllString(f.lines[f.matches[f.cursor]], "")}
+	}
+	return gocui.ErrQuit
+}
+
+// cancel quits, or leaves the list of tags
+func (f *finder) cancel(g *gocui.Gui, v *gocui.View) error {
+	if f.tagMode {
+		return f.toggleTags(g, v)
+	}
+	return gocui.ErrQuit
+}
+
+// acceptKey accepts the selection with an expected key
+func (f *finder) acceptKey(name string) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if f.tagMode {
+			return nil
+		}
+		f.key = name
+		return f.accept(g, v)
+	}
+}
+
+// Find is the embedded fuzzy finder used when no selector is installed. It
+// shows the lines filtered by the typed query and returns the chosen ones.
+func Find(lines []string, opts FindOptions) ([]string, error) {
+	g, err := gocui.NewGui(gocui.OutputNormal, false)
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+
+	g.Cursor = true
+	g.SetManagerFunc(layout)
+
+	f := &finder{lines: lines, marked: map[int]bool{}, multi: opts.Multi, preview: opts.Preview,
+		header: opts.Header, expect: len(opts.Expect) > 0}
+	f.initTags(opts.Tags)
+	f.score = opts.Score
+	query := opts.Query
+	maxX, maxY := g.Size()
+	q, err := g.SetView(finderQueryView, 0, 0, maxX-1, 2, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return nil, err
+	}
+	q.Editable = true
+	q.Wrap = false
+	fmt.Fprint(q, query)
+	q.SetCursor(len([]rune(query)), 0)
+	q.Editor = gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+		f.filter(strings.TrimSpace(v.Buffer()))
+		f.draw(g)
+	})
+
+	listEnd := maxY - 1
+	if f.preview != nil {
+		// the preview pane takes the bottom half
+		listEnd = 3 + (maxY-3)/2
+		p, err := g.SetView(finderPreviewView, 0, listEnd+1, maxX-1, maxY-1, 0)
+		if err != nil && err != gocui.ErrUnknownView {
+			return nil, err
+		}
+		p.Title = "Preview"
+		p.Wrap = true
+	}
+	l, err := g.SetView(finderListView, 0, 3, maxX-1, listEnd, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return nil, err
+	}
+	l.Frame = false
+	l.Highlight = true
+	l.SelFgColor = SelectedColor
+	l.Wrap = false
+
+	type binding struct {
+		key     gocui.Key
+		handler func(*gocui.Gui, *gocui.View) error
+	}
+	bindings := []binding{
+		{gocui.KeyCtrlC, quit},
+		{gocui.KeyEsc, f.cancel},
+		{gocui.KeyEnter, f.accept},
+		{gocui.KeyTab, f.mark},
+		{gocui.KeyArrowUp, f.move(-1)},
+		{gocui.KeyCtrlP, f.move(-1)},
+		{gocui.KeyArrowDown, f.move(1)},
+		{gocui.KeyCtrlN, f.move(1)},
+	}
+	if f.tags != nil {
+		bindings = append(bindings, binding{gocui.KeyCtrlT, f.toggleTags})
+	}
+	// the expected keys are bound last to take precedence
+	for _, name := range opts.Expect {
+		key, err := ParseKey(name)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, binding{key, f.acceptKey(name)})
+	}
+	for _, b := range bindings {
+		if err := g.SetKeybinding("", b.key, gocui.ModNone, b.handler); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := g.SetCurrentView(finderQueryView); err != nil {
+		return nil, err
+	}
+	f.filter(query)
+	if err := f.draw(g); err != nil {
+		return nil, err
+	}
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return nil, err
+	}
+	if len(f.result) == 0 {
+		return nil, ErrCanceled
+	}
+	if f.expect {
+		return append([]string{f.key}, f.result...), nil
+	}
+	return f.result, nil
+}
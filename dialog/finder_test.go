@@ -0,0 +1,85 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, line string
+		want        bool
+	}{
+		{"", "anything", true},
+		{"kgp", "[pods]: kubectl get pods", true},
+		{"pods kube", "[pods]: kubectl get pods", true},
+		{"Pods", "[pods]: kubectl get pods", false},
+		{"pdk", "[pods]: kubectl get pods", true},
+		{"xyz", "[pods]: kubectl get pods", false},
+		{"red", "\x1b[31m[red]\x1b[0m: ls", true},
+	}
+	for _, tt := range tests {
+		if _, got := FuzzyMatch(tt.query, tt.line); got != tt.want {
+			t.Errorf("%q in %q: wanted %v, got %v", tt.query, tt.line, tt.want, got)
+		}
+	}
+
+	// consecutive characters at the start of a word score higher
+	tight, _ := FuzzyMatch("get", "[x]: kubectl get pods")
+	loose, _ := FuzzyMatch("get", "[x]: git remote set-url")
+	if tight <= loose {
+		t.Errorf("wanted %d > %d", tight, loose)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    gocui.Key
+		wantErr bool
+	}{
+		{"ctrl-a", gocui.KeyCtrlA, false},
+		{"ctrl-y", gocui.KeyCtrlY, false},
+		{"CTRL-E", gocui.KeyCtrlE, false},
+		{"f1", gocui.KeyF1, false},
+		{"f12", gocui.KeyF12, false},
+		{"del", gocui.KeyDelete, false},
+		{"f13", 0, true},
+		{"ctrl-1", 0, true},
+		{"alt-y", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKey(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: wanted %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestFinder_ChosenTags(t *testing.T) {
+	lines := []string{"[a]: ls #fs", "[b]: df #fs #disk", "[c]: ps"}
+	tags := map[string][]string{lines[0]: {"fs"}, lines[1]: {"fs", "disk"}}
+	f := &finder{lines: lines}
+	f.initTags(func(line string) []string { return tags[line] })
+
+	if len(f.allTags) != 2 || f.allTags[0] != "disk" || f.allTags[1] != "fs" {
+		t.Errorf("unexpected tags %v", f.allTags)
+	}
+	f.chosen["fs"] = true
+	f.filter("")
+	if len(f.matches) != 2 {
+		t.Errorf("#fs: wanted 2 lines, got %v", f.matches)
+	}
+	f.chosen["disk"] = true
+	f.filter("")
+	if len(f.matches) != 1 || f.matches[0] != 1 {
+		t.Errorf("#fs #disk: wanted line 1, got %v", f.matches)
+	}
+	f.filter("ls")
+	if len(f.matches) != 0 {
+		t.Errorf("#fs #disk ls: wanted no lines, got %v", f.matches)
+	}
+}
@@ -0,0 +1,116 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+const formHelp = "TAB => Next field, ENTER => Next field (new line in multi-line fields), Ctrl-S => Save, Ctrl-C => Cancel"
+
+// SnippetForm is the content of the form for a new snippet
+type SnippetForm struct {
+	Command     string
+	Description string
+	Tag         string
+	Output      string
+}
+
+type formField struct {
+	name      string
+	height    int
+	multiLine bool
+	value     *string
+}
+
+// GenerateSnippetForm shows a form to fill in a snippet.
+// validate is called when saving; its error is shown and the form stays open.
+// ok is false if the form was canceled.
+func GenerateSnippetForm(form SnippetForm, validate func(SnippetForm) error) (result SnippetForm, ok bool, err error) {
+	g, err := gocui.NewGui(gocui.OutputNormal, false)
+	if err != nil {
+		return form, false, err
+	}
+	defer g.Close()
+
+	g.Highlight = true
+	g.Cursor = true
+	g.SelFgColor = SelectedColor
+	g.SetManagerFunc(layout)
+
+	fields := []formField{
+		{name: "Command", height: 6, multiLine: true, value: &form.Command},
+		{name: "Description", height: 2, value: &form.Description},
+		{name: "Tag (delimiter: space)", height: 2, value: &form.Tag},
+		{name: "Output", height: 5, multiLine: true, value: &form.Output},
+	}
+
+	maxX, _ := g.Size()
+	x0, x1 := maxX/10, (maxX/2)+(maxX/3)
+	y := 1
+	for _, f := range fields {
+		v, err := g.SetView(f.name, x0, y, x1, y+f.height, 0)
+		if err != nil && err != gocui.ErrUnknownView {
+			return form, false, err
+		}
+		v.Title = f.name
+		v.Editable = true
+		v.Wrap = false
+		fmt.Fprint(v, *f.value)
+		y += f.height + 1
+	}
+	status, err := g.SetView("status", x0, y, x1, y+2, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return form, false, err
+	}
+	status.Frame = false
+	fmt.Fprint(status, formHelp)
+
+	cur := 0
+	next := func(g *gocui.Gui, _ *gocui.View) error {
+		cur = (cur + 1) % len(fields)
+		_, err := g.SetCurrentView(fields[cur].name)
+		return err
+	}
+	save := func(g *gocui.Gui, _ *gocui.View) error {
+		for _, f := range fields {
+			v, _ := g.View(f.name)
+			*f.value = strings.TrimSpace(v.Buffer())
+		}
+		if err := validate(form); err != nil {
+			status.Clear()
+			status.FgColor = WarningColor
+			fmt.Fprint(status, err.Error())
+			return nil
+		}
+		ok = true
+		return gocui.ErrQuit
+	}
+
+	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
+		return form, false, err
+	}
+	if err := g.SetKeybinding("", gocui.KeyCtrlS, gocui.ModNone, save); err != nil {
+		return form, false, err
+	}
+	if err := g.SetKeybinding("", gocui.KeyTab, gocui.ModNone, next); err != nil {
+		return form, false, err
+	}
+	for _, f := range fields {
+		if f.multiLine {
+			continue
+		}
+		if err := g.SetKeybinding(f.name, gocui.KeyEnter, gocui.ModNone, next); err != nil {
+			return form, false, err
+		}
+	}
+
+	if _, err := g.SetCurrentView(fields[0].name); err != nil {
+		return form, false, err
+	}
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return form, false, err
+	}
+	return form, ok, nil
+}
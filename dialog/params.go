@@ -1,8 +1,13 @@
 package dialog
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/awesome-gocui/gocui"
@@ -19,81 +24,466 @@ var (
 	CurrentCommand string
 	//FinalCommand is the command after assigning to variables
 	FinalCommand string
+	//FilledParams are the values assigned to the variables
+	FilledParams map[string]string
 )
 
 type parameter struct {
-	original string
-	name     string
-	options  []string
-	current  int
+	name    string
+	typ     string
+	options []string
+	current int
+	// message is shown in the title, e.g. the error of a provider
+	message string
+	secret  bool
+	// suggestions are the value and the previously entered ones of a
+	// parameter which is not a choice, cycled with up/down
+	suggestions []string
+}
+
+// pick makes the value chosen with Pick the current option
+func (p *parameter) pick() {
+	picked, err := Pick(p.name, p.options)
+	if err != nil {
+		p.message = err.Error()
+		return
+	}
+	for i, o := range p.options {
+		if o == picked {
+			p.current = i
+		}
+	}
+}
+
+// choice reports whether the parameter only accepts one of its options
+func (p *parameter) choice() bool {
+	return len(p.options) > 1
+}
+
+// suggest offers the previously entered values of the parameter, most
+// recent first. A choice starts with the last one picked. Other parameters
+// start with their default, or the last value if they have none.
+func (p *parameter) suggest(history []string) {
+	if p.choice() {
+		for _, h := range history {
+			for i, o := range p.options {
+				if o == h {
+					p.current = i
+					return
+				}
+			}
+		}
+		return
+	}
+	if len(history) == 0 {
+		return
+	}
+	seen := map[string]bool{}
+	for _, v := range append([]string{p.options[0]}, history...) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			p.suggestions = append(p.suggestions, v)
+		}
+	}
+	p.options[0] = p.suggestions[0]
 }
 
 func insertParams(command string, params map[string]string) string {
-	resultCommand := command
 	log.Println("in command ", command)
-	for _, v := range parameters {
-		resultCommand = strings.Replace(resultCommand, v.original, params[v.name], -1)
-	}
-	log.Println("out command ", resultCommand)
+	resultCommand := ReplaceParams(command, func(name string) string {
+		return params[name]
+	})
+	redacted, _ := Redact(command, params)
+	log.Println("out command ", redacted)
 	return resultCommand
 }
 
-// SearchForParams returns variables from a command
+// SearchForParams returns variables from a command with their options.
+// If a variable is defined several times, the last definition wins.
 func SearchForParams(lines []string) map[string][]string {
-	re := `<([\S]+?)>`
-	if len(lines) == 1 {
-		r, _ := regexp.Compile(re)
+	parameters = nil
+	if len(lines) != 1 {
+		return nil
+	}
+	params := ParseParams(lines[0])
+	if len(params) == 0 {
+		return nil
+	}
 
-		params := r.FindAllStringSubmatch(lines[0], -1)
-		if len(params) == 0 {
-			return nil
+	extracted := map[string][]string{}
+	for _, p := range params {
+		var message string
+		if p.Provider != "" && Provide != nil {
+			lines, err := Provide(p.Provider)
+			if err != nil {
+				message = err.Error()
+			}
+			p.Options = lines
+		}
+		options := p.Options
+		if len(options) == 0 {
+			options = []string{""}
 		}
+		param := &parameter{name: p.Name, typ: p.Type, options: options, message: message, secret: p.Secret}
+		if History != nil && !p.Secret && p.Provider == "" {
+			param.suggest(History(p.Name))
+		}
+		if Pick != nil && param.choice() && !p.Secret {
+			param.pick()
+		}
+		parameters = append(parameters, param)
+		extracted[p.Name] = options
+	}
+	return extracted
+}
 
-		extracted := map[string][]string{}
+// Param is a parameter of a command
+type Param struct {
+	Name string `json:"name"`
+	// Type restricts the values (<port:int>), see CheckType
+	Type string `json:"type,omitempty"`
+	// Options are the default values, the first one is used by default
+	Options []string `json:"options,omitempty"`
+	// Provider is a command whose output lines are the choices (<pod=$(cmd)>)
+	Provider string `json:"provider,omitempty"`
+	// Secret values (<token!>) are masked and never saved
+	Secret bool `json:"secret,omitempty"`
+	// Store is the secret store the value is read from, vault, op
+	// (1Password) or bw (Bitwarden), at Ref (<pass@vault:secret/db#password>).
+	// The parameter is a secret.
+	Store string `json:"store,omitempty"`
+	Ref   string `json:"ref,omitempty"`
+}
 
-		for _, p := range params {
-			splitted := strings.Split(p[1], "=")
-			key := splitted[0]
+// Provide runs the command of a provider and returns its output lines.
+// Without it, parameters with a provider have no default value.
+var Provide func(command string) ([]string, error)
 
-			// _, param_exists := extracted[key]
+// Pick returns the value of a parameter chosen among its choices (or the
+// lines of its provider) in an external picker, before the dialog opens,
+// and "" if none was chosen. Without it, the choices are cycled in the
+// dialog.
+var Pick func(name string, choices []string) (string, error)
 
-			// Set to empty if no value is provided and param is not already set
-			// if len(splitted) == 1 && !param_exists {
-			if len(splitted) == 1 {
-				p := &parameter{
-					original: "<" + p[1] + ">",
-					name:     key,
-					options:  []string{""},
-					current:  0,
-				}
-				parameters = append(parameters, p)
-			} else if len(splitted) > 1 {
-				// From a list of parameters (divided with "|", get all of them
-				pSplit := strings.Split(splitted[1], "|")
-				p := &parameter{
-					original: "<" + p[1] + ">",
-					name:     key,
-					options:  pSplit,
-					current:  0,
-				}
-				parameters = append(parameters, p)
+// History returns the previously entered values of a parameter, most recent
+// first, offered in the dialog. Secret parameters are not looked up.
+var History func(name string) []string
+
+// Default returns the default value of the parameter
+func (p Param) Default() string {
+	if len(p.Options) == 0 {
+		return ""
+	}
+	return p.Options[0]
+}
+
+// Choices returns the allowed values of a parameter defined with a list of
+// values (<env=dev|staging|prod>), or nil if any value is allowed.
+func (p Param) Choices() []string {
+	if len(p.Options) < 2 {
+		return nil
+	}
+	return p.Options
+}
+
+// Allows reports whether the value is allowed for the parameter
+func (p Param) Allows(value string) bool {
+	return p.Validate(value) == nil
+}
+
+// Validate checks that the value is one of the choices and of the type of
+// the parameter.
+func (p Param) Validate(value string) error {
+	if choices := p.Choices(); choices != nil {
+		for _, c := range choices {
+			if c == value {
+				return nil
 			}
 		}
-		return extracted
+		return fmt.Errorf("invalid value for <%s>: %s (choices: %s)",
+			p.Name, value, strings.Join(choices, ", "))
+	}
+	if err := checkValue(p.Type, value); err != nil {
+		return fmt.Errorf("invalid value for <%s>: %v", p.Name, err)
+	}
+	return nil
+}
+
+// CheckType returns an error if the type of a parameter is unknown. The types
+// are int, path, file (an existing file), dir (an existing directory) and
+// /regexp/ (a value matching the regular expression).
+func CheckType(typ string) error {
+	switch typ {
+	case "", "int", "path", "file", "dir":
+		return nil
+	}
+	if len(typ) > 1 && strings.HasPrefix(typ, "/") && strings.HasSuffix(typ, "/") {
+		if _, err := regexp.Compile(typ[1 : len(typ)-1]); err != nil {
+			return fmt.Errorf("invalid pattern %s: %v", typ, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown type: %s (int, path, file, dir or /regexp/)", typ)
+}
+
+func checkValue(typ, value string) error {
+	if err := CheckType(typ); err != nil {
+		return err
+	}
+	switch typ {
+	case "":
+		return nil
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+		return nil
+	case "path":
+		if value == "" {
+			return errors.New("path must not be empty")
+		}
+		return nil
+	case "file", "dir":
+		fi, err := os.Stat(expandHome(value))
+		switch {
+		case err != nil:
+			return fmt.Errorf("%s does not exist", value)
+		case typ == "file" && fi.IsDir():
+			return fmt.Errorf("%s is a directory", value)
+		case typ == "dir" && !fi.IsDir():
+			return fmt.Errorf("%s is not a directory", value)
+		}
+		return nil
+	}
+	if !regexp.MustCompile(typ[1 : len(typ)-1]).MatchString(value) {
+		return fmt.Errorf("%s does not match %s", value, typ)
 	}
 	return nil
 }
 
+// expandHome expands a leading ~ like the shell does for the unquoted value
+func expandHome(path string) string {
+	if path != "~" && (len(path) < 2 || path[0] != '~' || !os.IsPathSeparator(path[1])) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// ValidateParams checks the values of the parameters against their choices
+// and types.
+func ValidateParams(command string, values map[string]string) error {
+	for _, p := range ParseParams(command) {
+		if v, ok := values[p.Name]; ok {
+			if err := p.Validate(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParamPattern matches the parameters of a command. The command of a
+// provider (<pod=$(kubectl get pods -o name)>) may contain spaces but no
+// parentheses.
+var ParamPattern = regexp.MustCompile(`<(\S+?=\$\([^()]*\)|\S+?)>`)
+
+// SecretStores are the stores of the parameters read from a secret store
+// (<name@store:ref>)
+var SecretStores = []string{"vault", "op", "bw"}
+
+// parseParam parses the inside of a parameter, name[:type][=options] or
+// name@store:ref. The pattern of a /regexp/ type may contain = and escaped
+// slashes.
+func parseParam(inner string) (p Param, head string) {
+	head = inner
+	if i := strings.Index(inner, "@"); i > 0 && !strings.ContainsAny(inner[:i], ":=") {
+		for _, store := range SecretStores {
+			if ref := strings.TrimPrefix(inner[i+1:], store+":"); ref != inner[i+1:] {
+				p.Name, p.Store, p.Ref, p.Secret = strings.TrimSuffix(inner[:i], "!"), store, ref, true
+				return p, head
+			}
+		}
+	}
+	rest := ""
+	defaults := false
+	if i := strings.IndexAny(inner, ":="); i >= 0 && inner[i] == ':' {
+		p.Name = inner[:i]
+		typ := inner[i+1:]
+		end := strings.Index(typ, "=")
+		if strings.HasPrefix(typ, "/") {
+			for j := 1; j < len(typ); j++ {
+				if typ[j] == '\\' {
+					j++
+				} else if typ[j] == '/' {
+					end = strings.Index(typ[j:], "=")
+					if end >= 0 {
+						end += j
+					}
+					break
+				}
+			}
+		}
+		if end >= 0 {
+			typ, rest, defaults = typ[:end], typ[end+1:], true
+		}
+		p.Type = typ
+		head = p.Name + ":" + typ
+	} else if i >= 0 {
+		p.Name, head, rest, defaults = inner[:i], inner[:i], inner[i+1:], true
+	} else {
+		p.Name = inner
+	}
+	if strings.HasSuffix(p.Name, "!") {
+		p.Name, p.Secret = strings.TrimSuffix(p.Name, "!"), true
+	}
+	if defaults && strings.HasPrefix(rest, "$(") && strings.HasSuffix(rest, ")") {
+		p.Provider = rest[2 : len(rest)-1]
+	} else if defaults {
+		p.Options = strings.Split(rest, "|")
+	}
+	return p, head
+}
+
+// ParseParams returns the parameters of a command in order of appearance.
+// If a parameter is defined several times, the last definition wins, except
+// that one read from a secret store stays so.
+func ParseParams(command string) []Param {
+	var params []Param
+	index := map[string]int{}
+	for _, m := range ParamPattern.FindAllStringSubmatch(command, -1) {
+		p, _ := parseParam(m[1])
+		if i, ok := index[p.Name]; ok {
+			if params[i].Store == "" || p.Store != "" {
+				params[i] = p
+			}
+			continue
+		}
+		index[p.Name] = len(params)
+		params = append(params, p)
+	}
+	return params
+}
+
+// ExpandParams replaces the parameters of a command with the given values.
+// Parameters without a value are replaced with their (first) default value.
+func ExpandParams(command string, values map[string]string) string {
+	return ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p, _ := parseParam(s[1 : len(s)-1])
+		if v, ok := values[p.Name]; ok {
+			return v
+		}
+		return p.Default()
+	})
+}
+
+// FillParams replaces the parameters having a value and keeps the others
+func FillParams(command string, values map[string]string) string {
+	return ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p, _ := parseParam(s[1 : len(s)-1])
+		if v, ok := values[p.Name]; ok {
+			return v
+		}
+		return s
+	})
+}
+
+// Redact returns the command with the values filled in except for the
+// secret parameters, which are kept as they are, and the values without the
+// secret ones. The result can be shown and saved.
+func Redact(command string, values map[string]string) (string, map[string]string) {
+	secrets := map[string]bool{}
+	for _, p := range ParseParams(command) {
+		if p.Secret {
+			secrets[p.Name] = true
+		}
+	}
+	public := map[string]string{}
+	for k, v := range values {
+		if !secrets[k] {
+			public[k] = v
+		}
+	}
+	redacted := ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p, _ := parseParam(s[1 : len(s)-1])
+		if p.Secret || secrets[p.Name] {
+			return s
+		}
+		if v, ok := public[p.Name]; ok {
+			return v
+		}
+		return p.Default()
+	})
+	return redacted, public
+}
+
+// HasSecrets reports whether the command has secret parameters
+func HasSecrets(command string) bool {
+	for _, p := range ParseParams(command) {
+		if p.Secret {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceParams replaces each parameter of a command with the result of f
+// for its name.
+func ReplaceParams(command string, f func(name string) string) string {
+	return ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p, _ := parseParam(s[1 : len(s)-1])
+		return f(p.Name)
+	})
+}
+
+// WithDefaults makes the values the defaults of the parameters of a command.
+// For a list of values, an allowed value is moved to the front.
+func WithDefaults(command string, values map[string]string) string {
+	return ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p, head := parseParam(s[1 : len(s)-1])
+		v, ok := values[p.Name]
+		if !ok || v == "" || strings.ContainsAny(v, " \t\n<>|") || p.Provider != "" || p.Secret {
+			return s
+		}
+		if p.Choices() == nil {
+			return "<" + head + "=" + v + ">"
+		}
+		if !p.Allows(v) {
+			return s
+		}
+		options := []string{v}
+		for _, o := range p.Options {
+			if o != v {
+				options = append(options, o)
+			}
+		}
+		return "<" + head + "=" + strings.Join(options, "|") + ">"
+	})
+}
+
 func evaluateParams(g *gocui.Gui, _ *gocui.View) error {
-	paramsFilled := map[string]string{}
-	for _, v := range views {
-		view, _ := g.View(v)
-		if !strings.Contains(v, "Command") {
-			res := view.Buffer()
-			res = strings.Replace(res, "\n", "", -1)
-			paramsFilled[v] = strings.TrimSpace(res)
+	paramsFilled := currentValues(g)
+
+	// stay in the dialog until every value is valid
+	for i, p := range parameters {
+		param := Param{Name: p.name, Type: p.typ, Options: p.options}
+		if err := param.Validate(paramsFilled[p.name]); err != nil {
+			reviewing = false
+			view, _ := g.View(p.name)
+			setTitle(view, p, err)
+			curView = i + 1
+			_, err := g.SetCurrentView(p.name)
+			return err
 		}
 	}
+	if Review && !reviewing {
+		return enterReview(g)
+	}
+
+	FilledParams = paramsFilled
 	FinalCommand = insertParams(CurrentCommand, paramsFilled)
 	return gocui.ErrQuit
 }
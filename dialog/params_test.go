@@ -10,23 +10,15 @@ func TestSearchForParams(t *testing.T) {
 	command := "<a=1> <b> hello <multi=aaa|bbb|ccc>"
 
 	params := map[string][]string{
-		"a":     []string{"1"},
-		"b":     []string{""},
-		"multi": []string{"aaa", "bbb", "ccc"},
+		"a":     {"1"},
+		"b":     {""},
+		"multi": {"aaa", "bbb", "ccc"},
 	}
 
 	got := SearchForParams([]string{command})
 
-	for key, value := range params {
-		if got[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
-	}
-
-	for key, value := range got {
-		if params[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
+	if diff := deep.Equal(params, got); diff != nil {
+		t.Fatal(diff)
 	}
 }
 
@@ -34,21 +26,13 @@ func TestSearchForParams_MultiOptions(t *testing.T) {
 	command := "<multi=aaa|bbb|ccc>"
 
 	params := map[string][]string{
-		"multi": []string{"aaa", "bbb", "ccc"},
+		"multi": {"aaa", "bbb", "ccc"},
 	}
 
 	got := SearchForParams([]string{command})
 
-	for key, value := range params {
-		if got[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
-	}
-
-	for key, value := range got {
-		if params[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
+	if diff := deep.Equal(params, got); diff != nil {
+		t.Fatal(diff)
 	}
 }
 
@@ -65,24 +49,16 @@ func TestSearchForParams_WithNoParams(t *testing.T) {
 func TestSearchForParams_WithMultipleParams(t *testing.T) {
 	command := "<a=1> <b> <c=3>"
 
-	params := map[string]string{
-		"a": "1",
-		"b": "",
-		"c": "3",
+	params := map[string][]string{
+		"a": {"1"},
+		"b": {""},
+		"c": {"3"},
 	}
 
 	got := SearchForParams([]string{command})
 
-	for key, value := range params {
-		if got[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
-	}
-
-	for key, value := range got {
-		if params[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
+	if diff := deep.Equal(params, got); diff != nil {
+		t.Fatal(diff)
 	}
 }
 
@@ -99,31 +75,23 @@ func TestSearchForParams_WithEmptyCommand(t *testing.T) {
 func TestSearchForParams_WithNewline(t *testing.T) {
 	command := "<a=1> <b> hello\n<c=3>"
 
-	params := map[string]string{
-		"a": "1",
-		"b": "",
-		"c": "3",
+	params := map[string][]string{
+		"a": {"1"},
+		"b": {""},
+		"c": {"3"},
 	}
 
 	got := SearchForParams([]string{command})
 
-	for key, value := range params {
-		if got[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
-	}
-
-	for key, value := range got {
-		if params[key] != value {
-			t.Fatalf("wanted param '%s' to equal '%s', got '%s'", key, value, got[key])
-		}
+	if diff := deep.Equal(params, got); diff != nil {
+		t.Fatal(diff)
 	}
 }
 
 func TestSearchForParams_InvalidParamFormat(t *testing.T) {
 	command := "<a=1 <b> hello"
-	want := map[string]string{
-		"b": "",
+	want := map[string][]string{
+		"b": {""},
 	}
 	got := SearchForParams([]string{command})
 
@@ -134,8 +102,8 @@ func TestSearchForParams_InvalidParamFormat(t *testing.T) {
 
 func TestSearchForParams_ConfusingBrackets(t *testing.T) {
 	command := "cat <<EOF > <file=path/to/file>\nEOF"
-	want := map[string]string{
-		"file": "path/to/file",
+	want := map[string][]string{
+		"file": {"path/to/file"},
 	}
 	got := SearchForParams([]string{command})
 	if diff := deep.Equal(want, got); diff != nil {
@@ -145,8 +113,8 @@ func TestSearchForParams_ConfusingBrackets(t *testing.T) {
 
 func TestSearchForParams_MultipleParamsSameKey(t *testing.T) {
 	command := "<a=1> <a=2> <a=3>"
-	want := map[string]string{
-		"a": "3",
+	want := map[string][]string{
+		"a": {"3"},
 	}
 	got := SearchForParams([]string{command})
 
@@ -157,8 +125,8 @@ func TestSearchForParams_MultipleParamsSameKey(t *testing.T) {
 
 func TestSearchForParams_MultipleParamsSameKeyDifferentValues(t *testing.T) {
 	command := "<a=1> <a=2> <a=3>"
-	want := map[string]string{
-		"a": "3",
+	want := map[string][]string{
+		"a": {"3"},
 	}
 	got := SearchForParams([]string{command})
 
@@ -169,9 +137,9 @@ func TestSearchForParams_MultipleParamsSameKeyDifferentValues(t *testing.T) {
 
 func TestSearchForParams_MultipleParamsSameKeyDifferentValues_MultipleLines(t *testing.T) {
 	command := "<a=1> <a=2> <a=3>\n<b=4>"
-	want := map[string]string{
-		"a": "3",
-		"b": "4",
+	want := map[string][]string{
+		"a": {"3"},
+		"b": {"4"},
 	}
 	got := SearchForParams([]string{command})
 
@@ -182,8 +150,8 @@ func TestSearchForParams_MultipleParamsSameKeyDifferentValues_MultipleLines(t *t
 
 func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat(t *testing.T) {
 	command := "<a=1> <a=2 <a=3>"
-	want := map[string]string{
-		"a": "3",
+	want := map[string][]string{
+		"a": {"3"},
 	}
 	got := SearchForParams([]string{command})
 
@@ -194,9 +162,9 @@ func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat(t *t
 
 func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat_MultipleLines(t *testing.T) {
 	command := "<a=1> <a=2> <a=3 \n<b=4>"
-	want := map[string]string{
-		"a": "2",
-		"b": "4",
+	want := map[string][]string{
+		"a": {"2"},
+		"b": {"4"},
 	}
 
 	got := SearchForParams([]string{command})
@@ -208,8 +176,8 @@ func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat_Mult
 
 func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat_MultipleLines2(t *testing.T) {
 	command := "<a=1> <a=2> <a=3>\n<b=4"
-	want := map[string]string{
-		"a": "3",
+	want := map[string][]string{
+		"a": {"3"},
 	}
 
 	got := SearchForParams([]string{command})
@@ -218,3 +186,194 @@ func TestSearchForParams_MultipleParamsSameKeyDifferentValues_InvalidFormat_Mult
 		t.Fatal(diff)
 	}
 }
+
+func TestValidateParams(t *testing.T) {
+	command := "deploy <env=dev|staging|prod> <tag=latest>"
+
+	if err := ValidateParams(command, map[string]string{"env": "prod", "tag": "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateParams(command, map[string]string{"env": "qa"}); err == nil {
+		t.Fatal("wanted an error for a value not in the choices")
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	command := "deploy <env=dev|staging|prod> <tag=latest> <host>"
+
+	got := WithDefaults(command, map[string]string{"env": "prod", "tag": "v1", "host": "web"})
+	want := "deploy <env=prod|dev|staging> <tag=v1> <host=web>"
+	if got != want {
+		t.Fatalf("wanted '%s', got '%s'", want, got)
+	}
+
+	got = WithDefaults(command, map[string]string{"env": "qa"})
+	if got != command {
+		t.Fatalf("wanted a value outside of the choices to be ignored, got '%s'", got)
+	}
+}
+
+func TestParseParams_Types(t *testing.T) {
+	command := "<port:int=8080> <ver:/^v[0-9]=?$/=v1|v2> <conf:file> <name=a:b>"
+	want := []Param{
+		{Name: "port", Type: "int", Options: []string{"8080"}},
+		{Name: "ver", Type: "/^v[0-9]=?$/", Options: []string{"v1", "v2"}},
+		{Name: "conf", Type: "file"},
+		{Name: "name", Options: []string{"a:b"}},
+	}
+	got := ParseParams(command)
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got := ExpandParams(command, map[string]string{"conf": "x"}); got != "8080 v1 x a:b" {
+		t.Fatalf("unexpected command '%s'", got)
+	}
+}
+
+func TestParam_Validate(t *testing.T) {
+	tests := []struct {
+		param Param
+		value string
+		valid bool
+	}{
+		{Param{Name: "n", Type: "int"}, "42", true},
+		{Param{Name: "n", Type: "int"}, "4x", false},
+		{Param{Name: "p", Type: "path"}, "", false},
+		{Param{Name: "f", Type: "file"}, "params_test.go", true},
+		{Param{Name: "f", Type: "file"}, "missing.go", false},
+		{Param{Name: "d", Type: "dir"}, ".", true},
+		{Param{Name: "d", Type: "dir"}, "params_test.go", false},
+		{Param{Name: "v", Type: "/^v[0-9]+$/"}, "v12", true},
+		{Param{Name: "v", Type: "/^v[0-9]+$/"}, "12", false},
+		{Param{Name: "x", Type: "float"}, "1.5", false},
+	}
+	for _, tt := range tests {
+		if err := tt.param.Validate(tt.value); (err == nil) != tt.valid {
+			t.Errorf("%s %s: wanted valid=%v, got %v", tt.param.Type, tt.value, tt.valid, err)
+		}
+	}
+}
+
+func TestSearchForParams_Provider(t *testing.T) {
+	Provide = func(command string) ([]string, error) {
+		if command != "kubectl get pods -o name | grep web" {
+			t.Fatalf("unexpected provider '%s'", command)
+		}
+		return []string{"pod/web-1", "pod/web-2"}, nil
+	}
+	defer func() { Provide = nil }()
+
+	command := "kubectl logs <pod=$(kubectl get pods -o name | grep web)> <c=app>"
+	want := map[string][]string{
+		"pod": {"pod/web-1", "pod/web-2"},
+		"c":   {"app"},
+	}
+	got := SearchForParams([]string{command})
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got := ExpandParams(command, map[string]string{"pod": "pod/web-2"}); got != "kubectl logs pod/web-2 app" {
+		t.Fatalf("unexpected command '%s'", got)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	command := "login <user=me> <token!> <pin!:int>"
+	params := ParseParams(command)
+	if len(params) != 3 || !params[1].Secret || params[1].Name != "token" || params[2].Type != "int" {
+		t.Fatalf("unexpected params %+v", params)
+	}
+
+	values := map[string]string{"user": "bob", "token": "s3cr3t", "pin": "1234"}
+	redacted, public := Redact(command, values)
+	if redacted != "login bob <token!> <pin!:int>" {
+		t.Fatalf("unexpected command '%s'", redacted)
+	}
+	if diff := deep.Equal(map[string]string{"user": "bob"}, public); diff != nil {
+		t.Fatal(diff)
+	}
+	if got := WithDefaults(command, values); got != "login <user=bob> <token!> <pin!:int>" {
+		t.Fatalf("secret saved as default: '%s'", got)
+	}
+}
+
+func TestParseParams_Store(t *testing.T) {
+	command := "psql -U <user=app> <db_pass@vault:secret/data/prod/db#password> <db_pass>"
+	params := ParseParams(command)
+	if len(params) != 2 || params[1].Name != "db_pass" || params[1].Store != "vault" || params[1].Ref != "secret/data/prod/db#password" || !params[1].Secret {
+		t.Fatalf("unexpected params %+v", params)
+	}
+	redacted, _ := Redact(command, map[string]string{"user": "bob"})
+	if redacted != "psql -U bob <db_pass@vault:secret/data/prod/db#password> <db_pass>" {
+		t.Fatalf("unexpected command '%s'", redacted)
+	}
+	got := FillParams(command, map[string]string{"db_pass": "s3cr3t"})
+	if want := "psql -U <user=app> s3cr3t s3cr3t"; got != want {
+		t.Fatalf("wanted '%s', got '%s'", want, got)
+	}
+}
+
+func TestFillParams(t *testing.T) {
+	got := FillParams("ssh <host> -i <key=~/.ssh/id>", map[string]string{"host": "i-123 abc"})
+	if want := "ssh i-123 abc -i <key=~/.ssh/id>"; got != want {
+		t.Fatalf("wanted '%s', got '%s'", want, got)
+	}
+}
+
+func TestSearchForParams_History(t *testing.T) {
+	History = func(name string) []string {
+		return map[string][]string{
+			"ns":    {"kube-system", "default"},
+			"host":  {"db", "localhost"},
+			"env":   {"qa", "prod"},
+			"token": {"leaked"},
+		}[name]
+	}
+	defer func() { History = nil }()
+
+	SearchForParams([]string{"cmd <ns> <host=localhost> <env=dev|staging|prod> <token!>"})
+	want := []struct {
+		value       string
+		suggestions []string
+	}{
+		{"kube-system", []string{"kube-system", "default"}},
+		{"localhost", []string{"localhost", "db"}},
+		{"prod", nil},
+		{"", nil},
+	}
+	for i, w := range want {
+		p := parameters[i]
+		if got := p.options[p.current]; got != w.value {
+			t.Errorf("%s: wanted %q, got %q", p.name, w.value, got)
+		}
+		if diff := deep.Equal(w.suggestions, p.suggestions); diff != nil {
+			t.Errorf("%s: %v", p.name, diff)
+		}
+	}
+}
+
+func TestSearchForParams_Pick(t *testing.T) {
+	Provide = func(command string) ([]string, error) {
+		return []string{"pod/web-1", "pod/web-2", "pod/web-3"}, nil
+	}
+	var picked []string
+	Pick = func(name string, choices []string) (string, error) {
+		picked = append(picked, name)
+		return choices[len(choices)-1], nil
+	}
+	defer func() { Provide, Pick = nil, nil }()
+
+	SearchForParams([]string{"kubectl -n <ns=default> logs <pod=$(kubectl get pods -o name)> -c <c=app|sidecar> <token!=a|b>"})
+	if diff := deep.Equal([]string{"pod", "c"}, picked); diff != nil {
+		t.Errorf("picked %v", diff)
+	}
+	want := []string{"default", "pod/web-3", "sidecar", "a"}
+	for i, w := range want {
+		p := parameters[i]
+		if got := p.options[p.current]; got != w {
+			t.Errorf("%s: wanted %q, got %q", p.name, w, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package dialog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+const tagHelp = "CTRL-T => tags"
+
+// tagPanel is the list of tags of the embedded fuzzy finder, shown instead
+// of the lines with ctrl-t
+type tagPanel struct {
+	tags     func(line string) []string
+	lineTags [][]string
+	allTags  []string
+	chosen   map[string]bool
+	tagMode  bool
+	// tagMatches are the tags matching the query in the tag list
+	tagMatches []string
+	// lineQuery is the query of the lines while the tags are shown
+	lineQuery string
+}
+
+// initTags collects the tags of the lines
+func (f *finder) initTags(tags func(line string) []string) {
+	f.tags = tags
+	f.chosen = map[string]bool{}
+	if tags == nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, l := range f.lines {
+		lt := tags(ansiRe.ReplaceAllString(l, ""))
+		f.lineTags = append(f.lineTags, lt)
+		for _, t := range lt {
+			if !seen[t] {
+				seen[t] = true
+				f.allTags = append(f.allTags, t)
+			}
+		}
+	}
+	sort.Strings(f.allTags)
+}
+
+// hasChosenTags reports whether the line at i has all the chosen tags
+func (f *finder) hasChosenTags(i int) bool {
+	if len(f.chosen) == 0 {
+		return true
+	}
+	n := 0
+	for _, t := range f.lineTags[i] {
+		if f.chosen[t] {
+			n++
+		}
+	}
+	return n == len(f.chosen)
+}
+
+// chosenTitle lists the chosen tags for the title of the query
+func (f *finder) chosenTitle() string {
+	var tags []string
+	for _, t := range f.allTags {
+		if f.chosen[t] {
+			tags = append(tags, "#"+t)
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return " " + strings.Join(tags, " ")
+}
+
+func (f *finder) filterTags(query string) {
+	type match struct {
+		tag   string
+		score int
+	}
+	var found []match
+	for _, t := range f.allTags {
+		if score, ok := FuzzyMatch(query, t); ok {
+			found = append(found, match{t, score})
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].score > found[b].score })
+	f.tagMatches = nil
+	for _, m := range found {
+		f.tagMatches = append(f.tagMatches, m.tag)
+	}
+	f.cursor = 0
+}
+
+// taggedCount returns the number of lines with the tag
+func (f *finder) taggedCount(tag string) int {
+	n := 0
+	for _, lt := range f.lineTags {
+		for _, t := range lt {
+			if t == tag {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+func (f *finder) drawTags(g *gocui.Gui) error {
+	v, err := g.View(finderListView)
+	if err != nil {
+		return err
+	}
+	v.Clear()
+	for _, t := range f.tagMatches {
+		mark := "  "
+		if f.chosen[t] {
+			mark = "* "
+		}
+		fmt.Fprintf(v, "%s#%s (%d)\n", mark, t, f.taggedCount(t))
+	}
+	f.scroll(v)
+
+	q, _ := g.View(finderQueryView)
+	q.Title = fmt.Sprintf("Tags %d/%d%s (ENTER => apply, TAB => choose, ESC/CTRL-T => back)",
+		len(f.tagMatches), len(f.allTags), f.chosenTitle())
+
+	if p, err := g.View(finderPreviewView); err == nil {
+		p.Clear()
+		p.SetOrigin(0, 0)
+		if len(f.tagMatches) > 0 {
+			tag := f.tagMatches[f.cursor]
+			for i, l := range f.lines {
+				for _, t := range f.lineTags[i] {
+					if t == tag {
+						fmt.Fprintln(p, ansiRe.ReplaceAllString(l, ""))
+						break
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setQuery replaces the text of the query view
+func setQuery(g *gocui.Gui, query string) {
+	q, _ := g.View(finderQueryView)
+	q.Clear()
+	fmt.Fprint(q, query)
+	q.SetCursor(len([]rune(query)), 0)
+}
+
+// toggleTags switches between the lines and the tags, keeping the query
+// of the lines
+func (f *finder) toggleTags(g *gocui.Gui, v *gocui.View) error {
+	q, _ := g.View(finderQueryView)
+	query := strings.TrimSpace(q.Buffer())
+	f.tagMode = !f.tagMode
+	if f.tagMode {
+		f.lineQuery = query
+		setQuery(g, "")
+		f.filter("")
+	} else {
+		setQuery(g, f.lineQuery)
+		f.filter(f.lineQuery)
+	}
+	return f.draw(g)
+}
+
+// chooseTag toggles the highlighted tag
+func (f *finder) chooseTag(g *gocui.Gui, v *gocui.View) error {
+	if len(f.tagMatches) == 0 {
+		return nil
+	}
+	t := f.tagMatches[f.cursor]
+	if f.chosen[t] {
+		delete(f.chosen, t)
+	} else {
+		f.chosen[t] = true
+	}
+	return f.move(1)(g, v)
+}
+
+// applyTags narrows the lines to the chosen tags, or to the highlighted
+// tag if none is chosen
+func (f *finder) applyTags(g *gocui.Gui, v *gocui.View) error {
+	if len(f.chosen) == 0 && len(f.tagMatches) > 0 {
+		f.chosen[f.tagMatches[f.cursor]] = true
+	}
+	return f.toggleTags(g, v)
+}
@@ -3,13 +3,33 @@ package dialog
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/awesome-gocui/gocui"
 )
 
+// SelectedColor and WarningColor are the colors of the highlighted line and
+// of the errors in the dialogs and the embedded fuzzy finder
+var (
+	SelectedColor = gocui.ColorGreen
+	WarningColor  = gocui.ColorRed
+)
+
+// Review asks to confirm the expanded command, shown in the Command view,
+// before the dialog returns it
+var Review bool
+
+// reviewing is set while the expanded command waits for confirmation
+var reviewing bool
+
+const (
+	commandTitle = "Command (TAB/Shift-TAB => next/previous, ENTER => Execute command, Cursor up/down => change optional parameter):"
+	reviewTitle  = "Run this command? (ENTER => run, ESC => back to the parameters)"
+)
+
 func generateView(g *gocui.Gui, p *parameter, coords []int, editable bool) error {
 	desc := p.name
-	fill := p.options[0]
+	fill := p.options[p.current]
 
 	if StringInSlice(desc, views) {
 		return nil
@@ -23,14 +43,30 @@ func generateView(g *gocui.Gui, p *parameter, coords []int, editable bool) error
 	}
 	view, _ := g.View(desc)
 
-	if len(p.options) > 1 {
-		view.Title = desc + " (...)"
-	} else {
-		view.Title = desc
+	if p.choice() {
+		// only one of the options can be picked
+		editable = false
+	}
+	setTitle(view, p, nil)
+	if p.secret {
+		view.Mask = '*'
+	}
+	if editable {
+		view.Editor = gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+			gocui.DefaultEditor.Edit(v, key, ch, mod)
+			// validate while typing
+			param := Param{Name: p.name, Type: p.typ, Options: p.options}
+			setTitle(v, p, param.Validate(viewValue(v)))
+			refreshPreview(g)
+		})
 	}
 	view.Wrap = false
 	view.Autoscroll = true
 	view.Editable = editable
+	if editable {
+		// edit the default value from its end
+		view.SetCursor(len([]rune(fill)), 0)
+	}
 
 	views = append(views, desc)
 
@@ -39,8 +75,68 @@ func generateView(g *gocui.Gui, p *parameter, coords []int, editable bool) error
 	return nil
 }
 
+// setTitle shows the name of the parameter with its type and how to change
+// it, or err
+func setTitle(view *gocui.View, p *parameter, err error) {
+	view.TitleColor = gocui.ColorDefault
+	switch {
+	case err != nil:
+		view.Title = err.Error()
+		view.TitleColor = WarningColor
+	case p.message != "":
+		view.Title = p.name + ": " + p.message
+		view.TitleColor = WarningColor
+	case p.choice():
+		view.Title = choiceTitle(p)
+	case len(p.suggestions) > 1:
+		view.Title = suggestionTitle(p)
+	case p.typ != "":
+		view.Title = fmt.Sprintf("%s (%s)", p.name, p.typ)
+	default:
+		view.Title = p.name
+	}
+}
+
+// viewValue returns the value typed in the view of a parameter
+func viewValue(v *gocui.View) string {
+	return strings.TrimSpace(strings.Replace(v.Buffer(), "\n", "", -1))
+}
+
+// currentValues returns the values of the parameters in their views
+func currentValues(g *gocui.Gui) map[string]string {
+	values := map[string]string{}
+	for _, p := range parameters {
+		if view, err := g.View(p.name); err == nil {
+			values[p.name] = viewValue(view)
+		}
+	}
+	return values
+}
+
+// refreshPreview shows the command with the current values in the Command
+// view. Secret parameters are not shown.
+func refreshPreview(g *gocui.Gui) {
+	if len(views) == 0 {
+		return
+	}
+	view, err := g.View(views[0])
+	if err != nil {
+		return
+	}
+	command, _ := Redact(CurrentCommand, currentValues(g))
+	view.Clear()
+	fmt.Fprint(view, command)
+}
+
 // GenerateParamsLayout generates CUI to receive params
 func GenerateParamsLayout(params map[string][]string, command string) {
+	views = nil
+	idxView = 0
+	curView = -1
+	FinalCommand = ""
+	FilledParams = nil
+	reviewing = false
+
 	g, err := gocui.NewGui(gocui.OutputNormal, false)
 	if err != nil {
 		log.Panicln(err)
@@ -49,18 +145,22 @@ func GenerateParamsLayout(params map[string][]string, command string) {
 
 	g.Highlight = true
 	g.Cursor = true
-	g.SelFgColor = gocui.ColorGreen
+	g.SelFgColor = SelectedColor
 
 	g.SetManagerFunc(layout)
 
 	maxX, maxY := g.Size()
 	generateView(g,
 		&parameter{
-			name:    "Command(TAB => Select next, ENTER => Execute command, Cursor up/down => change optional parameter):",
+			name:    commandTitle,
 			options: []string{command},
 		},
 		[]int{maxX / 10, maxY / 10, (maxX / 2) + (maxX / 3), maxY/10 + 5},
 		false)
+	if v, err := g.View(commandTitle); err == nil {
+		v.Wrap = true
+		v.Autoscroll = false
+	}
 
 	idx := 0
 	for _, p := range parameters {
@@ -74,6 +174,7 @@ func GenerateParamsLayout(params map[string][]string, command string) {
 	}
 
 	initKeybindings(g)
+	refreshPreview(g)
 
 	curView = 0
 	if idx > 0 {
@@ -86,11 +187,11 @@ func GenerateParamsLayout(params map[string][]string, command string) {
 	}
 }
 
-func nextView(g *gocui.Gui) error {
-	next := curView + 1
-	if next > len(views)-1 {
-		next = 0
+func nextView(g *gocui.Gui, delta int) error {
+	if reviewing {
+		return leaveReview(g, nil)
 	}
+	next := (curView + delta + len(views)) % len(views)
 
 	if _, err := g.SetCurrentView(views[next]); err != nil {
 		return err
@@ -110,10 +211,19 @@ func initKeybindings(g *gocui.Gui) error {
 	}
 	if err := g.SetKeybinding("", gocui.KeyTab, gocui.ModNone,
 		func(g *gocui.Gui, v *gocui.View) error {
-			return nextView(g)
+			return nextView(g, 1)
 		}); err != nil {
 		return err
 	}
+	if err := g.SetKeybinding("", gocui.KeyBacktab, gocui.ModNone,
+		func(g *gocui.Gui, v *gocui.View) error {
+			return nextView(g, -1)
+		}); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", gocui.KeyEsc, gocui.ModNone, leaveReview); err != nil {
+		return err
+	}
 
 	if err := g.SetKeybinding("", gocui.KeyArrowUp, gocui.ModNone, updateOptionInViewUp); err != nil {
 		return err
@@ -134,8 +244,8 @@ func updateOptionInView(g *gocui.Gui, ch int) error {
 
 	// Shitfting one view as Command view is not on the parameters list
 	p := parameters[curView-1]
-	if len(p.options) < 2 {
-		return nil
+	if !p.choice() {
+		return updateSuggestionInView(g, p, ch)
 	}
 
 	// If ch(ange) is -1 --> key down
@@ -156,9 +266,62 @@ func updateOptionInView(g *gocui.Gui, ch int) error {
 	view, _ := g.View(views[curView])
 	view.Clear()
 	view.Write([]byte(p.options[p.current]))
+	view.Title = choiceTitle(p)
+	refreshPreview(g)
+	return nil
+}
+
+// updateSuggestionInView replaces the value of a parameter which is not a
+// choice with the next or previous of its suggestions
+func updateSuggestionInView(g *gocui.Gui, p *parameter, ch int) error {
+	if len(p.suggestions) < 2 {
+		return nil
+	}
+	p.current = (p.current + ch + len(p.suggestions)) % len(p.suggestions)
+
+	view, _ := g.View(views[curView])
+	view.Clear()
+	view.Write([]byte(p.suggestions[p.current]))
+	view.SetCursor(len(p.suggestions[p.current]), 0)
+	view.Title = suggestionTitle(p)
+	refreshPreview(g)
 	return nil
 }
 
+// enterReview asks to confirm the expanded command in the Command view
+func enterReview(g *gocui.Gui) error {
+	reviewing = true
+	refreshPreview(g)
+	view, _ := g.View(views[0])
+	view.Title = reviewTitle
+	_, err := g.SetCurrentView(views[0])
+	return err
+}
+
+// leaveReview goes back to the parameter being edited before the review
+func leaveReview(g *gocui.Gui, _ *gocui.View) error {
+	if !reviewing {
+		return nil
+	}
+	reviewing = false
+	view, _ := g.View(views[0])
+	view.Title = commandTitle
+	_, err := g.SetCurrentView(views[curView])
+	return err
+}
+
+func suggestionTitle(p *parameter) string {
+	name := p.name
+	if p.typ != "" {
+		name = fmt.Sprintf("%s (%s)", p.name, p.typ)
+	}
+	return fmt.Sprintf("%s (previous %d/%d, cursor up/down => change)", name, p.current+1, len(p.suggestions))
+}
+
+func choiceTitle(p *parameter) string {
+	return fmt.Sprintf("%s (%d/%d, cursor up/down => choose)", p.name, p.current+1, len(p.options))
+}
+
 func updateOptionInViewUp(g *gocui.Gui, _ *gocui.View) error {
 	return updateOptionInView(g, 1)
 }
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// AlfredSnippet is a snippet of an Alfred snippet collection
+type AlfredSnippet struct {
+	Snippet string `json:"snippet"`
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Keyword string `json:"keyword"`
+}
+
+// ToAlfred writes the snippets as an Alfred snippet collection
+// (.alfredsnippets), a zip of one JSON file per snippet. The name of a
+// snippet is its keyword.
+func ToAlfred(w io.Writer, snippets []snippet.SnippetInfo) error {
+	z := zip.NewWriter(w)
+	for _, s := range snippets {
+		a := AlfredSnippet{
+			Snippet: AlfredPlaceholders(s.Command),
+			UID:     uid(s),
+			Name:    s.Description,
+			Keyword: s.Name,
+		}
+		name := strings.NewReplacer("/", "-", "\\", "-", ":", "-").Replace(s.Description)
+		f, err := z.Create(fmt.Sprintf("%s [%s].json", name, a.UID))
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(map[string]AlfredSnippet{"alfredsnippet": a}); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// AlfredPlaceholders replaces the parameters of a command for Alfred, which
+// has no named placeholders: a parameter becomes its default, and the first
+// one without a default the {cursor}. The others are kept as they are.
+func AlfredPlaceholders(command string) string {
+	cursor := false
+	return dialog.ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p := dialog.ParseParams(s)[0]
+		if v := p.Default(); v != "" && !p.Secret {
+			return v
+		}
+		if !cursor {
+			cursor = true
+			return "{cursor}"
+		}
+		return s
+	})
+}
+
+// uid returns a stable UUID of the snippet, so that importing the snippets
+// again updates them
+func uid(s snippet.SnippetInfo) string {
+	key := s.Name
+	if key == "" {
+		key = s.Description
+	}
+	h := sha1.Sum([]byte(key))
+	return strings.ToUpper(fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16]))
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestAlfredPlaceholders(t *testing.T) {
+	tests := map[string]string{
+		"ssh <host>":                        "ssh {cursor}",
+		"curl <url=localhost:8080> -H <h>":  "curl localhost:8080 -H {cursor}",
+		"kubectl -n <ns=dev|prod> logs <p>": "kubectl -n dev logs {cursor}",
+		"login <user> <token!=abc> <other>": "login {cursor} <token!=abc> <other>",
+		"echo no params":                    "echo no params",
+	}
+	for command, want := range tests {
+		if got := AlfredPlaceholders(command); got != want {
+			t.Errorf("AlfredPlaceholders(%s) = %s, want %s", command, got, want)
+		}
+	}
+}
+
+func TestToAlfred(t *testing.T) {
+	snippets := []snippet.SnippetInfo{
+		{Name: "logs", Description: "tail k8s/logs", Command: "kubectl logs <pod>"},
+		{Description: "greet", Command: "echo hello"},
+	}
+	var buf bytes.Buffer
+	if err := ToAlfred(&buf, snippets); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(z.File) != 2 || !strings.HasPrefix(z.File[0].Name, "tail k8s-logs [") {
+		t.Fatalf("unexpected files %v", z.File)
+	}
+	f, _ := z.File[0].Open()
+	var got map[string]AlfredSnippet
+	if err := json.NewDecoder(f).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	a := got["alfredsnippet"]
+	if a.Snippet != "kubectl logs {cursor}" || a.Keyword != "logs" || a.Name != "tail k8s/logs" || len(a.UID) != 36 {
+		t.Errorf("unexpected snippet %+v", a)
+	}
+	if a.UID != uid(snippets[0]) {
+		t.Error("the uid is not stable")
+	}
+}
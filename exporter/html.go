@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+// untagged is the group of the snippets without tags
+const untagged = "untagged"
+
+// HTMLGroup is the snippets of a tag in the HTML cheatsheet
+type HTMLGroup struct {
+	Tag      string
+	Snippets []snippet.SnippetInfo
+}
+
+// ToHTML writes the snippets as a self-contained HTML cheatsheet, grouped by
+// tag, with a search field and a copy button for every command. A snippet
+// with several tags is in each of their groups.
+func ToHTML(w io.Writer, title string, snippets []snippet.SnippetInfo) error {
+	return htmlTemplate.Execute(w, struct {
+		Title  string
+		Count  int
+		Groups []HTMLGroup
+	}{title, len(snippets), GroupByTag(snippets)})
+}
+
+// GroupByTag returns the snippets by tag in the order of the tags, and the
+// snippets without tags last
+func GroupByTag(snippets []snippet.SnippetInfo) []HTMLGroup {
+	byTag := map[string][]snippet.SnippetInfo{}
+	for _, s := range snippets {
+		if len(s.Tag) == 0 {
+			byTag[untagged] = append(byTag[untagged], s)
+		}
+		for _, t := range s.Tag {
+			byTag[t] = append(byTag[t], s)
+		}
+	}
+	var groups []HTMLGroup
+	for t, s := range byTag {
+		if t != untagged {
+			groups = append(groups, HTMLGroup{Tag: t, Snippets: s})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Tag < groups[j].Tag })
+	if s, ok := byTag[untagged]; ok {
+		groups = append(groups, HTMLGroup{Tag: untagged, Snippets: s})
+	}
+	return groups
+}
+
+var htmlTemplate = template.Must(template.New("cheatsheet").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 960px; padding: 1em; color: #24292f; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; margin-top: 1.5em; }
+#search { width: 100%; box-sizing: border-box; padding: .5em; font-size: 1em; border: 1px solid #d0d7de; border-radius: 6px; }
+.snippet { margin: .8em 0; }
+.description { font-weight: 600; }
+.tag { display: inline-block; font-size: .75em; background: #ddf4ff; color: #0969da; border-radius: 1em; padding: 0 .6em; margin-left: .3em; }
+.command { display: flex; align-items: flex-start; background: #f6f8fa; border-radius: 6px; margin-top: .3em; }
+pre { flex: 1; margin: 0; padding: .6em; overflow-x: auto; white-space: pre-wrap; font-size: .9em; }
+button { margin: .4em; cursor: pointer; border: 1px solid #d0d7de; border-radius: 6px; background: #fff; }
+.notes { font-size: .9em; color: #57606a; white-space: pre-wrap; margin-top: .3em; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="Search {{.Count}} snippets" autofocus>
+{{range .Groups}}<section class="group">
+<h2>{{.Tag}}</h2>
+{{range .Snippets}}<div class="snippet" data-search="{{.Description}} {{range .Tag}}{{.}} {{end}}{{.Command}} {{.Notes}}">
+<div><span class="description">{{.Description}}</span>{{range .Tag}}<span class="tag">{{.}}</span>{{end}}</div>
+<div class="command"><pre><code>{{.Command}}</code></pre><button type="button" class="copy">Copy</button></div>
+{{if .Notes}}<div class="notes">{{.Notes}}</div>
+{{end}}</div>
+{{end}}</section>
+{{end}}<script>
+document.querySelectorAll("button.copy").forEach(function (b) {
+  b.addEventListener("click", function () {
+    var text = b.parentNode.querySelector("code").textContent;
+    var done = function () { b.textContent = "Copied"; setTimeout(function () { b.textContent = "Copy"; }, 1500); };
+    if (navigator.clipboard) {
+      navigator.clipboard.writeText(text).then(done);
+      return;
+    }
+    var t = document.createElement("textarea");
+    t.value = text;
+    document.body.appendChild(t);
+    t.select();
+    document.execCommand("copy");
+    document.body.removeChild(t);
+    done();
+  });
+});
+document.getElementById("search").addEventListener("input", function (e) {
+  var words = e.target.value.toLowerCase().split(/\s+/).filter(Boolean);
+  document.querySelectorAll("section.group").forEach(function (g) {
+    var shown = 0;
+    g.querySelectorAll(".snippet").forEach(function (s) {
+      var text = s.getAttribute("data-search").toLowerCase();
+      var match = words.every(function (w) { return text.indexOf(w) >= 0; });
+      s.classList.toggle("hidden", !match);
+      if (match) shown++;
+    });
+    g.classList.toggle("hidden", shown === 0);
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestGroupByTag(t *testing.T) {
+	snippets := []snippet.SnippetInfo{
+		{Description: "pods", Tag: []string{"k8s", "debug"}},
+		{Description: "greet"},
+		{Description: "nodes", Tag: []string{"k8s"}},
+	}
+	var got []string
+	for _, g := range GroupByTag(snippets) {
+		var names []string
+		for _, s := range g.Snippets {
+			names = append(names, s.Description)
+		}
+		got = append(got, g.Tag+": "+strings.Join(names, ", "))
+	}
+	want := "debug: pods; k8s: pods, nodes; untagged: greet"
+	if strings.Join(got, "; ") != want {
+		t.Errorf("GroupByTag() = %s, want %s", strings.Join(got, "; "), want)
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	snippets := []snippet.SnippetInfo{
+		{Description: "grep <b>", Command: `grep -r "<pattern>" . | less`, Tag: []string{"search"}, Notes: "wraps grep"},
+	}
+	var buf bytes.Buffer
+	if err := ToHTML(&buf, "Team & ops", snippets); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"<title>Team &amp; ops</title>",
+		"<h2>search</h2>",
+		"<code>grep -r &#34;&lt;pattern&gt;&#34; . | less</code>",
+		`<span class="description">grep &lt;b&gt;</span>`,
+		`<div class="notes">wraps grep</div>`,
+		"Search 1 snippets",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("the cheatsheet has no %s", want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+// vaultMarker is the front matter of the notes written by pet, which are
+// removed when their snippet is
+const vaultMarker = "source: pet"
+
+// noteNameReplacer replaces the characters which Obsidian and Notion do not
+// allow in the names of the notes
+var noteNameReplacer = strings.NewReplacer(
+	"/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", `"`, "'", "<", "(", ">", ")",
+	"|", "-", "#", "", "^", "", "[", "(", "]", ")", "\n", " ",
+)
+
+// VaultResult is what ToMarkdownVault changed in the directory
+type VaultResult struct {
+	Written   []string
+	Removed   []string
+	Unchanged int
+}
+
+// ToMarkdownVault writes a markdown note per snippet into the directory,
+// e.g. an Obsidian vault: the front matter has the tags, and a code block
+// the command. Only the notes which changed are written, and the notes of
+// pet whose snippet is gone are removed.
+func ToMarkdownVault(dir string, snippets []snippet.SnippetInfo) (VaultResult, error) {
+	var result VaultResult
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return result, err
+	}
+	notes := map[string][]byte{}
+	// the names differing by case are the same file on macOS and Windows
+	taken := map[string]bool{}
+	for _, s := range snippets {
+		name := noteName(s.Description)
+		for i := 2; taken[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s (%d)", noteName(s.Description), i)
+		}
+		taken[strings.ToLower(name)] = true
+		notes[name+".md"] = Note(s)
+	}
+
+	names := make([]string, 0, len(notes))
+	for name := range notes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		if old, err := os.ReadFile(file); err == nil && bytes.Equal(old, notes[name]) {
+			result.Unchanged++
+			continue
+		} else if err == nil && !isPetNote(old) {
+			return result, fmt.Errorf("%s is not a note of pet, it is not overwritten", file)
+		}
+		if err := os.WriteFile(file, notes[name], 0o644); err != nil {
+			return result, err
+		}
+		result.Written = append(result.Written, name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return result, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" || notes[e.Name()] != nil {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		if data, err := os.ReadFile(file); err != nil || !isPetNote(data) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return result, err
+		}
+		result.Removed = append(result.Removed, e.Name())
+	}
+	return result, nil
+}
+
+// Note returns the markdown note of the snippet
+func Note(s snippet.SnippetInfo) []byte {
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	b.WriteString(vaultMarker + "\n")
+	if s.Name != "" {
+		fmt.Fprintf(&b, "name: %s\n", strconv.Quote(s.Name))
+	}
+	if s.Path != "" {
+		fmt.Fprintf(&b, "path: %s\n", strconv.Quote(s.Path))
+	}
+	if len(s.Tag) > 0 {
+		var tags []string
+		for _, t := range s.Tag {
+			// the tags of Obsidian have no spaces
+			tags = append(tags, strconv.Quote(strings.Join(strings.Fields(t), "-")))
+		}
+		fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(tags, ", "))
+	}
+	if s.CreatedAt != nil && !s.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "created: %s\n", s.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if s.UpdatedAt != nil && !s.UpdatedAt.IsZero() {
+		fmt.Fprintf(&b, "updated: %s\n", s.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", strings.Replace(s.Description, "\n", " ", -1))
+	language := s.Shell
+	if language == "" {
+		language = "sh"
+	}
+	writeCode(&b, language, s.Command)
+	if s.Output != "" {
+		b.WriteString("\n## Output\n\n")
+		writeCode(&b, "", s.Output)
+	}
+	if s.Notes != "" {
+		b.WriteString("\n" + strings.TrimRight(s.Notes, "\n") + "\n")
+	}
+	return b.Bytes()
+}
+
+// writeCode writes a fenced code block longer than the fences of the code
+func writeCode(b *bytes.Buffer, language, code string) {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, language, strings.TrimRight(code, "\n"), fence)
+}
+
+// noteName returns the name of the note of a snippet, without the
+// characters not allowed in the names of the notes
+func noteName(description string) string {
+	name := strings.TrimSpace(noteNameReplacer.Replace(description))
+	name = strings.TrimLeft(name, ".")
+	if r := []rune(name); len(r) > 100 {
+		name = strings.TrimSpace(string(r[:100]))
+	}
+	if name == "" {
+		name = "snippet"
+	}
+	return name
+}
+
+// isPetNote reports whether the note was written by pet
+func isPetNote(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || scanner.Text() != "---" {
+		return false
+	}
+	return scanner.Scan() && scanner.Text() == vaultMarker
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestNote(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := snippet.SnippetInfo{
+		Name:        "logs",
+		Description: "tail logs",
+		Command:     "kubectl logs <pod>\n",
+		Tag:         []string{"k8s", "on call"},
+		Output:      "hello",
+		Notes:       "see the runbook",
+		CreatedAt:   &created,
+	}
+	want := "---\n" +
+		"source: pet\n" +
+		"name: \"logs\"\n" +
+		"tags: [\"k8s\", \"on-call\"]\n" +
+		"created: 2024-01-02T03:04:05Z\n" +
+		"---\n\n" +
+		"# tail logs\n\n" +
+		"```sh\nkubectl logs <pod>\n```\n" +
+		"\n## Output\n\n```\nhello\n```\n" +
+		"\nsee the runbook\n"
+	if got := string(Note(s)); got != want {
+		t.Errorf("Note() = %q, want %q", got, want)
+	}
+
+	fenced := string(Note(snippet.SnippetInfo{Description: "md", Command: "echo '```'", Shell: "bash"}))
+	if !strings.Contains(fenced, "````bash\necho '```'\n````\n") {
+		t.Errorf("the fence of the code is not longer than its fences: %q", fenced)
+	}
+}
+
+func TestNoteName(t *testing.T) {
+	tests := map[string]string{
+		"tail logs":    "tail logs",
+		"a/b: c?":      "a-b- c-",
+		"[x] #tag":     "(x) tag",
+		"  ..hidden  ": "hidden",
+		"///":          "---",
+		"":             "snippet",
+	}
+	for description, want := range tests {
+		if got := noteName(description); got != want {
+			t.Errorf("noteName(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
+
+func TestToMarkdownVault(t *testing.T) {
+	dir := t.TempDir()
+	snippets := []snippet.SnippetInfo{
+		{Description: "tail logs", Command: "kubectl logs <pod>"},
+		{Description: "greet", Command: "echo hello"},
+		{Description: "Greet", Command: "echo Hello"},
+	}
+	result, err := ToMarkdownVault(dir, snippets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal([]string{"Greet (2).md", "greet.md", "tail logs.md"}, result.Written); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// a note of the user is kept
+	if err := os.WriteFile(filepath.Join(dir, "mine.md"), []byte("# mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snippets = []snippet.SnippetInfo{
+		{Description: "tail logs", Command: "kubectl logs -f <pod>"},
+		{Description: "greet", Command: "echo hello"},
+	}
+	result, err = ToMarkdownVault(dir, snippets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VaultResult{Written: []string{"tail logs.md"}, Removed: []string{"Greet (2).md"}, Unchanged: 1}
+	if diff := deep.Equal(want, result); diff != nil {
+		t.Fatal(diff)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if diff := deep.Equal([]string{"greet.md", "mine.md", "tail logs.md"}, names); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// nor is it overwritten
+	if _, err := ToMarkdownVault(dir, []snippet.SnippetInfo{{Description: "mine", Command: "ls"}}); err == nil {
+		t.Fatal("the note of the user is overwritten")
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "mine.md")); string(b) != "# mine\n" {
+		t.Errorf("mine.md = %q", b)
+	}
+}
@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// RaycastSnippet is a snippet of the JSON imported by Raycast
+type RaycastSnippet struct {
+	Name    string `json:"name"`
+	Text    string `json:"text"`
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// ToRaycast writes the snippets as the JSON imported by Raycast. The name of
+// a snippet is its keyword.
+func ToRaycast(w io.Writer, snippets []snippet.SnippetInfo) error {
+	exported := []RaycastSnippet{}
+	for _, s := range snippets {
+		exported = append(exported, RaycastSnippet{
+			Name:    s.Description,
+			Text:    RaycastPlaceholders(s.Command),
+			Keyword: s.Name,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(exported)
+}
+
+// RaycastPlaceholders replaces the parameters of a command with the
+// arguments of Raycast ({argument name="port" default="8080"}). A list of
+// values defaults to the first one, secrets have no default.
+func RaycastPlaceholders(command string) string {
+	return dialog.ParamPattern.ReplaceAllStringFunc(command, func(s string) string {
+		p := dialog.ParseParams(s)[0]
+		arg := `{argument name="` + p.Name + `"`
+		if v := p.Default(); v != "" && !p.Secret && !strings.ContainsAny(v, `"{}`) {
+			arg += ` default="` + v + `"`
+		}
+		return arg + "}"
+	})
+}
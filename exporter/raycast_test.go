@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestRaycastPlaceholders(t *testing.T) {
+	tests := map[string]string{
+		"ssh <host>":                    `ssh {argument name="host"}`,
+		"curl <port:int=8080>":          `curl {argument name="port" default="8080"}`,
+		"deploy <env=dev|prod>":         `deploy {argument name="env" default="dev"}`,
+		"login <token!=abc>":            `login {argument name="token"}`,
+		`cd <dir=C:\Users>`:             `cd {argument name="dir" default="C:\Users"}`,
+		"echo <msg={x}> no params here": `echo {argument name="msg"} no params here`,
+	}
+	for command, want := range tests {
+		if got := RaycastPlaceholders(command); got != want {
+			t.Errorf("RaycastPlaceholders(%s) = %s, want %s", command, got, want)
+		}
+	}
+}
+
+func TestToRaycast(t *testing.T) {
+	snippets := []snippet.SnippetInfo{
+		{Name: "logs", Description: "tail logs", Command: "kubectl logs <pod>"},
+		{Description: "greet", Command: "echo hello"},
+	}
+	var buf bytes.Buffer
+	if err := ToRaycast(&buf, snippets); err != nil {
+		t.Fatal(err)
+	}
+	var got []RaycastSnippet
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []RaycastSnippet{
+		{Name: "tail logs", Text: `kubectl logs {argument name="pod"}`, Keyword: "logs"},
+		{Name: "greet", Text: "echo hello"},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,63 @@
+// Package i18n translates the messages of pet. The messages are identified
+// by their English text, which is shown when there is no translation.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Languages are the languages pet is translated in
+var Languages = []string{"en", "ja"}
+
+// catalogs are the translations of the messages by language
+var catalogs = map[string]map[string]string{
+	"ja": ja,
+}
+
+// language is the language of the messages
+var language = "en"
+
+// SetLanguage sets the language of the messages, e.g. ja or ja_JP.UTF-8.
+// Without a translation, the messages are in English.
+func SetLanguage(lang string) {
+	language = Detect(lang)
+}
+
+// Language returns the language of the messages
+func Language() string {
+	return language
+}
+
+// Detect returns the language given, or else the one of the locale
+// ($LC_ALL, $LC_MESSAGES or $LANG), which pet is translated in; en if it is
+// not translated.
+func Detect(lang string) string {
+	for _, l := range []string{lang, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if l == "" {
+			continue
+		}
+		// ja_JP.UTF-8
+		l = strings.ToLower(strings.FieldsFunc(l, func(r rune) bool { return r == '_' || r == '-' || r == '.' || r == '@' })[0])
+		for _, s := range Languages {
+			if l == s {
+				return l
+			}
+		}
+		return "en"
+	}
+	return "en"
+}
+
+// T returns the translation of the message, formatted with the arguments
+// like fmt.Sprintf if there are any
+func T(message string, a ...interface{}) string {
+	if t, ok := catalogs[language][message]; ok {
+		message = t
+	}
+	if len(a) > 0 {
+		return fmt.Sprintf(message, a...)
+	}
+	return message
+}
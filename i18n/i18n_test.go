@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		lang, lcAll, lang2 string
+		want               string
+	}{
+		{"ja", "", "", "ja"},
+		{"", "", "ja_JP.UTF-8", "ja"},
+		{"", "ja_JP.eucJP", "en_US.UTF-8", "ja"},
+		{"en", "", "ja_JP.UTF-8", "en"},
+		{"", "", "fr_FR.UTF-8", "en"},
+		{"", "C", "ja_JP.UTF-8", "en"},
+		{"", "", "", "en"},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang2)
+		if got := Detect(tt.lang); got != tt.want {
+			t.Errorf("Detect(%q) with LC_ALL=%q LANG=%q = %s, want %s", tt.lang, tt.lcAll, tt.lang2, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage("en")
+
+	SetLanguage("en")
+	if got := T("Imported %d snippets", 3); got != "Imported 3 snippets" {
+		t.Errorf("T() = %s", got)
+	}
+	SetLanguage("ja")
+	if got := T("Imported %d snippets", 3); got != "3 個のスニペットをインポートしました" {
+		t.Errorf("T() = %s", got)
+	}
+	if got := T("not translated 100%"); got != "not translated 100%" {
+		t.Errorf("T() = %s", got)
+	}
+}
+
+// verbs matches the verbs of fmt
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for message, translation := range catalog {
+			if diff := deep.Equal(verbs.FindAllString(message, -1), verbs.FindAllString(translation, -1)); diff != nil {
+				t.Errorf("%s: the verbs of %q differ: %v", lang, message, diff)
+			}
+		}
+	}
+}
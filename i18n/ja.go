@@ -0,0 +1,48 @@
+package i18n
+
+// ja are the Japanese messages
+var ja = map[string]string{
+	// pet new
+	"Command>":                  "コマンド>",
+	"Command> ":                 "コマンド> ",
+	"Description> ":             "説明> ",
+	"Tag> ":                     "タグ> ",
+	"Warning:":                  "警告:",
+	"[%s] has the same command": "[%s] に同じコマンドがあります",
+	"tag %s looks like %s":      "タグ %s は %s に似ています",
+	"Update its description and tags instead? [y/N]: ": "代わりに説明とタグを更新しますか? [y/N]: ",
+
+	// pet exec
+	"Snippet [%s] is for %s. Run it on %s?":                                 "スニペット [%s] は %s 用です。%s で実行しますか?",
+	"Snippet [%s] requires %s, not found on PATH. Run it anyway?":           "スニペット [%s] には %s が必要ですが PATH にありません。それでも実行しますか?",
+	"This snippet is marked as dangerous. Run it?":                          "このスニペットは危険とマークされています。実行しますか?",
+	"This snippet is marked as dangerous. Run it with elevated privileges?": "このスニペットは危険とマークされています。管理者権限で実行しますか?",
+	"Run this snippet with elevated privileges?":                            "このスニペットを管理者権限で実行しますか?",
+	"shellcheck found problems. Run it anyway?":                             "shellcheck が問題を見つけました。それでも実行しますか?",
+	"Snippet [%s] failed. Run [%s]?":                                        "スニペット [%s] が失敗しました。[%s] を実行しますか?",
+	"canceled":                                                              "キャンセルしました",
+
+	// pet run
+	"Press Enter to continue ":                 "Enter キーで続行します ",
+	"Run this step? [y]es, [s]kip or [q]uit: ": "このステップを実行しますか? [y]実行, [s]スキップ, [q]終了: ",
+	"[r]etry, [s]kip or [q]uit: ":              "[r]再実行, [s]スキップ, [q]終了: ",
+
+	// pet prune and pet merge
+	"  (a)rchive, (d)elete, (k)eep, (q)uit [k]: ":              "  (a)アーカイブ, (d)削除, (k)残す, (q)終了 [k]: ",
+	"  keep (l)ocal, take (t)heirs, keep (b)oth, (q)uit [l]: ": "  (l)ローカルを残す, (t)相手を採用, (b)両方残す, (q)終了 [l]: ",
+
+	// pet import
+	"Imported %d snippets":      "%d 個のスニペットをインポートしました",
+	"No new snippets to import": "インポートする新しいスニペットはありません",
+
+	// pet restore
+	"Restore the snippets from %s?": "%s からスニペットを復元しますか?",
+
+	// sync
+	" Getting Gist...":            " Gist を取得しています...",
+	" Creating Gist...":           " Gist を作成しています...",
+	" Updating Gist...":           " Gist を更新しています...",
+	" Getting GitLab Snippet...":  " GitLab スニペットを取得しています...",
+	" Creating GitLab Snippet...": " GitLab スニペットを作成しています...",
+	" Updating GitLab Snippet...": " GitLab スニペットを更新しています...",
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AtuinCommand is a command of the atuin history and how many times it ran
+type AtuinCommand struct {
+	Command string
+	Count   int
+}
+
+// AtuinFile returns the history database of atuin ($ATUIN_DB_PATH, or
+// history.db in its data directory)
+func AtuinFile() string {
+	if f := os.Getenv("ATUIN_DB_PATH"); f != "" {
+		return f
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, _ := os.UserHomeDir()
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "atuin", "history.db")
+}
+
+// AtuinQuery selects the n most frequent successful commands of the atuin
+// history as the CSV read by FromAtuin, the most recent first among the
+// same count
+func AtuinQuery(n int) string {
+	return fmt.Sprintf(`SELECT command, count(*) AS count FROM history
+WHERE deleted_at IS NULL AND exit = 0 AND trim(command) != ''
+GROUP BY command ORDER BY count DESC, max(timestamp) DESC LIMIT %d`, n)
+}
+
+// FromAtuin returns the commands selected by AtuinQuery. Commands differing
+// only by their surrounding spaces are merged.
+func FromAtuin(r io.Reader) ([]AtuinCommand, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the atuin history: %v", err)
+	}
+	var commands []AtuinCommand
+	index := map[string]int{}
+	for i, row := range rows {
+		if len(row) != 2 || i == 0 && row[0] == "command" {
+			continue
+		}
+		count, err := strconv.Atoi(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse the atuin history: invalid count %q", row[1])
+		}
+		command := strings.TrimSpace(row[0])
+		if j, ok := index[command]; ok {
+			commands[j].Count += count
+			continue
+		}
+		index[command] = len(commands)
+		commands = append(commands, AtuinCommand{Command: command, Count: count})
+	}
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].Count > commands[j].Count })
+	return commands, nil
+}
@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestFromAtuin(t *testing.T) {
+	out := `command,count
+"git status",12
+"kubectl get pods -n ""prod""",4
+"git status ",9
+"for f in *; do
+  echo $f
+done",2
+`
+	got, err := FromAtuin(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AtuinCommand{
+		{Command: "git status", Count: 21},
+		{Command: `kubectl get pods -n "prod"`, Count: 4},
+		{Command: "for f in *; do\n  echo $f\ndone", Count: 2},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := FromAtuin(strings.NewReader("command,count\nls,many\n")); err == nil {
+		t.Error("FromAtuin() of an invalid count succeeded")
+	}
+}
+
+func TestAtuinFile(t *testing.T) {
+	t.Setenv("ATUIN_DB_PATH", "")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if got := AtuinFile(); got != "/data/atuin/history.db" && got != `\data\atuin\history.db` {
+		t.Errorf("AtuinFile() = %s", got)
+	}
+	t.Setenv("ATUIN_DB_PATH", "/tmp/h.db")
+	if got := AtuinFile(); got != "/tmp/h.db" {
+		t.Errorf("AtuinFile() = %s", got)
+	}
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+var (
+	// dashPlaceholder is a placeholder of Dash (__name__)
+	dashPlaceholder = regexp.MustCompile(`__([A-Za-z][\w -]*?)__`)
+	// dashVariable is a variable of Dash (@clipboard)
+	dashVariable = regexp.MustCompile(`@(cursor|clipboard|date|time)\b`)
+	// dashVariables are the variables of Dash in pet
+	dashVariables = map[string]string{
+		"cursor":    "",
+		"clipboard": "<clipboard>",
+		"date":      `{{date "2006-01-02"}}`,
+		"time":      `{{date "15:04"}}`,
+	}
+)
+
+// DashQuery selects the snippets of the SQLite library of Dash
+// (Snippets.dash) as the CSV read by FromDash
+const DashQuery = `SELECT s.title, s.body, s.syntax, group_concat(t.tag, ',') AS tags
+FROM snippets s LEFT JOIN tagsIndex i ON i.sid = s.sid LEFT JOIN tags t ON t.tid = i.tid
+GROUP BY s.sid ORDER BY s.sid`
+
+// FromDash returns the snippets of a CSV export of Dash with a header of
+// title (the abbreviation), body, syntax and tags (separated by commas).
+// The placeholders (__name__) are the parameters of the snippets, @date and
+// @time template functions and @clipboard a parameter.
+func FromDash(r io.Reader) ([]snippet.SnippetInfo, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the Dash snippets: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "body"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the Dash snippets have no %s column", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var snippets []snippet.SnippetInfo
+	for _, row := range rows[1:] {
+		body := strings.TrimRight(field(row, "body"), "\n")
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+		body = dashPlaceholder.ReplaceAllStringFunc(body, func(s string) string {
+			return "<" + paramName(dashPlaceholder.FindStringSubmatch(s)[1]) + ">"
+		})
+		body = dashVariable.ReplaceAllStringFunc(body, func(s string) string {
+			return dashVariables[s[1:]]
+		})
+		var tags []string
+		for _, t := range strings.Split(field(row, "tags"), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		snippets = append(snippets, snippet.SnippetInfo{
+			Description: field(row, "title"),
+			Command:     body,
+			Tag:         tags,
+			Shell:       shellOf(field(row, "syntax")),
+		})
+	}
+	return snippets, nil
+}
@@ -0,0 +1,34 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestFromDash(t *testing.T) {
+	export := `title,body,syntax,tags
+gco,git checkout __branch name__@cursor,Shell,"git,vcs"
+bk,"cp __file_name__ __file_name__.@date.bak",Bash,
+paste,echo @clipboard at @time,,
+empty,,,
+`
+	got, err := FromDash(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []snippet.SnippetInfo{
+		{Description: "gco", Command: "git checkout <branch_name>", Tag: []string{"git", "vcs"}},
+		{Description: "bk", Command: `cp <file_name> <file_name>.{{date "2006-01-02"}}.bak`, Shell: "bash"},
+		{Description: "paste", Command: `echo <clipboard> at {{date "15:04"}}`},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := FromDash(strings.NewReader("name,command\na,b\n")); err == nil {
+		t.Error("FromDash() without a title and a body succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HistoryFile returns the history file of the current shell ($HISTFILE,
+// otherwise guessed from $SHELL, or that of PowerShell on Windows)
+func HistoryFile() string {
+	if f := os.Getenv("HISTFILE"); f != "" {
+		return f
+	}
+	if os.Getenv("SHELL") == "" && runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "Microsoft", "Windows", "PowerShell", "PSReadLine", "ConsoleHost_history.txt")
+	}
+	home, _ := os.UserHomeDir()
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "zsh":
+		return filepath.Join(home, ".zsh_history")
+	case "fish":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "fish", "fish_history")
+	}
+	return filepath.Join(home, ".bash_history")
+}
+
+// ParseHistory returns the commands of a bash, zsh (also extended format)
+// or fish history, oldest first.
+func ParseHistory(r io.Reader) ([]string, error) {
+	var commands []string
+	var continued bool
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// multi-line zsh entries end with a backslash
+		if continued && len(commands) > 0 {
+			commands[len(commands)-1] += "\n" + strings.TrimSuffix(line, "\\")
+			continued = strings.HasSuffix(line, "\\")
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			// fish
+			line = strings.TrimPrefix(line, "- cmd: ")
+			line = strings.NewReplacer(`\n`, "\n", `\\`, `\`).Replace(line)
+		case strings.HasPrefix(line, "  when: ") || strings.HasPrefix(line, "  paths:") || strings.HasPrefix(line, "    - "):
+			continue
+		case strings.HasPrefix(line, ": ") && strings.Contains(line, ";"):
+			// zsh extended history ": <timestamp>:<duration>;<command>"
+			line = line[strings.Index(line, ";")+1:]
+		case strings.HasPrefix(line, "#") && len(line) > 1 && isDigits(line[1:]):
+			// bash HISTTIMEFORMAT timestamps
+			continue
+		}
+
+		continued = strings.HasSuffix(line, "\\")
+		line = strings.TrimSuffix(line, "\\")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read history: %v", err)
+	}
+	return commands, nil
+}
+
+// RecentCommands returns the last n distinct commands, most recent first.
+func RecentCommands(commands []string, n int) []string {
+	seen := map[string]bool{}
+	var recent []string
+	for i := len(commands) - 1; i >= 0 && (n <= 0 || len(recent) < n); i-- {
+		c := strings.TrimSpace(commands[i])
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		recent = append(recent, c)
+	}
+	return recent
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history string
+		want    []string
+	}{
+		{
+			name:    "bash",
+			history: "ls -la\n#1700000000\ngit status\n\n",
+			want:    []string{"ls -la", "git status"},
+		},
+		{
+			name:    "zsh extended",
+			history: ": 1700000000:0;ls -la\n: 1700000001:0;for f in *; do\\\necho $f\\\ndone\n",
+			want:    []string{"ls -la", "for f in *; do\necho $f\ndone"},
+		},
+		{
+			name:    "fish",
+			history: "- cmd: ls -la\n  when: 1700000000\n- cmd: echo a\\nb\n  when: 1700000001\n  paths:\n    - a\n",
+			want:    []string{"ls -la", "echo a\nb"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseHistory(strings.NewReader(tt.history))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(tt.want, got); diff != nil {
+			t.Fatalf("%s: %v", tt.name, diff)
+		}
+	}
+}
+
+func TestRecentCommands(t *testing.T) {
+	got := RecentCommands([]string{"a", "b", "a", "c", "b"}, 2)
+	if diff := deep.Equal([]string{"b", "c"}, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
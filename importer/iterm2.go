@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+// iTermVariable is a user variable interpolated in a snippet of iTerm2
+// (\(user.name))
+var iTermVariable = regexp.MustCompile(`\\\(user\.([A-Za-z_]\w*)\)`)
+
+// ITerm2File returns the preferences of iTerm2, which have its snippets
+func ITerm2File() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Preferences", "com.googlecode.iterm2.plist")
+}
+
+// FromITerm2 returns the snippets of iTerm2, read from its preferences (an
+// XML plist, see plutil -convert xml1) or from a JSON array of snippets. The
+// interpolated user variables (\(user.name)) become parameters.
+func FromITerm2(r io.Reader) ([]snippet.SnippetInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		err = json.Unmarshal(trimmed, &root)
+	} else {
+		root, err = decodePlist(xml.NewDecoder(bytes.NewReader(data)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the iTerm2 snippets: %v", err)
+	}
+	if prefs, ok := root.(map[string]interface{}); ok {
+		root = prefs["Snippets"]
+	}
+	items, ok := root.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no snippets of iTerm2 found")
+	}
+
+	var snippets []snippet.SnippetInfo
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, _ := fields["value"].(string)
+		value = strings.TrimRight(value, "\n")
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		description, _ := fields["title"].(string)
+		if description == "" {
+			description = strings.SplitN(value, "\n", 2)[0]
+		}
+		var tags []string
+		if list, ok := fields["tags"].([]interface{}); ok {
+			for _, t := range list {
+				if t, ok := t.(string); ok && t != "" {
+					tags = append(tags, t)
+				}
+			}
+		}
+		snippets = append(snippets, snippet.SnippetInfo{
+			Description: description,
+			Command:     iTermVariable.ReplaceAllString(value, "<$1>"),
+			Tag:         tags,
+		})
+	}
+	return snippets, nil
+}
+
+// decodePlist returns the value of an XML plist: dicts are maps, arrays
+// slices and the other values strings
+func decodePlist(d *xml.Decoder) (interface{}, error) {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return plistValue(d, start)
+		}
+	}
+}
+
+func plistValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		var key string
+		for {
+			t, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := t.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				v, err := plistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		array := []interface{}{}
+		for {
+			t, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := t.(type) {
+			case xml.StartElement:
+				v, err := plistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, v)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local, d.Skip()
+	}
+	var s string
+	err := d.DecodeElement(&s, &start)
+	return s, err
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestFromITerm2(t *testing.T) {
+	want := []snippet.SnippetInfo{
+		{Description: "ssh", Command: "ssh <host> -p 22", Tag: []string{"remote"}},
+		{Description: "ls -la", Command: "ls -la\necho done"},
+	}
+
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AppleAntiAliasingThreshold</key>
+	<integer>1</integer>
+	<key>New Bookmarks</key>
+	<array>
+		<dict>
+			<key>Name</key>
+			<string>Default</string>
+			<key>Blinking Cursor</key>
+			<false/>
+		</dict>
+	</array>
+	<key>Snippets</key>
+	<array>
+		<dict>
+			<key>guid</key>
+			<string>5F1A</string>
+			<key>tags</key>
+			<array>
+				<string>remote</string>
+			</array>
+			<key>title</key>
+			<string>ssh</string>
+			<key>value</key>
+			<string>ssh \(user.host) -p 22</string>
+		</dict>
+		<dict>
+			<key>title</key>
+			<string></string>
+			<key>value</key>
+			<string>ls -la
+echo done
+</string>
+		</dict>
+		<dict>
+			<key>title</key>
+			<string>empty</string>
+			<key>value</key>
+			<string></string>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+	got, err := FromITerm2(strings.NewReader(plist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	json := `[
+  {"title": "ssh", "value": "ssh \\(user.host) -p 22", "tags": ["remote"]},
+  {"value": "ls -la\necho done"}
+]`
+	got, err = FromITerm2(strings.NewReader(json))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := FromITerm2(strings.NewReader(`{"Window Arrangements": {}}`)); err == nil {
+		t.Error("preferences without snippets are parsed")
+	}
+}
@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+var (
+	justNameRe      = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)$`)
+	justInterpolate = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*}}`)
+	justKeywords    = map[string]bool{
+		"alias": true, "export": true, "import": true, "mod": true, "set": true,
+	}
+)
+
+// JustParam is a parameter of a justfile recipe
+type JustParam struct {
+	Name    string
+	Default string
+}
+
+// JustRecipe is a recipe parsed from a justfile
+type JustRecipe struct {
+	Name    string
+	Comment string
+	Params  []JustParam
+	Body    []string
+}
+
+// ParseJustfile returns the recipes defined in a justfile.
+func ParseJustfile(r io.Reader) ([]JustRecipe, error) {
+	var (
+		recipes  []JustRecipe
+		comments []string
+		current  *JustRecipe
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if current != nil && trimmed != "" && (line[0] == ' ' || line[0] == '\t') {
+			if !strings.HasPrefix(trimmed, "#") {
+				current.Body = append(current.Body, trimmed)
+			}
+			continue
+		}
+		if current != nil {
+			recipes = append(recipes, *current)
+			current = nil
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		case trimmed == "":
+			comments = nil
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			// attributes such as [private] keep the doc comment
+			continue
+		}
+
+		if recipe, ok := parseJustHeader(trimmed); ok {
+			recipe.Comment = strings.Join(comments, " ")
+			current = &recipe
+		}
+		comments = nil
+	}
+	if current != nil {
+		recipes = append(recipes, *current)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read justfile: %v", err)
+	}
+	return recipes, nil
+}
+
+// parseJustHeader parses a line like `deploy env='dev' +args: build`
+func parseJustHeader(line string) (JustRecipe, bool) {
+	var (
+		fields []string
+		field  strings.Builder
+		quote  rune
+		header = -1
+	)
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			field.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			field.WriteRune(c)
+		case c == ' ' || c == '\t':
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		case c == ':':
+			header = i
+		default:
+			field.WriteRune(c)
+		}
+		if header >= 0 {
+			break
+		}
+	}
+	if header < 0 || strings.HasPrefix(line[header:], ":=") {
+		return JustRecipe{}, false
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	if len(fields) == 0 || justKeywords[fields[0]] {
+		return JustRecipe{}, false
+	}
+
+	m := justNameRe.FindStringSubmatch(fields[0])
+	if m == nil {
+		return JustRecipe{}, false
+	}
+
+	recipe := JustRecipe{Name: m[1]}
+	for _, f := range fields[1:] {
+		f = strings.TrimLeft(f, "+*$")
+		kv := strings.SplitN(f, "=", 2)
+		p := JustParam{Name: kv[0]}
+		if len(kv) == 2 {
+			p.Default = strings.Trim(kv[1], `'"`)
+		}
+		recipe.Params = append(recipe.Params, p)
+	}
+	return recipe, true
+}
+
+// placeholder returns the pet parameter for p
+func (p JustParam) placeholder() string {
+	if p.Default == "" || strings.ContainsAny(p.Default, " \t<>") {
+		return "<" + p.Name + ">"
+	}
+	return "<" + p.Name + "=" + p.Default + ">"
+}
+
+// FromJustfile converts justfile recipes into snippets with their parameters
+// as pet placeholders. By default the snippet command invokes just with the
+// given justfile; if recipe is true the recipe body becomes the command.
+func FromJustfile(r io.Reader, path string, recipe bool, tags []string) ([]snippet.SnippetInfo, error) {
+	recipes, err := ParseJustfile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []snippet.SnippetInfo
+	for _, rcp := range recipes {
+		placeholders := map[string]string{}
+		args := []string{"just", "--justfile", shellescape.Quote(path), rcp.Name}
+		for _, p := range rcp.Params {
+			placeholders[p.Name] = p.placeholder()
+			args = append(args, p.placeholder())
+		}
+		command := strings.Join(args, " ")
+
+		if recipe {
+			if len(rcp.Body) == 0 {
+				continue
+			}
+			var lines []string
+			for _, l := range rcp.Body {
+				l = justInterpolate.ReplaceAllStringFunc(l, func(s string) string {
+					name := justInterpolate.FindStringSubmatch(s)[1]
+					if p, ok := placeholders[name]; ok {
+						return p
+					}
+					return s
+				})
+				lines = append(lines, strings.TrimLeft(l, "@-"))
+			}
+			command = strings.Join(lines, "\n")
+		}
+
+		description := rcp.Comment
+		if description == "" {
+			description = "just " + rcp.Name
+		}
+		snippets = append(snippets, snippet.SnippetInfo{
+			Description: description,
+			Command:     command,
+			Tag:         tags,
+		})
+	}
+	return snippets, nil
+}
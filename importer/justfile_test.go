@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseJustfile(t *testing.T) {
+	justfile := `set shell := ["bash", "-c"]
+version := "1.0"
+
+alias b := build
+
+# Build the binary
+build:
+    go build -o pet
+
+# Deploy to an environment
+[no-cd]
+deploy env='staging' +hosts: build
+    @echo deploying {{version}}
+    ./deploy.sh --env {{env}} {{ hosts }}
+
+_helper:
+	echo hidden
+`
+	want := []JustRecipe{
+		{Name: "build", Comment: "Build the binary", Body: []string{"go build -o pet"}},
+		{
+			Name:    "deploy",
+			Comment: "Deploy to an environment",
+			Params:  []JustParam{{Name: "env", Default: "staging"}, {Name: "hosts"}},
+			Body:    []string{"@echo deploying {{version}}", "./deploy.sh --env {{env}} {{ hosts }}"},
+		},
+		{Name: "_helper", Body: []string{"echo hidden"}},
+	}
+
+	got, err := ParseJustfile(strings.NewReader(justfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestFromJustfile(t *testing.T) {
+	justfile := "# Greet someone\ngreet name greeting=\"hello there\":\n    echo {{greeting}} {{name}}\n"
+
+	got, err := FromJustfile(strings.NewReader(justfile), "/src/justfile", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Command != "just --justfile /src/justfile greet <name> <greeting>" {
+		t.Fatalf("unexpected command '%s'", got[0].Command)
+	}
+
+	got, err = FromJustfile(strings.NewReader(justfile), "/src/justfile", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Command != "echo <greeting> <name>" || got[0].Description != "Greet someone" {
+		t.Fatalf("unexpected snippet %+v", got[0])
+	}
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+var makeTargetRe = regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_./-]*(?:\s+[A-Za-z0-9_][A-Za-z0-9_./-]*)*)\s*:(?:[^=]|$)`)
+
+// MakeTarget is a target parsed from a Makefile
+type MakeTarget struct {
+	Name    string
+	Comment string
+	Recipe  []string
+}
+
+// ParseMakefile returns the targets defined in a Makefile.
+// Special targets (.PHONY etc.) and pattern rules are skipped.
+func ParseMakefile(r io.Reader) ([]MakeTarget, error) {
+	var (
+		targets  []MakeTarget
+		comments []string
+		current  []int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			recipe := strings.TrimSpace(line)
+			if recipe == "" || strings.HasPrefix(recipe, "#") {
+				continue
+			}
+			for _, i := range current {
+				targets[i].Recipe = append(targets[i].Recipe, recipe)
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		case trimmed == "":
+			comments = nil
+			continue
+		}
+
+		current = nil
+		m := makeTargetRe.FindStringSubmatch(line)
+		if m == nil {
+			comments = nil
+			continue
+		}
+		for _, name := range strings.Fields(m[1]) {
+			if strings.ContainsAny(name, "%$") {
+				continue
+			}
+			targets = append(targets, MakeTarget{
+				Name:    name,
+				Comment: strings.Join(comments, " "),
+			})
+			current = append(current, len(targets)-1)
+		}
+		comments = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read Makefile: %v", err)
+	}
+	return targets, nil
+}
+
+// FromMakefile converts Makefile targets into snippets.
+// By default the snippet command invokes make in dir; if recipe is true the
+// recipe itself becomes the command.
+func FromMakefile(r io.Reader, dir string, recipe bool, tags []string) ([]snippet.SnippetInfo, error) {
+	targets, err := ParseMakefile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []snippet.SnippetInfo
+	for _, t := range targets {
+		command := fmt.Sprintf("make -C %s %s", shellescape.Quote(dir), t.Name)
+		if recipe {
+			if len(t.Recipe) == 0 {
+				continue
+			}
+			var lines []string
+			for _, l := range t.Recipe {
+				lines = append(lines, strings.TrimLeft(l, "@-+"))
+			}
+			command = strings.Join(lines, "\n")
+		}
+
+		description := t.Comment
+		if description == "" {
+			description = "make " + t.Name
+		}
+		snippets = append(snippets, snippet.SnippetInfo{
+			Description: description,
+			Command:     command,
+			Tag:         tags,
+		})
+	}
+	return snippets, nil
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseMakefile(t *testing.T) {
+	makefile := `.PHONY: build test
+
+VERSION := 1.0
+LDFLAGS = -X main.version=$(VERSION)
+
+# Build the binary
+build: main.go
+	@go build -ldflags "$(LDFLAGS)" -o pet
+
+## Run unit tests
+## with the race detector
+test:
+	go test -race ./...
+
+%.o: %.c
+	cc -c $<
+
+clean distclean:
+	rm -f pet
+`
+	want := []MakeTarget{
+		{Name: "build", Comment: "Build the binary", Recipe: []string{`@go build -ldflags "$(LDFLAGS)" -o pet`}},
+		{Name: "test", Comment: "Run unit tests with the race detector", Recipe: []string{"go test -race ./..."}},
+		{Name: "clean", Recipe: []string{"rm -f pet"}},
+		{Name: "distclean", Recipe: []string{"rm -f pet"}},
+	}
+
+	got, err := ParseMakefile(strings.NewReader(makefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestFromMakefile_Recipe(t *testing.T) {
+	makefile := "# Build it\nbuild:\n\t@go build\n\t-rm -f tmp\nempty:\n"
+
+	got, err := FromMakefile(strings.NewReader(makefile), "/src/pet", true, []string{"pet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("wanted 1 snippet, got %d", len(got))
+	}
+	if got[0].Command != "go build\nrm -f tmp" {
+		t.Fatalf("unexpected command '%s'", got[0].Command)
+	}
+	if got[0].Description != "Build it" {
+		t.Fatalf("unexpected description '%s'", got[0].Description)
+	}
+}
+
+func TestFromMakefile_Make(t *testing.T) {
+	got, err := FromMakefile(strings.NewReader("test:\n\tgo test\n"), "/src/my pet", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Command != "make -C '/src/my pet' test" || got[0].Description != "make test" {
+		t.Fatalf("unexpected snippet %+v", got[0])
+	}
+}
@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+)
+
+// labPlaceholder is a placeholder of SnippetsLab (<#name#>)
+var labPlaceholder = regexp.MustCompile(`<#(.+?)#>`)
+
+// snippetsLabExport is the JSON export of SnippetsLab
+type snippetsLabExport struct {
+	Contents struct {
+		Folders []struct {
+			UUID  string `json:"uuid"`
+			Title string `json:"title"`
+		} `json:"folders"`
+		Tags []struct {
+			UUID  string `json:"uuid"`
+			Title string `json:"title"`
+		} `json:"tags"`
+		Snippets []struct {
+			Title     string   `json:"title"`
+			Folder    string   `json:"folder"`
+			Tags      []string `json:"tags"`
+			Fragments []struct {
+				Title    string `json:"title"`
+				Language string `json:"language"`
+				Note     string `json:"note"`
+				Content  string `json:"content"`
+			} `json:"fragments"`
+		} `json:"snippets"`
+	} `json:"contents"`
+}
+
+// FromSnippetsLab returns the snippets of a JSON export of SnippetsLab, one
+// per fragment. The folder is the path of the snippet and the placeholders
+// (<#name#>) are its parameters.
+func FromSnippetsLab(r io.Reader) ([]snippet.SnippetInfo, error) {
+	var export snippetsLabExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("Failed to parse the SnippetsLab export: %v", err)
+	}
+	folders := map[string]string{}
+	for _, f := range export.Contents.Folders {
+		folders[f.UUID] = f.Title
+	}
+	tags := map[string]string{}
+	for _, t := range export.Contents.Tags {
+		tags[t.UUID] = t.Title
+	}
+
+	var snippets []snippet.SnippetInfo
+	for _, s := range export.Contents.Snippets {
+		var tag []string
+		for _, t := range s.Tags {
+			if title, ok := tags[t]; ok {
+				t = title
+			}
+			tag = append(tag, t)
+		}
+		for _, f := range s.Fragments {
+			if strings.TrimSpace(f.Content) == "" {
+				continue
+			}
+			description := s.Title
+			if len(s.Fragments) > 1 && f.Title != "" {
+				description += " - " + f.Title
+			}
+			snippets = append(snippets, snippet.SnippetInfo{
+				Description: description,
+				Command:     labPlaceholder.ReplaceAllStringFunc(strings.TrimRight(f.Content, "\n"), labParam),
+				Tag:         tag,
+				Path:        folders[s.Folder],
+				Shell:       shellOf(f.Language),
+				Notes:       f.Note,
+			})
+		}
+	}
+	return snippets, nil
+}
+
+func labParam(s string) string {
+	return "<" + paramName(labPlaceholder.FindStringSubmatch(s)[1]) + ">"
+}
+
+// paramName turns the name of a placeholder into the name of a parameter,
+// which has no spaces
+func paramName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// shellOf returns the shell of the snippets written in the language of
+// SnippetsLab or Dash, or "" for the default shell
+func shellOf(language string) string {
+	switch l := strings.ToLower(language); l {
+	case "bash", "zsh", "fish", "powershell", "python", "ruby", "perl", "sql":
+		return l
+	case "javascript":
+		return "node"
+	}
+	return ""
+}
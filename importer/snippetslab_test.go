@@ -0,0 +1,42 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestFromSnippetsLab(t *testing.T) {
+	export := `{"contents": {
+  "folders": [{"uuid": "F1", "title": "k8s"}],
+  "tags": [{"uuid": "T1", "title": "ops"}],
+  "snippets": [
+    {"title": "Tail logs", "folder": "F1", "tags": ["T1"], "fragments": [
+      {"title": "Fragment", "language": "Bash", "note": "follows the pod", "content": "kubectl logs -f <#pod name#> -n <#namespace#>\n"}
+    ]},
+    {"title": "Hello", "tags": ["misc"], "fragments": [
+      {"title": "py", "language": "Python", "content": "print('hi')"},
+      {"title": "empty", "language": "Go", "content": ""},
+      {"title": "go", "language": "Go", "content": "fmt.Println(\"hi\")"}
+    ]}
+  ]
+}}`
+	got, err := FromSnippetsLab(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []snippet.SnippetInfo{
+		{Description: "Tail logs", Command: "kubectl logs -f <pod_name> -n <namespace>", Tag: []string{"ops"}, Path: "k8s", Shell: "bash", Notes: "follows the pod"},
+		{Description: "Hello - py", Command: "print('hi')", Tag: []string{"misc"}, Shell: "python"},
+		{Description: "Hello - go", Command: `fmt.Println("hi")`, Tag: []string{"misc"}},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := FromSnippetsLab(strings.NewReader("[")); err == nil {
+		t.Error("FromSnippetsLab() of invalid JSON succeeded")
+	}
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/knqyf263/pet/snippet"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// warpArgument is an argument of a Warp workflow ({{name}})
+	warpArgument = regexp.MustCompile(`\{\{\s*([\w-]+)\s*\}\}`)
+	// paramDefault matches the defaults a parameter can have, which have no
+	// spaces, angle brackets or |
+	paramDefault = regexp.MustCompile(`^[^\s<>|]+$`)
+)
+
+// warpWorkflow is a workflow of Warp
+type warpWorkflow struct {
+	Name        string   `yaml:"name"`
+	Command     string   `yaml:"command"`
+	Tags        []string `yaml:"tags"`
+	Description string   `yaml:"description"`
+	SourceURL   string   `yaml:"source_url"`
+	Shells      []string `yaml:"shells"`
+	Arguments   []struct {
+		Name         string `yaml:"name"`
+		Description  string `yaml:"description"`
+		DefaultValue string `yaml:"default_value"`
+	} `yaml:"arguments"`
+}
+
+// WarpDir returns the directory of the local workflows of Warp
+func WarpDir() string {
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "linux" {
+		data := os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			data = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(data, "warp-terminal", "workflows")
+	}
+	return filepath.Join(home, ".warp", "workflows")
+}
+
+// FromWarp returns the snippets of the workflows of a Warp YAML file. The
+// arguments ({{name}}) become parameters with their default value, and the
+// descriptions of the workflow and of its arguments the notes.
+func FromWarp(r io.Reader) ([]snippet.SnippetInfo, error) {
+	var snippets []snippet.SnippetInfo
+	decoder := yaml.NewDecoder(r)
+	for {
+		var w warpWorkflow
+		if err := decoder.Decode(&w); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to parse the Warp workflow: %v", err)
+		}
+		command := strings.TrimRight(w.Command, "\n")
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+
+		defaults := map[string]string{}
+		var notes []string
+		if w.Description != "" {
+			notes = append(notes, w.Description)
+		}
+		var args []string
+		for _, a := range w.Arguments {
+			if paramDefault.MatchString(a.DefaultValue) {
+				defaults[a.Name] = a.DefaultValue
+			}
+			if a.Description != "" {
+				args = append(args, fmt.Sprintf("- `%s`: %s", a.Name, a.Description))
+			}
+		}
+		if len(args) > 0 {
+			notes = append(notes, strings.Join(args, "\n"))
+		}
+		if w.SourceURL != "" {
+			notes = append(notes, w.SourceURL)
+		}
+
+		description := w.Name
+		if description == "" {
+			description = strings.SplitN(command, "\n", 2)[0]
+		}
+		var shell string
+		if len(w.Shells) == 1 {
+			shell = shellOf(w.Shells[0])
+		}
+		snippets = append(snippets, snippet.SnippetInfo{
+			Description: description,
+			Command: warpArgument.ReplaceAllStringFunc(command, func(s string) string {
+				name := warpArgument.FindStringSubmatch(s)[1]
+				if d, ok := defaults[name]; ok {
+					return "<" + name + "=" + d + ">"
+				}
+				return "<" + name + ">"
+			}),
+			Tag:   w.Tags,
+			Shell: shell,
+			Notes: strings.Join(notes, "\n\n"),
+		})
+	}
+	return snippets, nil
+}
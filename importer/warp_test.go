@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestFromWarp(t *testing.T) {
+	workflows := `---
+name: Uninstall a Homebrew package and its dependencies
+command: |-
+  brew tap beeftornado/rmtree
+  brew rmtree {{package_name}}
+tags:
+  - homebrew
+description: Removes the package with rmtree
+arguments:
+  - name: package_name
+    description: The name of the package
+    default_value: ~
+source_url: "https://github.com/beeftornado/homebrew-rmtree"
+shells: []
+---
+command: git log -n {{ count }} --author "{{author}}"
+shells: [zsh]
+arguments:
+  - name: count
+    default_value: 10
+  - name: author
+    default_value: Jane Doe
+---
+name: empty
+command: ""
+`
+	got, err := FromWarp(strings.NewReader(workflows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []snippet.SnippetInfo{
+		{
+			Description: "Uninstall a Homebrew package and its dependencies",
+			Command:     "brew tap beeftornado/rmtree\nbrew rmtree <package_name>",
+			Tag:         []string{"homebrew"},
+			Notes: "Removes the package with rmtree\n\n" +
+				"- `package_name`: The name of the package\n\n" +
+				"https://github.com/beeftornado/homebrew-rmtree",
+		},
+		{
+			Description: `git log -n {{ count }} --author "{{author}}"`,
+			Command:     `git log -n <count=10> --author "<author>"`,
+			Shell:       "zsh",
+		},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := FromWarp(strings.NewReader("name: [")); err == nil {
+		t.Error("an invalid workflow is parsed")
+	}
+}
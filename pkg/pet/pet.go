@@ -0,0 +1,174 @@
+// Package pet is the Go API of pet, for tools embedding it (editor plugins,
+// bots) instead of running the pet binary: it loads and saves the snippets of
+// the pet config, searches them, fills in their parameters and syncs them.
+//
+// pet keeps its config in the process, so a program uses one Store at a
+// time, and a Store must not be used by several goroutines at once.
+package pet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/dialog"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+)
+
+// Snippet is a snippet of the snippet files
+type Snippet = snippet.SnippetInfo
+
+// Param is a <parameter> of a command, with its type, default and choices
+type Param = dialog.Param
+
+// Options are how Open finds the config
+type Options struct {
+	// ConfigFile is the config file, that of the pet binary ($PET_CONFIG
+	// or config.toml in the config directory) if empty
+	ConfigFile string
+	// Profile is the profile of the config, $PET_PROFILE if empty
+	Profile string
+}
+
+// Store is the snippets of a config
+type Store struct {
+	snippets snippet.Snippets
+}
+
+// Open loads the config and its snippets
+func Open(opts Options) (*Store, error) {
+	file := opts.ConfigFile
+	if file == "" {
+		var err error
+		if file, err = config.GetConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+	config.Flag.Profile = opts.Profile
+	if err := config.Conf.Load(file); err != nil {
+		return nil, err
+	}
+	s := &Store{}
+	return s, s.Reload()
+}
+
+// Reload reads the snippet files again, e.g. after they were changed by
+// the pet binary
+func (s *Store) Reload() error {
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		return err
+	}
+	s.snippets = snippets
+	return nil
+}
+
+// Snippets returns all the snippets, in the order of pet list
+func (s *Store) Snippets() []Snippet {
+	return append([]Snippet{}, s.snippets.Snippets...)
+}
+
+// Find returns the snippet with the name, or else with the description
+func (s *Store) Find(name string) (Snippet, bool) {
+	if sn, ok := s.snippets.FindByName(name); ok {
+		return sn, true
+	}
+	return s.snippets.Find(name)
+}
+
+// Search returns the snippets matching the query, best first, as the
+// embedded fuzzy finder ranks them
+func (s *Store) Search(query string) []Snippet {
+	return s.snippets.Search(query)
+}
+
+// Add adds the snippet to the snippet file and saves it
+func (s *Store) Add(sn Snippet) error {
+	if sn.Description == "" || sn.Command == "" {
+		return errors.New("A snippet needs a description and a command")
+	}
+	if _, ok := s.snippets.Find(sn.Description); ok {
+		return fmt.Errorf("Snippet [%s] already exists", sn.Description)
+	}
+	if _, ok := s.snippets.FindByName(sn.Name); ok {
+		return fmt.Errorf("Snippet named [%s] already exists", sn.Name)
+	}
+	if sn.File() == "" {
+		sn.SetFile(config.Conf.General.SnippetFile)
+	}
+	s.snippets.Snippets = append(s.snippets.Snippets, sn)
+	return s.Save()
+}
+
+// Update replaces the snippet with the description and saves it
+func (s *Store) Update(description string, sn Snippet) error {
+	for i, old := range s.snippets.Snippets {
+		if old.Description == description {
+			sn.SetFile(old.File())
+			s.snippets.Snippets[i] = sn
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("Snippet [%s] not found", description)
+}
+
+// Delete moves the snippet with the description to the trash, from which
+// pet undo restores it, and saves the snippets
+func (s *Store) Delete(description string) error {
+	for i, sn := range s.snippets.Snippets {
+		if sn.Description == description {
+			if err := snippet.Trash([]snippet.SnippetInfo{sn}); err != nil {
+				return err
+			}
+			s.snippets.Snippets = append(s.snippets.Snippets[:i], s.snippets.Snippets[i+1:]...)
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("Snippet [%s] not found", description)
+}
+
+// Save writes the snippets to their files, and syncs them if auto_sync is
+// set
+func (s *Store) Save() error {
+	if err := s.snippets.Save(); err != nil {
+		return err
+	}
+	if config.Conf.Gist.AutoSync {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Params returns the parameters of the command of the snippet
+func Params(sn Snippet) []Param {
+	return dialog.ParseParams(sn.Command)
+}
+
+// Expand returns the command of the snippet with the values filled in, like
+// pet exec does without the dialog: template functions, attachments and
+// variables are filled in, and the parameters without a value get their
+// default. The values are checked against the types and choices of the
+// parameters. The attachments are at the paths of a new directory which is
+// not written, pet exec writes them there when it runs.
+func (s *Store) Expand(sn Snippet, values map[string]string) (string, error) {
+	return s.snippets.Expand(sn, values)
+}
+
+// Sync syncs the snippet file with the backend of the config (Gist or
+// GitLab), like pet sync, and reloads the snippets
+func (s *Store) Sync() error {
+	if err := petSync.AutoSync(config.Conf.General.SnippetFile); err != nil {
+		return err
+	}
+	return s.Reload()
+}
+
+// SyncRemote syncs the snippet file with the named [[remote]] of the config,
+// like pet sync --remote
+func (s *Store) SyncRemote(name string) error {
+	if err := config.Conf.UseRemote(name); err != nil {
+		return err
+	}
+	return s.Sync()
+}
@@ -0,0 +1,62 @@
+package pet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	configFile := filepath.Join(dir, "config.toml")
+	snippetFile := filepath.Join(dir, "snippet.toml")
+	if err := os.WriteFile(configFile, []byte("[General]\n  snippetfile = \""+filepath.ToSlash(snippetFile)+"\"\n[variables]\n  REGISTRY = \"ghcr.io\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(Options{ConfigFile: configFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Add(Snippet{Name: "push", Description: "Push the image", Command: "docker push <REGISTRY>/<image=app>:<tag:int=1>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(Snippet{Description: "Push the image", Command: "true"}); err == nil {
+		t.Error("Add() of a duplicate description succeeded")
+	}
+
+	reopened, err := Open(Options{ConfigFile: configFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := reopened.Find("push")
+	if !ok {
+		t.Fatalf("Find() did not find the added snippet in %+v", reopened.Snippets())
+	}
+	if found := reopened.Search("push image"); len(found) != 1 {
+		t.Errorf("Search() = %+v, want the snippet", found)
+	}
+	if n := len(Params(s)); n != 3 {
+		t.Errorf("Params() returned %d parameters, want 3", n)
+	}
+
+	command, err := reopened.Expand(s, map[string]string{"tag": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "docker push ghcr.io/app:2"; command != want {
+		t.Errorf("Expand() = %q, want %q", command, want)
+	}
+	if _, err := reopened.Expand(s, map[string]string{"tag": "latest"}); err == nil {
+		t.Error("Expand() of an invalid value succeeded")
+	}
+
+	if err := reopened.Delete("Push the image"); err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.Snippets()) != 0 {
+		t.Errorf("Snippets() = %+v after Delete()", reopened.Snippets())
+	}
+}
@@ -0,0 +1,251 @@
+// Package schedule registers pet sync with the scheduler of the system: a
+// systemd user timer on Linux, a launchd agent on macOS and a task of the
+// Task Scheduler on Windows.
+package schedule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job is the periodic run of a pet command
+type Job struct {
+	// Name is the name of the timer, the agent or the task, e.g. pet-sync
+	Name string
+	// Command is the executable of pet and its arguments
+	Command []string
+	// Interval is the time between two runs
+	Interval time.Duration
+	// Log is the file the output of the runs is appended to, where the
+	// scheduler lets it be set (launchd)
+	Log string
+}
+
+// Install registers the job with the scheduler of the system, replacing the
+// job of the same name, and returns where it is defined
+func Install(j Job) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemd(j)
+	case "darwin":
+		return installLaunchd(j)
+	case "windows":
+		return j.Name, run("schtasks", SchtasksArgs(j)...)
+	}
+	return "", fmt.Errorf("scheduling is not supported on %s, run pet sync from cron", runtime.GOOS)
+}
+
+// Uninstall removes the job of the name from the scheduler of the system
+func Uninstall(name string) error {
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := systemdDir()
+		if err != nil {
+			return err
+		}
+		run("systemctl", "--user", "disable", "--now", name+".timer")
+		for _, ext := range []string{".timer", ".service"} {
+			if err := os.Remove(filepath.Join(dir, name+ext)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return run("systemctl", "--user", "daemon-reload")
+	case "darwin":
+		file, err := launchdFile(name)
+		if err != nil {
+			return err
+		}
+		run("launchctl", "unload", "-w", file)
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case "windows":
+		return run("schtasks", "/Delete", "/F", "/TN", name)
+	}
+	return fmt.Errorf("scheduling is not supported on %s", runtime.GOOS)
+}
+
+func installSystemd(j Job) (string, error) {
+	dir, err := systemdDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	service, timer := SystemdUnits(j)
+	if err := os.WriteFile(filepath.Join(dir, j.Name+".service"), []byte(service), 0o644); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, j.Name+".timer")
+	if err := os.WriteFile(file, []byte(timer), 0o644); err != nil {
+		return "", err
+	}
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return "", err
+	}
+	// restart the timer, for a new interval
+	run("systemctl", "--user", "stop", j.Name+".timer")
+	return file, run("systemctl", "--user", "enable", "--now", j.Name+".timer")
+}
+
+func installLaunchd(j Job) (string, error) {
+	file, err := launchdFile(j.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	run("launchctl", "unload", file)
+	if err := os.WriteFile(file, []byte(LaunchdPlist(j)), 0o644); err != nil {
+		return "", err
+	}
+	return file, run("launchctl", "load", "-w", file)
+}
+
+// systemdDir is the directory of the user units of systemd
+func systemdDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// launchdFile is the plist of the launchd agent of the job
+func launchdFile(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", Label(name)+".plist"), nil
+}
+
+// Label returns the label of the launchd agent of the job
+func Label(name string) string {
+	return "com.github.knqyf263." + strings.Replace(name, "-", ".", -1)
+}
+
+// SystemdUnits returns the service running the job and the timer starting
+// it a minute after the timer is (e.g. at login), then every interval
+func SystemdUnits(j Job) (service, timer string) {
+	var args []string
+	for _, a := range j.Command {
+		args = append(args, systemdQuote(a))
+	}
+	service = fmt.Sprintf(`[Unit]
+Description=%[1]s
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%[2]s
+`, j.Name, strings.Join(args, " "))
+	timer = fmt.Sprintf(`[Unit]
+Description=Run %[1]s every %[2]s
+
+[Timer]
+OnActiveSec=1min
+OnUnitActiveSec=%[3]ds
+
+[Install]
+WantedBy=timers.target
+`, j.Name, j.Interval, int(j.Interval.Seconds()))
+	return service, timer
+}
+
+// systemdQuote quotes an argument of ExecStart, in which systemd expands %
+// and $
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s)
+	return `"` + s + `"`
+}
+
+// LaunchdPlist returns the launchd agent running the job every interval
+func LaunchdPlist(j Job) string {
+	var b bytes.Buffer
+	str := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s))
+		return "<string>" + e.String() + "</string>"
+	}
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	` + str(Label(j.Name)) + `
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, a := range j.Command {
+		b.WriteString("\t\t" + str(a) + "\n")
+	}
+	b.WriteString(`	</array>
+	<key>StartInterval</key>
+	<integer>` + strconv.Itoa(int(j.Interval.Seconds())) + `</integer>
+`)
+	if j.Log != "" {
+		b.WriteString("\t<key>StandardOutPath</key>\n\t" + str(j.Log) + "\n")
+		b.WriteString("\t<key>StandardErrorPath</key>\n\t" + str(j.Log) + "\n")
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// SchtasksArgs returns the arguments of schtasks creating the task running
+// the job every interval, in days, hours or minutes. The Task Scheduler has
+// at most 23 hours, so the longer intervals are rounded up to days.
+func SchtasksArgs(j Job) []string {
+	minutes := int((j.Interval + time.Minute - 1) / time.Minute)
+	schedule, modifier := "MINUTE", minutes
+	switch {
+	case minutes%(24*60) == 0 || minutes > 23*60:
+		schedule, modifier = "DAILY", (minutes+24*60-1)/(24*60)
+	case minutes%60 == 0:
+		schedule, modifier = "HOURLY", (minutes+59)/60
+	}
+	var command []string
+	for _, a := range j.Command {
+		if a == "" || strings.ContainsAny(a, " \t\"") {
+			a = `"` + strings.Replace(a, `"`, `\"`, -1) + `"`
+		}
+		command = append(command, a)
+	}
+	return []string{"/Create", "/F", "/TN", j.Name, "/SC", schedule, "/MO", strconv.Itoa(modifier),
+		"/TR", strings.Join(command, " ")}
+}
+
+// run runs a command of the scheduler, with its output in the error
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		// e.g. systemctl daemon-reload
+		sub := args[0]
+		for _, a := range args {
+			if !strings.HasPrefix(a, "-") {
+				sub = a
+				break
+			}
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("Failed to run %s %s: %s", name, sub, msg)
+		}
+		return fmt.Errorf("Failed to run %s %s: %v", name, sub, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+var job = Job{
+	Name:     "pet-sync",
+	Command:  []string{"/opt/my tools/pet", "--config", "/home/u/100%/config.toml", "sync"},
+	Interval: 30 * time.Minute,
+	Log:      "/home/u/.local/share/pet/sync.log",
+}
+
+func TestSystemdUnits(t *testing.T) {
+	service, timer := SystemdUnits(job)
+	if want := `ExecStart="/opt/my tools/pet" "--config" "/home/u/100%%/config.toml" "sync"` + "\n"; !strings.Contains(service, want) {
+		t.Errorf("the service has no %q:\n%s", want, service)
+	}
+	if !strings.Contains(timer, "OnUnitActiveSec=1800s\n") {
+		t.Errorf("the timer does not run every 30 minutes:\n%s", timer)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	var plist struct {
+		Dict struct {
+			Keys    []string `xml:"key"`
+			Strings []string `xml:"string"`
+			Array   []string `xml:"array>string"`
+			Integer int      `xml:"integer"`
+		} `xml:"dict"`
+	}
+	if err := xml.Unmarshal([]byte(LaunchdPlist(job)), &plist); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(job.Command, plist.Dict.Array); diff != nil {
+		t.Error(diff)
+	}
+	if plist.Dict.Integer != 1800 {
+		t.Errorf("StartInterval = %d", plist.Dict.Integer)
+	}
+	want := []string{"com.github.knqyf263.pet.sync", job.Log, job.Log}
+	if diff := deep.Equal(want, plist.Dict.Strings); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSchtasksArgs(t *testing.T) {
+	tests := map[time.Duration][]string{
+		30 * time.Minute:  {"MINUTE", "30"},
+		90 * time.Second:  {"MINUTE", "2"},
+		6 * time.Hour:     {"HOURLY", "6"},
+		48 * time.Hour:    {"DAILY", "2"},
+		7 * time.Hour / 2: {"MINUTE", "210"},
+		36 * time.Hour:    {"DAILY", "2"},
+	}
+	for interval, want := range tests {
+		j := job
+		j.Interval = interval
+		args := SchtasksArgs(j)
+		if args[5] != want[0] || args[7] != want[1] {
+			t.Errorf("SchtasksArgs(%s) = %v, want /SC %s /MO %s", interval, args, want[0], want[1])
+		}
+	}
+	args := SchtasksArgs(job)
+	if want := `"/opt/my tools/pet" --config /home/u/100%/config.toml sync`; args[len(args)-1] != want {
+		t.Errorf("/TR %s, want %s", args[len(args)-1], want)
+	}
+}
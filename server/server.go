@@ -0,0 +1,426 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	petSync "github.com/knqyf263/pet/sync"
+)
+
+// ExecFunc runs a snippet with the values of its parameters, as pet exec
+// does, and returns its combined output
+type ExecFunc func(s snippet.SnippetInfo, params map[string]string) (output string, err error)
+
+// Server serves the snippets as a JSON API
+type Server struct {
+	// Token is required as a bearer token if not empty, and to run commands
+	Token string
+	// Hosts are the host names accepted besides localhost, e.g. the one of
+	// the address the server listens on
+	Hosts []string
+	// Exec runs snippets for /exec, one at a time; commands are only
+	// expanded if nil
+	Exec ExecFunc
+
+	mu     sync.Mutex
+	execMu sync.Mutex
+	mux    *http.ServeMux
+
+	// version changes with the snippet files, see Watch
+	versionMu sync.Mutex
+	version   uint64
+	// changed is closed, and replaced, when the version changes
+	changed chan struct{}
+}
+
+// ChangesResponse is the response of GET /changes
+type ChangesResponse struct {
+	Version uint64 `json:"version"`
+}
+
+// ExecRequest is the body of POST /exec
+type ExecRequest struct {
+	Description string            `json:"description"`
+	Params      map[string]string `json:"params"`
+	Run         bool              `json:"run"`
+	// Confirm must be set to run snippets that need confirmation
+	Confirm bool `json:"confirm"`
+}
+
+// ExecResponse is the response of POST /exec
+type ExecResponse struct {
+	Command  string `json:"command"`
+	Executed bool   `json:"executed"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns a Server
+func New(token string, exec ExecFunc) *Server {
+	s := &Server{Token: token, Exec: exec, mux: http.NewServeMux(), changed: make(chan struct{})}
+	s.mux.HandleFunc("/snippets", s.snippets)
+	s.mux.HandleFunc("/snippets/", s.snippet)
+	s.mux.HandleFunc("/search", s.search)
+	s.mux.HandleFunc("/exec", s.exec)
+	s.mux.HandleFunc("/sync", s.sync)
+	s.mux.HandleFunc("/changes", s.changes)
+	s.mux.Handle("/", uiHandler())
+	return s
+}
+
+// Handle registers an additional handler
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// web pages cannot call the API from other sites, nor through a host
+	// name resolving to the machine (DNS rebinding)
+	if !s.allowedHost(r.Host) {
+		writeError(w, http.StatusForbidden, "invalid host")
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !s.allowedHost(u.Host) {
+			writeError(w, http.StatusForbidden, "invalid origin")
+			return
+		}
+	}
+	// a form of another site cannot send JSON
+	if (r.Method == http.MethodPost || r.Method == http.MethodPut) && isAPI(r.URL.Path) {
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+	}
+	// the UI itself is public, it asks for the token to call the API
+	isUI := r.Method == http.MethodGet && !isAPI(r.URL.Path)
+	if s.Token != "" && !isUI && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// allowedHost reports whether the host of a request, with or without a
+// port, is localhost or one of Hosts
+func (s *Server) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, h := range s.Hosts {
+		if strings.EqualFold(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) load() (snippet.Snippets, error) {
+	var snippets snippet.Snippets
+	err := snippets.Load()
+	return snippets, err
+}
+
+// snippets handles GET /snippets[?tag=TAG] and POST /snippets
+func (s *Server) snippets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snippets, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tag := r.URL.Query().Get("tag")
+		path := r.URL.Query().Get("path")
+		list := []snippet.SnippetInfo{}
+		for _, sn := range snippets.Snippets {
+			if (tag == "" || hasTag(sn, tag)) && sn.InPath(path) {
+				list = append(list, sn)
+			}
+		}
+		writeJSON(w, http.StatusOK, list)
+	case http.MethodPost:
+		var sn snippet.SnippetInfo
+		if err := json.NewDecoder(r.Body).Decode(&sn); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid snippet: %v", err))
+			return
+		}
+		if sn.Description == "" || sn.Command == "" {
+			writeError(w, http.StatusBadRequest, "description and command are required")
+			return
+		}
+		if _, ok := snippets.Find(sn.Description); ok {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Snippet [%s] already exists", sn.Description))
+			return
+		}
+		snippets.Snippets = append(snippets.Snippets, sn)
+		if err := snippets.Save(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, sn)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// snippet handles GET, PUT and DELETE /snippets/DESCRIPTION
+func (s *Server) snippet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	description, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/snippets/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	snippets, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	idx := -1
+	for i, sn := range snippets.Snippets {
+		if sn.Description == description {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Snippet [%s] not found", description))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, snippets.Snippets[idx])
+		return
+	case http.MethodPut:
+		var sn snippet.SnippetInfo
+		if err := json.NewDecoder(r.Body).Decode(&sn); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid snippet: %v", err))
+			return
+		}
+		if sn.Description == "" || sn.Command == "" {
+			writeError(w, http.StatusBadRequest, "description and command are required")
+			return
+		}
+		if other, ok := snippets.Find(sn.Description); ok && other.Description != description {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Snippet [%s] already exists", sn.Description))
+			return
+		}
+		sn.SetFile(snippets.Snippets[idx].File())
+		snippets.Snippets[idx] = sn
+		if err := snippets.Save(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, sn)
+	case http.MethodDelete:
+		deleted := snippets.Snippets[idx]
+		snippets.Snippets = append(snippets.Snippets[:idx], snippets.Snippets[idx+1:]...)
+		if err := snippet.Trash([]snippet.SnippetInfo{deleted}); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := snippets.Save(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// search handles GET /search?q=QUERY
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	snippets, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	found := snippets.Search(r.URL.Query().Get("q"))
+	if found == nil {
+		found = []snippet.SnippetInfo{}
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// exec handles POST /exec
+func (s *Server) exec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// expanding runs the script and the template functions of the snippet
+	if s.Token == "" {
+		writeError(w, http.StatusForbidden, "expanding snippets requires a token (start pet serve with --token)")
+		return
+	}
+	var req ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	snippets, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sn, ok := snippets.Find(req.Description)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Snippet [%s] not found", req.Description))
+		return
+	}
+
+	command, err := snippets.Expand(sn, req.Params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res := ExecResponse{Command: command}
+	if req.Run {
+		if s.Exec == nil {
+			writeError(w, http.StatusForbidden, "execution is disabled (start pet serve with --allow-exec)")
+			return
+		}
+		if sn.NeedsConfirm() && !req.Confirm {
+			writeError(w, http.StatusPreconditionFailed, "snippet needs confirmation (set confirm to true)")
+			return
+		}
+		s.execMu.Lock()
+		out, err := s.Exec(sn, req.Params)
+		s.execMu.Unlock()
+		res.Executed = true
+		res.Output = out
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// sync handles POST /sync
+func (s *Server) sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := petSync.AutoSync(config.Conf.General.SnippetFile); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Watch bumps the version of GET /changes when the snippet files change on
+// disk, until the watcher is closed
+func (s *Server) Watch() (*snippet.Watcher, error) {
+	w, err := snippet.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	go w.Run(s.bump)
+	return w, nil
+}
+
+func (s *Server) bump() {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// changes handles GET /changes?version=N[&timeout=SECONDS], which returns
+// the version of the snippets once it is not N, or after the timeout
+// (default: 60s), so that clients reload the snippets when they change
+func (s *Server) changes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	timeout := time.Minute
+	if t, err := strconv.Atoi(r.URL.Query().Get("timeout")); err == nil && t > 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+	s.versionMu.Lock()
+	version, changed := s.version, s.changed
+	s.versionMu.Unlock()
+	if v := r.URL.Query().Get("version"); v == strconv.FormatUint(version, 10) {
+		select {
+		case <-changed:
+		case <-time.After(timeout):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	s.versionMu.Lock()
+	version = s.version
+	s.versionMu.Unlock()
+	writeJSON(w, http.StatusOK, ChangesResponse{Version: version})
+}
+
+func isAPI(path string) bool {
+	for _, prefix := range []string{"/snippets", "/search", "/exec", "/sync", "/changes"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(s snippet.SnippetInfo, tag string) bool {
+	for _, t := range s.Tag {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func setup(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+
+	snippets := snippet.Snippets{Snippets: []snippet.SnippetInfo{
+		{Description: "greet", Command: "echo hello <name=world>", Tag: []string{"demo"}},
+		{Description: "wipe", Command: "rm -rf <dir>", Confirm: true},
+	}}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRequest returns a request of a local client, with a JSON body
+func newRequest(method, target string, body *strings.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Host = "127.0.0.1:7777"
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func serve(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func do(t *testing.T, s *Server, method, target, body string) *httptest.ResponseRecorder {
+	req := newRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Snippets(t *testing.T) {
+	setup(t)
+	s := New("", nil)
+
+	w := do(t, s, http.MethodGet, "/snippets?tag=demo", "")
+	var list []snippet.SnippetInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Description != "greet" {
+		t.Fatalf("unexpected snippets %+v", list)
+	}
+
+	w = do(t, s, http.MethodPost, "/snippets", `{"description":"greet","command":"echo"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("wanted %d, got %d", http.StatusConflict, w.Code)
+	}
+	w = do(t, s, http.MethodPost, "/snippets", `{"description":"date","command":"date"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("wanted %d, got %d", http.StatusCreated, w.Code)
+	}
+	w = do(t, s, http.MethodGet, "/search?q=DATE", "")
+	if !strings.Contains(w.Body.String(), `"command":"date"`) {
+		t.Fatalf("created snippet not found: %s", w.Body.String())
+	}
+}
+
+func TestServer_Exec(t *testing.T) {
+	setup(t)
+	var executed snippet.SnippetInfo
+	var params map[string]string
+	s := New("secret", func(sn snippet.SnippetInfo, p map[string]string) (string, error) {
+		executed, params = sn, p
+		return "ok", nil
+	})
+
+	w := do(t, s, http.MethodPost, "/exec", `{"description":"greet"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wanted %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req := newRequest(http.MethodPost, "/exec", strings.NewReader(`{"description":"greet","params":{"name":"pet"},"run":true}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	var res ExecResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Executed || res.Command != "echo hello pet" || res.Output != "ok" {
+		t.Fatalf("unexpected response %+v", res)
+	}
+	if executed.Description != "greet" || params["name"] != "pet" {
+		t.Fatalf("executed [%s] with %v, want [greet] with name=pet", executed.Description, params)
+	}
+
+	req = newRequest(http.MethodPost, "/exec", strings.NewReader(`{"description":"wipe","run":true}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("wanted %d, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+}
+
+func TestServer_Forged(t *testing.T) {
+	setup(t)
+	s := New("secret", func(sn snippet.SnippetInfo, params map[string]string) (string, error) {
+		t.Fatalf("forged request ran [%s]", sn.Description)
+		return "", nil
+	})
+	body := `{"description":"greet","run":true}`
+
+	// a page of another site posting a form
+	req := newRequest(http.MethodPost, "/exec", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "text/plain")
+	if w := serve(s, req); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain: wanted %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+	req = newRequest(http.MethodPost, "/exec", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Origin", "https://evil.example")
+	if w := serve(s, req); w.Code != http.StatusForbidden {
+		t.Errorf("other origin: wanted %d, got %d", http.StatusForbidden, w.Code)
+	}
+	// DNS rebinding
+	req = newRequest(http.MethodGet, "/snippets", strings.NewReader(""))
+	req.Host = "evil.example:7777"
+	if w := serve(s, req); w.Code != http.StatusForbidden {
+		t.Errorf("other host: wanted %d, got %d", http.StatusForbidden, w.Code)
+	}
+	// the same origin and the hosts of the server
+	s.Hosts = []string{"pet.lan"}
+	req = newRequest(http.MethodGet, "/snippets", strings.NewReader(""))
+	req.Host = "pet.lan:7777"
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Origin", "http://pet.lan:7777")
+	if w := serve(s, req); w.Code != http.StatusOK {
+		t.Errorf("host of the server: wanted %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// commands are not run without a token
+	s = New("", func(sn snippet.SnippetInfo, params map[string]string) (string, error) {
+		t.Fatalf("request without token ran [%s]", sn.Description)
+		return "", nil
+	})
+	if w := do(t, s, http.MethodPost, "/exec", body); w.Code != http.StatusForbidden {
+		t.Errorf("no token: wanted %d, got %d", http.StatusForbidden, w.Code)
+	}
+	// nor expanded, which evaluates their script and template functions
+	s = New("", nil)
+	if w := do(t, s, http.MethodPost, "/exec", `{"description":"greet","params":{"name":"pet"}}`); w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "hello") {
+		t.Errorf("expansion without token: wanted %d, got %d %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	// a token of another length is refused too
+	s = New("secret", nil)
+	req = newRequest(http.MethodGet, "/snippets", strings.NewReader(""))
+	req.Header.Set("Authorization", "Bearer secret2")
+	if w := serve(s, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("other token: wanted %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestServer_UpdateDelete(t *testing.T) {
+	setup(t)
+	s := New("secret", nil)
+
+	if w := do(t, s, http.MethodGet, "/", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<title>pet</title>") {
+		t.Fatalf("UI not served without token: %d", w.Code)
+	}
+
+	auth := func(method, target, body string) *httptest.ResponseRecorder {
+		req := newRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := auth(http.MethodPut, "/snippets/greet", `{"description":"wipe","command":"echo"}`); w.Code != http.StatusConflict {
+		t.Fatalf("wanted %d, got %d", http.StatusConflict, w.Code)
+	}
+	if w := auth(http.MethodPut, "/snippets/greet", `{"description":"say hi","command":"echo hi"}`); w.Code != http.StatusOK {
+		t.Fatalf("wanted %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := auth(http.MethodDelete, "/snippets/say%20hi", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("wanted %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	var snippets snippet.Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets.Snippets) != 1 || snippets.Snippets[0].Description != "wipe" {
+		t.Fatalf("unexpected snippets %+v", snippets.Snippets)
+	}
+	trash, err := snippet.LoadTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].Description != "say hi" {
+		t.Fatalf("deleted snippet not in the trash: %+v", trash)
+	}
+}
+
+func TestServer_Changes(t *testing.T) {
+	setup(t)
+	s := New("", nil)
+	w, err := s.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var changes ChangesResponse
+	if err := json.Unmarshal(do(t, s, http.MethodGet, "/changes", "").Body.Bytes(), &changes); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan ChangesResponse)
+	go func() {
+		var c ChangesResponse
+		json.Unmarshal(do(t, s, http.MethodGet, "/changes?version=0&timeout=5", "").Body.Bytes(), &c)
+		done <- c
+	}()
+
+	snippets := snippet.Snippets{Snippets: []snippet.SnippetInfo{{Description: "date", Command: "date"}}}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if c := <-done; c.Version == changes.Version {
+		t.Errorf("version = %d after the snippet file changed", c.Version)
+	}
+}
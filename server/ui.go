@@ -0,0 +1,19 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed ui
+var ui embed.FS
+
+// uiHandler serves the embedded web UI
+func uiHandler() http.Handler {
+	sub, err := fs.Sub(ui, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(sub))
+}
@@ -0,0 +1,100 @@
+package snippet
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment is an auxiliary file of a snippet, e.g. a SQL script. It is
+// kept in the snippet file, so that it is synced with the snippet.
+type Attachment struct {
+	Name    string `toml:"name" json:"name"`
+	Content string `toml:"content" json:"content"`
+}
+
+// CheckAttachmentName returns an error if name cannot be the file name of an
+// attachment
+func CheckAttachmentName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid attachment name %s", name)
+	}
+	return nil
+}
+
+// NewAttachmentDir returns the path of a new directory for the attachments
+// of an execution in the temporary directory. It is random, so that every
+// execution has its own, and is created by WriteAttachments.
+func NewAttachmentDir() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("Failed to create the attachment directory: %v", err)
+	}
+	return filepath.Join(os.TempDir(), "pet-attachments-"+hex.EncodeToString(b)), nil
+}
+
+// AttachmentPaths returns the paths of the attachments when written to dir,
+// by their names.
+func (s SnippetInfo) AttachmentPaths(dir string) map[string]string {
+	if len(s.Attachments) == 0 {
+		return nil
+	}
+	paths := map[string]string{}
+	for _, a := range s.Attachments {
+		paths[a.Name] = filepath.Join(dir, a.Name)
+	}
+	return paths
+}
+
+// WriteAttachments writes the attachments of the snippet to dir, as returned
+// by NewAttachmentDir. The directory is created only accessible to the
+// current user: one which is already there, e.g. made or linked by another
+// user, is refused. It is removed if an attachment cannot be written.
+func (s SnippetInfo) WriteAttachments(dir string) error {
+	if len(s.Attachments) == 0 {
+		return nil
+	}
+	if dir == "" {
+		return errors.New("Failed to write the attachments: no directory")
+	}
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return fmt.Errorf("Failed to write the attachments: %v", err)
+	}
+	for _, a := range s.Attachments {
+		if err := writeAttachment(dir, a); err != nil {
+			os.RemoveAll(dir)
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAttachment(dir string, a Attachment) error {
+	if err := CheckAttachmentName(a.Name); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, a.Name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("Failed to write the attachment %s: %v", a.Name, err)
+	}
+	_, err = f.WriteString(a.Content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write the attachment %s: %v", a.Name, err)
+	}
+	return nil
+}
+
+// RemoveAttachments removes the attachment directory dir of an execution.
+func RemoveAttachments(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
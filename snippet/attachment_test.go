@@ -0,0 +1,95 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSnippetInfo_WriteAttachments(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	s := SnippetInfo{
+		Description: "migrate",
+		Attachments: []Attachment{{Name: "migrate.sql", Content: "SELECT 1;"}},
+	}
+
+	// every execution has its own directory
+	dir, err := NewAttachmentDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewAttachmentDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir == other {
+		t.Fatalf("NewAttachmentDir() returned %s twice", dir)
+	}
+	if err := s.WriteAttachments(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteAttachments(other); err != nil {
+		t.Fatal(err)
+	}
+	path := s.AttachmentPaths(dir)["migrate.sql"]
+	if data, err := os.ReadFile(path); err != nil || string(data) != "SELECT 1;" {
+		t.Fatalf("%s = %q, %v", path, data, err)
+	}
+	if fi, err := os.Stat(dir); err != nil || (runtime.GOOS != "windows" && fi.Mode().Perm() != 0o700) {
+		t.Errorf("the attachment directory is %v, %v, want 0700", fi.Mode(), err)
+	}
+	// removing one keeps the files of the other execution
+	if err := RemoveAttachments(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed: %v", dir, err)
+	}
+	if _, err := os.Stat(s.AttachmentPaths(other)["migrate.sql"]); err != nil {
+		t.Errorf("the attachment of the other execution was removed: %v", err)
+	}
+
+	// a directory or a link made before is refused
+	taken, err := NewAttachmentDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(taken, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteAttachments(taken); err == nil {
+		t.Error("WriteAttachments() wrote to an existing directory")
+	}
+	if runtime.GOOS != "windows" {
+		linked, err := NewAttachmentDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := t.TempDir()
+		if err := os.Symlink(target, linked); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.WriteAttachments(linked); err == nil {
+			t.Error("WriteAttachments() wrote through a link")
+		}
+		if _, err := os.Stat(filepath.Join(target, "migrate.sql")); !os.IsNotExist(err) {
+			t.Errorf("the attachment was written to the target of the link: %v", err)
+		}
+	}
+}
+
+func TestExecution_WithAttachmentDir(t *testing.T) {
+	e := Execution{
+		Command:       "psql -f /tmp/pet-attachments-old/migrate.sql",
+		Env:           map[string]string{"SQL": "/tmp/pet-attachments-old/migrate.sql"},
+		AttachmentDir: "/tmp/pet-attachments-old",
+	}
+	got := e.WithAttachmentDir("/tmp/pet-attachments-new")
+	if got.Command != "psql -f /tmp/pet-attachments-new/migrate.sql" || got.Env["SQL"] != "/tmp/pet-attachments-new/migrate.sql" || got.AttachmentDir != "/tmp/pet-attachments-new" {
+		t.Errorf("WithAttachmentDir() = %+v", got)
+	}
+	if e.Env["SQL"] != "/tmp/pet-attachments-old/migrate.sql" {
+		t.Errorf("WithAttachmentDir() changed the env of the execution: %v", e.Env)
+	}
+}
@@ -0,0 +1,145 @@
+package snippet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const (
+	auditFileName = "audit.jsonl"
+	// defaultAuditMaxSize and defaultAuditMaxFiles are used when max_size_mb
+	// and max_files are not set
+	defaultAuditMaxSize  = 10
+	defaultAuditMaxFiles = 5
+)
+
+// AuditEntry is a line of the audit log, one for each execution
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description"`
+	// Command is filled in, with the secret parameters redacted
+	Command string `json:"command"`
+	// Params are the values of the parameters, without the secret ones
+	Params     map[string]string `json:"params,omitempty"`
+	ExitCode   int               `json:"exit_code"`
+	DurationMS int64             `json:"duration_ms"`
+	User       string            `json:"user,omitempty"`
+	Dir        string            `json:"dir,omitempty"`
+}
+
+// AuditFile returns the audit log, file of [Audit] or audit.jsonl in the data
+// directory
+func AuditFile() (string, error) {
+	if file := config.Conf.Audit.File; file != "" {
+		return file, nil
+	}
+	return config.GetDataFile(auditFileName)
+}
+
+// Audit appends the executions to the audit log if it is enabled. The log
+// is rotated when it grows over max_size_mb, keeping max_files old logs
+// (audit.jsonl.1 the newest).
+func Audit(entries []AuditEntry) error {
+	if !config.Conf.Audit.Enabled {
+		return nil
+	}
+	file, err := AuditFile()
+	if err != nil {
+		return err
+	}
+	if err := rotateAudit(file); err != nil {
+		return err
+	}
+
+	var u string
+	if current, err := user.Current(); err == nil {
+		u = current.Username
+	}
+	dir, _ := os.Getwd()
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("Failed to open the audit log. %v", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		e.User, e.Dir = u, dir
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("Failed to write the audit log. %v", err)
+		}
+	}
+	return nil
+}
+
+// LoadAudit returns the entries of the audit log and of the old logs it was
+// rotated to, oldest first. Malformed lines are skipped.
+func LoadAudit() ([]AuditEntry, error) {
+	file, err := AuditFile()
+	if err != nil {
+		return nil, err
+	}
+	maxFiles := config.Conf.Audit.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultAuditMaxFiles
+	}
+	var entries []AuditEntry
+	for i := maxFiles; i >= 0; i-- {
+		name := file
+		if i > 0 {
+			name += "." + strconv.Itoa(i)
+		}
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to read the audit log. %v", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var e AuditEntry
+			if json.Unmarshal(scanner.Bytes(), &e) == nil && !e.Time.IsZero() {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the audit log. %v", err)
+		}
+	}
+	return entries, nil
+}
+
+func rotateAudit(file string) error {
+	maxSize := int64(config.Conf.Audit.MaxSizeMB)
+	if maxSize <= 0 {
+		maxSize = defaultAuditMaxSize
+	}
+	maxFiles := config.Conf.Audit.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultAuditMaxFiles
+	}
+	fi, err := os.Stat(file)
+	if err != nil || fi.Size() < maxSize<<20 {
+		return nil
+	}
+
+	os.Remove(file + "." + strconv.Itoa(maxFiles))
+	for i := maxFiles - 1; i >= 1; i-- {
+		os.Rename(file+"."+strconv.Itoa(i), file+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(file, file+".1"); err != nil {
+		return fmt.Errorf("Failed to rotate the audit log. %v", err)
+	}
+	return nil
+}
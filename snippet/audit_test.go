@@ -0,0 +1,99 @@
+package snippet
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "audit.jsonl")
+	defer func(c config.AuditConfig) { config.Conf.Audit = c }(config.Conf.Audit)
+
+	config.Conf.Audit = config.AuditConfig{File: file}
+	if err := Audit([]AuditEntry{{Description: "off"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatal("Audit() wrote the log while it is disabled")
+	}
+
+	config.Conf.Audit = config.AuditConfig{Enabled: true, File: file, MaxSizeMB: 1, MaxFiles: 2}
+	entry := AuditEntry{Description: "deploy", Command: "make deploy", ExitCode: 2, DurationMS: 5}
+	if err := Audit([]AuditEntry{entry, entry}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		var got AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Description != "deploy" || got.ExitCode != 2 || got.User == "" {
+			t.Errorf("unexpected entry %+v", got)
+		}
+		n++
+	}
+	f.Close()
+	if n != 2 {
+		t.Errorf("Audit() wrote %d entries, want 2", n)
+	}
+
+	// rotated when over max_size_mb, keeping max_files old logs
+	big := strings.Repeat("x", 1<<20) + "\n"
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(file, []byte(big), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := Audit([]AuditEntry{entry}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"audit.jsonl", "audit.jsonl.1", "audit.jsonl.2"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(file + ".3"); !os.IsNotExist(err) {
+		t.Errorf("more than max_files old logs are kept")
+	}
+	if fi, _ := os.Stat(file); fi.Size() > 1<<10 {
+		t.Errorf("the log was not rotated: %d bytes", fi.Size())
+	}
+}
+
+func TestLoadAudit(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "audit.jsonl")
+	defer func(c config.AuditConfig) { config.Conf.Audit = c }(config.Conf.Audit)
+	config.Conf.Audit = config.AuditConfig{Enabled: true, File: file, MaxFiles: 2}
+
+	old := `{"time":"2024-05-01T10:00:00Z","description":"old","command":"echo old"}` + "\n"
+	if err := os.WriteFile(file+".1", []byte(old+"not json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entry := AuditEntry{Time: time.Now(), Description: "greet", Command: "echo hi", Params: map[string]string{"who": "hi"}}
+	if err := Audit([]AuditEntry{entry}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadAudit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Description != "old" || entries[1].Params["who"] != "hi" {
+		t.Errorf("LoadAudit() = %+v", entries)
+	}
+}
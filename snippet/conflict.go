@@ -0,0 +1,110 @@
+package snippet
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// The conflict markers around the local ("ours") and the other ("theirs")
+// versions of a snippet, as git writes them
+const (
+	MarkerOurs   = "<<<<<<< ours"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> theirs"
+)
+
+// ConflictMarkers returns the lines (from 1) of the conflict markers in the
+// content of a snippet file
+func ConflictMarkers(data []byte) []int {
+	var lines []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "<<<<<<<") || strings.HasPrefix(line, ">>>>>>>") || line == MarkerSep {
+			lines = append(lines, n)
+		}
+	}
+	return lines
+}
+
+// WithConflictMarkers returns the snippet file of the local snippets and
+// the other ones, where the snippets of the same description which differ
+// are written between conflict markers, the local one first. The snippets
+// of only one side are between markers too, with nothing on the other
+// side, since either may have been added or deleted. The variables and the
+// runbooks are the local ones. It also returns the number of conflicts.
+func WithConflictMarkers(local Snippets, other []SnippetInfo) (string, int, error) {
+	var b strings.Builder
+	write := func(snippets ...SnippetInfo) error {
+		for _, s := range snippets {
+			body, err := (&Snippets{Snippets: []SnippetInfo{s}}).ToString()
+			if err != nil {
+				return err
+			}
+			b.WriteString(body)
+		}
+		return nil
+	}
+	conflicts := 0
+	conflict := func(ours, theirs []SnippetInfo) error {
+		conflicts++
+		b.WriteString(MarkerOurs + "\n")
+		if err := write(ours...); err != nil {
+			return err
+		}
+		b.WriteString(MarkerSep + "\n")
+		if err := write(theirs...); err != nil {
+			return err
+		}
+		b.WriteString(MarkerTheirs + "\n\n")
+		return nil
+	}
+
+	if len(local.Vars) > 0 {
+		body, err := (&Snippets{Vars: local.Vars}).ToString()
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(body + "\n")
+	}
+	theirs := map[string]SnippetInfo{}
+	for _, o := range other {
+		theirs[o.Description] = o
+	}
+	ours := map[string]bool{}
+	for _, s := range local.Snippets {
+		ours[s.Description] = true
+		o, ok := theirs[s.Description]
+		o.file = s.file
+		var err error
+		switch {
+		case !ok:
+			err = conflict([]SnippetInfo{s}, nil)
+		case sameContent(s, o):
+			err = write(s)
+			b.WriteString("\n")
+		default:
+			err = conflict([]SnippetInfo{s}, []SnippetInfo{o})
+		}
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	for _, o := range other {
+		if !ours[o.Description] {
+			if err := conflict(nil, []SnippetInfo{o}); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+	if len(local.Runbooks) > 0 {
+		body, err := (&Snippets{Runbooks: local.Runbooks}).ToString()
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(body)
+	}
+	return b.String(), conflicts, nil
+}
@@ -0,0 +1,54 @@
+package snippet
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-test/deep"
+)
+
+func TestWithConflictMarkers(t *testing.T) {
+	local := Snippets{
+		Vars: map[string]string{"host": "example.com"},
+		Snippets: []SnippetInfo{
+			{Description: "same", Command: "echo same"},
+			{Description: "edited", Command: "echo ours"},
+			{Description: "ours only", Command: "echo ours only"},
+		},
+	}
+	other := []SnippetInfo{
+		{Description: "same", Command: "echo same"},
+		{Description: "edited", Command: "echo theirs"},
+		{Description: "theirs only", Command: "echo theirs only"},
+	}
+	body, n, err := WithConflictMarkers(local, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("%d conflicts, want 3:\n%s", n, body)
+	}
+	if strings.Count(body, "echo same") != 1 || !strings.HasPrefix(body, "[variables]") {
+		t.Errorf("unexpected snippet file:\n%s", body)
+	}
+	if issues := Lint("f", []byte(body)); len(issues) != 9 || issues[0].Message != "unresolved conflict marker" {
+		t.Errorf("Lint() = %v", issues)
+	}
+
+	// keeping ours resolves the conflicts
+	theirs := regexp.MustCompile(`(?s)` + MarkerSep + `\n.*?` + MarkerTheirs + `\n`)
+	resolved := theirs.ReplaceAllString(body, "")
+	resolved = strings.Replace(resolved, MarkerOurs+"\n", "", -1)
+	if markers := ConflictMarkers([]byte(resolved)); len(markers) > 0 {
+		t.Fatalf("markers on the lines %v of\n%s", markers, resolved)
+	}
+	var snippets Snippets
+	if _, err := toml.Decode(resolved, &snippets); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(local, snippets); diff != nil {
+		t.Errorf("%v\n%s", diff, resolved)
+	}
+}
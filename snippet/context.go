@@ -0,0 +1,106 @@
+package snippet
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// contexts resolve the context variables of commands, e.g.
+// {{kube_context}}, from the environment when a snippet runs
+var contexts = map[string]func() (string, error){
+	"kube_context": func() (string, error) {
+		return output("kubectl", "config", "current-context")
+	},
+	"kube_namespace": func() (string, error) {
+		ns, err := output("kubectl", "config", "view", "--minify", "--output", "jsonpath={..namespace}")
+		if err == nil && ns == "" {
+			// the namespace of kubectl without one in the context
+			ns = "default"
+		}
+		return ns, err
+	},
+	"aws_profile": func() (string, error) {
+		for _, name := range []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE"} {
+			if p := os.Getenv(name); p != "" {
+				return p, nil
+			}
+		}
+		return "default", nil
+	},
+	"docker_context": func() (string, error) {
+		if c := os.Getenv("DOCKER_CONTEXT"); c != "" {
+			return c, nil
+		}
+		return output("docker", "context", "show")
+	},
+}
+
+// contextNameRe matches the names of the context variables
+var contextNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RegisterContext adds the context variable {{name}}, or replaces a
+// built-in one, whose value resolve returns each time a command uses it.
+// The names of the template functions are taken.
+func RegisterContext(name string, resolve func() (string, error)) error {
+	if !contextNameRe.MatchString(name) {
+		return fmt.Errorf("invalid context variable name %s", name)
+	}
+	if _, ok := templateFuncs[name]; ok || name == "include" {
+		return fmt.Errorf("%s is a template function, name the context variable otherwise", name)
+	}
+	contexts[name] = resolve
+	return nil
+}
+
+// ContextNames returns the names of the context variables, sorted
+func ContextNames() []string {
+	var names []string
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contextFuncs returns the context variables as template functions, each
+// resolved once at most
+func contextFuncs() map[string]interface{} {
+	funcs := map[string]interface{}{}
+	for name, resolve := range contexts {
+		name, resolve := name, resolve
+		var value string
+		var err error
+		resolved := false
+		funcs[name] = func() (string, error) {
+			if !resolved {
+				resolved = true
+				if value, err = resolve(); err != nil {
+					err = fmt.Errorf("%s: %v", name, err)
+				}
+			}
+			return value, err
+		}
+	}
+	return funcs
+}
+
+// output runs the program and returns its output without the trailing
+// newline, or its error output as the error
+func output(name string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	c := exec.Command(name, args...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,68 @@
+package snippet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRender_Context(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_DEFAULT_PROFILE", "staging")
+	t.Setenv("DOCKER_CONTEXT", "colima")
+	got, err := Render("echo {{aws_profile}} {{docker_context}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "echo staging colima"; got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+
+	calls := 0
+	defer delete(contexts, "pet_test")
+	if err := RegisterContext("pet_test", func() (string, error) {
+		calls++
+		return "value", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Render("echo {{pet_test}} {{pet_test}}", nil); err != nil || got != "echo value value" {
+		t.Errorf("Render() = %q, %v", got, err)
+	}
+	if calls != 1 {
+		t.Errorf("resolved %d times, want once", calls)
+	}
+
+	contexts["pet_test"] = func() (string, error) { return "", errors.New("no project") }
+	if _, err := Render("echo {{pet_test}}", nil); err == nil {
+		t.Error("wanted the error of the resolver")
+	}
+
+	for _, name := range []string{"env", "include", "gcloud-project"} {
+		if err := RegisterContext(name, nil); err == nil {
+			t.Errorf("RegisterContext(%s) succeeded", name)
+		}
+	}
+}
+
+func TestRender_KubeContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as kubectl")
+	}
+	dir := t.TempDir()
+	kubectl := "#!/bin/sh\nif [ \"$2\" = current-context ]; then echo prod; else echo; fi\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(kubectl), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	got, err := Render("kubectl --context {{kube_context}} -n {{kube_namespace}} get pods", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kubectl --context prod -n default get pods"; got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+}
@@ -0,0 +1,269 @@
+package snippet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	gosync "sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/knqyf263/pet/config"
+)
+
+// passphraseEnv is the passphrase of [Encryption], asked if it is not set
+const passphraseEnv = "PET_PASSPHRASE"
+
+// ageHeaders start the files encrypted by age, binary and armored
+var ageHeaders = [][]byte{[]byte("age-encryption.org/v1\n"), []byte(armor.Header)}
+
+// AskPassphrase asks the passphrase of [Encryption] if $PET_PASSPHRASE is
+// not set. pet asks it on the terminal.
+var AskPassphrase func() (string, error)
+
+var (
+	// cryptMu guards the passphrase and the decrypted files of this run
+	cryptMu    gosync.Mutex
+	passphrase string
+	decrypted  = map[string]decryptedFile{}
+)
+
+// decryptedFile is a decrypted file and the state of the file it was
+// decrypted from
+type decryptedFile struct {
+	modTime int64
+	size    int64
+	data    []byte
+}
+
+// encryptedData reports whether the data is encrypted with age
+func encryptedData(data []byte) bool {
+	for _, h := range ageHeaders {
+		if bytes.HasPrefix(data, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encrypted reports whether the file is encrypted with age
+func Encrypted(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(armor.Header))
+	n, _ := io.ReadFull(f, head)
+	return encryptedData(head[:n])
+}
+
+// ReadFile returns the content of the snippet file, decrypted if it is
+// encrypted
+func ReadFile(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil || !encryptedData(data) {
+		return data, err
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cryptMu.Lock()
+	defer cryptMu.Unlock()
+	// Load may read the files more than once, and a passphrase is slow
+	if d, ok := decrypted[file]; ok && d.modTime == fi.ModTime().UnixNano() && d.size == fi.Size() {
+		return d.data, nil
+	}
+	plain, err := decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt %s: %v", file, err)
+	}
+	decrypted[file] = decryptedFile{modTime: fi.ModTime().UnixNano(), size: fi.Size(), data: plain}
+	return plain, nil
+}
+
+// EncryptFile encrypts the snippet file with [Encryption], or decrypts it
+func EncryptFile(file string, encrypt bool) error {
+	data, err := ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return writeFile(file, data, encrypt)
+}
+
+// dataFileNames are the files of the data directory keeping snippets,
+// commands, parameter values or outputs, encrypted like the snippet file
+var dataFileNames = []string{versionsFileName, trashFileName, lastFileName, usageFileName, paramHistoryFileName, lastOutputFileName}
+
+// writeDataFile writes one of dataFileNames, encrypted if the snippet file
+// is (or would be, if it does not exist yet)
+func writeDataFile(file string, data []byte) error {
+	encrypted := encryptionEnabled()
+	if snippetFile := config.Conf.General.SnippetFile; snippetFile != "" {
+		if _, err := os.Stat(snippetFile); err == nil {
+			encrypted = Encrypted(snippetFile)
+		}
+	}
+	return writeFile(file, data, encrypted)
+}
+
+// EncryptDataFiles encrypts the files of the data directory keeping
+// snippets, commands, parameter values or outputs (versions, trash, the
+// last execution and its output, the usage and the parameter history), or
+// decrypts them, and returns those changed
+func EncryptDataFiles(encrypt bool) ([]string, error) {
+	var changed []string
+	for _, name := range dataFileNames {
+		file, err := config.GetDataFile(name)
+		if err != nil {
+			return changed, err
+		}
+		if _, err := os.Stat(file); os.IsNotExist(err) || Encrypted(file) == encrypt {
+			continue
+		}
+		if err := EncryptFile(file, encrypt); err != nil {
+			return changed, err
+		}
+		changed = append(changed, file)
+	}
+	return changed, nil
+}
+
+// encryptionEnabled reports whether [Encryption] is set, so that new
+// snippet files are encrypted
+func encryptionEnabled() bool {
+	return config.Conf.Encryption.Identity != "" || config.Conf.Encryption.Passphrase
+}
+
+func decrypt(data []byte) ([]byte, error) {
+	identities, err := identities()
+	if err != nil {
+		return nil, err
+	}
+	var src io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte(armor.Header)) {
+		src = armor.NewReader(src)
+	}
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// encrypt returns the data encrypted with [Encryption], armored
+func encrypt(data []byte) ([]byte, error) {
+	recipients, err := recipients()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	a := armor.NewWriter(&buf)
+	w, err := age.Encrypt(a, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := a.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func identities() ([]age.Identity, error) {
+	enc := config.Conf.Encryption
+	switch {
+	case enc.Identity != "":
+		f, err := os.Open(enc.Identity)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the identity: %v", err)
+		}
+		defer f.Close()
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the identity %s: %v", enc.Identity, err)
+		}
+		return identities, nil
+	case enc.Passphrase:
+		pass, err := getPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(pass)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+	return nil, fmt.Errorf("the file is encrypted, set identity or passphrase in [Encryption]")
+}
+
+func recipients() ([]age.Recipient, error) {
+	if config.Conf.Encryption.Passphrase && config.Conf.Encryption.Identity == "" {
+		cryptMu.Lock()
+		pass, err := getPassphrase()
+		cryptMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(pass)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+	identities, err := identities()
+	if err != nil {
+		return nil, err
+	}
+	var recipients []age.Recipient
+	for _, id := range identities {
+		if x, ok := id.(*age.X25519Identity); ok {
+			recipients = append(recipients, x.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("the identity %s has no X25519 key", config.Conf.Encryption.Identity)
+	}
+	return recipients, nil
+}
+
+// SetPassphrase sets the passphrase of [Encryption] for this run, e.g. one
+// asked twice to encrypt the files
+func SetPassphrase(pass string) {
+	cryptMu.Lock()
+	defer cryptMu.Unlock()
+	passphrase = pass
+}
+
+// getPassphrase returns $PET_PASSPHRASE or asks the passphrase once. cryptMu
+// is held.
+func getPassphrase() (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if env := os.Getenv(passphraseEnv); env != "" {
+		passphrase = env
+		return passphrase, nil
+	}
+	if AskPassphrase == nil {
+		return "", fmt.Errorf("the snippet files are encrypted with a passphrase, set $%s", passphraseEnv)
+	}
+	pass, err := AskPassphrase()
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", fmt.Errorf("the passphrase is empty")
+	}
+	passphrase = pass
+	return passphrase, nil
+}
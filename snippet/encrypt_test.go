@@ -0,0 +1,203 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/knqyf263/pet/config"
+)
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Conf.Encryption.Identity = filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(config.Conf.Encryption.Identity, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := config.Conf.General.SnippetFile
+	if err := os.WriteFile(file, []byte("[[snippets]]\n  description = \"db\"\n  command = \"psql postgres://internal-host\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptFile(file, true); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if !Encrypted(file) || strings.Contains(string(data), "internal-host") {
+		t.Fatalf("the snippet file is not encrypted: %q", data)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets.Snippets) != 1 || snippets.Snippets[0].Command != "psql postgres://internal-host" {
+		t.Fatalf("Load() = %+v", snippets.Snippets)
+	}
+	// an encrypted file stays encrypted
+	snippets.Snippets[0].Command = "psql postgres://other-host"
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(file)
+	if !Encrypted(file) || strings.Contains(string(data), "other-host") {
+		t.Errorf("Save() decrypted the file: %q", data)
+	}
+	// the previous version is encrypted too
+	versions, err := config.GetDataFile(versionsFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(versions)
+	if !Encrypted(versions) || strings.Contains(string(data), "internal-host") {
+		t.Errorf("the versions are not encrypted: %q", data)
+	}
+	if loaded, err := LoadVersions(); err != nil || len(loaded["db"]) != 1 || loaded["db"][0].Command != "psql postgres://internal-host" {
+		t.Errorf("LoadVersions() = %+v, %v", loaded, err)
+	}
+	if err := Trash(snippets.Snippets); err != nil {
+		t.Fatal(err)
+	}
+	trash, _ := config.GetDataFile(trashFileName)
+	if !Encrypted(trash) {
+		t.Error("the trash is not encrypted")
+	}
+	if changed, err := EncryptDataFiles(false); err != nil || len(changed) != 2 || Encrypted(versions) || Encrypted(trash) {
+		t.Errorf("EncryptDataFiles(false) = %v, %v", changed, err)
+	}
+
+	if err := EncryptFile(file, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(file)
+	if Encrypted(file) || !strings.Contains(string(data), "other-host") {
+		t.Errorf("the snippet file is not decrypted: %q", data)
+	}
+
+	// another identity cannot decrypt the file
+	if err := EncryptFile(file, true); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := age.GenerateX25519Identity()
+	if err := os.WriteFile(config.Conf.Encryption.Identity, []byte(other.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	decrypted = map[string]decryptedFile{}
+	if _, err := ReadFile(file); err == nil {
+		t.Error("ReadFile() with another identity succeeded")
+	}
+}
+
+func TestEncryptFile_Passphrase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PET_PASSPHRASE", "correct horse")
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	defer SetPassphrase("")
+	config.Conf.Encryption.Passphrase = true
+	file := filepath.Join(dir, "snippet.toml")
+
+	// a new file is encrypted
+	if err := WriteFile(file, []byte("[[snippets]]\n  description = \"a\"\n  command = \"secret\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !Encrypted(file) {
+		t.Fatal("the new snippet file is not encrypted")
+	}
+	data, err := ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "secret") {
+		t.Errorf("ReadFile() = %q", data)
+	}
+}
+
+func TestEncryptDataFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Conf.Encryption.Identity = filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(config.Conf.Encryption.Identity, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := SnippetInfo{Description: "db", Command: "psql postgres://internal-host/<db>"}
+	snippets := Snippets{Snippets: []SnippetInfo{s}}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// what pet exec keeps of an execution
+	execution := Execution{Description: s.Description, Command: "psql postgres://internal-host/app", Params: map[string]string{"db": "internal-db"}}
+	if err := SaveLast([]Execution{execution}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordParams([]Execution{execution}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordUsage([]SnippetInfo{s}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveLastOutput([]byte("connected to internal-host\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	grep := func() []string {
+		var found []string
+		filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err == nil && (strings.Contains(string(data), "internal-host") || strings.Contains(string(data), "internal-db")) {
+				found = append(found, filepath.Base(path))
+			}
+			return err
+		})
+		return found
+	}
+	if found := grep(); len(found) != 0 {
+		t.Errorf("the command is in clear text in %v", found)
+	}
+	history, err := LoadParamHistory()
+	if err != nil || len(history["db"]) != 1 || history["db"][0] != "internal-db" {
+		t.Errorf("LoadParamHistory() = %v, %v", history, err)
+	}
+	usage, err := LoadUsage()
+	if err != nil || usage.Get(s).Count != 1 {
+		t.Errorf("LoadUsage() = %v, %v", usage, err)
+	}
+	if output, err := LoadLastOutput(); err != nil || string(output) != "connected to internal-host\n" {
+		t.Errorf("LoadLastOutput() = %q, %v", output, err)
+	}
+
+	// the last execution, its output, the usage and the parameter history
+	if changed, err := EncryptDataFiles(false); err != nil || len(changed) != 4 {
+		t.Fatalf("EncryptDataFiles(false) = %v, %v", changed, err)
+	}
+	if found := grep(); len(found) != 4 {
+		t.Errorf("decrypted %v, want the 4 data files", found)
+	}
+	if changed, err := EncryptDataFiles(true); err != nil || len(changed) != 4 {
+		t.Fatalf("EncryptDataFiles(true) = %v, %v", changed, err)
+	}
+	if found := grep(); len(found) != 0 {
+		t.Errorf("the command is in clear text in %v", found)
+	}
+}
@@ -0,0 +1,78 @@
+package snippet
+
+// The events of the snippets, which run the hooks of [Hooks]
+const (
+	EventCreate   = "create"
+	EventEdit     = "edit"
+	EventDelete   = "delete"
+	EventSync     = "sync"
+	EventConflict = "conflict"
+)
+
+// Event is a change of the snippets
+type Event struct {
+	// Name is one of the events, e.g. EventCreate
+	Name     string
+	Snippets []SnippetInfo
+	// Detail is "upload" or "download" for sync and conflict
+	Detail string
+}
+
+// OnEvent is called with the events of Save and of the sync. pet runs the
+// hooks of [Hooks].
+var OnEvent func(Event)
+
+// Fire calls OnEvent with the event
+func Fire(e Event) {
+	if OnEvent != nil {
+		OnEvent(e)
+	}
+}
+
+// Changes returns the events of the snippets created, edited (renamed
+// ones too) and deleted from before to after, in that order
+func Changes(before, after []SnippetInfo) []Event {
+	renamed := Renamed(before, after)
+	old := map[string]SnippetInfo{}
+	for _, s := range before {
+		old[s.Description] = s
+	}
+	renamedTo := map[string]string{}
+	for from, to := range renamed {
+		renamedTo[to] = from
+	}
+
+	var created, edited, deleted []SnippetInfo
+	current := map[string]bool{}
+	for _, s := range after {
+		current[s.Description] = true
+		description := s.Description
+		if from, ok := renamedTo[description]; ok {
+			description = from
+		}
+		o, ok := old[description]
+		switch {
+		case !ok:
+			created = append(created, s)
+		case !sameContent(o, s):
+			edited = append(edited, s)
+		}
+	}
+	for _, s := range before {
+		if _, ok := renamed[s.Description]; !ok && !current[s.Description] {
+			deleted = append(deleted, s)
+		}
+	}
+
+	var events []Event
+	for _, e := range []Event{
+		{Name: EventCreate, Snippets: created},
+		{Name: EventEdit, Snippets: edited},
+		{Name: EventDelete, Snippets: deleted},
+	} {
+		if len(e.Snippets) > 0 {
+			events = append(events, e)
+		}
+	}
+	return events
+}
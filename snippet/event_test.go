@@ -0,0 +1,60 @@
+package snippet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/config"
+)
+
+func TestChanges(t *testing.T) {
+	before := []SnippetInfo{
+		{Description: "edited", Command: "echo v1"},
+		{Description: "renamed", Command: "echo same"},
+		{Description: "deleted", Command: "echo gone"},
+		{Description: "kept", Command: "echo kept"},
+	}
+	after := []SnippetInfo{
+		{Description: "edited", Command: "echo v1", Tag: []string{"new"}},
+		{Description: "new name", Command: "echo same"},
+		{Description: "kept", Command: "echo kept"},
+		{Description: "created", Command: "echo new"},
+	}
+	var got []string
+	for _, e := range Changes(before, after) {
+		for _, s := range e.Snippets {
+			got = append(got, e.Name+" "+s.Description)
+		}
+	}
+	want := []string{"create created", "edit edited", "edit new name", "delete deleted"}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+	if events := Changes(before, before); len(events) != 0 {
+		t.Errorf("Changes() without changes = %+v", events)
+	}
+}
+
+func TestSave_Events(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(f string) { config.Conf.General.SnippetFile = f }(config.Conf.General.SnippetFile)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+
+	var events []string
+	OnEvent = func(e Event) { events = append(events, e.Name) }
+	defer func() { OnEvent = nil }()
+
+	snippets := Snippets{Snippets: []SnippetInfo{{Description: "greet", Command: "echo hello"}}}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	snippets.Snippets = nil
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(events, []string{EventCreate, EventDelete}); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,57 @@
+package snippet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseAge parses a duration which may also use days (d) and weeks (w),
+// e.g. 180d, 2w or 36h
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n := strings.TrimSuffix(s, suffix); n != s {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid duration: %s", s)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+// ExpiresAt returns when the snippet expires, or the zero time if it does
+// not. Expires is a date (2025-12-31, expiring at its end in local time),
+// a time in RFC 3339 or a duration after the creation (e.g. 30d).
+func (s SnippetInfo) ExpiresAt() (time.Time, error) {
+	if s.Expires == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s.Expires, time.Local); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s.Expires); err == nil {
+		return t, nil
+	}
+	ttl, err := ParseAge(s.Expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expires: %s (a date, e.g. 2025-12-31, or a duration, e.g. 30d)", s.Expires)
+	}
+	if s.CreatedAt == nil {
+		// the creation time is set when the snippet is saved
+		return time.Time{}, nil
+	}
+	return s.CreatedAt.Add(ttl), nil
+}
+
+// Expired reports whether the snippet is expired at now.
+func (s SnippetInfo) Expired(now time.Time) bool {
+	t, err := s.ExpiresAt()
+	return err == nil && !t.IsZero() && !now.Before(t)
+}
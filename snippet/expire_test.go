@@ -0,0 +1,29 @@
+package snippet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnippetInfo_Expired(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		expires string
+		want    bool
+	}{
+		{"", false},
+		{"2025-03-01", false},
+		{"2025-02-28", true},
+		{"2025-02-01T11:00:00Z", true},
+		{"30d", true},
+		{"12w", false},
+		{"soon", false},
+	}
+	for _, tt := range tests {
+		s := SnippetInfo{Expires: tt.expires, CreatedAt: &created}
+		if got := s.Expired(now); got != tt.want {
+			t.Errorf("%s: wanted %v, got %v", tt.expires, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package snippet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/knqyf263/pet/config"
+)
+
+// indexFileName is the index of the snippet files in the data directory
+const indexFileName = "index.json"
+
+// Index keeps the decoded snippet files, so that only the files changed
+// since the last run are parsed again
+type Index struct {
+	Files map[string]IndexedFile `json:"files"`
+	// changed is set when Files needs to be written back
+	changed bool
+}
+
+// IndexedFile is a decoded snippet file and the state of the file it was
+// decoded from
+type IndexedFile struct {
+	ModTime  int64    `json:"mod_time"`
+	Size     int64    `json:"size"`
+	Snippets Snippets `json:"snippets"`
+}
+
+// index is the index of this run, loaded on first use
+var index *Index
+
+// LoadIndex reads the index. A missing or broken index is empty, it is
+// rebuilt as the files are decoded.
+func LoadIndex() *Index {
+	idx := &Index{Files: map[string]IndexedFile{}}
+	file, err := config.GetDataFile(indexFileName)
+	if err != nil {
+		return idx
+	}
+	data, err := os.ReadFile(file)
+	if err != nil || json.Unmarshal(data, idx) != nil || idx.Files == nil {
+		return &Index{Files: map[string]IndexedFile{}}
+	}
+	return idx
+}
+
+// Decode returns the snippet file from the index, or decodes it and adds
+// it to the index if it changed
+func (idx *Index) Decode(file string) (Snippets, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return Snippets{}, err
+	}
+	if f, ok := idx.Files[file]; ok && f.ModTime == fi.ModTime().UnixNano() && f.Size == fi.Size() {
+		// the snippets are changed by LoadFile, those of the index are kept
+		snippets := f.Snippets
+		snippets.Snippets = append([]SnippetInfo{}, f.Snippets.Snippets...)
+		return snippets, nil
+	}
+	snippets, err := DecodeFile(file)
+	if err != nil {
+		return Snippets{}, err
+	}
+	indexed := snippets
+	indexed.Snippets = append([]SnippetInfo{}, snippets.Snippets...)
+	idx.Files[file] = IndexedFile{ModTime: fi.ModTime().UnixNano(), Size: fi.Size(), Snippets: indexed}
+	idx.changed = true
+	return snippets, nil
+}
+
+// Save writes the index if it changed, without the files which are gone
+func (idx *Index) Save() error {
+	for file := range idx.Files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			delete(idx.Files, file)
+			idx.changed = true
+		}
+	}
+	if !idx.changed {
+		return nil
+	}
+	file, err := config.GetDataFile(indexFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	// another pet may read the index meanwhile
+	tmp, err := os.CreateTemp(filepath.Dir(file), indexFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+	idx.changed = false
+	return nil
+}
+
+// decodeIndexed decodes the file through the index of this run
+func decodeIndexed(file string) (Snippets, error) {
+	if index == nil {
+		index = LoadIndex()
+	}
+	return index.Decode(file)
+}
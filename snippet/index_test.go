@@ -0,0 +1,62 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/pet/config"
+)
+
+func TestLoad_Index(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(g config.GeneralConfig) { config.Conf.General = g }(config.Conf.General)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.Index = true
+	defer func() { index = nil }()
+
+	write := func(command string) {
+		data := "[[snippets]]\n  description = \"greet\"\n  command = \"" + command + "\"\n  tag = []\n"
+		if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() Snippets {
+		// every run of pet reads the index again
+		index = nil
+		var snippets Snippets
+		if err := snippets.Load(); err != nil {
+			t.Fatal(err)
+		}
+		return snippets
+	}
+
+	write("echo hello")
+	load()
+	indexFile := filepath.Join(dir, indexFileName)
+	if _, err := os.Stat(indexFile); err != nil {
+		t.Fatalf("the index is not written: %v", err)
+	}
+	snippets := load()
+	if got := snippets.Snippets[0]; got.Command != "echo hello" || got.Tag == nil || got.File() != config.Conf.General.SnippetFile {
+		t.Errorf("Load() from the index = %+v", got)
+	}
+
+	write("echo changed")
+	if got := load().Snippets[0].Command; got != "echo changed" {
+		t.Errorf("Command = %q, want the changed command", got)
+	}
+	idx := LoadIndex()
+	if got := idx.Files[config.Conf.General.SnippetFile].Snippets.Snippets[0].Command; got != "echo changed" {
+		t.Errorf("indexed Command = %q, want the changed command", got)
+	}
+
+	// a broken index is rebuilt
+	if err := os.WriteFile(indexFile, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := load().Snippets[0].Command; got != "echo changed" {
+		t.Errorf("Command with a broken index = %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const lastFileName = "last.json"
+
+// Execution is a snippet with its parameters filled in
+type Execution struct {
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description"`
+	Command     string            `json:"command"`
+	Params      map[string]string `json:"params,omitempty"`
+	Time        time.Time         `json:"time"`
+	// Dir is the expanded working directory, empty for the current one
+	Dir string `json:"dir,omitempty"`
+	// Env are the expanded environment variables of the snippet
+	Env map[string]string `json:"env,omitempty"`
+	// AttachmentDir is the directory the attachments of the snippet are
+	// written to, as returned by NewAttachmentDir
+	AttachmentDir string `json:"attachment_dir,omitempty"`
+	// Redacted is the command with the secret parameters left unfilled,
+	// shown and saved instead of Command if the snippet has secrets
+	Redacted string `json:"-"`
+}
+
+// Public returns the execution as it can be shown and saved
+func (e Execution) Public() Execution {
+	if e.Redacted != "" {
+		// the variables may have secret values too
+		e.Command, e.Env = e.Redacted, nil
+	}
+	return e
+}
+
+// WithAttachmentDir returns the execution with its attachments written to
+// dir instead of its AttachmentDir
+func (e Execution) WithAttachmentDir(dir string) Execution {
+	if e.AttachmentDir == "" || e.AttachmentDir == dir {
+		return e
+	}
+	replace := func(s string) string { return strings.ReplaceAll(s, e.AttachmentDir, dir) }
+	e.Command, e.Redacted = replace(e.Command), replace(e.Redacted)
+	if len(e.Env) > 0 {
+		env := make(map[string]string, len(e.Env))
+		for name, value := range e.Env {
+			env[name] = replace(value)
+		}
+		e.Env = env
+	}
+	e.AttachmentDir = dir
+	return e
+}
+
+func lastFile() (string, error) {
+	return config.GetDataFile(lastFileName)
+}
+
+// SaveLast remembers the last executed snippets.
+func SaveLast(executions []Execution) error {
+	file, err := lastFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(executions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode the last execution. %v", err)
+	}
+	return writeDataFile(file, data)
+}
+
+// LoadLast returns the last executed snippets.
+func LoadLast() ([]Execution, error) {
+	file, err := lastFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No snippet has been executed yet")
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read the last execution. %v", err)
+	}
+	var executions []Execution
+	if err := json.Unmarshal(data, &executions); err != nil {
+		return nil, fmt.Errorf("Failed to parse the last execution. %v", err)
+	}
+	return executions, nil
+}
+
+const lastOutputFileName = "last_output"
+
+// MaxLastOutput is the most output of the last execution that is kept, its
+// end
+const MaxLastOutput = 1 << 20
+
+// SaveLastOutput remembers the output of the last execution, at most its
+// last MaxLastOutput bytes.
+func SaveLastOutput(output []byte) error {
+	file, err := config.GetDataFile(lastOutputFileName)
+	if err != nil {
+		return err
+	}
+	if len(output) > MaxLastOutput {
+		output = output[len(output)-MaxLastOutput:]
+	}
+	if err := writeDataFile(file, output); err != nil {
+		return fmt.Errorf("Failed to save the last output. %v", err)
+	}
+	return nil
+}
+
+// LoadLastOutput returns the output of the last execution saved by
+// SaveLastOutput.
+func LoadLastOutput() ([]byte, error) {
+	file, err := config.GetDataFile(lastOutputFileName)
+	if err != nil {
+		return nil, err
+	}
+	output, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No output has been saved yet, run pet exec with --save-output, --tee or --pager")
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read the last output. %v", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,25 @@
+package snippet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSaveLastOutput(t *testing.T) {
+	t.Setenv("PET_CONFIG_DIR", t.TempDir())
+	if _, err := LoadLastOutput(); err == nil {
+		t.Fatal("LoadLastOutput() succeeded without a saved output")
+	}
+
+	output := append(bytes.Repeat([]byte("a"), MaxLastOutput), "end\n"...)
+	if err := SaveLastOutput(output); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadLastOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != MaxLastOutput || !bytes.HasSuffix(got, []byte("aend\n")) {
+		t.Errorf("LoadLastOutput() = %d bytes ending with %q, want the last %d bytes", len(got), got[len(got)-5:], MaxLastOutput)
+	}
+}
@@ -0,0 +1,253 @@
+package snippet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/knqyf263/pet/dialog"
+)
+
+// Severity of a lint issue
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found by Lint
+type Issue struct {
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Severity    string `json:"severity"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message"`
+}
+
+func (i Issue) String() string {
+	pos := i.File
+	if i.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	if i.Description != "" {
+		return fmt.Sprintf("%s: %s: [%s] %s", pos, i.Severity, i.Description, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, i.Severity, i.Message)
+}
+
+var (
+	openParamRe = regexp.MustCompile(`(^|[^<])<([A-Za-z_][\w.-]*)([:=][^\s<>]*)?(\s|$)`)
+	paramNameRe = regexp.MustCompile(`^[\w.-]+$`)
+	envNameRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// LintFile checks a snippet file
+func LintFile(file string) ([]Issue, error) {
+	data, err := ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(file, data), nil
+}
+
+// Lint checks the content of a snippet file for syntax errors, unknown
+// fields, duplicates, empty commands and malformed parameters
+func Lint(file string, data []byte) (issues []Issue) {
+	if markers := ConflictMarkers(data); len(markers) > 0 {
+		for _, line := range markers {
+			issues = append(issues, Issue{File: file, Line: line, Severity: SeverityError, Message: "unresolved conflict marker"})
+		}
+		return issues
+	}
+	var snippets Snippets
+	md, err := toml.Decode(string(data), &snippets)
+	if err != nil {
+		return []Issue{{File: file, Severity: SeverityError, Message: err.Error()}}
+	}
+
+	for _, k := range md.Undecoded() {
+		issues = append(issues, Issue{File: file, Severity: SeverityError,
+			Message: fmt.Sprintf("unknown field %s", k.String())})
+	}
+
+	lines := snippetLines(data)
+	add := func(i int, severity, description, format string, a ...interface{}) {
+		issue := Issue{File: file, Severity: severity, Description: description, Message: fmt.Sprintf(format, a...)}
+		if i < len(lines) {
+			issue.Line = lines[i]
+		}
+		issues = append(issues, issue)
+	}
+
+	descriptions := map[string]bool{}
+	names := map[string]bool{}
+	for i, s := range snippets.Snippets {
+		d := s.Description
+		switch {
+		case strings.TrimSpace(d) == "":
+			add(i, SeverityError, d, "empty description")
+		case descriptions[d]:
+			add(i, SeverityError, d, "duplicate description")
+		}
+		descriptions[d] = true
+
+		if s.Name != "" {
+			if names[s.Name] {
+				add(i, SeverityError, d, "duplicate name %s", s.Name)
+			}
+			names[s.Name] = true
+		}
+		if s.Capture != "" && !paramNameRe.MatchString(s.Capture) {
+			add(i, SeverityError, d, "invalid capture name %s", s.Capture)
+		}
+		var env []string
+		for name := range s.Env {
+			if !envNameRe.MatchString(name) {
+				env = append(env, name)
+			}
+		}
+		sort.Strings(env)
+		for _, name := range env {
+			add(i, SeverityError, d, "invalid env name %s", name)
+		}
+		attached := map[string]bool{}
+		for _, a := range s.Attachments {
+			if err := CheckAttachmentName(a.Name); err != nil {
+				add(i, SeverityError, d, "%v", err)
+			} else if attached[a.Name] {
+				add(i, SeverityError, d, "duplicate attachment %s", a.Name)
+			}
+			attached[a.Name] = true
+		}
+		if _, err := s.ExpiresAt(); err != nil {
+			add(i, SeverityError, d, "%v", err)
+		}
+		if _, err := s.TimeoutDuration(); err != nil {
+			add(i, SeverityError, d, "%v", err)
+		}
+		if s.Visibility != "" && VisibilityRank(s.Visibility) < 0 {
+			add(i, SeverityError, d, "invalid visibility %s (%s)", s.Visibility, strings.Join(Visibilities, ", "))
+		}
+		if s.OnFailure != "" && (s.OnFailure == s.Name || s.OnFailure == d) {
+			add(i, SeverityError, d, "on_failure refers to the snippet itself")
+		}
+		if strings.ContainsAny(s.Shell, " \t") {
+			add(i, SeverityError, d, "invalid shell %s", s.Shell)
+		}
+		if s.Script != "" {
+			if err := compileScript(s); err != nil {
+				add(i, SeverityError, d, "invalid script: %v", err)
+			}
+		}
+
+		if strings.TrimSpace(s.Command) == "" {
+			add(i, SeverityError, d, "empty command")
+			continue
+		}
+		for _, msg := range lintParams(s.Command) {
+			add(i, SeverityWarning, d, "%s", msg)
+		}
+		if q := unbalancedQuote(s.Command); q != 0 {
+			add(i, SeverityWarning, d, "unbalanced %c quote", q)
+		}
+	}
+	return append(issues, lintRunbooks(file, snippets.Runbooks)...)
+}
+
+// lintRunbooks checks the names and steps of the runbooks
+func lintRunbooks(file string, runbooks []Runbook) (issues []Issue) {
+	add := func(format string, a ...interface{}) {
+		issues = append(issues, Issue{File: file, Severity: SeverityError, Message: fmt.Sprintf(format, a...)})
+	}
+	names := map[string]bool{}
+	for _, r := range runbooks {
+		switch {
+		case r.Name == "":
+			add("runbook without a name")
+		case names[r.Name]:
+			add("duplicate runbook %s", r.Name)
+		}
+		names[r.Name] = true
+		if len(r.Steps) == 0 {
+			add("runbook %s has no steps", r.Name)
+		}
+		for i, step := range r.Steps {
+			if step.Snippet == "" {
+				add("step %d of runbook %s has no snippet", i+1, r.Name)
+			}
+			if step.Capture != "" && !paramNameRe.MatchString(step.Capture) {
+				add("step %d of runbook %s: invalid capture name %s", i+1, r.Name, step.Capture)
+			}
+		}
+	}
+	return issues
+}
+
+// snippetLines returns the line numbers of the [[snippets]] headers
+func snippetLines(data []byte) (lines []int) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for scanner.Scan() {
+		n++
+		if strings.TrimSpace(scanner.Text()) == "[[snippets]]" {
+			lines = append(lines, n)
+		}
+	}
+	return lines
+}
+
+func lintParams(command string) (messages []string) {
+	// the command of a provider may contain spaces
+	closed := dialog.ParamPattern.ReplaceAllString(command, "_")
+	for _, m := range openParamRe.FindAllStringSubmatch(closed, -1) {
+		messages = append(messages, fmt.Sprintf("parameter <%s%s is not closed", m[2], m[3]))
+	}
+	for _, m := range dialog.ParamPattern.FindAllStringSubmatch(command, -1) {
+		// <<EOF > <file> is matched as "<EOF > <file"
+		if strings.HasPrefix(m[1], "<") {
+			continue
+		}
+		p := dialog.ParseParams(m[0])
+		if len(p) != 1 || !paramNameRe.MatchString(p[0].Name) {
+			messages = append(messages, fmt.Sprintf("invalid parameter name in <%s>", m[1]))
+			continue
+		}
+		if err := dialog.CheckType(p[0].Type); err != nil {
+			messages = append(messages, fmt.Sprintf("%v in <%s>", err, m[1]))
+		}
+		for _, o := range p[0].Choices() {
+			if o == "" {
+				messages = append(messages, fmt.Sprintf("empty choice in <%s>", m[1]))
+				break
+			}
+		}
+		if d := p[0].Default(); d != "" && p[0].Type != "" && p[0].Type != "file" && p[0].Type != "dir" {
+			if err := p[0].Validate(d); err != nil {
+				messages = append(messages, fmt.Sprintf("%v (default)", err))
+			}
+		}
+	}
+	return messages
+}
+
+// unbalancedQuote returns the quote character left open in a shell command
+func unbalancedQuote(command string) rune {
+	var quote rune
+	escaped := false
+	for _, c := range command {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return quote
+}
@@ -0,0 +1,96 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestLint(t *testing.T) {
+	data := `[[snippets]]
+  description = "ok"
+  command = "cat <<EOF > <file=out.txt>\nhello\nEOF"
+
+[[snippets]]
+  description = "ok"
+  command = "echo 'unbalanced"
+
+[[snippets]]
+  description = "params"
+  command = "kubectl -n <ns get pods <pod=a||b> <bad!name> <port:num> <n:int=x>"
+  descripton = "typo"
+
+[[snippets]]
+  description = "empty"
+  command = ""
+
+[[snippets]]
+  description = "capture"
+  command = "echo id"
+  capture = "instance id"
+  env = { "AWS REGION" = "x", AWS_PROFILE = "y" }
+
+[[snippets]]
+  description = "shell"
+  command = "print(1)"
+  shell = "python -u"
+  expires = "soon"
+  timeout = "forever"
+  visibility = "secret"
+  on_failure = "shell"
+  script = "replicas = (1"
+`
+	want := []Issue{
+		{File: "f", Severity: SeverityError, Message: "unknown field snippets.descripton"},
+		{File: "f", Line: 5, Severity: SeverityError, Description: "ok", Message: "duplicate description"},
+		{File: "f", Line: 5, Severity: SeverityWarning, Description: "ok", Message: "unbalanced ' quote"},
+		{File: "f", Line: 9, Severity: SeverityWarning, Description: "params", Message: "parameter <ns is not closed"},
+		{File: "f", Line: 9, Severity: SeverityWarning, Description: "params", Message: "empty choice in <pod=a||b>"},
+		{File: "f", Line: 9, Severity: SeverityWarning, Description: "params", Message: "invalid parameter name in <bad!name>"},
+		{File: "f", Line: 9, Severity: SeverityWarning, Description: "params", Message: "unknown type: num (int, path, file, dir or /regexp/) in <port:num>"},
+		{File: "f", Line: 9, Severity: SeverityWarning, Description: "params", Message: "invalid value for <n>: x is not an integer (default)"},
+		{File: "f", Line: 14, Severity: SeverityError, Description: "empty", Message: "empty command"},
+		{File: "f", Line: 18, Severity: SeverityError, Description: "capture", Message: "invalid capture name instance id"},
+		{File: "f", Line: 18, Severity: SeverityError, Description: "capture", Message: "invalid env name AWS REGION"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "invalid expires: soon (a date, e.g. 2025-12-31, or a duration, e.g. 30d)"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "invalid timeout: forever (a duration, e.g. 30s or 5m)"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "invalid visibility secret (private, internal, public)"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "on_failure refers to the snippet itself"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "invalid shell python -u"},
+		{File: "f", Line: 24, Severity: SeverityError, Description: "shell", Message: "invalid script: shell:1:14: got end of file, want ')'"},
+	}
+
+	got := Lint("f", []byte(data))
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLint_Runbooks(t *testing.T) {
+	data := `[[runbooks]]
+  name = "incident"
+  [[runbooks.steps]]
+    snippet = "check"
+  [[runbooks.steps]]
+    capture = "pod id"
+
+[[runbooks]]
+  name = "incident"
+`
+	want := []Issue{
+		{File: "f", Severity: SeverityError, Message: "step 2 of runbook incident has no snippet"},
+		{File: "f", Severity: SeverityError, Message: "step 2 of runbook incident: invalid capture name pod id"},
+		{File: "f", Severity: SeverityError, Message: "duplicate runbook incident"},
+		{File: "f", Severity: SeverityError, Message: "runbook incident has no steps"},
+	}
+	if diff := deep.Equal(want, Lint("f", []byte(data))); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLint_SyntaxError(t *testing.T) {
+	got := Lint("f", []byte("[[snippets]]\n  command = \"unterminated\n"))
+	if len(got) != 1 || got[0].Severity != SeverityError {
+		t.Fatalf("unexpected issues %+v", got)
+	}
+}
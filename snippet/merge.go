@@ -0,0 +1,159 @@
+package snippet
+
+import "fmt"
+
+// Resolution is how Merge resolves a conflict
+type Resolution int
+
+const (
+	// KeepLocal keeps the local snippet
+	KeepLocal Resolution = iota
+	// TakeOther replaces the local snippet with the other one
+	TakeOther
+	// KeepBoth adds the other snippet with a numbered description
+	KeepBoth
+)
+
+// MergeResult counts the changes made by Merge
+type MergeResult struct {
+	Added    int
+	Replaced int
+	Updated  int
+	Skipped  int
+}
+
+// Merge merges other snippets into snippets. A snippet with the same
+// description and command gets the union of the tags; for the same
+// description with a different command, resolve decides. A snippet of
+// another description with the same normalized command as a local one is
+// merged into it (see MergeMetadata) instead of added. Names already used
+// by another local snippet are dropped from the merged snippets.
+func (snippets *Snippets) Merge(other []SnippetInfo, resolve func(local, other SnippetInfo) (Resolution, error)) (MergeResult, error) {
+	var result MergeResult
+	for _, o := range other {
+		i := -1
+		for j, s := range snippets.Snippets {
+			if s.Description == o.Description {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			if j := snippets.FindByCommand(o.Command); j >= 0 {
+				if _, ok := snippets.FindByName(o.Name); ok {
+					o.Name = ""
+				}
+				if snippets.Snippets[j].MergeMetadata(o) {
+					result.Updated++
+				} else {
+					result.Skipped++
+				}
+				continue
+			}
+			snippets.add(o, -1)
+			result.Added++
+			continue
+		}
+
+		local := &snippets.Snippets[i]
+		if local.Command == o.Command {
+			updated := false
+			for _, t := range o.Tag {
+				if local.AddTag(t) {
+					updated = true
+				}
+			}
+			if updated {
+				result.Updated++
+			} else {
+				result.Skipped++
+			}
+			continue
+		}
+
+		r, err := resolve(*local, o)
+		if err != nil {
+			return result, err
+		}
+		switch r {
+		case TakeOther:
+			snippets.add(o, i)
+			result.Replaced++
+		case KeepBoth:
+			for n := 2; ; n++ {
+				d := fmt.Sprintf("%s (%d)", o.Description, n)
+				if _, ok := snippets.Find(d); !ok {
+					o.Description = d
+					break
+				}
+			}
+			snippets.add(o, -1)
+			result.Added++
+		default:
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// add appends s, or replaces the snippet at index i keeping its file,
+// dropping a name used by another snippet
+func (snippets *Snippets) add(s SnippetInfo, i int) {
+	if s.Name != "" {
+		for j, t := range snippets.Snippets {
+			if j != i && t.Name == s.Name {
+				s.Name = ""
+				break
+			}
+		}
+	}
+	if i < 0 {
+		snippets.Snippets = append(snippets.Snippets, s)
+		return
+	}
+	s.file = snippets.Snippets[i].file
+	snippets.Snippets[i] = s
+}
+
+// MergeMetadata merges the metadata of other, a duplicate of the snippet
+// with the same command, into it: the union of the tags, and the name, the
+// path and the output if the snippet has none. The description is kept.
+// It reports whether the snippet changed.
+func (s *SnippetInfo) MergeMetadata(other SnippetInfo) bool {
+	changed := false
+	for _, t := range other.Tag {
+		if s.AddTag(t) {
+			changed = true
+		}
+	}
+	fill := func(field *string, value string) {
+		if *field == "" && value != "" {
+			*field = value
+			changed = true
+		}
+	}
+	fill(&s.Name, other.Name)
+	fill(&s.Path, other.Path)
+	fill(&s.Output, other.Output)
+	return changed
+}
+
+// MergeDuplicates merges each snippet with the same normalized command as
+// an earlier one into it (see MergeMetadata), and returns the snippets left
+// and the number of snippets merged
+func MergeDuplicates(snippets []SnippetInfo) ([]SnippetInfo, int) {
+	var merged Snippets
+	n := 0
+	for _, s := range snippets {
+		if i := merged.FindByCommand(s.Command); i >= 0 {
+			if _, ok := merged.FindByName(s.Name); ok {
+				s.Name = ""
+			}
+			merged.Snippets[i].MergeMetadata(s)
+			n++
+			continue
+		}
+		merged.Snippets = append(merged.Snippets, s)
+	}
+	return merged.Snippets, n
+}
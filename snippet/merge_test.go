@@ -0,0 +1,95 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSnippets_Merge(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Name: "a", Description: "same", Command: "echo same", Tag: []string{"x"}},
+		{Description: "conflict 1", Command: "echo local"},
+		{Description: "conflict 2", Command: "echo local"},
+		{Description: "conflict 3", Command: "echo local"},
+	}}
+	other := []SnippetInfo{
+		{Description: "same", Command: "echo same", Tag: []string{"x", "y"}},
+		{Description: "conflict 1", Command: "echo other"},
+		{Description: "conflict 2", Command: "echo other"},
+		{Description: "conflict 3", Command: "echo other"},
+		{Name: "a", Description: "new", Command: "echo new"},
+	}
+	resolutions := map[string]Resolution{
+		"conflict 1": KeepLocal,
+		"conflict 2": TakeOther,
+		"conflict 3": KeepBoth,
+	}
+
+	got, err := snippets.Merge(other, func(local, other SnippetInfo) (Resolution, error) {
+		return resolutions[local.Description], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SnippetInfo{
+		{Name: "a", Description: "same", Command: "echo same", Tag: []string{"x", "y"}},
+		{Description: "conflict 1", Command: "echo local"},
+		{Description: "conflict 2", Command: "echo other"},
+		{Description: "conflict 3", Command: "echo local"},
+		{Description: "conflict 3 (2)", Command: "echo other"},
+		{Description: "new", Command: "echo new"},
+	}
+	if diff := deep.Equal(want, snippets.Snippets); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(MergeResult{Added: 2, Replaced: 1, Updated: 1, Skipped: 1}, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestSnippets_Merge_SameCommand(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Name: "ls", Description: "list", Command: "ls -la", Tag: []string{"files"}},
+		{Description: "greet", Command: "echo hello", Tag: []string{"shell"}},
+	}}
+	other := []SnippetInfo{
+		{Name: "ls", Description: "list all", Command: "ls -la ", Tag: []string{"shell"}, Path: "fs"},
+		{Description: "say hello", Command: "echo hello;", Tag: []string{"shell"}},
+	}
+
+	got, err := snippets.Merge(other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SnippetInfo{
+		{Name: "ls", Description: "list", Command: "ls -la", Tag: []string{"files", "shell"}, Path: "fs"},
+		{Description: "greet", Command: "echo hello", Tag: []string{"shell"}},
+	}
+	if diff := deep.Equal(want, snippets.Snippets); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(MergeResult{Updated: 1, Skipped: 1}, got); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	got, n := MergeDuplicates([]SnippetInfo{
+		{Description: "a", Command: "echo a", Tag: []string{"x"}},
+		{Description: "b", Command: "echo b"},
+		{Name: "a", Description: "a again", Command: "  echo a", Tag: []string{"y"}, Output: "a"},
+	})
+	want := []SnippetInfo{
+		{Name: "a", Description: "a", Command: "echo a", Tag: []string{"x", "y"}, Output: "a"},
+		{Description: "b", Command: "echo b"},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+	if n != 1 {
+		t.Errorf("merged %d, want 1", n)
+	}
+}
@@ -0,0 +1,89 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const (
+	paramHistoryFileName = "params.json"
+	// maxParamHistory is the number of values remembered per parameter
+	maxParamHistory = 10
+)
+
+// ParamHistory maps parameter names to their previously entered values,
+// most recent first. Secret values are never recorded.
+type ParamHistory map[string][]string
+
+func paramHistoryFile() (string, error) {
+	return config.GetDataFile(paramHistoryFileName)
+}
+
+// LoadParamHistory reads the previously entered parameter values.
+func LoadParamHistory() (ParamHistory, error) {
+	history := ParamHistory{}
+	file, err := paramHistoryFile()
+	if err != nil {
+		return history, err
+	}
+	data, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return history, nil
+	} else if err != nil {
+		return history, fmt.Errorf("Failed to read parameter history file. %v", err)
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return history, fmt.Errorf("Failed to parse parameter history file. %v", err)
+	}
+	return history, nil
+}
+
+// Save writes the parameter history.
+func (history ParamHistory) Save() error {
+	file, err := paramHistoryFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode parameter history. %v", err)
+	}
+	return writeDataFile(file, data)
+}
+
+// Add moves value to the front of the values of the parameter.
+func (history ParamHistory) Add(name, value string) {
+	if value == "" {
+		return
+	}
+	values := []string{value}
+	for _, v := range history[name] {
+		if v != value && len(values) < maxParamHistory {
+			values = append(values, v)
+		}
+	}
+	history[name] = values
+}
+
+// RecordParams remembers the parameter values of the executions, which must
+// not contain secret values (see Execution.Public).
+func RecordParams(executions []Execution) error {
+	history, err := LoadParamHistory()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, e := range executions {
+		for name, value := range e.Params {
+			history.Add(name, value)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return history.Save()
+}
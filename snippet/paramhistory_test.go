@@ -0,0 +1,24 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParamHistory_Add(t *testing.T) {
+	history := ParamHistory{}
+	for _, v := range []string{"a", "b", "", "a"} {
+		history.Add("ns", v)
+	}
+	for i := 0; i < maxParamHistory+5; i++ {
+		history.Add("n", string(rune('a'+i)))
+	}
+
+	if diff := deep.Equal([]string{"a", "b"}, history["ns"]); diff != nil {
+		t.Error(diff)
+	}
+	if len(history["n"]) != maxParamHistory || history["n"][0] != "o" {
+		t.Errorf("unexpected history %v", history["n"])
+	}
+}
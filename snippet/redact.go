@@ -0,0 +1,97 @@
+package snippet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedText replaces the secrets found in the snippets
+const redactedText = "****"
+
+// SecretPatterns are the secrets found in the snippets by default: access
+// keys and tokens of well-known services, private keys, passwords of URLs,
+// of key=value pairs and after -p. A group named secret is the secret of
+// its match, which is the secret without one.
+var SecretPatterns = []string{
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key\s*[=:]\s*['"]?(?P<secret>[A-Za-z0-9/+=]{40})`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}`,
+	`\bglpat-[A-Za-z0-9_-]{20,}`,
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,
+	`\bsk-[A-Za-z0-9_-]{20,}`,
+	`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]{16,}=*)`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@<]+)@`,
+	`(?i)\b(?:password|passwd|pwd|secret|token|api_?key)\s*[=:]\s*['"]?(?P<secret>[^\s'"<]{4,})`,
+	`(?i)--password[= ]['"]?(?P<secret>[^\s'"<-][^\s'"]*)`,
+	`\b(?:mysql|mysqldump|mariadb)\b[^\n]*?\s-p['"]?(?P<secret>[^\s'"<-][^\s'"]*)`,
+	`\bsshpass\s+-p\s*['"]?(?P<secret>[^\s'"<-][^\s'"]*)`,
+}
+
+// Redactor hides the secrets of the snippets in what pet shows
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns the Redactor of SecretPatterns and the patterns
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string{}, SecretPatterns...), patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid secret pattern %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the text with its secrets replaced by ****. A nil Redactor
+// returns the text as it is.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, re := range r.patterns {
+		group := re.SubexpIndex("secret")
+		var b strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if group >= 0 && loc[2*group] >= 0 {
+				start, end = loc[2*group], loc[2*group+1]
+			}
+			b.WriteString(text[last:start])
+			b.WriteString(redactedText)
+			last = end
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+// Redact returns the snippet with the secrets of its command, output,
+// notes, environment, hooks and script hidden
+func (s SnippetInfo) Redact(r *Redactor) SnippetInfo {
+	if r == nil {
+		return s
+	}
+	s.Command = r.Redact(s.Command)
+	s.Output = r.Redact(s.Output)
+	s.Notes = r.Redact(s.Notes)
+	s.PreExec = r.Redact(s.PreExec)
+	s.PostExec = r.Redact(s.PostExec)
+	s.Script = r.Redact(s.Script)
+	if len(s.Env) > 0 {
+		env := make(map[string]string, len(s.Env))
+		for name, value := range s.Env {
+			// with its name, a value is found like one of the command
+			env[name] = strings.TrimPrefix(r.Redact(name+"="+value), name+"=")
+		}
+		s.Env = env
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package snippet
+
+import "fmt"
+
+// Runbook is a named list of snippets run step by step with pet run
+type Runbook struct {
+	Name        string        `toml:"name"`
+	Description string        `toml:"description,omitempty"`
+	Steps       []RunbookStep `toml:"steps"`
+}
+
+// RunbookStep is a step of a runbook
+type RunbookStep struct {
+	// Snippet is the name of the snippet of the step, or its description
+	Snippet string `toml:"snippet"`
+	// Confirm asks before the step runs
+	Confirm bool `toml:"confirm,omitempty"`
+	// Pause is shown before the step, which waits for Enter
+	Pause string `toml:"pause,omitempty"`
+	// Capture is the parameter of the next steps set to the output of the
+	// step, besides the capture of its snippet
+	Capture string `toml:"capture,omitempty"`
+}
+
+// FindRunbook returns the runbook with the name.
+func (snippets *Snippets) FindRunbook(name string) (Runbook, bool) {
+	for _, r := range snippets.Runbooks {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Runbook{}, false
+}
+
+// StepSnippets returns the snippets of the steps of the runbook, with the
+// capture of the steps
+func (snippets *Snippets) StepSnippets(r Runbook) ([]SnippetInfo, error) {
+	var steps []SnippetInfo
+	for i, step := range r.Steps {
+		s, ok := snippets.FindByRef(step.Snippet)
+		if !ok {
+			return nil, fmt.Errorf("Step %d of runbook %s: snippet [%s] not found", i+1, r.Name, step.Snippet)
+		}
+		if step.Capture != "" {
+			s.Capture = step.Capture
+		}
+		steps = append(steps, s)
+	}
+	return steps, nil
+}
@@ -0,0 +1,75 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/config"
+)
+
+func TestSnippets_Runbooks(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+
+	content := `[[snippets]]
+  name = "pods"
+  description = "List pods"
+  command = "kubectl get pods"
+
+[[snippets]]
+  description = "Restart the pod"
+  command = "kubectl delete pod <pod>"
+
+[[runbooks]]
+  name = "restart"
+  [[runbooks.steps]]
+    snippet = "pods"
+    capture = "pod"
+  [[runbooks.steps]]
+    snippet = "Restart the pod"
+    confirm = true
+`
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	// the runbooks of the file are written back
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Snippets
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := reloaded.FindRunbook("restart")
+	if !ok {
+		t.Fatalf("FindRunbook() did not find the runbook in %+v", reloaded.Runbooks)
+	}
+	steps, err := reloaded.StepSnippets(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.Description+":"+s.Capture)
+	}
+	if diff := deep.Equal([]string{"List pods:pod", "Restart the pod:"}, got); diff != nil {
+		t.Error(diff)
+	}
+	if !r.Steps[1].Confirm {
+		t.Error("the confirm of the step was not kept")
+	}
+
+	r.Steps = append(r.Steps, RunbookStep{Snippet: "missing"})
+	if _, err := reloaded.StepSnippets(r); err == nil {
+		t.Error("StepSnippets() of a missing snippet succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/knqyf263/pet/dialog"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxScriptSteps stops scripts which do not end
+const maxScriptSteps = 1000000
+
+// scriptOptions allow if and for at the top level of scripts, and globals
+// set more than once
+var scriptOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+
+// scriptBuiltins are the functions of the scripts of snippets
+var scriptBuiltins = starlark.StringDict{
+	"env": starlark.NewBuiltin("env", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name, def string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "default?", &def); err != nil {
+			return nil, err
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return starlark.String(v), nil
+		}
+		return starlark.String(def), nil
+	}),
+	"which": starlark.NewBuiltin("which", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name); err != nil {
+			return nil, err
+		}
+		path, _ := exec.LookPath(name)
+		return starlark.String(path), nil
+	}),
+	"exists": starlark.NewBuiltin("exists", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var path string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &path); err != nil {
+			return nil, err
+		}
+		path, err := ExpandDir(path)
+		if err != nil {
+			return nil, err
+		}
+		_, err = os.Stat(path)
+		return starlark.Bool(err == nil), nil
+	}),
+	"hostname": starlark.NewBuiltin("hostname", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+			return nil, err
+		}
+		name, err := os.Hostname()
+		return starlark.String(name), err
+	}),
+	"os":   starlark.String(runtime.GOOS),
+	"arch": starlark.String(runtime.GOARCH),
+}
+
+// compileScript parses the script of the snippet
+func compileScript(s SnippetInfo) error {
+	_, _, err := starlark.SourceProgramOptions(scriptOptions, s.Description, s.Script, func(name string) bool {
+		_, ok := scriptBuiltins[name]
+		return ok || name == "snippet"
+	})
+	return err
+}
+
+// ScriptedCommand returns the command of the snippet as its script sets it:
+// the script (Starlark) may set command to another command, and a global
+// named like a parameter becomes its default. The command is returned as it
+// is without a script.
+func (s SnippetInfo) ScriptedCommand() (string, error) {
+	if s.Script == "" {
+		return s.Command, nil
+	}
+	tags := make([]starlark.Value, len(s.Tag))
+	for i, t := range s.Tag {
+		tags[i] = starlark.String(t)
+	}
+	predeclared := starlark.StringDict{
+		"snippet": starlarkstruct.FromStringDict(starlark.String("snippet"), starlark.StringDict{
+			"command":     starlark.String(s.Command),
+			"description": starlark.String(s.Description),
+			"name":        starlark.String(s.Name),
+			"tags":        starlark.NewList(tags),
+		}),
+	}
+	for name, v := range scriptBuiltins {
+		predeclared[name] = v
+	}
+
+	thread := &starlark.Thread{
+		Name:  s.Description,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	globals, err := starlark.ExecFileOptions(scriptOptions, thread, s.Description, s.Script, predeclared)
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			err = fmt.Errorf("%s", evalErr.Backtrace())
+		}
+		return s.Command, fmt.Errorf("Failed to run the script of [%s]: %v", s.Description, err)
+	}
+
+	command := s.Command
+	if v, ok := globals["command"]; ok {
+		c, ok := starlark.AsString(v)
+		if !ok {
+			return s.Command, fmt.Errorf("Failed to run the script of [%s]: command is a %s, not a string", s.Description, v.Type())
+		}
+		command = c
+	}
+	defaults := map[string]string{}
+	for _, p := range dialog.ParseParams(command) {
+		switch v := globals[p.Name].(type) {
+		case starlark.String:
+			defaults[p.Name] = string(v)
+		case starlark.Bool:
+			defaults[p.Name] = strconv.FormatBool(bool(v))
+		case starlark.Int, starlark.Float:
+			defaults[p.Name] = v.String()
+		}
+	}
+	return dialog.WithDefaults(command, defaults), nil
+}
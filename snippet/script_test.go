@@ -0,0 +1,63 @@
+package snippet
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestScriptedCommand(t *testing.T) {
+	t.Setenv("PET_SCRIPT_TEST", "staging")
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{name: "no script", want: "deploy <env> <replicas=1>"},
+		{
+			name:   "defaults",
+			script: "env = env('PET_SCRIPT_TEST', 'dev')\nreplicas = 3 if env == 'staging' else 1",
+			want:   "deploy <env=staging> <replicas=3>",
+		},
+		{
+			name:   "command",
+			script: "command = snippet.command.replace('deploy', 'rollback') if 'ops' in snippet.tags else snippet.command",
+			want:   "rollback <env> <replicas=1>",
+		},
+		{
+			name:   "top level if",
+			script: "if env('PET_SCRIPT_TEST') == 'staging':\n    replicas = 2\nelse:\n    replicas = 1\nreplicas += 1",
+			want:   "deploy <env> <replicas=3>",
+		},
+		{
+			name:   "builtins",
+			script: "env = os + '-' + str(exists('/no/such/file')) + '-' + which('no-such-command-of-pet')",
+			want:   "deploy <env=" + runtime.GOOS + "-False-> <replicas=1>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SnippetInfo{Description: "deploy", Command: "deploy <env> <replicas=1>", Tag: []string{"ops"}, Script: tt.script}
+			got, err := s.ScriptedCommand()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ScriptedCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptedCommand_Errors(t *testing.T) {
+	for _, script := range []string{
+		"fail('no cluster')",
+		"command = 1",
+		"while True:\n  pass",
+	} {
+		s := SnippetInfo{Description: "deploy", Command: "deploy", Script: script}
+		if _, err := s.ScriptedCommand(); err == nil || !strings.Contains(err.Error(), "[deploy]") {
+			t.Errorf("ScriptedCommand() of %q = %v, want an error", script, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package snippet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretCommands are the CLIs of 1Password and Bitwarden
+var secretCommands = map[string][]string{
+	"op": {"op"},
+	"bw": {"bw"},
+}
+
+// bitwardenObjects are the fields of an item which bw get returns itself
+var bitwardenObjects = []string{"password", "username", "uri", "totp", "notes"}
+
+// ReadSecret returns the value of a parameter read from a secret store:
+//
+//	vault  path#field of a Vault secret, see ReadVault
+//	op     //vault/item/field, read with op read op://vault/item/field
+//	bw     item#field of Bitwarden, the password if there is no field
+//
+// The CLIs of 1Password and Bitwarden must be signed in, e.g. with
+// $OP_SESSION_* or $BW_SESSION.
+func ReadSecret(store, ref string) (string, error) {
+	switch store {
+	case "vault":
+		return ReadVault(ref)
+	case "op":
+		out, err := runSecretCommand("op", "read", "--no-newline", "op:"+ref)
+		return strings.TrimRight(out, "\r\n"), err
+	case "bw":
+		return readBitwarden(ref)
+	}
+	return "", fmt.Errorf("unknown secret store: %s", store)
+}
+
+func readBitwarden(ref string) (string, error) {
+	item, field, _ := strings.Cut(ref, "#")
+	if item == "" {
+		return "", fmt.Errorf("invalid Bitwarden item %s (item#field)", ref)
+	}
+	if field == "" {
+		field = "password"
+	}
+	for _, o := range bitwardenObjects {
+		if field == o {
+			out, err := runSecretCommand("bw", "get", o, item)
+			return strings.TrimRight(out, "\r\n"), err
+		}
+	}
+	// the other fields are the custom fields of the item
+	out, err := runSecretCommand("bw", "get", "item", item)
+	if err != nil {
+		return "", err
+	}
+	var it struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(out), &it); err != nil {
+		return "", fmt.Errorf("Failed to read the Bitwarden item %s: %v", item, err)
+	}
+	for _, f := range it.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("the Bitwarden item %s has no field %s", item, field)
+}
+
+// runSecretCommand runs the CLI of a password manager and returns its output
+func runSecretCommand(name string, args ...string) (string, error) {
+	command := secretCommands[name]
+	cmd := exec.Command(command[0], append(append([]string{}, command[1:]...), args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("%s is not installed", command[0])
+	} else if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("Failed to run %s %s: %s", name, args[0], msg)
+		}
+		return "", fmt.Errorf("Failed to run %s %s: %v", name, args[0], err)
+	}
+	return string(out), nil
+}
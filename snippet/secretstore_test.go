@@ -0,0 +1,69 @@
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSecretCommandHelper is the op and bw run by TestReadSecret
+func TestSecretCommandHelper(t *testing.T) {
+	if os.Getenv("PET_SECRET_HELPER") == "" {
+		return
+	}
+	args := os.Args
+	for i, a := range args {
+		if a == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	switch strings.Join(args, " ") {
+	case "op read --no-newline op://Private/db/password":
+		fmt.Print("op-s3cr3t")
+	case "bw get password db":
+		fmt.Print("bw-s3cr3t")
+	case "bw get username db":
+		fmt.Println("admin")
+	case "bw get item db":
+		fmt.Print(`{"name":"db","fields":[{"name":"host","value":"db.internal"}]}`)
+	default:
+		fmt.Fprint(os.Stderr, "Not found.")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestReadSecret(t *testing.T) {
+	defer func(c map[string][]string) { secretCommands = c }(secretCommands)
+	t.Setenv("PET_SECRET_HELPER", "1")
+	secretCommands = map[string][]string{}
+	for _, name := range []string{"op", "bw"} {
+		secretCommands[name] = []string{os.Args[0], "-test.run=TestSecretCommandHelper", "--", name}
+	}
+
+	tests := []struct {
+		store, ref string
+		want       string
+		ok         bool
+	}{
+		{"op", "//Private/db/password", "op-s3cr3t", true},
+		{"op", "//Private/db/missing", "", false},
+		{"bw", "db", "bw-s3cr3t", true},
+		{"bw", "db#username", "admin", true},
+		{"bw", "db#host", "db.internal", true},
+		{"bw", "db#port", "", false},
+		{"bw", "other", "", false},
+		{"keepass", "db", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ReadSecret(tt.store, tt.ref)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadSecret(%s, %s) = %q, %v", tt.store, tt.ref, got, err)
+		}
+	}
+	if _, err := ReadSecret("bw", "other"); err == nil || !strings.Contains(err.Error(), "Not found.") {
+		t.Errorf("the error of bw is not shown: %v", err)
+	}
+}
@@ -4,45 +4,581 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/knqyf263/pet/config"
 )
 
 type Snippets struct {
-	Snippets []SnippetInfo `toml:"snippets"`
+	// Vars are the [variables] of the snippet file, substituted for the
+	// parameters of the same name in its snippets
+	Vars     map[string]string `toml:"variables,omitempty"`
+	Snippets []SnippetInfo     `toml:"snippets"`
+	// Runbooks are the [[runbooks]] of the snippet file, see pet run
+	Runbooks []Runbook `toml:"runbooks,omitempty"`
+	// files are the loaded snippet files, written back by Save even if
+	// all their snippets were removed
+	files []string
+	// loaded are the snippets as loaded, by description, to find the
+	// snippets changed before Save
+	loaded []SnippetInfo
+	// fileVars are the variables of each loaded file, written back by Save
+	fileVars map[string]map[string]string
+	// fileRunbooks are the runbooks of each loaded file, written back by
+	// Save
+	fileRunbooks map[string][]Runbook
 }
 
 type SnippetInfo struct {
-	Description string   `toml:"description"`
-	Command     string   `toml:"command"`
-	Tag         []string `toml:"tag"`
-	Output      string   `toml:"output"`
+	Name        string   `toml:"name,omitempty" json:"name,omitempty"`
+	Path        string   `toml:"path,omitempty" json:"path,omitempty"`
+	Description string   `toml:"description" json:"description"`
+	Command     string   `toml:"command" json:"command"`
+	Tag         []string `toml:"tag" json:"tag"`
+	Output      string   `toml:"output" json:"output"`
+	Confirm     bool     `toml:"confirm,omitempty" json:"confirm,omitempty"`
+	Archived    bool     `toml:"archived,omitempty" json:"archived,omitempty"`
+	Favorite    bool     `toml:"favorite,omitempty" json:"favorite,omitempty"`
+	// Capture is the parameter filled in with the output in the next snippets
+	Capture string `toml:"capture,omitempty" json:"capture,omitempty"`
+	// OutputFilter is a command the output goes through before it is
+	// shown, copied or captured, or a jq filter (jq -r) if it starts with "."
+	OutputFilter string `toml:"output_filter,omitempty" json:"output_filter,omitempty"`
+	// Shell is the interpreter the command is run with, e.g. bash or python
+	Shell string `toml:"shell,omitempty" json:"shell,omitempty"`
+	// Dir is the working directory of the command, with ~ and the template
+	// functions of commands (see ExpandDir)
+	Dir string `toml:"dir,omitempty" json:"dir,omitempty"`
+	// Env are set in the environment of the command, with the template
+	// functions and parameters of commands
+	Env map[string]string `toml:"env,omitempty" json:"env,omitempty"`
+	// Sudo runs the command with elevated privileges (sudo or doas, UAC on
+	// Windows)
+	Sudo bool `toml:"sudo,omitempty" json:"sudo,omitempty"`
+	// Timeout is how long the command may run, e.g. 30s or 5m
+	Timeout string `toml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Platform are the operating systems (GOOS) the snippet applies to, all if empty
+	Platform []string `toml:"platform,omitempty" json:"platform,omitempty"`
+	// Requires are the programs the command needs on PATH, e.g. kubectl
+	Requires []string `toml:"requires,omitempty" json:"requires,omitempty"`
+	// Notes is a longer explanation in markdown, e.g. caveats and links
+	Notes string `toml:"notes,omitempty" json:"notes,omitempty"`
+	// Expires is when the snippet is stale, a date or a duration (see ExpiresAt)
+	Expires string `toml:"expires,omitempty" json:"expires,omitempty"`
+	// Attachments are files written next to each other when the snippet
+	// runs, their paths fill in the parameters of their names
+	Attachments []Attachment `toml:"attachments,omitempty" json:"attachments,omitempty"`
+	// Script is Starlark run before the command is filled in, which may
+	// change the command and the defaults of its parameters
+	Script string `toml:"script,omitempty" json:"script,omitempty"`
+	// PreExec and PostExec are run before and after the snippet like the
+	// hooks of the config
+	PreExec  string `toml:"pre_exec,omitempty" json:"pre_exec,omitempty"`
+	PostExec string `toml:"post_exec,omitempty" json:"post_exec,omitempty"`
+	// OnFailure is the snippet offered to run when the command fails, e.g.
+	// a cleanup, by its name or description
+	OnFailure string `toml:"on_failure,omitempty" json:"on_failure,omitempty"`
+	// Visibility is the one of the snippet when it is shared (private,
+	// internal or public), that of the backend if empty
+	Visibility string `toml:"visibility,omitempty" json:"visibility,omitempty"`
+	// CreatedAt and UpdatedAt are maintained by Save
+	CreatedAt *time.Time `toml:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt *time.Time `toml:"updated_at,omitempty" json:"updated_at,omitempty"`
+	// file is the snippet file the snippet was loaded from
+	file string
 }
 
-// Load reads toml file.
-func (snippets *Snippets) Load() error {
+// File returns the snippet file the snippet belongs to
+func (s SnippetInfo) File() string {
+	if s.file == "" {
+		return config.Conf.General.SnippetFile
+	}
+	return s.file
+}
+
+// SetFile makes Save write the snippet to file
+func (s *SnippetInfo) SetFile(file string) {
+	s.file = file
+}
+
+// Visibilities are the visibilities of a shared snippet, from the most
+// private
+var Visibilities = []string{"private", "internal", "public"}
+
+// VisibilityRank returns the index of the visibility in Visibilities, -1 if
+// it is not one
+func VisibilityRank(visibility string) int {
+	for i, v := range Visibilities {
+		if v == visibility {
+			return i
+		}
+	}
+	return -1
+}
+
+// DangerTag marks a snippet that needs confirmation before execution
+const DangerTag = "danger"
+
+// TimeoutDuration returns the timeout of the snippet, 0 if it has none
+func (s SnippetInfo) TimeoutDuration() (time.Duration, error) {
+	if s.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout: %s (a duration, e.g. 30s or 5m)", s.Timeout)
+	}
+	return d, nil
+}
+
+// NeedsConfirm reports whether the snippet must be confirmed before execution.
+func (s SnippetInfo) NeedsConfirm() bool {
+	if s.Confirm {
+		return true
+	}
+	for _, t := range s.Tag {
+		if t == DangerTag {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports reports whether the snippet applies to the operating system goos
+// (e.g. linux or darwin).
+func (s SnippetInfo) Supports(goos string) bool {
+	if len(s.Platform) == 0 {
+		return true
+	}
+	for _, p := range s.Platform {
+		if strings.EqualFold(p, goos) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the programs of requires which lookPath (exec.LookPath)
+// does not find
+func (s SnippetInfo) Missing(lookPath func(file string) (string, error)) []string {
+	var missing []string
+	for _, r := range s.Requires {
+		if _, err := lookPath(r); err != nil {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// ProjectFileName is the per-project snippet file looked up from the
+// working directory upwards
+const ProjectFileName = ".pet.toml"
+
+// ProjectFile returns the nearest project snippet file in the working
+// directory or its parents, or "" if there is none.
+func ProjectFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		file := filepath.Join(dir, ProjectFileName)
+		if fi, err := os.Stat(file); err == nil && !fi.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// projectTag returns the tag of the snippets in a project file, the name of
+// the project directory, or "" for other files.
+func projectTag(file string) string {
+	if filepath.Base(file) != ProjectFileName {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(file))
+}
+
+// SnippetFiles returns the snippet file followed by the *.toml files in
+// snippetdir, sorted by name, and the project file.
+func SnippetFiles() ([]string, error) {
 	snippetFile := config.Conf.General.SnippetFile
-	if _, err := os.Stat(snippetFile); os.IsNotExist(err) {
-		return nil
+	files := []string{snippetFile}
+	if dir := config.Conf.General.SnippetDir; dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read snippet directory. %v", err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if m != snippetFile {
+				files = append(files, m)
+			}
+		}
+	}
+	if project := ProjectFile(); project != "" && project != snippetFile {
+		files = append(files, project)
+	}
+	return files, nil
+}
+
+// Load reads the snippet file and the files in snippetdir.
+func (snippets *Snippets) Load() error {
+	files, err := SnippetFiles()
+	if err != nil {
+		return err
 	}
-	if _, err := toml.DecodeFile(snippetFile, snippets); err != nil {
-		return fmt.Errorf("Failed to load snippet file. %v", err)
+	for _, file := range files {
+		if err := snippets.LoadFile(file); err != nil {
+			return err
+		}
+	}
+	if index != nil {
+		if err := index.Save(); err != nil && config.Flag.Debug {
+			fmt.Fprintf(os.Stderr, "Failed to save the index: %v\n", err)
+		}
 	}
 	snippets.Order()
 	return nil
 }
 
-// Save saves the snippets to toml file.
+// Decoder decodes the snippet files for Load instead of reading them, e.g.
+// from the cache of pet daemon. The files are read if it fails.
+var Decoder func(file string) (Snippets, error)
+
+// SkipBodies drops the output, the notes, the script and the contents of
+// the attachments of the snippets as they are loaded, for the commands which
+// only show their descriptions, commands and tags. Such snippets cannot be
+// saved.
+var SkipBodies bool
+
+// DecodeFile decodes a snippet file as it is, without the tags of a project
+// file
+func DecodeFile(file string) (Snippets, error) {
+	var entries []SnippetInfo
+	loaded, err := DecodeEntries(file, func(s SnippetInfo) error {
+		entries = append(entries, s)
+		return nil
+	})
+	loaded.Snippets = entries
+	return loaded, err
+}
+
+func decodeFile(file string) (Snippets, error) {
+	if Decoder != nil {
+		if loaded, err := Decoder(file); err == nil {
+			return withoutBodies(loaded), nil
+		}
+	}
+	// the index would keep the snippets of an encrypted file in clear
+	if config.Conf.General.Index && !Encrypted(file) {
+		loaded, err := decodeIndexed(file)
+		return withoutBodies(loaded), err
+	}
+	if !SkipBodies {
+		return DecodeFile(file)
+	}
+	// the bodies of the snippets are dropped while the file is read
+	var entries []SnippetInfo
+	loaded, err := DecodeEntries(file, func(s SnippetInfo) error {
+		entries = append(entries, s.withoutBody())
+		return nil
+	})
+	loaded.Snippets = entries
+	return loaded, err
+}
+
+// withoutBodies drops the bodies of the snippets if SkipBodies is set
+func withoutBodies(snippets Snippets) Snippets {
+	if SkipBodies {
+		for i, s := range snippets.Snippets {
+			snippets.Snippets[i] = s.withoutBody()
+		}
+	}
+	return snippets
+}
+
+// withoutBody returns the snippet without its output, notes, script and
+// the contents of its attachments
+func (s SnippetInfo) withoutBody() SnippetInfo {
+	s.Output, s.Notes, s.Script = "", "", ""
+	var attachments []Attachment
+	for _, a := range s.Attachments {
+		attachments = append(attachments, Attachment{Name: a.Name})
+	}
+	s.Attachments = attachments
+	return s
+}
+
+// LoadFile appends the snippets of a toml file. The snippets of a project
+// file are tagged with the project name.
+func (snippets *Snippets) LoadFile(file string) error {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil
+	}
+	loaded, err := decodeFile(file)
+	if err != nil {
+		if data, rerr := ReadFile(file); rerr == nil && len(ConflictMarkers(data)) > 0 {
+			err = fmt.Errorf("it has unresolved conflict markers, see pet lint")
+		}
+		if file == config.Conf.General.SnippetFile {
+			return fmt.Errorf("Failed to load snippet file. %v", err)
+		}
+		return fmt.Errorf("Failed to load snippet file %s. %v", file, err)
+	}
+	tag := projectTag(file)
+	for i := range loaded.Snippets {
+		loaded.Snippets[i].file = file
+		if tag != "" {
+			loaded.Snippets[i].AddTag(tag)
+		}
+	}
+	snippets.Snippets = append(snippets.Snippets, loaded.Snippets...)
+	snippets.loaded = append(snippets.loaded, loaded.Snippets...)
+	snippets.files = append(snippets.files, file)
+	if len(loaded.Vars) > 0 {
+		if snippets.fileVars == nil {
+			snippets.fileVars = map[string]map[string]string{}
+			snippets.Vars = map[string]string{}
+		}
+		snippets.fileVars[file] = loaded.Vars
+		// a project file comes with the repository it is in
+		if tag == "" {
+			for k, v := range loaded.Vars {
+				snippets.Vars[k] = v
+			}
+		}
+	}
+	if len(loaded.Runbooks) > 0 {
+		if snippets.fileRunbooks == nil {
+			snippets.fileRunbooks = map[string][]Runbook{}
+		}
+		snippets.fileRunbooks[file] = loaded.Runbooks
+		snippets.Runbooks = append(snippets.Runbooks, loaded.Runbooks...)
+	}
+	return nil
+}
+
+// FileVariables returns the [variables] of one of the loaded files
+func (snippets *Snippets) FileVariables(file string) map[string]string {
+	return snippets.fileVars[file]
+}
+
+// FileRunbooks returns the [[runbooks]] of one of the loaded files
+func (snippets *Snippets) FileRunbooks(file string) []Runbook {
+	return snippets.fileRunbooks[file]
+}
+
+// FileSnippets returns the snippets of one of the loaded files with its
+// variables and runbooks as they are written to it, e.g. to rewrite the
+// file on its own
+func (snippets *Snippets) FileSnippets(file string) *Snippets {
+	var list []SnippetInfo
+	for _, s := range snippets.Snippets {
+		if s.File() == file {
+			list = append(list, s)
+		}
+	}
+	return snippets.fileSnippets(file, list)
+}
+
+// fileSnippets returns the content of a file with the snippets of list,
+// without the tag of a project file
+func (snippets *Snippets) fileSnippets(file string, list []SnippetInfo) *Snippets {
+	fs := &Snippets{Vars: snippets.FileVariables(file), Runbooks: snippets.FileRunbooks(file)}
+	tag := projectTag(file)
+	for _, s := range list {
+		if tag != "" {
+			s.RemoveTag(tag)
+		}
+		fs.Snippets = append(fs.Snippets, s)
+	}
+	return fs
+}
+
+// Variables returns the variables of the snippet: the [variables] of the
+// config, overridden by those of the file of the snippet. The variables of
+// a project file, which comes with any repository, only add to those of the
+// config.
+func (snippets *Snippets) Variables(s SnippetInfo) map[string]string {
+	vars := map[string]string{}
+	for k, v := range config.Conf.Variables {
+		vars[k] = v
+	}
+	project := projectTag(s.File()) != ""
+	for k, v := range snippets.fileVars[s.File()] {
+		if _, ok := vars[k]; !ok || !project {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// Save saves the snippets to the toml files they belong to, leaving the
+// files whose snippets did not change as they are. Snippets added or
+// changed since Load get their timestamps updated, the previous versions of
+// the edited ones are kept, and the usage statistics and versions follow
+// renamed snippets. The changes are then passed to OnEvent.
 func (snippets *Snippets) Save() error {
+	if SkipBodies {
+		return fmt.Errorf("Failed to save snippet file: the snippets were loaded without their bodies")
+	}
+	snippets.Stamp(snippets.loaded, time.Now())
+	before := snippets.loaded
+
 	snippetFile := config.Conf.General.SnippetFile
-	f, err := os.Create(snippetFile)
-	defer f.Close()
+	files := []string{snippetFile}
+	byFile := map[string][]SnippetInfo{snippetFile: nil}
+	for _, f := range snippets.files {
+		if _, ok := byFile[f]; !ok {
+			files = append(files, f)
+			byFile[f] = nil
+		}
+	}
+	for _, s := range snippets.Snippets {
+		f := s.File()
+		if _, ok := byFile[f]; !ok {
+			files = append(files, f)
+		}
+		byFile[f] = append(byFile[f], s)
+	}
+
+	for _, f := range files {
+		if f != snippetFile {
+			if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+				return fmt.Errorf("Failed to save snippet file. err: %s", err)
+			}
+		}
+		if err := saveFile(f, *snippets.fileSnippets(f, byFile[f])); err != nil {
+			return err
+		}
+	}
+	snippets.loaded = append([]SnippetInfo{}, snippets.Snippets...)
+	if err := RecordEdits(before, snippets.Snippets); err != nil {
+		return err
+	}
+	if OnEvent != nil {
+		for _, e := range Changes(before, snippets.Snippets) {
+			Fire(e)
+		}
+	}
+	return nil
+}
+
+// RecordEdits keeps the previous versions of the snippets edited from before
+// to after, moves the versions of the renamed ones and the usage statistics
+// of the renamed and edited ones.
+func RecordEdits(before, after []SnippetInfo) error {
+	renamed := Renamed(before, after)
+	if err := recordVersions(Edited(before, after, renamed), renamed); err != nil {
+		return err
+	}
+	return moveUsage(usageMoves(before, after, renamed))
+}
+
+// Renamed returns the old and new descriptions of the snippets of before
+// whose description is gone from after while a snippet with the same
+// command has a new description.
+func Renamed(before, after []SnippetInfo) map[string]string {
+	descriptions := map[string]bool{}
+	for _, s := range before {
+		descriptions[s.Description] = true
+	}
+	added := map[string]string{}
+	for _, s := range after {
+		if !descriptions[s.Description] {
+			added[s.Command] = s.Description
+		}
+	}
+	for _, s := range after {
+		delete(descriptions, s.Description)
+	}
+
+	renamed := map[string]string{}
+	for _, s := range before {
+		if !descriptions[s.Description] {
+			continue
+		}
+		if to, ok := added[s.Command]; ok {
+			renamed[s.Description] = to
+		}
+	}
+	return renamed
+}
+
+// Stamp sets UpdatedAt of the snippets added or changed compared to before,
+// and CreatedAt if it is not set yet. It reports whether any snippet was
+// stamped.
+func (snippets *Snippets) Stamp(before []SnippetInfo, now time.Time) bool {
+	now = now.Truncate(time.Second)
+	old := map[string]SnippetInfo{}
+	for _, s := range before {
+		if _, ok := old[s.Description]; !ok {
+			old[s.Description] = s
+		}
+	}
+
+	stamped := false
+	for i := range snippets.Snippets {
+		s := &snippets.Snippets[i]
+		if o, ok := old[s.Description]; ok && sameContent(o, *s) {
+			continue
+		}
+		if s.CreatedAt == nil {
+			s.CreatedAt = &now
+		}
+		s.UpdatedAt = &now
+		stamped = true
+	}
+	return stamped
+}
+
+// sameContent reports whether the snippets are equal except for their
+// timestamps
+func sameContent(a, b SnippetInfo) bool {
+	a.CreatedAt, a.UpdatedAt = nil, nil
+	b.CreatedAt, b.UpdatedAt = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func saveFile(file string, snippets Snippets) error {
+	body, err := snippets.ToString()
 	if err != nil {
+		return err
+	}
+	// a file without changes keeps its formatting and comments, e.g. a
+	// project file committed to its repository
+	if unchangedFile(file, body) {
+		return nil
+	}
+	if err := WriteFile(file, []byte(body)); err != nil {
 		return fmt.Errorf("Failed to save snippet file. err: %s", err)
 	}
-	return toml.NewEncoder(f).Encode(snippets)
+	return nil
+}
+
+// unchangedFile reports whether the file has the snippets of body, whatever
+// its formatting
+func unchangedFile(file, body string) bool {
+	if _, err := os.Stat(file); err != nil {
+		return false
+	}
+	onDisk, err := DecodeFile(file)
+	if err != nil {
+		return false
+	}
+	current, err := onDisk.ToString()
+	return err == nil && current == body
 }
 
 // ToString returns the contents of toml file.
@@ -52,7 +588,34 @@ func (snippets *Snippets) ToString() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("Failed to convert struct to TOML string: %v", err)
 	}
-	return buffer.String(), nil
+	return multiline(buffer.String()), nil
+}
+
+// multilineValue matches the encoded commands, outputs, notes and
+// attachment contents
+var multilineValue = regexp.MustCompile(`(?m)^(\s*(?:command|output|notes|content) = )"(.*)"$`)
+
+var unquoteReplacer = strings.NewReplacer(
+	"\\t", "\t",
+	"\\n", "\n",
+	"\\r", "\r",
+	"\\\"", "\"",
+	"\\\\", "\\",
+)
+
+// multiline rewrites the commands, outputs, notes and contents spanning several lines as
+// multi-line literal strings, so that they stay readable in the file.
+// Values which cannot be written literally are kept quoted.
+func multiline(body string) string {
+	return multilineValue.ReplaceAllStringFunc(body, func(line string) string {
+		m := multilineValue.FindStringSubmatch(line)
+		value := unquoteReplacer.Replace(m[2])
+		if !strings.Contains(value, "\n") || strings.Contains(value, "\r") ||
+			strings.Contains(value, "'''") || strings.HasSuffix(value, "'") {
+			return line
+		}
+		return m[1] + "'''\n" + value + "'''"
+	})
 }
 
 // Order snippets regarding SortBy option defined in config toml
@@ -75,6 +638,16 @@ func (snippets *Snippets) Order() {
 	case sortBy == "-output":
 		sort.Sort(sort.Reverse(ByOutput(snippets.Snippets)))
 
+	case sortBy == "created" || sortBy == "+created":
+		sort.Stable(ByCreated(snippets.Snippets))
+	case sortBy == "-created":
+		sort.Stable(sort.Reverse(ByCreated(snippets.Snippets)))
+
+	case sortBy == "updated" || sortBy == "+updated":
+		sort.Stable(ByUpdated(snippets.Snippets))
+	case sortBy == "-updated":
+		sort.Stable(sort.Reverse(ByUpdated(snippets.Snippets)))
+
 	case sortBy == "-recency":
 		snippets.reverse()
 	}
@@ -103,3 +676,343 @@ type ByOutput []SnippetInfo
 func (a ByOutput) Len() int           { return len(a) }
 func (a ByOutput) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByOutput) Less(i, j int) bool { return a[i].Output > a[j].Output }
+
+type ByCreated []SnippetInfo
+
+func (a ByCreated) Len() int           { return len(a) }
+func (a ByCreated) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ByCreated) Less(i, j int) bool { return timeOf(a[i].CreatedAt).After(timeOf(a[j].CreatedAt)) }
+
+type ByUpdated []SnippetInfo
+
+func (a ByUpdated) Len() int           { return len(a) }
+func (a ByUpdated) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ByUpdated) Less(i, j int) bool { return timeOf(a[i].UpdatedAt).After(timeOf(a[j].UpdatedAt)) }
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// Index returns the position of the snippet with the same description and
+// command as s, or -1 if there is none.
+func (snippets *Snippets) Index(s SnippetInfo) int {
+	for i, t := range snippets.Snippets {
+		if t.Description == s.Description && t.Command == s.Command {
+			return i
+		}
+	}
+	return -1
+}
+
+// Search returns the snippets where every word of the query is contained in
+// the description, command or tags (case insensitive), best matches first
+// (see Score).
+func (snippets *Snippets) Search(query string) []SnippetInfo {
+	contains := func(word, text string) (int, bool) {
+		return 1, strings.Contains(strings.ToLower(text), strings.ToLower(word))
+	}
+	type match struct {
+		s     SnippetInfo
+		score int
+	}
+	var found []match
+	for _, s := range snippets.Snippets {
+		if score, ok := s.Score(query, contains); ok {
+			found = append(found, match{s, score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].score > found[j].score })
+	var result []SnippetInfo
+	for _, m := range found {
+		result = append(result, m.s)
+	}
+	return result
+}
+
+// Score returns the score of the query for the snippet, and false if a word
+// of the query matches none of its fields. A word scores the best match of
+// match in the description, the tags (and path) or the command, times the
+// weight of the field in the [Search] config, so that a description match
+// outranks a match in a long command.
+func (s SnippetInfo) Score(query string, match func(word, text string) (int, bool)) (int, bool) {
+	w := config.Conf.Search
+	if w == (config.SearchConfig{}) {
+		w = config.DefaultSearch()
+	}
+	fields := []struct {
+		text   string
+		weight int
+	}{
+		{s.Description, w.DescriptionWeight},
+		{strings.Join(append(append([]string{}, s.Tag...), s.Path), " "), w.TagWeight},
+		{s.Command, w.CommandWeight},
+	}
+	total := 0
+	for _, word := range strings.Fields(query) {
+		best, found := 0, false
+		for _, f := range fields {
+			if f.weight <= 0 {
+				continue
+			}
+			if score, ok := match(word, f.text); ok {
+				found = true
+				if score*f.weight > best {
+					best = score * f.weight
+				}
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// FindByName returns the snippet with the name
+func (snippets *Snippets) FindByName(name string) (SnippetInfo, bool) {
+	if name == "" {
+		return SnippetInfo{}, false
+	}
+	for _, s := range snippets.Snippets {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return SnippetInfo{}, false
+}
+
+// Find returns the snippet with the description
+func (snippets *Snippets) Find(description string) (SnippetInfo, bool) {
+	for _, s := range snippets.Snippets {
+		if s.Description == description {
+			return s, true
+		}
+	}
+	return SnippetInfo{}, false
+}
+
+// FindByRef returns the snippet referred to by its name, or by its
+// description, e.g. by a runbook step or on_failure
+func (snippets *Snippets) FindByRef(ref string) (SnippetInfo, bool) {
+	if s, ok := snippets.FindByName(ref); ok {
+		return s, true
+	}
+	return snippets.Find(ref)
+}
+
+// NormalizeCommand drops the whitespace around the command, and the
+// trailing whitespace and semicolon of its lines, so that trivially
+// different commands compare equal. The whitespace within the lines is
+// kept, it may be quoted or the indentation of a heredoc.
+func NormalizeCommand(command string) string {
+	lines := strings.Split(strings.TrimSpace(command), "\n")
+	for i, l := range lines {
+		l = strings.TrimSuffix(strings.TrimRight(l, " \t\r"), ";")
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FindByCommand returns the index of the snippet with the same normalized
+// command, or -1
+func (snippets *Snippets) FindByCommand(command string) int {
+	command = NormalizeCommand(command)
+	for i, s := range snippets.Snippets {
+		if NormalizeCommand(s.Command) == command {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasTag reports whether the snippet has the tag
+func (s SnippetInfo) HasTag(tag string) bool {
+	for _, t := range s.Tag {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalOnly reports whether the snippet has one of sync_exclude_tags, so
+// that it never leaves the machine
+func (s SnippetInfo) LocalOnly() bool {
+	for _, t := range config.Conf.General.SyncExcludeTags {
+		if s.HasTag(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag adds the tag if the snippet does not have it yet
+func (s *SnippetInfo) AddTag(tag string) bool {
+	if s.HasTag(tag) {
+		return false
+	}
+	s.Tag = append(s.Tag, tag)
+	return true
+}
+
+// RemoveTag removes the tag from the snippet
+func (s *SnippetInfo) RemoveTag(tag string) bool {
+	var tags []string
+	for _, t := range s.Tag {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	removed := len(tags) != len(s.Tag)
+	s.Tag = tags
+	return removed
+}
+
+// TagFilter selects snippets by their tags
+type TagFilter struct {
+	Tags []string
+	// Any matches snippets having any of the tags instead of all of them
+	Any bool
+}
+
+// Match reports whether the snippet passes the filter. An empty filter
+// matches every snippet.
+func (f TagFilter) Match(s SnippetInfo) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, t := range f.Tags {
+		if s.HasTag(t) == f.Any {
+			return f.Any
+		}
+	}
+	return !f.Any
+}
+
+// FilterTags returns the snippets passing the filter
+func (snippets *Snippets) FilterTags(f TagFilter) Snippets {
+	var filtered Snippets
+	for _, s := range snippets.Snippets {
+		if f.Match(s) {
+			filtered.Snippets = append(filtered.Snippets, s)
+		}
+	}
+	return filtered
+}
+
+// InPath reports whether the snippet is in the namespace prefix, e.g.
+// "k8s" and "k8s/" contain "k8s/debug/pods" but not "k8s-old".
+func (s SnippetInfo) InPath(prefix string) bool {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	path := strings.Trim(s.Path, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// FilterPath returns the snippets in the namespace prefix
+func (snippets *Snippets) FilterPath(prefix string) Snippets {
+	var filtered Snippets
+	for _, s := range snippets.Snippets {
+		if s.InPath(prefix) {
+			filtered.Snippets = append(filtered.Snippets, s)
+		}
+	}
+	return filtered
+}
+
+// Paths returns the namespaces used by the snippets and all their parents,
+// sorted
+func (snippets *Snippets) Paths() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, s := range snippets.Snippets {
+		parts := strings.Split(strings.Trim(s.Path, "/"), "/")
+		for i := range parts {
+			p := strings.Join(parts[:i+1], "/")
+			if p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// CreatedSince returns the snippets created at or after t
+func (snippets *Snippets) CreatedSince(t time.Time) Snippets {
+	var filtered Snippets
+	for _, s := range snippets.Snippets {
+		if s.CreatedAt != nil && !s.CreatedAt.Before(t) {
+			filtered.Snippets = append(filtered.Snippets, s)
+		}
+	}
+	return filtered
+}
+
+// Active returns the snippets which are not archived
+func (snippets *Snippets) Active() Snippets {
+	var active Snippets
+	for _, s := range snippets.Snippets {
+		if !s.Archived {
+			active.Snippets = append(active.Snippets, s)
+		}
+	}
+	return active
+}
+
+// ForPlatform returns the snippets which apply to the operating system goos
+func (snippets *Snippets) ForPlatform(goos string) Snippets {
+	var supported Snippets
+	for _, s := range snippets.Snippets {
+		if s.Supports(goos) {
+			supported.Snippets = append(supported.Snippets, s)
+		}
+	}
+	return supported
+}
+
+// Runnable returns the snippets whose required programs lookPath finds.
+// Each program is looked up once.
+func (snippets *Snippets) Runnable(lookPath func(file string) (string, error)) Snippets {
+	found := map[string]bool{}
+	cached := func(file string) (string, error) {
+		ok, seen := found[file]
+		if !seen {
+			_, err := lookPath(file)
+			ok = err == nil
+			found[file] = ok
+		}
+		if !ok {
+			return "", fmt.Errorf("%s not found", file)
+		}
+		return file, nil
+	}
+	var runnable Snippets
+	for _, s := range snippets.Snippets {
+		if len(s.Missing(cached)) == 0 {
+			runnable.Snippets = append(runnable.Snippets, s)
+		}
+	}
+	return runnable
+}
+
+// Pinned returns the snippets with the favorites first, keeping the order
+// within favorites and the other snippets
+func (snippets *Snippets) Pinned() Snippets {
+	var pinned, others []SnippetInfo
+	for _, s := range snippets.Snippets {
+		if s.Favorite {
+			pinned = append(pinned, s)
+		} else {
+			others = append(others, s)
+		}
+	}
+	return Snippets{Snippets: append(pinned, others...)}
+}
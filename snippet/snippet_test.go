@@ -0,0 +1,548 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/config"
+)
+
+func TestTagFilter_Match(t *testing.T) {
+	s := SnippetInfo{Tag: []string{"k8s", "debug"}}
+	tests := []struct {
+		filter TagFilter
+		want   bool
+	}{
+		{TagFilter{}, true},
+		{TagFilter{Tags: []string{"k8s"}}, true},
+		{TagFilter{Tags: []string{"k8s", "debug"}}, true},
+		{TagFilter{Tags: []string{"k8s", "aws"}}, false},
+		{TagFilter{Tags: []string{"k8s", "aws"}, Any: true}, true},
+		{TagFilter{Tags: []string{"aws", "gcp"}, Any: true}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(s); got != tt.want {
+			t.Errorf("%+v: wanted %v, got %v", tt.filter, tt.want, got)
+		}
+	}
+}
+
+func TestSnippets_Search(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "Show pods", Command: "kubectl get pods", Tag: []string{"k8s"}},
+		{Description: "Disk usage", Command: "du -sh *"},
+	}}
+
+	if got := snippets.Search("PODS k8s"); len(got) != 1 || got[0].Description != "Show pods" {
+		t.Fatalf("unexpected result %+v", got)
+	}
+	if got := snippets.Search(""); len(got) != 2 {
+		t.Fatalf("wanted all snippets, got %+v", got)
+	}
+}
+
+func TestSnippets_Search_Weights(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "Long script", Command: "for f in *; do echo deploy $f; done"},
+		{Description: "Tagged", Command: "make", Tag: []string{"deploy"}},
+		{Description: "Deploy the app", Command: "make release"},
+	}}
+
+	var got []string
+	for _, s := range snippets.Search("deploy") {
+		got = append(got, s.Description)
+	}
+	want := []string{"Deploy the app", "Tagged", "Long script"}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+
+	defer func(c config.SearchConfig) { config.Conf.Search = c }(config.Conf.Search)
+	config.Conf.Search = config.SearchConfig{DescriptionWeight: 1}
+	if got := snippets.Search("deploy"); len(got) != 1 || got[0].Description != "Deploy the app" {
+		t.Errorf("wanted only the description match, got %+v", got)
+	}
+}
+
+func TestSnippets_FindByCommand(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "Show pods", Command: "kubectl get pods -A"},
+		{Description: "Say a  b", Command: `echo "a  b"`},
+		{Description: "Write the config", Command: "cat <<EOF > config\n  indented: true\nEOF"},
+	}}
+
+	if got := snippets.FindByCommand("  kubectl get pods -A ;\t\n"); got != 0 {
+		t.Fatalf("wanted 0, got %d", got)
+	}
+	if got := snippets.FindByCommand("kubectl get pods"); got != -1 {
+		t.Fatalf("wanted -1, got %d", got)
+	}
+	// the whitespace within the lines is part of the command
+	if got := snippets.FindByCommand(`echo "a b"`); got != -1 {
+		t.Fatalf("wanted -1 for a single space, got %d", got)
+	}
+	if got := snippets.FindByCommand(`echo "a  b";`); got != 1 {
+		t.Fatalf("wanted 1, got %d", got)
+	}
+	if got := snippets.FindByCommand("cat <<EOF > config\nindented: true\nEOF"); got != -1 {
+		t.Fatalf("wanted -1 for another indentation of the heredoc, got %d", got)
+	}
+	if got := snippets.FindByCommand("cat <<EOF > config  \n  indented: true\nEOF\n"); got != 2 {
+		t.Fatalf("wanted 2, got %d", got)
+	}
+}
+
+func TestSnippets_Pinned(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "a"},
+		{Description: "b", Favorite: true},
+		{Description: "c"},
+		{Description: "d", Favorite: true},
+	}}
+	var got []string
+	for _, s := range snippets.Pinned().Snippets {
+		got = append(got, s.Description)
+	}
+	if strings.Join(got, ",") != "b,d,a,c" {
+		t.Errorf("wanted favorites first, got %v", got)
+	}
+}
+
+func TestSnippets_ForPlatform(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "any"},
+		{Description: "mac", Platform: []string{"darwin"}},
+		{Description: "unix", Platform: []string{"darwin", "Linux"}},
+		{Description: "win", Platform: []string{"windows"}},
+	}}
+	var got []string
+	for _, s := range snippets.ForPlatform("linux").Snippets {
+		got = append(got, s.Description)
+	}
+	if strings.Join(got, ",") != "any,unix" {
+		t.Errorf("wanted the snippets for linux, got %v", got)
+	}
+}
+
+func TestSnippets_Runnable(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "any"},
+		{Description: "k8s", Requires: []string{"kubectl"}},
+		{Description: "aws", Requires: []string{"kubectl", "aws"}},
+		{Description: "git", Requires: []string{"git"}},
+	}}
+	lookups := map[string]int{}
+	lookPath := func(file string) (string, error) {
+		lookups[file]++
+		if file == "aws" {
+			return "", os.ErrNotExist
+		}
+		return "/usr/bin/" + file, nil
+	}
+	var got []string
+	for _, s := range snippets.Runnable(lookPath).Snippets {
+		got = append(got, s.Description)
+	}
+	if strings.Join(got, ",") != "any,k8s,git" {
+		t.Errorf("wanted the snippets with their programs, got %v", got)
+	}
+	if lookups["kubectl"] != 1 {
+		t.Errorf("kubectl looked up %d times", lookups["kubectl"])
+	}
+	if missing := snippets.Snippets[2].Missing(lookPath); strings.Join(missing, ",") != "aws" {
+		t.Errorf("Missing() = %v", missing)
+	}
+}
+
+func TestSnippets_FilterPath(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "pods", Path: "k8s/debug/pods"},
+		{Description: "nodes", Path: "k8s/nodes"},
+		{Description: "old", Path: "k8s-old"},
+		{Description: "root"},
+	}}
+
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{"", 4},
+		{"k8s", 2},
+		{"k8s/", 2},
+		{"k8s/debug", 1},
+		{"k8s/deb", 0},
+	}
+	for _, tt := range tests {
+		if got := snippets.FilterPath(tt.prefix); len(got.Snippets) != tt.want {
+			t.Errorf("%s: wanted %d snippets, got %+v", tt.prefix, tt.want, got.Snippets)
+		}
+	}
+
+	want := []string{"k8s", "k8s-old", "k8s/debug", "k8s/debug/pods", "k8s/nodes"}
+	if got := snippets.Paths(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestSnippets_LoadSave_SnippetDir(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = filepath.Join(dir, "snippets.d")
+	defer func() { config.Conf.General.SnippetDir = "" }()
+
+	team := SnippetInfo{Description: "team", Command: "make deploy"}
+	team.SetFile(filepath.Join(dir, "snippets.d", "team.toml"))
+	saved := Snippets{Snippets: []SnippetInfo{{Description: "mine", Command: "ls"}, team}}
+	if err := saved.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets.Snippets) != 2 || snippets.Snippets[1].File() != team.File() {
+		t.Fatalf("unexpected snippets %+v", snippets.Snippets)
+	}
+
+	// removing the last snippet of a file empties the file
+	snippets.Snippets = snippets.Snippets[:1]
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Snippets
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Snippets) != 1 || reloaded.Snippets[0].Description != "mine" {
+		t.Fatalf("unexpected snippets %+v", reloaded.Snippets)
+	}
+}
+
+func TestSnippets_Load_ProjectFile(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	project := filepath.Join(dir, "myapp")
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[[snippets]]\n  description = \"run\"\n  command = \"make run\"\n"
+	if err := os.WriteFile(filepath.Join(project, ProjectFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(filepath.Join(project, "src")); err != nil {
+		t.Fatal(err)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets.Snippets) != 1 || !snippets.Snippets[0].HasTag("myapp") {
+		t.Fatalf("unexpected snippets %+v", snippets.Snippets)
+	}
+
+	// the project tag is not written to the project file
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(project, ProjectFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "myapp") {
+		t.Fatalf("project tag saved: %s", data)
+	}
+}
+
+func TestSnippets_Save_UnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = filepath.Join(dir, "snippets")
+	defer func() { config.Conf.General.SnippetDir = "" }()
+	if err := os.MkdirAll(config.Conf.General.SnippetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	personal := "[[snippets]]\n  description = \"mine\"\n  command = \"ls\"\n"
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(personal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := "# shared with the team\n[[snippets]]\n  description = \"theirs\"\n  command = \"make\"\n"
+	otherFile := filepath.Join(config.Conf.General.SnippetDir, "team.toml")
+	if err := os.WriteFile(otherFile, []byte(other), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "myapp")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "# committed to the repository\n[[snippets]]\n  description = \"run\"\n  command = \"make run\"\n"
+	projectFile := filepath.Join(project, ProjectFileName)
+	if err := os.WriteFile(projectFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range snippets.Snippets {
+		if snippets.Snippets[i].Description == "mine" {
+			snippets.Snippets[i].AddTag("shell")
+		}
+	}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// only the changed file is written
+	for file, want := range map[string]string{projectFile: content, otherFile: other} {
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("Save() rewrote %s:\n%s", file, data)
+		}
+	}
+	if data, _ := os.ReadFile(config.Conf.General.SnippetFile); !strings.Contains(string(data), "shell") {
+		t.Errorf("Save() did not write the changed file:\n%s", data)
+	}
+
+	// the file of a project is written without its tag
+	body, err := snippets.FileSnippets(projectFile).ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(body, "myapp") {
+		t.Errorf("FileSnippets() has the project tag:\n%s", body)
+	}
+}
+
+func TestSnippets_Variables_ProjectFile(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+	config.Conf.Variables = map[string]string{"CLUSTER": "dev"}
+	defer func() { config.Conf.Variables = nil }()
+
+	personal := "[variables]\n  HOST = \"example.com\"\n\n[[snippets]]\n  description = \"ping\"\n  command = \"ping <HOST> <X>\"\n"
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(personal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "cloned")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[variables]\n  X = \"1; id\"\n  HOST = \"evil.com\"\n  CLUSTER = \"prod\"\n  APP = \"cloned\"\n\n[[snippets]]\n  description = \"deploy\"\n  command = \"deploy <APP> <CLUSTER>\"\n"
+	if err := os.WriteFile(filepath.Join(project, ProjectFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	ping, _ := snippets.Find("ping")
+	deploy, _ := snippets.Find("deploy")
+	// the variables of the project file do not reach the personal snippets
+	if diff := deep.Equal(map[string]string{"CLUSTER": "dev", "HOST": "example.com"}, snippets.Variables(ping)); diff != nil {
+		t.Error(diff)
+	}
+	// nor override the config for its own snippets
+	if diff := deep.Equal(map[string]string{"CLUSTER": "dev", "HOST": "evil.com", "X": "1; id", "APP": "cloned"}, snippets.Variables(deploy)); diff != nil {
+		t.Error(diff)
+	}
+	command, err := snippets.Expand(ping, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command != "ping example.com " {
+		t.Errorf("Expand() = %q, want the parameter of the project file left out", command)
+	}
+}
+
+func TestSnippets_Stamp(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := []SnippetInfo{
+		{Description: "same", Command: "a", CreatedAt: &created, UpdatedAt: &created},
+		{Description: "changed", Command: "b", CreatedAt: &created, UpdatedAt: &created},
+	}
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "same", Command: "a", CreatedAt: &created, UpdatedAt: &created},
+		{Description: "changed", Command: "b2", CreatedAt: &created, UpdatedAt: &created},
+		{Description: "new", Command: "c"},
+	}}
+
+	if !snippets.Stamp(before, now) {
+		t.Fatal("wanted snippets to be stamped")
+	}
+	want := []struct{ created, updated time.Time }{
+		{created, created},
+		{created, now},
+		{now, now},
+	}
+	for i, w := range want {
+		s := snippets.Snippets[i]
+		if !s.CreatedAt.Equal(w.created) || !s.UpdatedAt.Equal(w.updated) {
+			t.Errorf("%s: wanted %v/%v, got %v/%v", s.Description, w.created, w.updated, s.CreatedAt, s.UpdatedAt)
+		}
+	}
+	if snippets.Stamp(snippets.Snippets, now) {
+		t.Error("wanted no changes")
+	}
+}
+
+func TestSnippets_Save_RenameUsage(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "old", Command: "echo old"},
+		{Description: "other", Command: "echo other"},
+	}}
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordUsage(snippets.Snippets[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	snippets = Snippets{}
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	snippets.Snippets[0].Description = "new"
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := LoadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stats["old"]; ok {
+		t.Errorf("usage of the old description kept: %+v", stats)
+	}
+	if u := stats.Get(SnippetInfo{Description: "old", Command: "echo old"}); u.Count != 0 {
+		t.Errorf("usage of the old snippet kept: %+v", stats)
+	}
+	if u := stats.Get(snippets.Snippets[0]); u.Count != 1 {
+		t.Errorf("wanted usage to follow the rename, got %+v", u)
+	}
+
+	// and the edit of the command
+	snippets.Snippets[0].Command = "echo new"
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = LoadUsage(); err != nil {
+		t.Fatal(err)
+	}
+	if u := stats.Get(snippets.Snippets[0]); u.Count != 1 {
+		t.Errorf("wanted usage to follow the edit, got %+v", u)
+	}
+	if u := stats.Get(snippets.Snippets[1]); u.Count != 0 {
+		t.Errorf("wanted no usage of the other snippet, got %+v", u)
+	}
+}
+
+func TestSnippets_ToString_Multiline(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "heredoc", Command: "cat <<EOF\n\t\"quoted\" \\n\nEOF", Output: "one", Notes: "# Caveats\n- see `man cat`"},
+		{Description: "quote", Command: "echo '\nend'"},
+	}}
+	body, err := snippets.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "command = '''\ncat <<EOF\n") || !strings.Contains(body, "notes = '''\n# Caveats\n") {
+		t.Errorf("wanted multi-line strings, got:\n%s", body)
+	}
+
+	var decoded Snippets
+	if _, err := toml.Decode(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range decoded.Snippets {
+		if s.Command != snippets.Snippets[i].Command {
+			t.Errorf("wanted %q, got %q", snippets.Snippets[i].Command, s.Command)
+		}
+		if s.Notes != snippets.Snippets[i].Notes {
+			t.Errorf("wanted %q, got %q", snippets.Snippets[i].Notes, s.Notes)
+		}
+	}
+}
+
+func TestSnippets_Variables(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+	config.Conf.Variables = map[string]string{"REGISTRY": "docker.io", "CLUSTER": "dev"}
+	defer func() { config.Conf.Variables = nil }()
+
+	content := "[variables]\n  REGISTRY = \"ghcr.io/acme\"\n\n[[snippets]]\n  description = \"push\"\n  command = \"docker push <REGISTRY>/app\"\n"
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"REGISTRY": "ghcr.io/acme", "CLUSTER": "dev"}
+	if diff := deep.Equal(want, snippets.Variables(snippets.Snippets[0])); diff != nil {
+		t.Error(diff)
+	}
+
+	// the variables of the file are written back
+	if err := snippets.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Snippets
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Vars["REGISTRY"] != "ghcr.io/acme" || len(reloaded.Snippets) != 1 {
+		t.Fatalf("unexpected snippets %+v", reloaded)
+	}
+}
+
+func TestSnippets_ToString_Attachments(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{{
+		Description: "migrate",
+		Command:     "psql -f <migrate.sql>",
+		Attachments: []Attachment{{Name: "migrate.sql", Content: "BEGIN;\nSELECT 1;\nCOMMIT;\n"}},
+	}}}
+	body, err := snippets.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "[[snippets.attachments]]") || !strings.Contains(body, "content = '''\nBEGIN;\n") {
+		t.Errorf("wanted the attachment in the snippet, got:\n%s", body)
+	}
+
+	var decoded Snippets
+	if _, err := toml.Decode(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(snippets.Snippets[0].Attachments, decoded.Snippets[0].Attachments); diff != nil {
+		t.Error(diff)
+	}
+}
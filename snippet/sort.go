@@ -0,0 +1,207 @@
+package snippet
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// untagged is the group header of snippets without tags
+const untagged = "(untagged)"
+
+// noNamespace is the group header of snippets without a path
+const noNamespace = "(no namespace)"
+
+// Sort sorts the snippets by "description", "tag" (the first tag) or
+// "usage" (most executed first). Ties are broken by description and command
+// so that the order is stable across machines.
+func (snippets *Snippets) Sort(by string, usage UsageStats) error {
+	s := snippets.Snippets
+	byDescription := func(i, j int) bool {
+		di, dj := strings.ToLower(s[i].Description), strings.ToLower(s[j].Description)
+		if di != dj {
+			return di < dj
+		}
+		return s[i].Command < s[j].Command
+	}
+
+	var less func(i, j int) bool
+	switch by {
+	case "description":
+		less = byDescription
+	case "tag":
+		less = func(i, j int) bool {
+			ti, tj := groupOf(s[i]), groupOf(s[j])
+			if ti != tj {
+				return lessGroup(ti, tj)
+			}
+			return byDescription(i, j)
+		}
+	case "usage":
+		less = func(i, j int) bool {
+			ci, cj := usage.Get(s[i]).Count, usage.Get(s[j]).Count
+			if ci != cj {
+				return ci > cj
+			}
+			return byDescription(i, j)
+		}
+	default:
+		return fmt.Errorf("Invalid sort key: %s (tag, description or usage)", by)
+	}
+	sort.SliceStable(s, less)
+	return nil
+}
+
+// GroupedString returns the contents of the toml file with the snippets
+// grouped by their first tag under comment headers, followed by the
+// runbooks. The order within a group is kept.
+func (snippets *Snippets) GroupedString() (string, error) {
+	var groups []string
+	members := map[string][]SnippetInfo{}
+	for _, s := range snippets.Snippets {
+		g := groupOf(s)
+		if _, ok := members[g]; !ok {
+			groups = append(groups, g)
+		}
+		members[g] = append(members[g], s)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return lessGroup(groups[i], groups[j]) })
+
+	// each part is encoded like the snippet file, the commands spanning
+	// several lines written literally
+	var buffer bytes.Buffer
+	if len(snippets.Vars) > 0 {
+		vars, err := (&Snippets{Vars: snippets.Vars}).ToString()
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(vars + "\n")
+	}
+	for i, g := range groups {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		group, err := (&Snippets{Snippets: members[g]}).ToString()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buffer, "# %s\n\n%s", g, group)
+	}
+	if len(snippets.Runbooks) > 0 {
+		runbooks, err := (&Snippets{Runbooks: snippets.Runbooks}).ToString()
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString("\n" + runbooks)
+	}
+	return buffer.String(), nil
+}
+
+func groupOf(s SnippetInfo) string {
+	if len(s.Tag) == 0 {
+		return untagged
+	}
+	return s.Tag[0]
+}
+
+// lessGroup sorts tags alphabetically with untagged snippets last
+func lessGroup(a, b string) bool {
+	if a == untagged || b == untagged || a == noNamespace || b == noNamespace {
+		return (b == untagged || b == noNamespace) && a != untagged && a != noNamespace
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// Group is the snippets under a header of pet list --group-by
+type Group struct {
+	Name     string
+	Snippets []SnippetInfo
+}
+
+// GroupBy groups the snippets by "tag", a snippet under each of its tags,
+// or by "namespace", their path. The groups are sorted with the snippets
+// without a tag or a path last; the order within a group is kept.
+func GroupBy(snippets []SnippetInfo, by string) ([]Group, error) {
+	var keys func(s SnippetInfo) []string
+	switch by {
+	case "tag":
+		keys = func(s SnippetInfo) []string {
+			if len(s.Tag) == 0 {
+				return []string{untagged}
+			}
+			return s.Tag
+		}
+	case "namespace":
+		keys = func(s SnippetInfo) []string {
+			if path := strings.Trim(s.Path, "/"); path != "" {
+				return []string{path}
+			}
+			return []string{noNamespace}
+		}
+	default:
+		return nil, fmt.Errorf("Invalid group: %s (tag or namespace)", by)
+	}
+
+	var groups []Group
+	index := map[string]int{}
+	for _, s := range snippets {
+		for _, k := range keys(s) {
+			i, ok := index[k]
+			if !ok {
+				i = len(groups)
+				index[k] = i
+				groups = append(groups, Group{Name: k})
+			}
+			groups[i].Snippets = append(groups[i].Snippets, s)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return lessGroup(groups[i].Name, groups[j].Name) })
+	return groups, nil
+}
+
+// Namespace is a node of the tree of the namespaces of pet list --tree
+type Namespace struct {
+	// Name is the last part of the path
+	Name string
+	Path string
+	// Snippets are those of the path itself, not of the children
+	Snippets []SnippetInfo
+	Children []*Namespace
+	// Count is the number of snippets of the namespace and of its children
+	Count int
+}
+
+// NamespaceTree returns the tree of the namespaces of the snippets, whose
+// root has the snippets without a path. The children are sorted by name.
+func NamespaceTree(snippets []SnippetInfo) *Namespace {
+	root := &Namespace{}
+	nodes := map[string]*Namespace{"": root}
+	for _, s := range snippets {
+		node := root
+		root.Count++
+		path := strings.Trim(s.Path, "/")
+		if path != "" {
+			parts := strings.Split(path, "/")
+			for i, part := range parts {
+				p := strings.Join(parts[:i+1], "/")
+				child, ok := nodes[p]
+				if !ok {
+					child = &Namespace{Name: part, Path: p}
+					nodes[p] = child
+					node.Children = append(node.Children, child)
+				}
+				child.Count++
+				node = child
+			}
+		}
+		node.Snippets = append(node.Snippets, s)
+	}
+	for _, node := range nodes {
+		children := node.Children
+		sort.SliceStable(children, func(i, j int) bool {
+			return strings.ToLower(children[i].Name) < strings.ToLower(children[j].Name)
+		})
+	}
+	return root
+}
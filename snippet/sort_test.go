@@ -0,0 +1,253 @@
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/config"
+)
+
+func TestSnippets_Sort(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "b", Command: "b", Tag: []string{"k8s"}},
+		{Description: "a", Command: "a"},
+		{Description: "C", Command: "c", Tag: []string{"aws"}},
+		{Description: "d", Command: "d", Tag: []string{"k8s"}},
+	}}
+	usage := UsageStats{"d": &Usage{Count: 3}, "a": &Usage{Count: 1}}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{"description", []string{"a", "b", "C", "d"}},
+		{"tag", []string{"C", "b", "d", "a"}},
+		{"usage", []string{"d", "a", "b", "C"}},
+	}
+	for _, tt := range tests {
+		if err := snippets.Sort(tt.by, usage); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, s := range snippets.Snippets {
+			got = append(got, s.Description)
+		}
+		if diff := deep.Equal(tt.want, got); diff != nil {
+			t.Errorf("%s: %v", tt.by, diff)
+		}
+	}
+
+	if err := snippets.Sort("unknown", usage); err == nil {
+		t.Error("wanted an error for an unknown key")
+	}
+}
+
+func TestUsageStats_ByFrecency(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	snippets := []SnippetInfo{
+		{Description: "never"},
+		{Description: "often long ago"},
+		{Description: "yesterday"},
+		{Description: "twice last week"},
+	}
+	usage := UsageStats{
+		"often long ago":  &Usage{Count: 50, LastUsed: now.AddDate(-1, 0, 0)},
+		"yesterday":       &Usage{Count: 1, LastUsed: now.AddDate(0, 0, -1)},
+		"twice last week": &Usage{Count: 2, LastUsed: now.AddDate(0, 0, -7)},
+	}
+
+	var got []string
+	for _, s := range usage.ByFrecency(snippets, now) {
+		got = append(got, s.Description)
+	}
+	want := []string{"twice last week", "yesterday", "often long ago", "never"}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+	if snippets[0].Description != "never" {
+		t.Error("the snippets were reordered in place")
+	}
+}
+
+func TestSnippets_GroupedString(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "a", Command: "a"},
+		{Description: "b", Command: "b", Tag: []string{"k8s"}},
+	}}
+	want := `# k8s
+
+[[snippets]]
+  description = "b"
+  command = "b"
+  tag = ["k8s"]
+  output = ""
+
+# (untagged)
+
+[[snippets]]
+  description = "a"
+  command = "a"
+  output = ""
+`
+	got, err := snippets.GroupedString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Fatal(diff)
+	}
+
+	var decoded Snippets
+	if _, err := toml.Decode(got, &decoded); err != nil || len(decoded.Snippets) != 2 {
+		t.Fatalf("grouped output does not round-trip: %v %+v", err, decoded)
+	}
+}
+
+func TestSnippets_GroupedString_Multiline(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Description: "deploy", Command: "make build\nmake deploy", Tag: []string{"ops"}},
+	}}
+	got, err := snippets.GroupedString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written like the snippet file, not as an escaped string
+	if !strings.Contains(got, "command = '''\nmake build\nmake deploy'''") {
+		t.Fatalf("multi-line command not written literally:\n%s", got)
+	}
+	var decoded Snippets
+	if _, err := toml.Decode(got, &decoded); err != nil || len(decoded.Snippets) != 1 || decoded.Snippets[0].Command != "make build\nmake deploy" {
+		t.Fatalf("grouped output does not round-trip: %v %+v", err, decoded)
+	}
+}
+
+func TestSnippets_FileSnippets_Runbooks(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = ""
+	content := `[[snippets]]
+  description = "b"
+  command = "b"
+
+[[snippets]]
+  description = "a"
+  command = "a"
+
+[[runbooks]]
+  name = "release"
+  [[runbooks.steps]]
+    snippet = "a"
+`
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the runbooks survive a sort of the file, grouped or not
+	fs := snippets.FileSnippets(config.Conf.General.SnippetFile)
+	if err := fs.Sort("description", nil); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := fs.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	grouped, err := fs.GroupedString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{plain, grouped} {
+		var decoded Snippets
+		if _, err := toml.Decode(body, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.Snippets) != 2 || decoded.Snippets[0].Description != "a" || len(decoded.Runbooks) != 1 || decoded.Runbooks[0].Steps[0].Snippet != "a" {
+			t.Errorf("sorted file lost its runbooks:\n%s", body)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	snippets := []SnippetInfo{
+		{Description: "a", Tag: []string{"k8s", "aws"}, Path: "k8s/debug"},
+		{Description: "b"},
+		{Description: "c", Tag: []string{"aws"}, Path: "/aws/"},
+	}
+
+	tests := []struct {
+		by   string
+		want map[string][]string
+		keys []string
+	}{
+		{"tag", map[string][]string{"aws": {"a", "c"}, "k8s": {"a"}, "(untagged)": {"b"}}, []string{"aws", "k8s", "(untagged)"}},
+		{"namespace", map[string][]string{"aws": {"c"}, "k8s/debug": {"a"}, "(no namespace)": {"b"}}, []string{"aws", "k8s/debug", "(no namespace)"}},
+	}
+	for _, tt := range tests {
+		groups, err := GroupBy(snippets, tt.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		got := map[string][]string{}
+		for _, g := range groups {
+			keys = append(keys, g.Name)
+			for _, s := range g.Snippets {
+				got[g.Name] = append(got[g.Name], s.Description)
+			}
+		}
+		if diff := deep.Equal(tt.keys, keys); diff != nil {
+			t.Errorf("%s: %v", tt.by, diff)
+		}
+		if diff := deep.Equal(tt.want, got); diff != nil {
+			t.Errorf("%s: %v", tt.by, diff)
+		}
+	}
+
+	if _, err := GroupBy(snippets, "file"); err == nil {
+		t.Error("GroupBy(file) succeeded")
+	}
+}
+
+func TestNamespaceTree(t *testing.T) {
+	root := NamespaceTree([]SnippetInfo{
+		{Description: "pods", Path: "k8s/debug"},
+		{Description: "top"},
+		{Description: "ctx", Path: "k8s"},
+		{Description: "old", Path: "k8s-old"},
+		{Description: "logs", Path: "k8s/debug"},
+	})
+
+	var lines []string
+	var walk func(n *Namespace, depth int)
+	walk = func(n *Namespace, depth int) {
+		var descriptions []string
+		for _, s := range n.Snippets {
+			descriptions = append(descriptions, s.Description)
+		}
+		lines = append(lines, fmt.Sprintf("%d %s %d %v", depth, n.Path, n.Count, descriptions))
+		for _, c := range n.Children {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	want := []string{
+		"0  5 [top]",
+		"1 k8s 3 [ctx]",
+		"2 k8s/debug 2 [pods logs]",
+		"1 k8s-old 1 [old]",
+	}
+	if diff := deep.Equal(want, lines); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,182 @@
+package snippet
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// entryHeader starts a snippet of a snippet file
+const entryHeader = "[[snippets]]"
+
+// entryBatch is the size of the snippets decoded at once; one call to
+// the decoder per snippet is slower than decoding the whole file
+const entryBatch = 64 * 1024
+
+// DecodeEntries decodes the snippets of a snippet file a few at a time
+// while it is read, and calls fn with each one. The rest of the file (e.g. the
+// variables and the runbooks) is decoded last, and returned. The whole file
+// is decoded again for the error of a broken file, with its line.
+func DecodeEntries(file string, fn func(SnippetInfo) error) (Snippets, error) {
+	f, err := openFile(file)
+	if err != nil {
+		return Snippets{}, err
+	}
+	defer f.Close()
+
+	var rest strings.Builder
+	var entry []byte
+	inEntry := false
+	decode := func() error {
+		if len(entry) == 0 {
+			return nil
+		}
+		var batch struct {
+			Snippets []SnippetInfo `toml:"snippets"`
+		}
+		if _, err := toml.Decode(string(entry), &batch); err != nil {
+			return err
+		}
+		for _, s := range batch.Snippets {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+		entry = entry[:0]
+		return nil
+	}
+
+	var split lineSplitter
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			header := split.header(line)
+			switch {
+			case header == entryHeader:
+				if len(entry) >= entryBatch {
+					if derr := decode(); derr != nil {
+						return decodeWhole(file, derr)
+					}
+				}
+				inEntry = true
+			case header != "" && !strings.HasPrefix(header, "[snippets.") && !strings.HasPrefix(header, "[[snippets."):
+				// another table, e.g. [variables] or [[runbooks]]
+				if derr := decode(); derr != nil {
+					return decodeWhole(file, derr)
+				}
+				inEntry = false
+			}
+			if inEntry {
+				entry = append(entry, line...)
+			} else {
+				rest.Write(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Snippets{}, err
+		}
+	}
+	if err := decode(); err != nil {
+		return decodeWhole(file, err)
+	}
+
+	var snippets Snippets
+	if _, err := toml.Decode(rest.String(), &snippets); err != nil {
+		return decodeWhole(file, err)
+	}
+	return snippets, nil
+}
+
+// decodeWhole returns the error of decoding the whole file, which has the
+// line of the mistake, or err
+func decodeWhole(file string, err error) (Snippets, error) {
+	data, rerr := ReadFile(file)
+	if rerr != nil {
+		return Snippets{}, rerr
+	}
+	var snippets Snippets
+	if _, werr := toml.Decode(string(data), &snippets); werr != nil {
+		return Snippets{}, werr
+	}
+	return Snippets{}, err
+}
+
+// openFile opens the snippet file, decrypted in memory if it is encrypted
+func openFile(file string) (io.ReadCloser, error) {
+	if !Encrypted(file) {
+		return os.Open(file)
+	}
+	data, err := ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// lineSplitter follows the strings of the lines of a TOML file, to find the
+// table headers outside of multi-line strings
+type lineSplitter struct {
+	// multiline is the delimiter of the multi-line string in progress
+	multiline string
+}
+
+// header returns the table header of the line, e.g. "[[snippets]]", or ""
+func (l *lineSplitter) header(line []byte) string {
+	inString := l.multiline != ""
+	l.scan(line)
+	if inString {
+		return ""
+	}
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		return ""
+	}
+	// a comment may follow the header
+	if i := bytes.IndexByte(trimmed, '#'); i >= 0 {
+		trimmed = bytes.TrimSpace(trimmed[:i])
+	}
+	return strings.ReplaceAll(string(trimmed), " ", "")
+}
+
+// scan skips the strings and the comment of the line and keeps a
+// multi-line string which goes on after it
+func (l *lineSplitter) scan(line []byte) {
+	for i := 0; i < len(line); i++ {
+		if l.multiline != "" {
+			if l.multiline == `"""` && line[i] == '\\' {
+				i++
+				continue
+			}
+			if bytes.HasPrefix(line[i:], []byte(l.multiline)) {
+				// quotes may end the string, e.g. """a"""" is a"
+				i += len(l.multiline) - 1
+				for i+1 < len(line) && line[i+1] == l.multiline[0] {
+					i++
+				}
+				l.multiline = ""
+			}
+			continue
+		}
+		switch c := line[i]; {
+		case c == '#':
+			return
+		case bytes.HasPrefix(line[i:], []byte(`"""`)), bytes.HasPrefix(line[i:], []byte(`'''`)):
+			l.multiline = string(line[i : i+3])
+			i += 2
+		case c == '"' || c == '\'':
+			for i++; i < len(line) && line[i] != c && line[i] != '\n'; i++ {
+				if c == '"' && line[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/knqyf263/pet/config"
+)
+
+const streamFile = `# snippets
+[variables]
+  host = "example.com"
+
+[[snippets]]
+  description = "multi-line"
+  command = """
+[[snippets]]
+echo \"""
+"""
+  tag = ["a"]
+  [snippets.env]
+    NAME = "x"
+
+[[snippets]] # a comment
+  description = "literal"
+  command = '''echo '[[runbooks]]'
+[[snippets]]'''
+  output = "x # not a comment"
+  [[snippets.attachments]]
+    name = "a.txt"
+    content = "content"
+
+[[runbooks]]
+  name = "deploy"
+  [[runbooks.steps]]
+    snippet = "literal"
+
+[[snippets]]
+  description = "last"
+  command = "echo '''"
+`
+
+func TestDecodeEntries(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snippet.toml")
+	if err := os.WriteFile(file, []byte(streamFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var want Snippets
+	if _, err := toml.Decode(streamFile, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	var descriptions []string
+	got, err := DecodeFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range got.Snippets {
+		descriptions = append(descriptions, s.Description)
+	}
+	if !reflect.DeepEqual(descriptions, []string{"multi-line", "literal", "last"}) {
+		t.Fatalf("descriptions = %q", descriptions)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeFile() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeEntries_Error(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snippet.toml")
+	data := "[[snippets]]\n  description = \"a\"\n  command = \"a\"\n\n[[snippets]]\n  description = \"b\"\n  command = \n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := DecodeFile(file)
+	// the line is of the whole file
+	if err == nil || !strings.Contains(err.Error(), "line 7") {
+		t.Errorf("DecodeFile() error = %v, want an error at line 7", err)
+	}
+}
+
+func TestLoad_SkipBodies(t *testing.T) {
+	dir := t.TempDir()
+	defer func(g config.GeneralConfig) { config.Conf.General = g }(config.Conf.General)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(streamFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SkipBodies = true
+	defer func() { SkipBodies = false }()
+
+	var snippets Snippets
+	if err := snippets.Load(); err != nil {
+		t.Fatal(err)
+	}
+	literal := snippets.Snippets[1]
+	if literal.Output != "" || literal.Attachments[0].Content != "" {
+		t.Errorf("the body of the snippet is loaded: %+v", literal)
+	}
+	if literal.Command == "" || literal.Attachments[0].Name != "a.txt" {
+		t.Errorf("the snippet is missing its command or attachments: %+v", literal)
+	}
+	if err := snippets.Save(); err == nil {
+		t.Error("Save() succeeded without the bodies")
+	}
+}
@@ -0,0 +1,120 @@
+package snippet
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tagAliases are the groups of tags commonly written for the same thing
+var tagAliases = [][]string{
+	{"k8s", "kube", "kubernetes"},
+	{"js", "javascript"},
+	{"ts", "typescript"},
+	{"py", "python"},
+	{"rb", "ruby"},
+	{"golang", "go"},
+	{"pg", "postgres", "postgresql", "psql"},
+	{"tf", "terraform"},
+	{"gcp", "gcloud"},
+	{"db", "database"},
+}
+
+// TagNames returns the tags of the snippets, sorted
+func TagNames(snippets []SnippetInfo) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, s := range snippets {
+		for _, t := range s.Tag {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SimilarTags returns the tags of the vocabulary which look like another
+// spelling of the tag, e.g. "k8s" for "kubernetes" or "Docker" for
+// "docker", so that a snippet is not tagged both ways. The tag itself is
+// not similar to itself.
+func SimilarTags(tag string, vocabulary []string) []string {
+	var similar []string
+	for _, t := range vocabulary {
+		if t != tag && similarTag(tag, t) {
+			similar = append(similar, t)
+		}
+	}
+	return similar
+}
+
+func similarTag(a, b string) bool {
+	a, b = normalizeTag(a), normalizeTag(b)
+	if a == b || numeronym(a, b) || numeronym(b, a) {
+		return true
+	}
+	for _, group := range tagAliases {
+		if inGroup(a, group) && inGroup(b, group) {
+			return true
+		}
+	}
+	// one typo or a plural, only for tags long enough to tell
+	if len(a) >= 4 && len(b) >= 4 {
+		return editDistance(a, b) <= 1
+	}
+	return false
+}
+
+// normalizeTag ignores the case and the separators of a tag
+func normalizeTag(tag string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(tag))
+}
+
+// numeronym reports whether short abbreviates long like k8s does kubernetes:
+// the first and the last letter with the count of the letters between them
+func numeronym(short, long string) bool {
+	if len(short) < 3 || len(long) < 4 {
+		return false
+	}
+	n, err := strconv.Atoi(short[1 : len(short)-1])
+	return err == nil && short[0] == long[0] && short[len(short)-1] == long[len(long)-1] && n == len(long)-2
+}
+
+func inGroup(tag string, group []string) bool {
+	for _, t := range group {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance of a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,43 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestTagNames(t *testing.T) {
+	snippets := []SnippetInfo{
+		{Tag: []string{"k8s", "aws"}},
+		{},
+		{Tag: []string{"aws", "docker"}},
+	}
+	if diff := deep.Equal([]string{"aws", "docker", "k8s"}, TagNames(snippets)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSimilarTags(t *testing.T) {
+	vocabulary := []string{"kubernetes", "docker", "Docker", "aws", "git", "go", "javascript", "i18n", "dockerfile"}
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"k8s", []string{"kubernetes"}},
+		{"kube", []string{"kubernetes"}},
+		{"docker", []string{"Docker"}},
+		{"dockers", []string{"docker", "Docker"}},
+		{"js", []string{"javascript"}},
+		{"golang", []string{"go"}},
+		{"internationalization", []string{"i18n"}},
+		{"docker-file", []string{"dockerfile"}},
+		{"gcp", nil},
+		{"gh", nil},
+		{"aws", nil},
+	}
+	for _, tt := range tests {
+		if diff := deep.Equal(tt.want, SimilarTags(tt.tag, vocabulary)); diff != nil {
+			t.Errorf("%s: %v", tt.tag, diff)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package snippet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/knqyf263/pet/dialog"
+	"gopkg.in/alessio/shellescape.v1"
+)
+
+// templateFuncs are the functions available in commands
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"date": func(layout ...string) string {
+		if len(layout) == 0 {
+			return time.Now().Format("2006-01-02")
+		}
+		return time.Now().Format(strings.Join(layout, " "))
+	},
+	"uuid": func() (string, error) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	},
+	"hostname": os.Hostname,
+	"args": func() string {
+		return quoteArgs(Args)
+	},
+}
+
+// Args are the arguments of {{args}}, given after -- to pet exec
+var Args []string
+
+// argsCallRe matches the uses of {{args}}
+var argsCallRe = regexp.MustCompile(`{{-?\s*args\s*-?}}`)
+
+// UsesArgs reports whether the command places the arguments with {{args}},
+// instead of getting them at its end
+func UsesArgs(command string) bool {
+	return argsCallRe.MatchString(command)
+}
+
+// WithArgs returns the command with the arguments at its end, quoted
+func WithArgs(command string, args []string) string {
+	if len(args) == 0 {
+		return command
+	}
+	return strings.TrimRight(command, " \t\n") + " " + quoteArgs(args)
+}
+
+// quoteArgs returns the arguments quoted for the shell, separated by spaces
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellescape.Quote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// funcCallRe returns the pattern of the calls of the template functions
+// and the context variables. Other {{...}} (e.g. docker --format
+// '{{.Names}}') are left as they are.
+func funcCallRe() *regexp.Regexp {
+	names := append([]string{"env", "date", "uuid", "hostname", "include", "args"}, ContextNames()...)
+	return regexp.MustCompile(`{{-?\s*(` + strings.Join(names, "|") + `)\b.*?}}`)
+}
+
+// Render evaluates the template functions in a command, e.g.
+// {{env "HOME"}}, {{date "2006-01-02"}}, {{uuid}}, {{hostname}} and
+// {{args}}, the quoted Args, and the context variables, e.g.
+// {{kube_context}} (see RegisterContext).
+// {{include "NAME"}} inserts the command of the snippet named NAME, as
+// returned by lookup.
+func Render(command string, lookup func(name string) (SnippetInfo, bool)) (string, error) {
+	rendered, err := render(command, lookup, map[string]bool{})
+	if err != nil {
+		return command, fmt.Errorf("Failed to evaluate the command: %v", err)
+	}
+	return rendered, nil
+}
+
+// Expand returns the command of the snippet as its script sets it, with its
+// template functions evaluated, its attachments, the variables and the
+// values filled in. The values must be valid for the parameters, and each
+// one not in values gets its default.
+func (snippets *Snippets) Expand(s SnippetInfo, values map[string]string) (string, error) {
+	command, err := s.ScriptedCommand()
+	if err != nil {
+		return "", err
+	}
+	if command, err = Render(command, snippets.FindByName); err != nil {
+		return "", err
+	}
+	if len(s.Attachments) > 0 {
+		// pet exec writes them to a directory of its own when it runs
+		dir, err := NewAttachmentDir()
+		if err != nil {
+			return "", err
+		}
+		command = dialog.FillParams(command, s.AttachmentPaths(dir))
+	}
+	vars := snippets.Variables(s)
+	for name := range values {
+		delete(vars, name)
+	}
+	command = dialog.FillParams(command, vars)
+	if err := dialog.ValidateParams(command, values); err != nil {
+		return "", err
+	}
+	return dialog.ExpandParams(command, values), nil
+}
+
+// ExpandDir evaluates the template functions in the working directory of a
+// snippet, e.g. {{env "PROJECT"}}, and expands a leading ~
+func ExpandDir(dir string) (string, error) {
+	expanded, err := RenderDir(dir)
+	if err != nil {
+		return dir, err
+	}
+	if expanded == "~" || len(expanded) > 1 && expanded[0] == '~' && os.IsPathSeparator(expanded[1]) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return dir, err
+		}
+		expanded = filepath.Join(home, expanded[1:])
+	}
+	return expanded, nil
+}
+
+// RenderDir evaluates the template functions in the working directory of a
+// snippet and leaves a leading ~ as it is, e.g. for another machine
+func RenderDir(dir string) (string, error) {
+	rendered, err := render(dir, nil, map[string]bool{})
+	if err != nil {
+		return dir, fmt.Errorf("Failed to evaluate the directory %s: %v", dir, err)
+	}
+	return rendered, nil
+}
+
+func render(command string, lookup func(name string) (SnippetInfo, bool), including map[string]bool) (string, error) {
+	funcs := template.FuncMap{}
+	for name, f := range contextFuncs() {
+		funcs[name] = f
+	}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+	funcs["include"] = func(name string) (string, error) {
+		if lookup == nil {
+			return "", fmt.Errorf("cannot include [%s] here", name)
+		}
+		s, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("Snippet named [%s] not found", name)
+		}
+		if including[name] {
+			return "", fmt.Errorf("Snippet named [%s] includes itself", name)
+		}
+		including[name] = true
+		defer delete(including, name)
+		return render(s.Command, lookup, including)
+	}
+
+	var err error
+	rendered := funcCallRe().ReplaceAllStringFunc(command, func(call string) string {
+		if err != nil {
+			return call
+		}
+		var t *template.Template
+		if t, err = template.New("command").Funcs(funcs).Parse(call); err != nil {
+			return call
+		}
+		var buf bytes.Buffer
+		if err = t.Execute(&buf, nil); err != nil {
+			return call
+		}
+		return buf.String()
+	})
+	return rendered, err
+}
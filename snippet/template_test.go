@@ -0,0 +1,98 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("PET_TEST_VALUE", "value")
+	hostname, _ := os.Hostname()
+
+	got, err := Render(`echo {{env "PET_TEST_VALUE"}} {{date "2006"}} {{hostname}} && docker ps --format '{{.Names}}'`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "echo value " + time.Now().Format("2006") + " " + hostname + " && docker ps --format '{{.Names}}'"
+	if got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+
+	got, err = Render("touch {{uuid}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^touch [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(got) {
+		t.Errorf("unexpected uuid in '%s'", got)
+	}
+
+	if _, err := Render(`echo {{env "A" "B"}}`, nil); err == nil {
+		t.Error("wanted an error for a wrong call")
+	}
+}
+
+func TestExpandDir(t *testing.T) {
+	t.Setenv("PET_TEST_VALUE", "project")
+	home, _ := os.UserHomeDir()
+
+	got, err := ExpandDir(`~/src/{{env "PET_TEST_VALUE"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "src", "project"); got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+
+	if got, _ := ExpandDir("/tmp/~user"); got != "/tmp/~user" {
+		t.Errorf("wanted '/tmp/~user', got '%s'", got)
+	}
+	if _, err := ExpandDir(`{{include "other"}}`); err == nil {
+		t.Error("wanted an error for include")
+	}
+}
+
+func TestRender_Include(t *testing.T) {
+	snippets := Snippets{Snippets: []SnippetInfo{
+		{Name: "bastion", Command: "ssh -J bastion <host>"},
+		{Name: "forward", Command: `{{include "bastion"}} -L <port=8080>:localhost:<port=8080>`},
+		{Name: "loop", Command: `echo {{include "loop"}}`},
+	}}
+
+	got, err := Render(`{{include "forward"}} -N`, snippets.FindByName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ssh -J bastion <host> -L <port=8080>:localhost:<port=8080> -N"; got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+
+	if _, err := Render(`{{include "loop"}}`, snippets.FindByName); err == nil {
+		t.Error("wanted an error for a snippet including itself")
+	}
+	if _, err := Render(`{{include "missing"}}`, snippets.FindByName); err == nil {
+		t.Error("wanted an error for a missing snippet")
+	}
+}
+
+func TestRender_Args(t *testing.T) {
+	defer func(args []string) { Args = args }(Args)
+	Args = []string{"a b.txt", "$HOME"}
+
+	command := "grep -n TODO {{args}} | head"
+	if !UsesArgs(command) || UsesArgs("grep -n TODO") {
+		t.Error("UsesArgs() does not find {{args}}")
+	}
+	got, err := Render(command, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "grep -n TODO 'a b.txt' '$HOME' | head"; got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+	if got, want := WithArgs("wc -l \n", Args), "wc -l 'a b.txt' '$HOME'"; got != want {
+		t.Errorf("wanted '%s', got '%s'", want, got)
+	}
+}
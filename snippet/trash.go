@@ -0,0 +1,150 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const (
+	trashFileName = "trash.json"
+	// defaultTrashDays is used when trash_days is not set
+	defaultTrashDays = 30
+)
+
+// TrashedSnippet is a deleted snippet kept in the trash
+type TrashedSnippet struct {
+	SnippetInfo
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Trashcan holds the deleted snippets, oldest first. It is kept in a
+// sidecar file so that deletions can be undone within trash_days.
+type Trashcan []TrashedSnippet
+
+func trashFile() (string, error) {
+	return config.GetDataFile(trashFileName)
+}
+
+// LoadTrash reads the trash and drops the snippets deleted before the
+// retention window.
+func LoadTrash() (Trashcan, error) {
+	var trash Trashcan
+	file, err := trashFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read trash file. %v", err)
+	}
+	if err := json.Unmarshal(data, &trash); err != nil {
+		return nil, fmt.Errorf("Failed to parse trash file. %v", err)
+	}
+
+	days := config.Conf.General.TrashDays
+	if days <= 0 {
+		days = defaultTrashDays
+	}
+	return trash.Expire(time.Now().AddDate(0, 0, -days)), nil
+}
+
+// Save writes the trash.
+func (trash Trashcan) Save() error {
+	file, err := trashFile()
+	if err != nil {
+		return err
+	}
+	if trash == nil {
+		trash = Trashcan{}
+	}
+	data, err := json.MarshalIndent(trash, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode trash. %v", err)
+	}
+	return writeDataFile(file, data)
+}
+
+// Expire returns the snippets deleted at or after cutoff.
+func (trash Trashcan) Expire(cutoff time.Time) Trashcan {
+	var kept Trashcan
+	for _, t := range trash {
+		if !t.DeletedAt.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// Last returns the indexes of the snippets deleted together most recently.
+func (trash Trashcan) Last() []int {
+	var last []int
+	for i := len(trash) - 1; i >= 0; i-- {
+		if !trash[i].DeletedAt.Equal(trash[len(trash)-1].DeletedAt) {
+			break
+		}
+		last = append(last, i)
+	}
+	sort.Ints(last)
+	return last
+}
+
+// Restore removes the snippets at the indexes from the trash and returns them.
+func (trash *Trashcan) Restore(indexes []int) []SnippetInfo {
+	restore := map[int]bool{}
+	for _, i := range indexes {
+		restore[i] = true
+	}
+	var restored []SnippetInfo
+	var kept Trashcan
+	for i, t := range *trash {
+		if restore[i] {
+			restored = append(restored, t.SnippetInfo)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	*trash = kept
+	return restored
+}
+
+// Trash moves the deleted snippets to the trash.
+func Trash(deleted []SnippetInfo) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+	trash, err := LoadTrash()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, s := range deleted {
+		trash = append(trash, TrashedSnippet{SnippetInfo: s, DeletedAt: now})
+	}
+	return trash.Save()
+}
+
+// Removed returns the snippets of before that are not in after, matching
+// on the description or the command so that edited snippets are not
+// considered removed.
+func Removed(before, after []SnippetInfo) []SnippetInfo {
+	descriptions := map[string]bool{}
+	commands := map[string]bool{}
+	for _, s := range after {
+		descriptions[s.Description] = true
+		commands[s.Command] = true
+	}
+	var removed []SnippetInfo
+	for _, s := range before {
+		if !descriptions[s.Description] && !commands[s.Command] {
+			removed = append(removed, s)
+		}
+	}
+	return removed
+}
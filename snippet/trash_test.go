@@ -0,0 +1,57 @@
+package snippet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestTrashcan(t *testing.T) {
+	t.Setenv("PET_CONFIG_DIR", t.TempDir())
+
+	if err := Trash([]SnippetInfo{{Description: "a", Command: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Trash([]SnippetInfo{{Description: "b", Command: "b"}, {Description: "c", Command: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	trash, err := LoadTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal([]int{1, 2}, trash.Last()); diff != nil {
+		t.Fatal(diff)
+	}
+
+	restored := trash.Restore(trash.Last())
+	if len(restored) != 2 || restored[0].Description != "b" || restored[1].Description != "c" {
+		t.Fatalf("unexpected restored snippets %+v", restored)
+	}
+	if len(trash) != 1 || trash[0].Description != "a" {
+		t.Fatalf("unexpected trash %+v", trash)
+	}
+
+	if got := trash.Expire(time.Now().Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("wanted an empty trash, got %+v", got)
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	before := []SnippetInfo{
+		{Description: "kept", Command: "a"},
+		{Description: "renamed", Command: "b"},
+		{Description: "edited", Command: "c"},
+		{Description: "removed", Command: "d"},
+	}
+	after := []SnippetInfo{
+		{Description: "kept", Command: "a"},
+		{Description: "new name", Command: "b"},
+		{Description: "edited", Command: "c2"},
+	}
+	got := Removed(before, after)
+	if len(got) != 1 || got[0].Description != "removed" {
+		t.Fatalf("unexpected removed snippets %+v", got)
+	}
+}
@@ -0,0 +1,229 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const usageFileName = "usage.json"
+
+// Usage is the execution statistics of a snippet
+type Usage struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// UsageStats maps snippets, by their usage key, to their execution
+// statistics. It is kept in a sidecar file so that the snippet file stays
+// clean. The statistics written before the usage keys are by description.
+type UsageStats map[string]*Usage
+
+// usageKey returns the key of the statistics of a snippet: its description
+// and its command, as several snippets may have the same description
+func usageKey(s SnippetInfo) string {
+	return s.Description + "\n" + s.Command
+}
+
+func usageFile() (string, error) {
+	return config.GetDataFile(usageFileName)
+}
+
+// LoadUsage reads the usage statistics.
+func LoadUsage() (UsageStats, error) {
+	stats := UsageStats{}
+	file, err := usageFile()
+	if err != nil {
+		return stats, err
+	}
+	data, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return stats, nil
+	} else if err != nil {
+		return stats, fmt.Errorf("Failed to read usage file. %v", err)
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return stats, fmt.Errorf("Failed to parse usage file. %v", err)
+	}
+	return stats, nil
+}
+
+// Save writes the usage statistics.
+func (stats UsageStats) Save() error {
+	file, err := usageFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode usage. %v", err)
+	}
+	return writeDataFile(file, data)
+}
+
+// Get returns the statistics of the snippet. A never used snippet has a zero Usage.
+func (stats UsageStats) Get(s SnippetInfo) Usage {
+	if u, ok := stats[usageKey(s)]; ok {
+		return *u
+	}
+	if u, ok := stats[s.Description]; ok {
+		return *u
+	}
+	return Usage{}
+}
+
+// Unused reports whether the snippet was neither executed nor created at or
+// after cutoff, so that a snippet just added is not stale for not having
+// run yet. A snippet without a creation time only counts its executions.
+func (stats UsageStats) Unused(s SnippetInfo, cutoff time.Time) bool {
+	last := stats.Get(s).LastUsed
+	if s.CreatedAt != nil && s.CreatedAt.After(last) {
+		last = *s.CreatedAt
+	}
+	return last.Before(cutoff)
+}
+
+// frecencyHalfLife is the age after which an execution counts half for the
+// frecency
+const frecencyHalfLife = 7 * 24 * time.Hour
+
+// Frecency returns the score of the snippet for the selector: its number of
+// executions decayed by the time since the last one, so that a snippet run
+// yesterday ranks above one run often a year ago.
+func (stats UsageStats) Frecency(s SnippetInfo, now time.Time) float64 {
+	u := stats.Get(s)
+	if u.Count == 0 {
+		return 0
+	}
+	age := now.Sub(u.LastUsed)
+	if age < 0 {
+		age = 0
+	}
+	return float64(u.Count) * math.Pow(0.5, float64(age)/float64(frecencyHalfLife))
+}
+
+// ByFrecency returns the snippets ordered by their frecency, highest first.
+// Snippets with the same score keep their order.
+func (stats UsageStats) ByFrecency(snippets []SnippetInfo, now time.Time) []SnippetInfo {
+	sorted := append([]SnippetInfo{}, snippets...)
+	scores := map[string]float64{}
+	for _, s := range sorted {
+		scores[usageKey(s)] = stats.Frecency(s, now)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return scores[usageKey(sorted[i])] > scores[usageKey(sorted[j])]
+	})
+	return sorted
+}
+
+// Record counts an execution of the snippet. Its statistics by description
+// are moved to its usage key.
+func (stats UsageStats) Record(s SnippetInfo, at time.Time) {
+	key := usageKey(s)
+	u, ok := stats[key]
+	if !ok {
+		if u, ok = stats[s.Description]; ok {
+			delete(stats, s.Description)
+		} else {
+			u = &Usage{}
+		}
+		stats[key] = u
+	}
+	u.Count++
+	u.LastUsed = at
+}
+
+// Rename moves the statistics of a renamed or edited snippet, from and to
+// being usage keys, or from the description of statistics by description.
+func (stats UsageStats) Rename(from, to string) {
+	u, ok := stats[from]
+	if !ok || from == to {
+		return
+	}
+	if old, ok := stats[to]; ok {
+		u.Count += old.Count
+		if old.LastUsed.After(u.LastUsed) {
+			u.LastUsed = old.LastUsed
+		}
+	}
+	stats[to] = u
+	delete(stats, from)
+}
+
+// usageMoves returns the usage keys of the snippets of before which are
+// renamed or whose command changed in after, with their new keys, and their
+// descriptions for the statistics by description. renamed are the renamed
+// descriptions as returned by Renamed. A snippet is only followed to the
+// single new snippet of its description, the statistics of the others
+// having the same description are left alone.
+func usageMoves(before, after []SnippetInfo, renamed map[string]string) map[string]string {
+	kept, current := map[string]bool{}, map[string]bool{}
+	for _, s := range before {
+		kept[usageKey(s)] = true
+	}
+	added := map[string][]string{}
+	for _, s := range after {
+		key := usageKey(s)
+		current[key] = true
+		if !kept[key] {
+			added[s.Description] = append(added[s.Description], key)
+		}
+	}
+
+	moves := map[string]string{}
+	for _, s := range before {
+		if current[usageKey(s)] {
+			continue
+		}
+		description := s.Description
+		if to, ok := renamed[description]; ok {
+			description = to
+		}
+		if keys := added[description]; len(keys) == 1 {
+			moves[usageKey(s)] = keys[0]
+			moves[s.Description] = keys[0]
+		}
+	}
+	return moves
+}
+
+// moveUsage moves the statistics of the renamed and edited snippets, as
+// returned by usageMoves, and saves them.
+func moveUsage(moves map[string]string) error {
+	if len(moves) == 0 {
+		return nil
+	}
+	stats, err := LoadUsage()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for from, to := range moves {
+		if _, ok := stats[from]; ok && from != to {
+			stats.Rename(from, to)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return stats.Save()
+}
+
+// RecordUsage counts an execution of the snippets and saves the statistics.
+func RecordUsage(snippets []SnippetInfo) error {
+	stats, err := LoadUsage()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, s := range snippets {
+		stats.Record(s, now)
+	}
+	return stats.Save()
+}
@@ -0,0 +1,104 @@
+package snippet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageStats_RecordGet(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	prod := SnippetInfo{Description: "deploy", Command: "make deploy ENV=prod"}
+	staging := SnippetInfo{Description: "deploy", Command: "make deploy ENV=staging"}
+	stats := UsageStats{}
+
+	stats.Record(prod, now.Add(-time.Hour))
+	stats.Record(prod, now)
+	// the snippets with the same description are counted apart
+	if u := stats.Get(prod); u.Count != 2 || !u.LastUsed.Equal(now) {
+		t.Errorf("Get(prod) = %+v, want 2 executions, the last at %v", u, now)
+	}
+	if u := stats.Get(staging); u.Count != 0 {
+		t.Errorf("Get(staging) = %+v, want none", u)
+	}
+	stats.Record(staging, now)
+	if u := stats.Get(staging); u.Count != 1 {
+		t.Errorf("Get(staging) = %+v, want 1 execution", u)
+	}
+
+	// the statistics by description are read, and moved by the next execution
+	stats = UsageStats{"deploy": &Usage{Count: 5, LastUsed: now.AddDate(0, 0, -1)}}
+	if u := stats.Get(prod); u.Count != 5 {
+		t.Errorf("Get(prod) of the statistics by description = %+v, want 5 executions", u)
+	}
+	stats.Record(prod, now)
+	if _, ok := stats["deploy"]; ok {
+		t.Errorf("statistics by description kept: %+v", stats)
+	}
+	if u := stats.Get(prod); u.Count != 6 {
+		t.Errorf("Get(prod) = %+v, want 6 executions", u)
+	}
+	if u := stats.Get(staging); u.Count != 0 {
+		t.Errorf("Get(staging) = %+v, want none", u)
+	}
+}
+
+func TestUsageMoves(t *testing.T) {
+	before := []SnippetInfo{
+		{Description: "deploy", Command: "make deploy ENV=prod"},
+		{Description: "deploy", Command: "make deploy ENV=staging"},
+		{Description: "old", Command: "echo"},
+		{Description: "same", Command: "true"},
+	}
+	after := []SnippetInfo{
+		{Description: "deploy", Command: "make deploy ENV=production"},
+		{Description: "deploy", Command: "make deploy ENV=staging"},
+		{Description: "new", Command: "echo"},
+		{Description: "same", Command: "true"},
+	}
+	moves := usageMoves(before, after, Renamed(before, after))
+	want := map[string]string{
+		usageKey(before[0]): usageKey(after[0]),
+		"deploy":            usageKey(after[0]),
+		usageKey(before[2]): usageKey(after[2]),
+		"old":               usageKey(after[2]),
+	}
+	if len(moves) != len(want) {
+		t.Errorf("usageMoves() = %q, want %q", moves, want)
+	}
+	for from, to := range want {
+		if moves[from] != to {
+			t.Errorf("usageMoves()[%q] = %q, want %q", from, moves[from], to)
+		}
+	}
+}
+
+func TestUsageStats_Unused(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, 0, -30)
+	minuteAgo := now.Add(-time.Minute)
+	yearAgo := now.AddDate(-1, 0, 0)
+	stats := UsageStats{
+		"run lately":   &Usage{Count: 3, LastUsed: now.AddDate(0, 0, -1)},
+		"run long ago": &Usage{Count: 1, LastUsed: yearAgo},
+	}
+
+	tests := []struct {
+		snippet SnippetInfo
+		want    bool
+	}{
+		// a snippet just added has not had the time to run
+		{SnippetInfo{Description: "fresh", CreatedAt: &minuteAgo}, false},
+		{SnippetInfo{Description: "old", CreatedAt: &yearAgo}, true},
+		{SnippetInfo{Description: "run lately", CreatedAt: &yearAgo}, false},
+		{SnippetInfo{Description: "run long ago", CreatedAt: &yearAgo}, true},
+		{SnippetInfo{Description: "run long ago", CreatedAt: &minuteAgo}, false},
+		// without a creation time, only the executions count
+		{SnippetInfo{Description: "unknown"}, true},
+		{SnippetInfo{Description: "run lately"}, false},
+	}
+	for _, tt := range tests {
+		if got := stats.Unused(tt.snippet, cutoff); got != tt.want {
+			t.Errorf("Unused(%s, created %v) = %v, want %v", tt.snippet.Description, tt.snippet.CreatedAt, got, tt.want)
+		}
+	}
+}
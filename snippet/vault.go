@@ -0,0 +1,107 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// vaultClient reads the secrets of the parameters from Vault
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// vaultSecrets are the secrets read from Vault in this run, by path
+var vaultSecrets = map[string]map[string]interface{}{}
+
+// ReadVault returns a field of a Vault secret, ref being path#field, e.g.
+// secret/data/prod/db#password. It uses $VAULT_ADDR and $VAULT_TOKEN (or
+// ~/.vault-token, written by vault login) like the vault CLI, and
+// $VAULT_NAMESPACE if it is set. Secrets of KV version 1 and 2 are read.
+func ReadVault(ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("invalid Vault secret %s (path#field)", ref)
+	}
+	data, ok := vaultSecrets[path]
+	if !ok {
+		var err error
+		if data, err = readVaultSecret(path); err != nil {
+			return "", fmt.Errorf("Failed to read %s from Vault: %v", path, err)
+		}
+		vaultSecrets[path] = data
+	}
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("the Vault secret %s has no field %s", path, field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func readVaultSecret(path string) (map[string]interface{}, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("$VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.Join(body.Errors, ", "))
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	// KV version 2 nests the fields in data next to the metadata
+	if inner, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, ok := body.Data["metadata"]; ok {
+			return inner, nil
+		}
+	}
+	return body.Data, nil
+}
+
+// vaultToken returns $VAULT_TOKEN or the token saved by vault login
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if b, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			if token := strings.TrimSpace(string(b)); token != "" {
+				return token, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("$VAULT_TOKEN is not set, run vault login")
+}
@@ -0,0 +1,54 @@
+package snippet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "t0ken" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/prod/db":
+			w.Write([]byte(`{"data":{"data":{"password":"s3cr3t","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/kv/legacy":
+			w.Write([]byte(`{"data":{"password":"old"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "t0ken")
+	defer func() { vaultSecrets = map[string]map[string]interface{}{} }()
+
+	tests := []struct {
+		ref  string
+		want string
+		ok   bool
+	}{
+		{"secret/data/prod/db#password", "s3cr3t", true},
+		{"secret/data/prod/db#port", "5432", true},
+		{"kv/legacy#password", "old", true},
+		{"secret/data/prod/db#user", "", false},
+		{"secret/data/missing#password", "", false},
+		{"secret/data/prod/db", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ReadVault(tt.ref)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadVault(%s) = %q, %v", tt.ref, got, err)
+		}
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	if _, err := ReadVault("kv/other#password"); err == nil {
+		t.Error("ReadVault() with a wrong token succeeded")
+	}
+}
@@ -0,0 +1,140 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+const (
+	versionsFileName = "versions.json"
+	// maxVersions is the number of previous versions kept per snippet
+	maxVersions = 20
+)
+
+// Version is a previous version of a snippet
+type Version struct {
+	SnippetInfo
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Versions maps snippet descriptions to their previous versions, oldest
+// first. They are kept in a sidecar file so that edits can be reverted.
+type Versions map[string][]Version
+
+func versionsFile() (string, error) {
+	return config.GetDataFile(versionsFileName)
+}
+
+// LoadVersions reads the previous versions of the snippets.
+func LoadVersions() (Versions, error) {
+	versions := Versions{}
+	file, err := versionsFile()
+	if err != nil {
+		return versions, err
+	}
+	data, err := ReadFile(file)
+	if os.IsNotExist(err) {
+		return versions, nil
+	} else if err != nil {
+		return versions, fmt.Errorf("Failed to read versions file. %v", err)
+	}
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return versions, fmt.Errorf("Failed to parse versions file. %v", err)
+	}
+	return versions, nil
+}
+
+// Save writes the previous versions of the snippets.
+func (versions Versions) Save() error {
+	file, err := versionsFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode versions. %v", err)
+	}
+	return writeDataFile(file, data)
+}
+
+// Get returns the previous versions of the snippet, newest first.
+func (versions Versions) Get(s SnippetInfo) []Version {
+	var newest []Version
+	old := versions[s.Description]
+	for i := len(old) - 1; i >= 0; i-- {
+		newest = append(newest, old[i])
+	}
+	return newest
+}
+
+// Add keeps s as a previous version of the snippet described by description.
+func (versions Versions) Add(description string, s SnippetInfo, at time.Time) {
+	old := append(versions[description], Version{SnippetInfo: s, ReplacedAt: at})
+	if len(old) > maxVersions {
+		old = old[len(old)-maxVersions:]
+	}
+	versions[description] = old
+}
+
+// Rename moves the versions of a renamed snippet.
+func (versions Versions) Rename(from, to string) {
+	old, ok := versions[from]
+	if !ok || from == to {
+		return
+	}
+	versions[to] = append(versions[to], old...)
+	delete(versions, from)
+}
+
+// changedVersion reports whether the command, output or tags differ, which
+// are worth a version unlike e.g. toggling favorite
+func changedVersion(a, b SnippetInfo) bool {
+	return a.Command != b.Command || a.Output != b.Output || !reflect.DeepEqual(a.Tag, b.Tag)
+}
+
+// Edited returns the snippets of before whose command, output or tags are
+// changed in after, by the description in after. renamed are the renamed
+// descriptions as returned by Renamed.
+func Edited(before, after []SnippetInfo, renamed map[string]string) map[string]SnippetInfo {
+	current := map[string]SnippetInfo{}
+	for _, s := range after {
+		current[s.Description] = s
+	}
+	edited := map[string]SnippetInfo{}
+	for _, s := range before {
+		description := s.Description
+		if to, ok := renamed[description]; ok {
+			description = to
+		}
+		if c, ok := current[description]; ok && changedVersion(s, c) {
+			edited[description] = s
+		}
+	}
+	return edited
+}
+
+// recordVersions keeps the previous versions of the edited snippets and
+// moves the versions of the renamed ones.
+func recordVersions(edited map[string]SnippetInfo, renamed map[string]string) error {
+	if len(edited) == 0 && len(renamed) == 0 {
+		return nil
+	}
+	versions, err := LoadVersions()
+	if err != nil {
+		return err
+	}
+	for from, to := range renamed {
+		versions.Rename(from, to)
+	}
+	now := time.Now()
+	for description, s := range edited {
+		s.CreatedAt, s.UpdatedAt = nil, nil
+		versions.Add(description, s, now)
+	}
+	return versions.Save()
+}
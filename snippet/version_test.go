@@ -0,0 +1,48 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestRecordEdits(t *testing.T) {
+	t.Setenv("PET_CONFIG_DIR", t.TempDir())
+
+	before := []SnippetInfo{
+		{Description: "edited", Command: "echo v1"},
+		{Description: "renamed", Command: "echo same"},
+		{Description: "pinned", Command: "echo pin"},
+	}
+	after := []SnippetInfo{
+		{Description: "edited", Command: "echo v2"},
+		{Description: "new name", Command: "echo same"},
+		{Description: "pinned", Command: "echo pin", Favorite: true},
+	}
+	if err := RecordEdits(before, after); err != nil {
+		t.Fatal(err)
+	}
+	after2 := []SnippetInfo{after[0], {Description: "new name", Command: "echo changed"}, after[2]}
+	after2[0].Command = "echo v3"
+	if err := RecordEdits(after, after2); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := LoadVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, v := range versions.Get(after2[0]) {
+		commands = append(commands, v.Command)
+	}
+	if diff := deep.Equal([]string{"echo v2", "echo v1"}, commands); diff != nil {
+		t.Fatal(diff)
+	}
+	if got := versions.Get(after2[1]); len(got) != 1 || got[0].Description != "new name" || got[0].Command != "echo same" {
+		t.Fatalf("unexpected versions of the renamed snippet %+v", got)
+	}
+	if got := versions.Get(after2[2]); len(got) != 0 {
+		t.Fatalf("wanted no version for a favorite toggle, got %+v", got)
+	}
+}
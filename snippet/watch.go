@@ -0,0 +1,105 @@
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/knqyf263/pet/config"
+)
+
+// WatchDelay is how long a Watcher waits for the other writes of a change,
+// e.g. an editor saving with a temporary file
+var WatchDelay = 100 * time.Millisecond
+
+// Watcher notices the changes of the snippet files on disk, e.g. made in
+// another terminal or by a sync, with the notifications of the system
+type Watcher struct {
+	fs    *fsnotify.Watcher
+	state string
+}
+
+// NewWatcher watches the directories of the snippet files and snippetdir
+func NewWatcher() (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	files, err := SnippetFiles()
+	if err != nil {
+		fs.Close()
+		return nil, err
+	}
+	dirs := map[string]bool{}
+	for _, file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+	if dir := config.Conf.General.SnippetDir; dir != "" {
+		dirs[dir] = true
+	}
+	watched := 0
+	for dir := range dirs {
+		if err := fs.Add(dir); err == nil {
+			watched++
+		} else if !os.IsNotExist(err) {
+			fs.Close()
+			return nil, fmt.Errorf("Failed to watch %s: %v", dir, err)
+		}
+	}
+	if watched == 0 {
+		fs.Close()
+		return nil, fmt.Errorf("none of the directories of the snippet files exists")
+	}
+	return &Watcher{fs: fs, state: FilesState()}, nil
+}
+
+// Run calls changed when the snippet files, or their list, change until the
+// Watcher is closed. The events of the other files of the directories are
+// ignored.
+func (w *Watcher) Run(changed func()) error {
+	var timer <-chan time.Time
+	for {
+		select {
+		case _, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			timer = time.After(WatchDelay)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer:
+			timer = nil
+			if state := FilesState(); state != w.state {
+				w.state = state
+				changed()
+			}
+		}
+	}
+}
+
+// Close stops the Watcher
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// FilesState returns the names, modification times and sizes of the
+// snippet files, which change with them
+func FilesState() string {
+	files, err := SnippetFiles()
+	if err != nil {
+		return ""
+	}
+	var state strings.Builder
+	for _, file := range files {
+		if fi, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&state, "%s %d %d\n", file, fi.ModTime().UnixNano(), fi.Size())
+		}
+	}
+	return state.String()
+}
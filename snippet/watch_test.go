@@ -0,0 +1,60 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SnippetDir = filepath.Join(dir, "snippets.d")
+	defer func() { config.Conf.General.SnippetDir = "" }()
+	if err := os.Mkdir(config.Conf.General.SnippetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(d time.Duration) { WatchDelay = d }(WatchDelay)
+	WatchDelay = 10 * time.Millisecond
+
+	w, err := NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan struct{}, 10)
+	go w.Run(func() { changed <- struct{}{} })
+	defer w.Close()
+
+	expect := func(what string, want bool) {
+		t.Helper()
+		select {
+		case <-changed:
+			if !want {
+				t.Errorf("%s is a change", what)
+			}
+		case <-time.After(500 * time.Millisecond):
+			if want {
+				t.Errorf("%s is not noticed", what)
+			}
+		}
+	}
+	write := func(file, content string) {
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(config.Conf.General.SnippetFile, "[[snippets]]\n")
+	expect("a new snippet file", true)
+	write(filepath.Join(config.Conf.General.SnippetDir, "k8s.toml"), "[[snippets]]\n")
+	expect("a file of snippetdir", true)
+	write(filepath.Join(dir, "notes.txt"), "x")
+	expect("another file", false)
+	if err := os.Remove(filepath.Join(config.Conf.General.SnippetDir, "k8s.toml")); err != nil {
+		t.Fatal(err)
+	}
+	expect("a removed file", true)
+}
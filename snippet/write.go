@@ -0,0 +1,96 @@
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/knqyf263/pet/config"
+)
+
+// lockFileName is the lock of the snippet files in the data directory,
+// held while one of them is written
+const lockFileName = "snippet.lock"
+
+// WriteFile replaces the snippet file with data. The other pets wait for
+// the file while it is written, and data is written to a temporary file
+// renamed over the snippet file, so that the file is never half written.
+// An encrypted file stays encrypted, and a new file is encrypted if
+// [Encryption] is set.
+func WriteFile(file string, data []byte) error {
+	encrypted := Encrypted(file)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		encrypted = encryptionEnabled()
+	}
+	return writeFile(file, data, encrypted)
+}
+
+// RestoreFile replaces the snippet file with data as it is, e.g. encrypted,
+// such as the content of a backup
+func RestoreFile(file string, data []byte) error {
+	return writeFile(file, data, false)
+}
+
+func writeFile(file string, data []byte, encrypted bool) error {
+	// a symlink to the snippet file is kept
+	if target, err := filepath.EvalSymlinks(file); err == nil {
+		file = target
+	}
+	if encrypted {
+		var err error
+		if data, err = encrypt(data); err != nil {
+			return fmt.Errorf("Failed to encrypt snippet file: %v", err)
+		}
+	}
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("Failed to lock snippet file: %v", err)
+	}
+	defer unlock()
+
+	mode := os.FileMode(0o600)
+	if fi, err := os.Stat(file); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// lockSnippets waits for the lock of the snippet files and returns its
+// release
+func lockSnippets() (func(), error) {
+	file, err := config.GetDataFile(lockFileName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,56 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, "snippet.toml")
+	if err := os.WriteFile(file, []byte("old"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.toml")
+	if err := os.Symlink(file, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// the writes of many pets at once are never mixed
+	contents := []string{strings.Repeat("a", 1<<20), strings.Repeat("b", 1<<20), strings.Repeat("c", 1<<20)}
+	var wg gosync.WaitGroup
+	for _, c := range contents {
+		wg.Add(1)
+		go func(c string) {
+			defer wg.Done()
+			if err := WriteFile(link, []byte(c)); err != nil {
+				t.Error(err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != contents[0] && got != contents[1] && got != contents[2] {
+		t.Errorf("the snippet file is mixed: %q...", got[:10])
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("the symlink is replaced: %v", err)
+	}
+	if fi, _ := os.Stat(file); fi.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640", fi.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".snippet.toml.") {
+			t.Errorf("the temporary file %s is left", e.Name())
+		}
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/google/go-github/github"
 	"github.com/knqyf263/pet/config"
 	"github.com/pkg/errors"
@@ -21,6 +19,10 @@ const (
 type GistClient struct {
 	Client *github.Client
 	ID     string
+	// FileName and Public are those of the config when the client was made,
+	// which may be a remote of many synced at once
+	FileName string
+	Public   bool
 }
 
 // NewGistClient returns GistClient
@@ -29,13 +31,15 @@ func NewGistClient() (Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf(`access_token is empty.
 Go https://github.com/settings/tokens/new and create access_token (only need "gist" scope).
-Write access_token in config file (pet configure) or export $%v.
+Write access_token in config file (pet configure), export $%v or store it in the keychain (pet configure --store-token).
 		`, githubTokenEnvVariable)
 	}
 
 	client := GistClient{
-		Client: githubClient(accessToken),
-		ID:     config.Conf.Gist.GistID,
+		Client:   githubClient(accessToken),
+		ID:       config.Conf.Gist.GistID,
+		FileName: config.Conf.Gist.FileName,
+		Public:   config.Conf.Gist.Public,
 	}
 	return client, nil
 }
@@ -45,15 +49,15 @@ func getGithubAccessToken() (string, error) {
 		return config.Conf.Gist.AccessToken, nil
 	} else if os.Getenv(githubTokenEnvVariable) != "" {
 		return os.Getenv(githubTokenEnvVariable), nil
+	} else if token := config.KeyringToken("gist"); token != "" {
+		return token, nil
 	}
 	return "", errors.New("Github AccessToken not found in any source")
 }
 
 // GetSnippet returns the remote snippet
 func (g GistClient) GetSnippet() (*Snippet, error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Getting Gist..."
+	s := startSpinner(" Getting Gist...")
 	defer s.Stop()
 
 	if g.ID == "" {
@@ -69,7 +73,7 @@ func (g GistClient) GetSnippet() (*Snippet, error) {
 	}
 
 	content := ""
-	filename := config.Conf.Gist.FileName
+	filename := g.FileName
 	for _, file := range gist.Files {
 		if *file.Filename == filename {
 			content = *file.Content
@@ -89,9 +93,9 @@ func (g GistClient) GetSnippet() (*Snippet, error) {
 func (g GistClient) UploadSnippet(content string) error {
 	gist := &github.Gist{
 		Description: github.String("description"),
-		Public:      github.Bool(config.Conf.Gist.Public),
+		Public:      github.Bool(g.Public),
 		Files: map[github.GistFilename]github.GistFile{
-			github.GistFilename(config.Conf.Gist.FileName): github.GistFile{
+			github.GistFilename(g.FileName): github.GistFile{
 				Content: github.String(content),
 			},
 		},
@@ -112,9 +116,7 @@ func (g GistClient) UploadSnippet(content string) error {
 }
 
 func (g GistClient) createGist(ctx context.Context, gist *github.Gist) (gistID *string, err error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Creating Gist..."
+	s := startSpinner(" Creating Gist...")
 	defer s.Stop()
 
 	retGist, _, err := g.Client.Gists.Create(ctx, gist)
@@ -125,9 +127,7 @@ func (g GistClient) createGist(ctx context.Context, gist *github.Gist) (gistID *
 }
 
 func (g GistClient) updateGist(ctx context.Context, gist *github.Gist) (err error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Updating Gist..."
+	s := startSpinner(" Updating Gist...")
 	defer s.Stop()
 
 	if _, _, err = g.Client.Gists.Edit(ctx, g.ID, gist); err != nil {
@@ -7,9 +7,7 @@ import (
 	"net/http"
 	"crypto/tls"
 	"strconv"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/knqyf263/pet/config"
 	"github.com/pkg/errors"
 	"github.com/xanzy/go-gitlab"
@@ -23,6 +21,10 @@ const (
 type GitLabClient struct {
 	Client *gitlab.Client
 	ID     int
+	// FileName and Visibility are those of the config when the client was
+	// made, which may be a remote of many synced at once
+	FileName   string
+	Visibility string
 }
 
 // NewGitLabClient returns GitLabClient
@@ -31,7 +33,7 @@ func NewGitLabClient() (Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf(`access_token is empty.
 Go https://gitlab.com/profile/personal_access_tokens and create access_token.
-Write access_token in config file (pet configure) or export $%v.
+Write access_token in config file (pet configure), export $%v or store it in the keychain (pet configure --store-token).
 		`, gitlabTokenEnvVariable)
 	}
 
@@ -59,8 +61,10 @@ Write access_token in config file (pet configure) or export $%v.
 
 	if config.Conf.GitLab.ID == "" {
 		client := GitLabClient{
-			Client: c,
-			ID:     id,
+			Client:     c,
+			ID:         id,
+			FileName:   config.Conf.GitLab.FileName,
+			Visibility: config.Conf.GitLab.Visibility,
 		}
 
 		return client, nil
@@ -72,8 +76,10 @@ Write access_token in config file (pet configure) or export $%v.
 	}
 
 	client := GitLabClient{
-		Client: c,
-		ID:     id,
+		Client:     c,
+		ID:         id,
+		FileName:   config.Conf.GitLab.FileName,
+		Visibility: config.Conf.GitLab.Visibility,
 	}
 
 	return client, nil
@@ -84,15 +90,15 @@ func getGitlabAccessToken() (string, error) {
 		return config.Conf.GitLab.AccessToken, nil
 	} else if os.Getenv(gitlabTokenEnvVariable) != "" {
 		return os.Getenv(gitlabTokenEnvVariable), nil
+	} else if token := config.KeyringToken("gitlab"); token != "" {
+		return token, nil
 	}
 	return "", errors.New("GitLab AccessToken not found in any source")
 }
 
 // GetSnippet returns the remote snippet
 func (g GitLabClient) GetSnippet() (*Snippet, error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Getting GitLab Snippet..."
+	s := startSpinner(" Getting GitLab Snippet...")
 	defer s.Stop()
 
 	if g.ID == 0 {
@@ -107,7 +113,7 @@ func (g GitLabClient) GetSnippet() (*Snippet, error) {
 		return nil, errors.Wrapf(err, "Failed to get GitLab Snippet (ID: %d)", g.ID)
 	}
 
-	filename := config.Conf.GitLab.FileName
+	filename := g.FileName
 	if snippet.FileName != filename {
 		return nil, fmt.Errorf("No snippet file in GitLab Snippet (ID: %d)", g.ID)
 	}
@@ -122,9 +128,22 @@ func (g GitLabClient) GetSnippet() (*Snippet, error) {
 		return nil, fmt.Errorf("%s is empty", filename)
 	}
 
+	meta, ok := ParseMetadata(snippet.Description)
+	if ok {
+		unchanged, err := meta.Check(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GitLab Snippet (ID: %d)", g.ID)
+		}
+		if !unchanged {
+			fmt.Fprintf(os.Stderr, "GitLab Snippet (ID: %d) was changed outside pet, its metadata is out of date\n", g.ID)
+			meta = nil
+		}
+	}
+
 	return &Snippet{
 		Content:   content,
 		UpdatedAt: *snippet.UpdatedAt,
+		Metadata:  meta,
 	}, nil
 }
 
@@ -145,17 +164,15 @@ func (g GitLabClient) UploadSnippet(content string) error {
 }
 
 func (g GitLabClient) createSnippet(ctx context.Context, content string) (id int, err error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Creating GitLab Snippet..."
+	s := startSpinner(" Creating GitLab Snippet...")
 	defer s.Stop()
 
 	opt := &gitlab.CreateSnippetOptions{
 		Title:       gitlab.String("pet-snippet"),
-		FileName:    gitlab.String(config.Conf.GitLab.FileName),
-		Description: gitlab.String("Snippet file generated by pet"),
+		FileName:    gitlab.String(g.FileName),
+		Description: gitlab.String(NewMetadata(content).Description()),
 		Content:     gitlab.String(content),
-		Visibility:  gitlab.Visibility(gitlab.VisibilityValue(config.Conf.GitLab.Visibility)),
+		Visibility:  gitlab.Visibility(gitlab.VisibilityValue(g.Visibility)),
 	}
 
 	ret, _, err := g.Client.Snippets.CreateSnippet(opt)
@@ -166,17 +183,15 @@ func (g GitLabClient) createSnippet(ctx context.Context, content string) (id int
 }
 
 func (g GitLabClient) updateSnippet(ctx context.Context, content string) (err error) {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Start()
-	s.Suffix = " Updating GitLab Snippet..."
+	s := startSpinner(" Updating GitLab Snippet...")
 	defer s.Stop()
 
 	opt := &gitlab.UpdateSnippetOptions{
 		Title:       gitlab.String("pet-snippet"),
-		FileName:    gitlab.String(config.Conf.GitLab.FileName),
-		Description: gitlab.String("Snippet file generated by pet"),
+		FileName:    gitlab.String(g.FileName),
+		Description: gitlab.String(NewMetadata(content).Description()),
 		Content:     gitlab.String(content),
-		Visibility:  gitlab.Visibility(gitlab.VisibilityValue(config.Conf.GitLab.Visibility)),
+		Visibility:  gitlab.Visibility(gitlab.VisibilityValue(g.Visibility)),
 	}
 
 	_, _, err = g.Client.Snippets.UpdateSnippet(g.ID, opt)
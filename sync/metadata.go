@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/knqyf263/pet/snippet"
+)
+
+// metadataSchema is the version of the format of the snippet file pet
+// uploads. A pet refuses to download a file of a newer schema.
+const metadataSchema = 1
+
+// metadataTitle is the first line of the description of an uploaded file
+const metadataTitle = "Snippet file generated by pet"
+
+// Metadata is what pet records about the snippet file it uploads, in the
+// description of the GitLab Snippet
+type Metadata struct {
+	// Schema is the version of the format of the file
+	Schema int
+	// Checksum is the sha256 of the content
+	Checksum string
+	// Tags are the tags of the snippets of the file, sorted
+	Tags []string
+}
+
+// NewMetadata returns the metadata of the content of a snippet file
+func NewMetadata(content string) Metadata {
+	m := Metadata{Schema: metadataSchema, Checksum: checksum(content)}
+	var snippets snippet.Snippets
+	if _, err := toml.Decode(content, &snippets); err != nil {
+		return m
+	}
+	seen := map[string]bool{}
+	for _, s := range snippets.Snippets {
+		for _, t := range s.Tag {
+			if !seen[t] {
+				seen[t] = true
+				m.Tags = append(m.Tags, t)
+			}
+		}
+	}
+	sort.Strings(m.Tags)
+	return m
+}
+
+// Description returns the metadata as the description of a remote snippet,
+// under a line for the people looking at it
+func (m Metadata) Description() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\npet-schema: %d\npet-sha256: %s\n", metadataTitle, m.Schema, m.Checksum)
+	if len(m.Tags) > 0 {
+		fmt.Fprintf(&b, "pet-tags: %s\n", strings.Join(m.Tags, ", "))
+	}
+	return b.String()
+}
+
+// ParseMetadata returns the metadata in the description of a remote
+// snippet, and false if it has none, e.g. it was uploaded by an older pet
+func ParseMetadata(description string) (*Metadata, bool) {
+	var m Metadata
+	found := false
+	for _, line := range strings.Split(description, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.HasPrefix(key, "pet-") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "pet-schema":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			m.Schema = n
+			found = true
+		case "pet-sha256":
+			m.Checksum = value
+		case "pet-tags":
+			for _, t := range strings.Split(value, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					m.Tags = append(m.Tags, t)
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return &m, true
+}
+
+// Check returns an error if pet cannot read the content of the metadata,
+// written by a newer pet, and whether the content is the one pet uploaded:
+// false if it was edited elsewhere, e.g. on the web
+func (m Metadata) Check(content string) (bool, error) {
+	if m.Schema > metadataSchema {
+		return false, fmt.Errorf("the snippet file has the schema %d of a newer pet (this one reads %d), update pet", m.Schema, metadataSchema)
+	}
+	return m.Checksum == "" || m.Checksum == checksum(content), nil
+}
+
+// checksum returns the sha256 of the content in hex
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// contentChecksum returns the checksum of the synced snippets of a snippet
+// file, as pet writes them whatever the formatting of the file
+func contentChecksum(content string) string {
+	var snippets snippet.Snippets
+	if _, err := toml.Decode(content, &snippets); err != nil {
+		return checksum(content)
+	}
+	var synced []snippet.SnippetInfo
+	for _, s := range snippets.Snippets {
+		if !s.LocalOnly() {
+			synced = append(synced, s)
+		}
+	}
+	snippets.Snippets = synced
+	snippets.Order()
+	body, err := snippets.ToString()
+	if err != nil {
+		return checksum(content)
+	}
+	return checksum(body)
+}
+
+// upToDate reports whether the metadata of the remote snippet has the
+// checksum of the local snippets, which need neither an upload nor a
+// download whatever the times of the files
+func upToDate(s *Snippet) bool {
+	if s.Metadata == nil || s.Metadata.Checksum == "" {
+		return false
+	}
+	body, err := localContent()
+	return err == nil && checksum(body) == s.Metadata.Checksum
+}
@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/knqyf263/pet/config"
+)
+
+const metadataContent = `[[snippets]]
+  description = "status"
+  command = "git status"
+  tag = ["git"]
+
+[[snippets]]
+  description = "ps"
+  command = "docker ps"
+  tag = ["docker", "git"]
+`
+
+func TestMetadata(t *testing.T) {
+	m := NewMetadata(metadataContent)
+	if diff := deep.Equal([]string{"docker", "git"}, m.Tags); diff != nil {
+		t.Error(diff)
+	}
+
+	parsed, ok := ParseMetadata(m.Description())
+	if !ok {
+		t.Fatalf("no metadata in %q", m.Description())
+	}
+	if diff := deep.Equal(m, *parsed); diff != nil {
+		t.Error(diff)
+	}
+	if _, ok := ParseMetadata(metadataTitle); ok {
+		t.Error("metadata in the description of an older pet")
+	}
+
+	if unchanged, err := m.Check(metadataContent); !unchanged || err != nil {
+		t.Errorf("Check() = %v, %v for the uploaded content", unchanged, err)
+	}
+	if unchanged, err := m.Check(metadataContent + "\n"); unchanged || err != nil {
+		t.Errorf("Check() = %v, %v for an edited content", unchanged, err)
+	}
+	m.Schema = metadataSchema + 1
+	if _, err := m.Check(metadataContent); err == nil {
+		t.Error("no error for a newer schema")
+	}
+}
+
+func TestUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(metadataContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	body, err := localContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMetadata(body)
+	if !upToDate(&Snippet{Content: body, Metadata: &m}) {
+		t.Error("the snippets differ from the remote ones of the same checksum")
+	}
+	m = NewMetadata("")
+	if upToDate(&Snippet{Content: "", Metadata: &m}) {
+		t.Error("the snippets are the remote ones of another checksum")
+	}
+	if upToDate(&Snippet{Content: body}) {
+		t.Error("up-to-date without metadata")
+	}
+}
@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/pkg/errors"
+)
+
+// PluginProtocol is the version of the protocol of the sync plugins
+const PluginProtocol = 1
+
+// PluginRequest is the JSON pet writes to the stdin of a sync plugin, run as
+// "pet-sync-NAME get" or "pet-sync-NAME put"
+type PluginRequest struct {
+	Version int `json:"version"`
+	// Name is the name of the plugin in the config
+	Name     string            `json:"name"`
+	FileName string            `json:"file_name"`
+	Options  map[string]string `json:"options,omitempty"`
+	// Content is the snippet file to store, for put
+	Content string `json:"content,omitempty"`
+}
+
+// PluginResponse is the JSON a sync plugin writes to its stdout. put may
+// write nothing.
+type PluginResponse struct {
+	// Content is the stored snippet file, empty if there is none yet
+	Content   string    `json:"content"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Error fails the sync, like a non-zero exit status
+	Error string `json:"error,omitempty"`
+}
+
+// PluginClient manages communication with a sync plugin ([plugin.NAME])
+type PluginClient struct {
+	Name     string
+	Command  []string
+	FileName string
+	Options  map[string]string
+}
+
+// NewPluginClient returns the PluginClient of the plugin of the config
+func NewPluginClient(name string) (Client, error) {
+	p := config.Conf.Plugins[name]
+	command := p.Command
+	if len(command) == 0 {
+		command = []string{"pet-sync-" + name}
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("The sync plugin %s is not installed: %v", command[0], err)
+	}
+	fileName := p.FileName
+	if fileName == "" {
+		fileName = "pet-snippet.toml"
+	}
+	return PluginClient{Name: name, Command: command, FileName: fileName, Options: p.Options}, nil
+}
+
+// GetSnippet returns the remote snippet
+func (p PluginClient) GetSnippet() (*Snippet, error) {
+	res, err := p.call("get", "")
+	if err != nil {
+		return nil, err
+	}
+	return &Snippet{Content: res.Content, UpdatedAt: res.UpdatedAt}, nil
+}
+
+// UploadSnippet stores the snippet file with the plugin
+func (p PluginClient) UploadSnippet(content string) error {
+	_, err := p.call("put", content)
+	return err
+}
+
+func (p PluginClient) call(action, content string) (PluginResponse, error) {
+	req, err := json.Marshal(PluginRequest{
+		Version:  PluginProtocol,
+		Name:     p.Name,
+		FileName: p.FileName,
+		Options:  p.Options,
+		Content:  content,
+	})
+	if err != nil {
+		return PluginResponse{}, err
+	}
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.Command[0], append(append([]string{}, p.Command[1:]...), action)...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return PluginResponse{}, errors.Wrapf(err, "The sync plugin %s failed to %s", p.Name, action)
+	}
+
+	var res PluginResponse
+	if len(bytes.TrimSpace(stdout.Bytes())) > 0 {
+		if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+			return PluginResponse{}, errors.Wrapf(err, "The sync plugin %s returned invalid JSON", p.Name)
+		}
+	}
+	if res.Error != "" {
+		return PluginResponse{}, fmt.Errorf("The sync plugin %s failed to %s: %s", p.Name, action, res.Error)
+	}
+	return res, nil
+}
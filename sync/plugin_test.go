@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+)
+
+// TestPluginHelper is the sync plugin run by TestPluginClient: it keeps the
+// snippet file in $PLUGIN_STORE
+func TestPluginHelper(t *testing.T) {
+	store := os.Getenv("PLUGIN_STORE")
+	if store == "" {
+		return
+	}
+	var req PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil || req.Version != PluginProtocol {
+		fmt.Print(`{"error": "bad request"}`)
+		os.Exit(0)
+	}
+	file := filepath.Join(store, req.Options["prefix"]+req.FileName)
+	switch os.Args[len(os.Args)-1] {
+	case "get":
+		content, _ := os.ReadFile(file)
+		json.NewEncoder(os.Stdout).Encode(PluginResponse{Content: string(content), UpdatedAt: time.Unix(1700000000, 0)})
+	case "put":
+		if err := os.WriteFile(file, []byte(req.Content), 0o600); err != nil {
+			os.Exit(2)
+		}
+	default:
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func TestPluginClient(t *testing.T) {
+	store := t.TempDir()
+	t.Setenv("PLUGIN_STORE", store)
+	defer func(p map[string]config.PluginConfig) { config.Conf.Plugins = p }(config.Conf.Plugins)
+	defer func(b string) { config.Conf.General.Backend = b }(config.Conf.General.Backend)
+
+	config.Conf.General.Backend = "helper"
+	config.Conf.Plugins = map[string]config.PluginConfig{"helper": {
+		Command: []string{os.Args[0], "-test.run=TestPluginHelper", "--"},
+		Options: map[string]string{"prefix": "team-"},
+	}}
+	client, err := NewSyncClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadSnippet("[[snippets]]\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(store, "team-pet-snippet.toml")); err != nil {
+		t.Fatalf("the plugin did not get the options: %v", err)
+	}
+	snippet, err := client.GetSnippet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Content != "[[snippets]]\n" || snippet.UpdatedAt.Unix() != 1700000000 {
+		t.Errorf("GetSnippet() = %+v", snippet)
+	}
+
+	config.Conf.Plugins["helper"] = config.PluginConfig{Command: []string{"pet-sync-missing-plugin"}}
+	if _, err := NewSyncClient(); err == nil {
+		t.Error("NewSyncClient() of a missing plugin succeeded")
+	}
+}
@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/pkg/errors"
+)
+
+// remote is one of the remotes of SyncRemotes
+type remote struct {
+	name string
+	// key is the remote in the last syncs, see remoteKey
+	key     string
+	client  Client
+	snippet *Snippet
+	// status is "upload", "download" or "" when it is up-to-date
+	status string
+	// checksum is that of the snippets on the remote after the sync
+	checksum string
+	// conflicts are the conflicts written to the snippet file, with the
+	// status "conflict"
+	conflicts int
+	err       error
+}
+
+// SyncRemotes syncs the snippet file with the named remotes ([[remote]]).
+// The remotes are fetched at once; then the newer ones are downloaded one
+// after the other, and the file is uploaded to the older ones at once. The
+// status of each remote is printed, and the error lists the remotes which
+// failed.
+func SyncRemotes(file string, names []string) error {
+	remotes := make([]*remote, len(names))
+	for i, name := range names {
+		r := &remote{name: name}
+		remotes[i] = r
+		// the clients keep the config of their remote
+		if r.err = config.Conf.UseRemote(name); r.err != nil {
+			continue
+		}
+		r.key = remoteKey()
+		if r.client, r.err = NewSyncClient(); r.err != nil {
+			r.err = errors.Wrap(r.err, "Failed to initialize API client")
+		}
+	}
+
+	quiet = true
+	defer func() { quiet = false }()
+	parallel(remotes, func(r *remote) {
+		r.snippet, r.err = r.client.GetSnippet()
+	})
+
+	// a download changes the file the other remotes are compared with
+	synced := lastSync()
+	var uploads []*remote
+	for _, r := range remotes {
+		if r.err != nil {
+			continue
+		}
+		var local time.Time
+		fi, err := os.Stat(file)
+		if err != nil && !os.IsNotExist(err) {
+			r.err = errors.Wrap(err, "Failed to get a FileInfo")
+			continue
+		}
+		if err == nil && fi.Size() > 0 {
+			local = fi.ModTime().UTC()
+		}
+		body, err := localContent()
+		if err != nil {
+			r.err = err
+			continue
+		}
+		last, ok := synced[r.key]
+		direction, conflict := syncDirection(local, body, r.snippet, last, ok)
+		if conflict && config.Conf.General.SyncConflict == "markers" {
+			// the other remotes fail until the conflicts are resolved
+			if r.conflicts, r.err = writeConflicts(file, r.snippet.Content); r.err == nil {
+				r.status = "conflict"
+				r.checksum = contentChecksum(r.snippet.Content)
+			}
+			continue
+		}
+		if conflict {
+			fireConflict(direction, file, r.snippet.Content)
+		}
+
+		r.checksum = contentChecksum(body)
+		switch direction {
+		case "upload":
+			uploads = append(uploads, r)
+		case "download":
+			written, err := writeContent(r.snippet.Content)
+			if err != nil {
+				r.err = err
+			} else if written {
+				r.status = "download"
+			}
+			r.checksum = contentChecksum(r.snippet.Content)
+		}
+	}
+
+	if len(uploads) > 0 {
+		body, err := localContent()
+		parallel(uploads, func(r *remote) {
+			if err != nil {
+				r.err = err
+			} else if r.err = r.client.UploadSnippet(body); r.err != nil {
+				r.err = errors.Wrap(r.err, "Failed to upload snippet")
+			} else {
+				r.checksum = contentChecksum(body)
+				r.status = "upload"
+			}
+		})
+	}
+
+	var failed []string
+	for _, r := range remotes {
+		switch {
+		case r.err != nil:
+			fmt.Printf("%s: %v\n", r.name, r.err)
+			failed = append(failed, r.name)
+			continue
+		case r.status == "conflict":
+			fmt.Printf("%s: %v\n", r.name, conflictError(r.conflicts, file))
+			failed = append(failed, r.name)
+		case r.status == "upload":
+			fmt.Printf("%s: Upload success\n", r.name)
+		case r.status == "download":
+			fmt.Printf("%s: Download success\n", r.name)
+		default:
+			fmt.Printf("%s: Already up-to-date\n", r.name)
+		}
+		if r.status == "upload" || r.status == "download" {
+			snippet.Fire(snippet.Event{Name: snippet.EventSync, Detail: r.status})
+		}
+		if err := recordSync(r.key, time.Now(), r.checksum); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to sync with %d of %d remotes: %s", len(failed), len(remotes), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// parallel runs fn for the remotes without an error at once
+func parallel(remotes []*remote, fn func(*remote)) {
+	var wg gosync.WaitGroup
+	for _, r := range remotes {
+		if r.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(r *remote) {
+			defer wg.Done()
+			fn(r)
+		}(r)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/pet/config"
+)
+
+func TestSyncRemotes(t *testing.T) {
+	dir := t.TempDir()
+	store := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	t.Setenv("PLUGIN_STORE", store)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+
+	helper := []string{os.Args[0], "-test.run=TestPluginHelper", "--"}
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.Plugins = map[string]config.PluginConfig{
+		"a": {Command: helper, Options: map[string]string{"prefix": "a-"}},
+		"b": {Command: helper, Options: map[string]string{"prefix": "b-"}},
+	}
+	config.Conf.Remotes = []config.RemoteConfig{{Name: "first", Backend: "a"}, {Name: "second", Backend: "b"}}
+	content := "[[snippets]]\n  description = \"greet\"\n  command = \"echo hello\"\n  tag = []\n  output = \"\"\n"
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snippet file is newer than the remotes of the plugin
+	err := SyncRemotes(config.Conf.General.SnippetFile, []string{"first", "unknown", "second"})
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 remotes: unknown") {
+		t.Errorf("SyncRemotes() error = %v, want the unknown remote", err)
+	}
+	for _, name := range []string{"a-pet-snippet.toml", "b-pet-snippet.toml"} {
+		uploaded, err := os.ReadFile(filepath.Join(store, name))
+		if err != nil {
+			t.Fatalf("%s is not uploaded: %v", name, err)
+		}
+		if !strings.Contains(string(uploaded), "echo hello") {
+			t.Errorf("%s = %q", name, uploaded)
+		}
+	}
+	synced := lastSync()
+	if _, ok := synced["a"]; !ok {
+		t.Errorf("the sync of the remotes is not recorded: %v", synced)
+	}
+}
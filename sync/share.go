@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/google/go-github/github"
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
+	"github.com/knqyf263/pet/snippet"
+	"github.com/pkg/errors"
+	"github.com/xanzy/go-gitlab"
+)
+
+// Sharer publishes snippets as a standalone remote snippet
+type Sharer interface {
+	Share(title, content, visibility string) (string, error)
+}
+
+// Share uploads the content as a new gist or GitLab snippet with the
+// visibility (see Visibility), and returns its URL
+func Share(title, content, visibility string) (string, error) {
+	client, err := NewSyncClient()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to initialize API client")
+	}
+	sharer, ok := client.(Sharer)
+	if !ok {
+		return "", fmt.Errorf("%s backend does not support sharing", config.Conf.General.Backend)
+	}
+	return sharer.Share(title, content, visibility)
+}
+
+// Visibility returns the visibility of the snippets shared together, the
+// most private of theirs. Those without one have that of the backend:
+// public (Gist) or visibility (GitLab) of the config.
+func Visibility(snippets []snippet.SnippetInfo) string {
+	backend := config.Conf.GitLab.Visibility
+	if config.Conf.General.Backend != "gitlab" {
+		backend = "private"
+		if config.Conf.Gist.Public {
+			backend = "public"
+		}
+	}
+	visibility := ""
+	for _, s := range snippets {
+		v := s.Visibility
+		if snippet.VisibilityRank(v) < 0 {
+			v = backend
+		}
+		if visibility == "" || snippet.VisibilityRank(v) < snippet.VisibilityRank(visibility) {
+			visibility = v
+		}
+	}
+	if visibility == "" {
+		return backend
+	}
+	return visibility
+}
+
+// Share creates a new gist with the content, secret unless the visibility is
+// public: gists have no internal visibility
+func (g GistClient) Share(title, content, visibility string) (string, error) {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Start()
+	s.Suffix = i18n.T(" Creating Gist...")
+	defer s.Stop()
+
+	gist := &github.Gist{
+		Description: github.String(title),
+		Public:      github.Bool(visibility == "public"),
+		Files: map[github.GistFilename]github.GistFile{
+			github.GistFilename(config.Conf.Gist.FileName): github.GistFile{
+				Content: github.String(content),
+			},
+		},
+	}
+	ret, _, err := g.Client.Gists.Create(context.Background(), gist)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create gist")
+	}
+	return ret.GetHTMLURL(), nil
+}
+
+// Share creates a new GitLab Snippet with the content
+func (g GitLabClient) Share(title, content, visibility string) (string, error) {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Start()
+	s.Suffix = i18n.T(" Creating GitLab Snippet...")
+	defer s.Stop()
+
+	opt := &gitlab.CreateSnippetOptions{
+		Title:       gitlab.String(title),
+		FileName:    gitlab.String(config.Conf.GitLab.FileName),
+		Description: gitlab.String("Snippet shared by pet"),
+		Content:     gitlab.String(content),
+		Visibility:  gitlab.Visibility(gitlab.VisibilityValue(visibility)),
+	}
+	ret, _, err := g.Client.Snippets.CreateSnippet(opt)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create GitLab Snippet")
+	}
+	return ret.WebURL, nil
+}
+
+// FetchShared returns the content of a shared snippet. Gist and GitLab
+// Snippet URLs are resolved to their raw content, other URLs are fetched as is.
+func FetchShared(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "Invalid URL")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case u.Host == "gist.github.com":
+		return fetchGist(strings.Trim(u.Path, "/"))
+	case strings.Contains(u.Path, "/snippets/") && !strings.Contains(u.Path, "/raw"):
+		req.URL.Path = strings.TrimSuffix(u.Path, "/") + "/raw"
+		if token, err := getGitlabAccessToken(); err == nil && gitlabHost() == u.Host {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to fetch the snippet")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to fetch the snippet: %s", res.Status)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to read the snippet")
+	}
+	return string(body), nil
+}
+
+// fetchGist returns the TOML file of a gist given as "user/id" or "id"
+func fetchGist(path string) (string, error) {
+	id := path[strings.LastIndex(path, "/")+1:]
+	client := github.NewClient(nil)
+	if token, err := getGithubAccessToken(); err == nil {
+		client = githubClient(token)
+	}
+	gist, _, err := client.Gists.Get(context.Background(), id)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to get gist")
+	}
+	for _, file := range gist.Files {
+		if strings.HasSuffix(file.GetFilename(), ".toml") {
+			return file.GetContent(), nil
+		}
+	}
+	return "", fmt.Errorf("No TOML file in gist (%s)", id)
+}
+
+func gitlabHost() string {
+	u, err := url.Parse(config.Conf.GitLab.Url)
+	if err != nil || config.Conf.GitLab.Url == "" {
+		return "gitlab.com"
+	}
+	return u.Host
+}
@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestVisibility(t *testing.T) {
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.Backend = "gitlab"
+	config.Conf.GitLab.Visibility = "internal"
+
+	public := snippet.SnippetInfo{Visibility: "public"}
+	private := snippet.SnippetInfo{Visibility: "private"}
+	tests := []struct {
+		snippets []snippet.SnippetInfo
+		want     string
+	}{
+		{[]snippet.SnippetInfo{public}, "public"},
+		{[]snippet.SnippetInfo{public, private}, "private"},
+		{[]snippet.SnippetInfo{public, {}}, "internal"},
+		{[]snippet.SnippetInfo{{}}, "internal"},
+	}
+	for _, tt := range tests {
+		if got := Visibility(tt.snippets); got != tt.want {
+			t.Errorf("Visibility(%v) = %s, want %s", tt.snippets, got, tt.want)
+		}
+	}
+
+	config.Conf.General.Backend = "gist"
+	config.Conf.Gist.Public = false
+	if got := Visibility([]snippet.SnippetInfo{{}}); got != "private" {
+		t.Errorf("Visibility() = %s for a secret gist", got)
+	}
+}
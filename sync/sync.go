@@ -1,11 +1,16 @@
 package sync
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/briandowns/spinner"
 	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/i18n"
 	"github.com/knqyf263/pet/snippet"
 	"github.com/pkg/errors"
 )
@@ -20,6 +25,9 @@ type Client interface {
 type Snippet struct {
 	Content   string
 	UpdatedAt time.Time
+	// Metadata is what pet recorded about the content when it uploaded it,
+	// nil if the backend keeps none or the content was changed elsewhere
+	Metadata *Metadata
 }
 
 // AutoSync syncs snippets automatically
@@ -40,22 +48,241 @@ func AutoSync(file string) error {
 	} else if err != nil {
 		return errors.Wrap(err, "Failed to get a FileInfo")
 	}
+	body, err := localContent()
+	if err != nil {
+		return err
+	}
 
-	local := fi.ModTime().UTC()
-	remote := snippet.UpdatedAt.UTC()
+	last, synced := lastSync()[remoteKey()]
+	direction, conflict := syncDirection(fi.ModTime(), body, snippet, last, synced)
+	if conflict && config.Conf.General.SyncConflict == "markers" {
+		n, err := writeConflicts(file, snippet.Content)
+		if err != nil {
+			return err
+		}
+		// the resolved snippets are uploaded by the next sync
+		if err := recordSync(remoteKey(), time.Now(), contentChecksum(snippet.Content)); err != nil {
+			return err
+		}
+		return conflictError(n, file)
+	}
+	if conflict {
+		fireConflict(direction, file, snippet.Content)
+	}
+	switch direction {
+	case "upload":
+		err = upload(client)
+	case "download":
+		err = download(snippet.Content)
+		body = snippet.Content
+	default:
+		fmt.Println("Already up-to-date")
+	}
+	if err != nil {
+		return err
+	}
+	return recordSync(remoteKey(), time.Now(), contentChecksum(body))
+}
 
+// syncDirection returns whether the local snippets (body, of the file
+// modified at local, zero if there is none) are uploaded, the remote ones
+// downloaded, or "" if they are up-to-date, and whether both changed since
+// the last sync. The checksums of the snippets of the last sync tell which
+// side changed; without them, or when both did, the newer side wins.
+func syncDirection(local time.Time, body string, s *Snippet, last syncRecord, synced bool) (string, bool) {
+	if upToDate(s) {
+		return "", false
+	}
+	conflict := false
+	if synced && last.Checksum != "" && !local.IsZero() {
+		localSum, remoteSum := contentChecksum(body), contentChecksum(s.Content)
+		switch {
+		case localSum == remoteSum:
+			return "", false
+		case remoteSum == last.Checksum:
+			return "upload", false
+		case localSum == last.Checksum:
+			return "download", false
+		}
+		conflict = true
+	}
+
+	local, remote := local.UTC(), s.UpdatedAt.UTC()
+	if synced && local.After(last.Time.Add(syncSlack)) && remote.After(last.Time.Add(syncSlack)) {
+		conflict = true
+	}
 	switch {
 	case local.After(remote):
-		return upload(client)
-	case remote.After(local):
-		return download(snippet.Content)
-	default:
+		return "upload", conflict
+	case remote.After(local) || local.IsZero():
+		return "download", conflict
+	}
+	return "", false
+}
+
+// syncSlack is the difference of the clocks of pet and of the backend
+// which is still the time of the last sync
+const syncSlack = time.Minute
+
+// lastSyncFileName records when each remote was last synced
+const lastSyncFileName = "last_sync.json"
+
+// syncRecord is the last sync with a remote
+type syncRecord struct {
+	Time time.Time `json:"time"`
+	// Checksum is that of the snippets on both sides after the sync (see
+	// contentChecksum), "" if it was recorded by an older pet
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// UnmarshalJSON reads the record, or the time alone of an older pet
+func (r *syncRecord) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Time); err == nil {
 		return nil
 	}
+	type record syncRecord
+	return json.Unmarshal(data, (*record)(r))
+}
+
+// remoteKey returns the remote snippet of the config in the last syncs
+func remoteKey() string {
+	backend := config.Conf.General.Backend
+	switch {
+	case backend == "gitlab":
+		return "gitlab:" + config.Conf.GitLab.Url + ":" + config.Conf.GitLab.ID
+	case backend != "" && backend != "gist":
+		return backend
+	}
+	return "gist:" + config.Conf.Gist.GistID
+}
+
+// lastSync returns the last syncs of the remotes
+func lastSync() map[string]syncRecord {
+	synced := map[string]syncRecord{}
+	file, err := config.GetDataFile(lastSyncFileName)
+	if err != nil {
+		return synced
+	}
+	if data, err := os.ReadFile(file); err == nil {
+		json.Unmarshal(data, &synced)
+	}
+	return synced
+}
+
+// recordSync records the time of the sync with the remote (see remoteKey)
+// and the checksum of the snippets it left on both sides
+func recordSync(key string, now time.Time, checksum string) error {
+	file, err := config.GetDataFile(lastSyncFileName)
+	if err != nil {
+		return err
+	}
+	synced := lastSync()
+	synced[key] = syncRecord{Time: now.UTC(), Checksum: checksum}
+	data, err := json.MarshalIndent(synced, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o600)
+}
+
+// fireConflict fires the conflict event with the snippets the sync in the
+// direction overwrites: the remote ones for an upload, the local ones for a
+// download
+func fireConflict(direction, file, content string) {
+	var local, remote snippet.Snippets
+	if err := local.LoadFile(file); err != nil {
+		return
+	}
+	if _, err := toml.Decode(content, &remote); err != nil {
+		return
+	}
+	from, to := local.Snippets, remote.Snippets
+	if direction == "download" {
+		from, to = to, from
+	}
+	var lost []snippet.SnippetInfo
+	for _, e := range snippet.Changes(from, to) {
+		if e.Name != snippet.EventDelete {
+			lost = append(lost, e.Snippets...)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Conflict: the snippets changed on both sides since the last sync, the %s overwrites the %s changes\n",
+		direction, map[string]string{"upload": "remote", "download": "local"}[direction])
+	snippet.Fire(snippet.Event{Name: snippet.EventConflict, Snippets: lost, Detail: direction})
+}
+
+// writeConflicts writes the local snippets and the remote ones (content) to
+// the snippet file, the conflicting ones between conflict markers (see
+// snippet.WithConflictMarkers), and returns the number of conflicts. The
+// local only snippets are kept as they are.
+func writeConflicts(file, content string) (int, error) {
+	synced, local, err := localSnippets()
+	if err != nil {
+		return 0, err
+	}
+	var remote snippet.Snippets
+	if _, err := toml.Decode(content, &remote); err != nil {
+		return 0, errors.Wrap(err, "Failed to parse the remote snippets")
+	}
+	body, n, err := snippet.WithConflictMarkers(synced, remote.Snippets)
+	if err != nil {
+		return 0, err
+	}
+	if len(local) > 0 {
+		rest, err := (&snippet.Snippets{Snippets: local}).ToString()
+		if err != nil {
+			return 0, err
+		}
+		body += rest
+	}
+	if err := snippet.WriteFile(file, []byte(body)); err != nil {
+		return 0, err
+	}
+	snippet.Fire(snippet.Event{Name: snippet.EventConflict, Detail: "markers"})
+	return n, nil
+}
+
+// mergeDownload merges the duplicates of the downloaded snippets: those
+// with the same normalized command as another downloaded one, and the local
+// snippets of a description the remote lacks with the same command as a
+// remote one, e.g. the same command saved on two machines, whose tags would
+// be lost otherwise. It returns the number of snippets merged.
+func mergeDownload(remote *snippet.Snippets, local []snippet.SnippetInfo) int {
+	snippets := remote.Snippets
+	for _, s := range local {
+		if _, ok := remote.Find(s.Description); !ok && remote.FindByCommand(s.Command) >= 0 {
+			snippets = append(snippets, s)
+		}
+	}
+	var n int
+	remote.Snippets, n = snippet.MergeDuplicates(snippets)
+	return n
+}
+
+// conflictError tells to resolve the conflicts written by writeConflicts
+func conflictError(n int, file string) error {
+	return fmt.Errorf("%d conflicting snippets written to %s between conflict markers: resolve them, check with pet lint and run pet sync again", n, file)
+}
+
+// quiet hides the spinners, while the remotes are synced in parallel
+var quiet bool
+
+// startSpinner shows the spinner of a request to the backend
+func startSpinner(suffix string) *spinner.Spinner {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = i18n.T(suffix)
+	if quiet {
+		s.Writer = io.Discard
+	}
+	s.Start()
+	return s
 }
 
 // NewSyncClient returns Client
 func NewSyncClient() (Client, error) {
+	if _, ok := config.Conf.Plugins[config.Conf.General.Backend]; ok {
+		return NewPluginClient(config.Conf.General.Backend)
+	}
 	if config.Conf.General.Backend == "gitlab" {
 		client, err := NewGitLabClient()
 		if err != nil {
@@ -71,41 +298,95 @@ func NewSyncClient() (Client, error) {
 }
 
 func upload(client Client) (err error) {
-	var snippets snippet.Snippets
-	if err := snippets.Load(); err != nil {
-		return errors.Wrap(err, "Failed to load the local snippets")
-	}
-
-	body, err := snippets.ToString()
+	body, err := localContent()
 	if err != nil {
 		return err
 	}
-
 	if err = client.UploadSnippet(body); err != nil {
 		return errors.Wrap(err, "Failed to upload snippet")
 	}
 
 	fmt.Println("Upload success")
+	snippet.Fire(snippet.Event{Name: snippet.EventSync, Detail: "upload"})
 	return nil
 }
 
-func download(content string) error {
-	snippetFile := config.Conf.General.SnippetFile
+// localContent returns the snippet file as it is uploaded, without the
+// snippets having sync_exclude_tags
+func localContent() (string, error) {
+	snippets, _, err := localSnippets()
+	if err != nil {
+		return "", err
+	}
+	snippets.Order()
+	return snippets.ToString()
+}
 
-	var snippets snippet.Snippets
-	if err := snippets.Load(); err != nil {
-		return err
+// localSnippets returns the snippets of the snippet file which are synced
+// and those which are local only
+func localSnippets() (synced snippet.Snippets, local []snippet.SnippetInfo, err error) {
+	// only the snippet file is synced, not the files in snippetdir
+	if err := synced.LoadFile(config.Conf.General.SnippetFile); err != nil {
+		return synced, nil, errors.Wrap(err, "Failed to load the local snippets")
 	}
-	body, err := snippets.ToString()
+	var snippets []snippet.SnippetInfo
+	for _, s := range synced.Snippets {
+		if s.LocalOnly() {
+			local = append(local, s)
+		} else {
+			snippets = append(snippets, s)
+		}
+	}
+	synced.Snippets = snippets
+	return synced, local, nil
+}
+
+func download(content string) error {
+	written, err := writeContent(content)
 	if err != nil {
 		return err
 	}
-	if content == body {
-		// no need to download
+	if !written {
 		fmt.Println("Already up-to-date")
 		return nil
 	}
-
 	fmt.Println("Download success")
-	return os.WriteFile(snippetFile, []byte(content), os.ModePerm)
+	snippet.Fire(snippet.Event{Name: snippet.EventSync, Detail: "download"})
+	return nil
+}
+
+// writeContent writes the remote snippet file to the snippet file, unless
+// they are the same. The local only snippets are kept, and the local
+// snippets with the same command as a remote one are merged into it (see
+// mergeDownload).
+func writeContent(content string) (bool, error) {
+	body, err := localContent()
+	if err != nil {
+		return false, err
+	}
+	if content == body {
+		// no need to download
+		return false, nil
+	}
+	synced, local, err := localSnippets()
+	if err != nil {
+		return false, err
+	}
+	var remote snippet.Snippets
+	if _, err := toml.Decode(content, &remote); err != nil {
+		return false, errors.Wrap(err, "Failed to parse the remote snippets")
+	}
+	if n := mergeDownload(&remote, synced.Snippets); n > 0 || len(local) > 0 {
+		if n > 0 {
+			fmt.Printf("Merged %d duplicate snippets with the same command\n", n)
+		}
+		remote.Snippets = append(remote.Snippets, local...)
+		if content, err = remote.ToString(); err != nil {
+			return false, err
+		}
+	}
+	if err := snippet.WriteFile(config.Conf.General.SnippetFile, []byte(content)); err != nil {
+		return false, err
+	}
+	return true, nil
 }
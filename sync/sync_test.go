@@ -0,0 +1,200 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/pet/config"
+	"github.com/knqyf263/pet/snippet"
+)
+
+func TestSyncExcludeTags(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SyncExcludeTags = []string{"private"}
+	content := `[[snippets]]
+  description = "greet"
+  command = "echo hello"
+
+[[snippets]]
+  description = "vpn"
+  command = "openconnect vpn.internal"
+  tag = ["work", "private"]
+`
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := localContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "echo hello") || strings.Contains(body, "vpn.internal") {
+		t.Errorf("localContent() = %q", body)
+	}
+
+	// the remote snippets replace the synced ones, the private one stays
+	remote := "[[snippets]]\n  description = \"bye\"\n  command = \"echo bye\"\n"
+	if written, err := writeContent(remote); err != nil || !written {
+		t.Fatalf("writeContent() = %v, %v", written, err)
+	}
+	var snippets snippet.Snippets
+	if err := snippets.LoadFile(config.Conf.General.SnippetFile); err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, s := range snippets.Snippets {
+		commands = append(commands, s.Command)
+	}
+	if got := strings.Join(commands, ", "); got != "echo bye, openconnect vpn.internal" {
+		t.Errorf("the snippets after the download are %s", got)
+	}
+}
+
+func TestSyncDirection(t *testing.T) {
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	synced := "[[snippets]]\n  description = \"greet\"\n  command = \"echo hello\"\n"
+	edited := "[[snippets]]\n  description = \"greet\"\n  command = \"echo hi\"\n"
+	other := "[[snippets]]\n  description = \"bye\"\n  command = \"echo bye\"\n"
+	// formatted by another pet
+	reformatted := "[[snippets]]\ncommand = 'echo hello'\ndescription = 'greet'\n"
+
+	now := time.Now()
+	old, older := now.Add(-time.Hour), now.Add(-2*time.Hour)
+	last := syncRecord{Time: older, Checksum: contentChecksum(synced)}
+	tests := []struct {
+		name         string
+		local        time.Time
+		body, remote string
+		remoteTime   time.Time
+		last         syncRecord
+		want         string
+		wantConflict bool
+	}{
+		{"no changes", now, synced, reformatted, old, last, "", false},
+		// a newer remote, but only the local snippets changed
+		{"only local changed", old, edited, synced, now, last, "upload", false},
+		{"only remote changed", now, synced, edited, old, last, "download", false},
+		{"both changed", now, edited, other, old, last, "upload", true},
+		{"no checksum", old, edited, other, now, syncRecord{Time: older}, "download", true},
+		{"no checksum nor sync", now, edited, other, old, syncRecord{}, "upload", false},
+	}
+	for _, tt := range tests {
+		got, conflict := syncDirection(tt.local, tt.body, &Snippet{Content: tt.remote, UpdatedAt: tt.remoteTime}, tt.last, !tt.last.Time.IsZero())
+		if got != tt.want || conflict != tt.wantConflict {
+			t.Errorf("%s: syncDirection() = %q, %v, want %q, %v", tt.name, got, conflict, tt.want, tt.wantConflict)
+		}
+	}
+}
+
+func TestLastSync(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	file := filepath.Join(dir, lastSyncFileName)
+	// the times alone of an older pet
+	if err := os.WriteFile(file, []byte(`{"gist:abc": "2024-05-01T10:00:00Z"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if r := lastSync()["gist:abc"]; r.Time.IsZero() || r.Checksum != "" {
+		t.Errorf("lastSync() = %+v", r)
+	}
+
+	if err := recordSync("gitlab::1", time.Now(), "sum"); err != nil {
+		t.Fatal(err)
+	}
+	synced := lastSync()
+	if synced["gitlab::1"].Checksum != "sum" || synced["gist:abc"].Time.IsZero() {
+		t.Errorf("lastSync() = %+v", synced)
+	}
+}
+
+func TestWriteConflicts(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	config.Conf.General.SyncExcludeTags = []string{"private"}
+	content := `[[snippets]]
+  description = "greet"
+  command = "echo hello"
+
+[[snippets]]
+  description = "vpn"
+  command = "openconnect vpn.internal"
+  tag = ["private"]
+`
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := "[[snippets]]\n  description = \"greet\"\n  command = \"echo hi\"\n"
+	n, err := writeConflicts(config.Conf.General.SnippetFile, remote)
+	if err != nil || n != 1 {
+		t.Fatalf("writeConflicts() = %d, %v", n, err)
+	}
+	data, err := os.ReadFile(config.Conf.General.SnippetFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.Contains(got, snippet.MarkerOurs) || !strings.Contains(got, "echo hi") || !strings.Contains(got, "vpn.internal") {
+		t.Errorf("the snippet file is\n%s", got)
+	}
+	// neither uploaded nor overwritten until the conflicts are resolved
+	if _, err := localContent(); err == nil || !strings.Contains(err.Error(), "conflict markers") {
+		t.Errorf("localContent() error = %v", err)
+	}
+}
+
+func TestWriteContent_Duplicates(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PET_CONFIG_DIR", dir)
+	defer func(c config.Config) { config.Conf = c }(config.Conf)
+	config.Conf.General.SnippetFile = filepath.Join(dir, "snippet.toml")
+	content := `[[snippets]]
+  description = "list all files"
+  command = "ls -la "
+  tag = ["files"]
+
+[[snippets]]
+  description = "local only"
+  command = "echo local"
+`
+	if err := os.WriteFile(config.Conf.General.SnippetFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same command saved on another machine, and twice in the remote file
+	remote := `[[snippets]]
+  description = "ls long"
+  command = "ls -la"
+  tag = ["shell"]
+
+[[snippets]]
+  description = "ls long again"
+  command = "ls -la;"
+  tag = ["ls"]
+
+[[snippets]]
+  description = "bye"
+  command = "echo bye"
+`
+	if written, err := writeContent(remote); err != nil || !written {
+		t.Fatalf("writeContent() = %v, %v", written, err)
+	}
+	var snippets snippet.Snippets
+	if err := snippets.LoadFile(config.Conf.General.SnippetFile); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range snippets.Snippets {
+		got = append(got, s.Description+": "+strings.Join(s.Tag, " "))
+	}
+	if want := "ls long: shell ls files, bye: "; strings.Join(got, ", ") != want {
+		t.Errorf("the snippets after the download are %q, want %q", strings.Join(got, ", "), want)
+	}
+}
@@ -0,0 +1,238 @@
+// Package update checks the releases of pet on GitHub and replaces the
+// binary with the latest one, for the installs from the release archives.
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestURL is the latest release of pet in the API of GitHub
+var LatestURL = "https://api.github.com/repos/knqyf263/pet/releases/latest"
+
+// client downloads the releases
+var client = &http.Client{Timeout: time.Minute}
+
+// Release is a release of pet on GitHub
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file of a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the version of the release, without the v of its tag
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Latest returns the latest release of pet. $GITHUB_TOKEN, if it is set,
+// raises the rate limit of the API.
+func Latest() (Release, error) {
+	var release Release
+	req, err := http.NewRequest(http.MethodGet, LatestURL, nil)
+	if err != nil {
+		return release, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return release, fmt.Errorf("Failed to check the latest release: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release, fmt.Errorf("Failed to check the latest release: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return release, fmt.Errorf("Failed to read the latest release: %v", err)
+	}
+	return release, nil
+}
+
+// Newer reports whether the version is newer than the current one. A
+// current version which is not a release (e.g. dev) is older than any.
+func Newer(current, version string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	v, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	for i := range v {
+		if v[i] != c[i] {
+			return v[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion returns the major, minor and patch numbers of a version,
+// ignoring a pre-release or build suffix
+func parseVersion(version string) ([3]int, bool) {
+	var n [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return n, false
+	}
+	for i, p := range parts {
+		var err error
+		if n[i], err = strconv.Atoi(p); err != nil {
+			return n, false
+		}
+	}
+	return n, true
+}
+
+// ArchiveName returns the name of the release archive for the platform, as
+// named by goreleaser
+func ArchiveName(version, goos, goarch string) string {
+	if goarch == "arm" {
+		goarch = "armv6"
+	}
+	return fmt.Sprintf("pet_%s_%s_%s.tar.gz", version, goos, goarch)
+}
+
+// Download returns the binary of the release for the platform, from the
+// archive whose SHA-256 checksum is the one of the checksums of the release
+func Download(r Release, goos, goarch string) ([]byte, error) {
+	name := ArchiveName(r.Version(), goos, goarch)
+	checksums := fmt.Sprintf("pet_%s_checksums.txt", r.Version())
+	var archiveURL, checksumsURL string
+	for _, a := range r.Assets {
+		switch a.Name {
+		case name:
+			archiveURL = a.URL
+		case checksums:
+			checksumsURL = a.URL
+		}
+	}
+	if archiveURL == "" {
+		return nil, fmt.Errorf("pet %s has no release for %s/%s", r.Version(), goos, goarch)
+	}
+	if checksumsURL == "" {
+		return nil, fmt.Errorf("pet %s has no checksums, it is not verified", r.Version())
+	}
+
+	sums, err := get(checksumsURL)
+	if err != nil {
+		return nil, err
+	}
+	want, err := checksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := get(archiveURL)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(archive); hex.EncodeToString(sum[:]) != want {
+		return nil, fmt.Errorf("the checksum of %s does not match, it is not installed", name)
+	}
+
+	binary := "pet"
+	if goos == "windows" {
+		binary += ".exe"
+	}
+	return extract(archive, binary)
+}
+
+// checksum returns the checksum of the file in the output of sha256sum
+func checksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("the checksums have no %s", name)
+}
+
+// extract returns the file of the tar.gz archive
+func extract(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the archive: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("the archive has no %s", name)
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to read the archive: %v", err)
+		}
+		if h.Typeflag == tar.TypeReg && filepath.Base(h.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+func get(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Replace replaces the executable with the binary. The binary is written
+// next to it and renamed over it, so that it is never half written; the
+// running executable of Windows, which cannot be replaced, is moved aside to
+// .old.
+func Replace(exe string, binary []byte) error {
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, binary, fi.Mode().Perm()|0o100); err != nil {
+		return fmt.Errorf("Failed to write %s: %v", tmp, err)
+	}
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
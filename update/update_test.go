@@ -0,0 +1,133 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, version string
+		want             bool
+	}{
+		{"0.3.6", "0.4.0", true},
+		{"0.4.0", "0.4.0", false},
+		{"0.10.0", "0.9.1", false},
+		{"v1.2.3", "1.2.4", true},
+		{"1.0", "1.0.1", true},
+		{"1.0.0-rc1", "1.0.0", false},
+		{"dev", "0.1.0", true},
+		{"1.0.0", "latest", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.version); got != tt.want {
+			t.Errorf("Newer(%s, %s) = %v, want %v", tt.current, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	if got := ArchiveName("1.0.0", "linux", "amd64"); got != "pet_1.0.0_linux_amd64.tar.gz" {
+		t.Errorf("ArchiveName() = %s", got)
+	}
+	if got := ArchiveName("1.0.0", "linux", "arm"); got != "pet_1.0.0_linux_armv6.tar.gz" {
+		t.Errorf("ArchiveName() = %s", got)
+	}
+}
+
+func archive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestDownload(t *testing.T) {
+	name := ArchiveName("1.2.0", "linux", "amd64")
+	tarball := archive(t, map[string]string{"LICENSE": "MIT", "pet": "new binary"})
+	sum := sha256.Sum256(tarball)
+	checksums := fmt.Sprintf("%s  %s\n%s  pet_1.2.0_darwin_arm64.tar.gz\n", hex.EncodeToString(sum[:]), name, hex.EncodeToString(sum[:]))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) { w.Write(tarball) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, checksums) })
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tag_name": "v1.2.0",
+			"html_url": "https://github.com/knqyf263/pet/releases/tag/v1.2.0",
+			"assets": []map[string]string{
+				{"name": name, "browser_download_url": ts.URL + "/" + name},
+				{"name": "pet_1.2.0_checksums.txt", "browser_download_url": ts.URL + "/checksums"},
+			},
+		})
+	})
+	LatestURL = ts.URL + "/latest"
+	release, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Version() != "1.2.0" {
+		t.Fatalf("Version() = %s", release.Version())
+	}
+
+	binary, err := Download(release, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(binary) != "new binary" {
+		t.Errorf("Download() = %q", binary)
+	}
+
+	if _, err := Download(release, "windows", "386"); err == nil {
+		t.Error("a release for a missing platform is downloaded")
+	}
+
+	checksums = "0000  " + name + "\n"
+	if _, err := Download(release, "linux", "amd64"); err == nil {
+		t.Error("an archive with a wrong checksum is downloaded")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "pet")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new binary")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "new binary" {
+		t.Errorf("the binary is %q", b)
+	}
+	if fi, _ := os.Stat(exe); fi.Mode().Perm() != 0o755 {
+		t.Errorf("the mode of the binary is %v", fi.Mode())
+	}
+	if _, err := os.Stat(exe + ".new"); !os.IsNotExist(err) {
+		t.Error("the new binary is left")
+	}
+}